@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// amountPrecision and amountHalfEven set how amounts are rounded in every
+// output, set by --precision and --rounding. A precision of -1 uses each
+// currency's ISO 4217 decimals. minorUnits writes CSV and destination amounts as
+// integers of the currency's minor unit instead, set by --amount-minor-units.
+var (
+	amountPrecision int32 = -1
+	amountHalfEven  bool
+	minorUnits      bool
+)
+
+// setAmountRounding validates and applies --precision and --rounding: half-up
+// rounds halves away from zero, half-even (banker's rounding) to the even digit.
+func setAmountRounding(precision int, rounding string) error {
+	if precision < -1 || precision > 8 {
+		return fmt.Errorf("invalid --precision %d, expected 0 to 8 decimals", precision)
+	}
+	switch rounding {
+	case "half-up":
+		amountHalfEven = false
+	case "half-even":
+		amountHalfEven = true
+	default:
+		return fmt.Errorf("invalid --rounding %q, expected half-up or half-even", rounding)
+	}
+	amountPrecision = int32(precision)
+	return nil
+}
+
+// amountDecimals returns the decimals amounts in currency are written with.
+func amountDecimals(currency string) int32 {
+	if amountPrecision >= 0 {
+		return amountPrecision
+	}
+	return currencyExponent(currency)
+}
+
+// roundAmount rounds amount in currency to its decimals.
+func roundAmount(amount decimal.Decimal, currency string) decimal.Decimal {
+	if amountHalfEven {
+		return amount.RoundBank(amountDecimals(currency))
+	}
+	return amount.Round(amountDecimals(currency))
+}
+
+// formatAmount writes amount with exactly the decimals of currency, so JPY
+// amounts have none and USD amounts two.
+func formatAmount(amount decimal.Decimal, currency string) string {
+	return roundAmount(amount, currency).StringFixed(amountDecimals(currency))
+}
+
+// minorUnitAmount returns amount as an integer number of the minor unit of
+// currency, such as cents: 12.34 USD is 1234 and 1000 JPY is 1000. Fractions
+// of the minor unit are rounded with --rounding.
+func minorUnitAmount(amount decimal.Decimal, currency string) string {
+	shifted := amount.Shift(currencyExponent(currency))
+	if amountHalfEven {
+		return shifted.RoundBank(0).String()
+	}
+	return shifted.Round(0).String()
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+const (
+	googleAdsAPIVersion = "v18"
+	// googleAdsBatchSize is the number of member operations sent per addOperations request.
+	googleAdsBatchSize = 10000
+)
+
+// googleAdsClient calls the Google Ads REST API for a single customer account.
+type googleAdsClient struct {
+	customerID string
+	headers    map[string]string
+}
+
+func newGoogleAdsClient() (*googleAdsClient, error) {
+	customerID := os.Getenv("GOOGLE_ADS_CUSTOMER_ID")
+	developerToken := os.Getenv("GOOGLE_ADS_DEVELOPER_TOKEN")
+	accessToken := os.Getenv("GOOGLE_ADS_ACCESS_TOKEN")
+	if customerID == "" || developerToken == "" || accessToken == "" {
+		return nil, fmt.Errorf("GOOGLE_ADS_CUSTOMER_ID, GOOGLE_ADS_DEVELOPER_TOKEN and GOOGLE_ADS_ACCESS_TOKEN must be set")
+	}
+
+	headers := map[string]string{
+		"Authorization":   "Bearer " + accessToken,
+		"developer-token": developerToken,
+	}
+	if loginCustomerID := os.Getenv("GOOGLE_ADS_LOGIN_CUSTOMER_ID"); loginCustomerID != "" {
+		headers["login-customer-id"] = loginCustomerID
+	}
+	return &googleAdsClient{customerID: customerID, headers: headers}, nil
+}
+
+func (g *googleAdsClient) post(ctx context.Context, path string, payload, out interface{}) error {
+	url := fmt.Sprintf("https://googleads.googleapis.com/%s/%s", googleAdsAPIVersion, path)
+	return sendJSON(ctx, "POST", url, g.headers, payload, out)
+}
+
+// googlePartialFailure is the partialFailureError of an addOperations response. Each
+// error locates the rejected operation by its index in the request.
+type googlePartialFailure struct {
+	Details []struct {
+		Errors []struct {
+			Message  string `json:"message"`
+			Location struct {
+				FieldPathElements []struct {
+					FieldName string `json:"fieldName"`
+					Index     *int   `json:"index"`
+				} `json:"fieldPathElements"`
+			} `json:"location"`
+		} `json:"errors"`
+	} `json:"details"`
+}
+
+// pushGoogleAudience uploads members to a Customer Match user list. In create mode a
+// new list named audienceName is created first; in replace mode existing members of
+// the list identified by audienceID are removed before the upload. Members rejected
+// by Google Ads are returned instead of failing the upload. The list creation is
+// recorded in audit as it happens, and the removal and members with the outcome of
+// the job that applies them.
+func pushGoogleAudience(ctx context.Context, mode, audienceID, audienceName string, members []audienceMember, audit *audienceAudit) ([]memberError, error) {
+	g, err := newGoogleAdsClient()
+	if err != nil {
+		return nil, err
+	}
+
+	var userList string
+	if mode == "create" {
+		if audienceName == "" {
+			return nil, fmt.Errorf("--audience-name is required with --mode create")
+		}
+		userList, err = g.createUserList(ctx, audienceName)
+		if auditErr := audit.created(err); auditErr != nil {
+			return nil, auditErr
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create user list: %w", err)
+		}
+	} else {
+		if audienceID == "" {
+			return nil, fmt.Errorf("--audience-id is required with --mode %s", mode)
+		}
+		userList = fmt.Sprintf("customers/%s/userLists/%s", g.customerID, audienceID)
+	}
+
+	// The operations of an offline user data job only take effect when it runs, so
+	// all its batches share the job's outcome.
+	rejected, err := g.runUserDataJob(ctx, userList, mode == "replace", members)
+	accepted := acceptedMembers(members, rejected)
+	if mode == "replace" {
+		if auditErr := audit.replaced(len(accepted), err); auditErr != nil {
+			return rejected, auditErr
+		}
+	}
+	if auditErr := audit.added(accepted, err); auditErr != nil {
+		return rejected, auditErr
+	}
+	return rejected, err
+}
+
+// runUserDataJob adds members to userList, after removing its existing members
+// with removeAll, through an offline user data job.
+func (g *googleAdsClient) runUserDataJob(ctx context.Context, userList string, removeAll bool, members []audienceMember) ([]memberError, error) {
+	var job struct {
+		ResourceName string `json:"resourceName"`
+	}
+	err := g.post(ctx, fmt.Sprintf("customers/%s/offlineUserDataJobs:create", g.customerID), map[string]interface{}{
+		"job": map[string]interface{}{
+			"type":                          "CUSTOMER_MATCH_USER_LIST",
+			"customerMatchUserListMetadata": map[string]string{"userList": userList},
+		},
+	}, &job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create offline user data job: %w", err)
+	}
+
+	var operations []map[string]interface{}
+	// opMembers maps each operation to the index of its member, or -1.
+	var opMembers []int
+	if removeAll {
+		operations = append(operations, map[string]interface{}{"removeAll": true})
+		opMembers = append(opMembers, -1)
+	}
+	for i, m := range members {
+		var identifiers []map[string]string
+		if m.EmailHash != "" {
+			identifiers = append(identifiers, map[string]string{"hashedEmail": m.EmailHash})
+		}
+		if m.PhoneHash != "" {
+			identifiers = append(identifiers, map[string]string{"hashedPhoneNumber": m.PhoneHash})
+		}
+		operations = append(operations, map[string]interface{}{
+			"create": map[string]interface{}{"userIdentifiers": identifiers},
+		})
+		opMembers = append(opMembers, i)
+	}
+
+	var rejected []memberError
+	for start := 0; start < len(operations); start += googleAdsBatchSize {
+		end := min(start+googleAdsBatchSize, len(operations))
+		var resp struct {
+			PartialFailureError *googlePartialFailure `json:"partialFailureError"`
+		}
+		err := g.post(ctx, job.ResourceName+":addOperations", map[string]interface{}{
+			"operations":           operations[start:end],
+			"enablePartialFailure": true,
+		}, &resp)
+		if err != nil {
+			return rejected, fmt.Errorf("failed to add operations: %w", err)
+		}
+		if resp.PartialFailureError != nil {
+			rejected = append(rejected, resp.PartialFailureError.memberErrors(members, opMembers[start:end])...)
+		}
+	}
+
+	if err := g.post(ctx, job.ResourceName+":run", map[string]interface{}{}, nil); err != nil {
+		return rejected, fmt.Errorf("failed to run offline user data job: %w", err)
+	}
+	return rejected, nil
+}
+
+// memberErrors maps the failed operations of a batch to their members. opMembers
+// holds the member index of each operation in the batch.
+func (f *googlePartialFailure) memberErrors(members []audienceMember, opMembers []int) []memberError {
+	var errs []memberError
+	for _, d := range f.Details {
+		for _, e := range d.Errors {
+			for _, el := range e.Location.FieldPathElements {
+				if el.FieldName != "operations" || el.Index == nil || *el.Index >= len(opMembers) || opMembers[*el.Index] < 0 {
+					continue
+				}
+				errs = append(errs, memberError{CustomerID: members[opMembers[*el.Index]].CustomerID, Error: e.Message})
+				break
+			}
+		}
+	}
+	return errs
+}
+
+// createUserList creates a contact-info Customer Match list and returns its resource name.
+func (g *googleAdsClient) createUserList(ctx context.Context, name string) (string, error) {
+	var resp struct {
+		Results []struct {
+			ResourceName string `json:"resourceName"`
+		} `json:"results"`
+	}
+	err := g.post(ctx, fmt.Sprintf("customers/%s/userLists:mutate", g.customerID), map[string]interface{}{
+		"operations": []map[string]interface{}{{
+			"create": map[string]interface{}{
+				"name":               name,
+				"membershipLifeSpan": 10000,
+				"crmBasedUserList":   map[string]string{"uploadKeyType": "CONTACT_INFO"},
+			},
+		}},
+	}, &resp)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Results) == 0 {
+		return "", fmt.Errorf("no user list returned")
+	}
+	return resp.Results[0].ResourceName, nil
+}
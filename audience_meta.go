@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+)
+
+const (
+	metaGraphAPIVersion = "v21.0"
+	// metaBatchSize is the maximum number of users per Custom Audience upload request.
+	metaBatchSize = 10000
+)
+
+// pushMetaAudience appends members to a Meta Custom Audience, recording each
+// batch in audit once it was uploaded or failed. Batches after a failure are not
+// sent.
+func pushMetaAudience(ctx context.Context, audienceID string, members []audienceMember, audit *audienceAudit) error {
+	accessToken := os.Getenv("META_ACCESS_TOKEN")
+	if accessToken == "" {
+		return fmt.Errorf("META_ACCESS_TOKEN must be set")
+	}
+	if audienceID == "" {
+		return fmt.Errorf("--audience-id is required for the meta provider")
+	}
+
+	endpoint := fmt.Sprintf("https://graph.facebook.com/%s/%s/users", metaGraphAPIVersion, url.PathEscape(audienceID))
+	headers := map[string]string{"Authorization": "Bearer " + accessToken}
+
+	for start := 0; start < len(members); start += metaBatchSize {
+		end := min(start+metaBatchSize, len(members))
+
+		data := make([][]string, 0, end-start)
+		for _, m := range members[start:end] {
+			data = append(data, []string{m.EmailHash, m.PhoneHash})
+		}
+
+		payload := map[string]interface{}{
+			"payload": map[string]interface{}{
+				"schema": []string{"EMAIL", "PHONE"},
+				"data":   data,
+			},
+		}
+		err := sendJSON(ctx, "POST", endpoint, headers, payload, nil)
+		if auditErr := audit.added(members[start:end], err); auditErr != nil {
+			return auditErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// audienceMember holds the normalized, SHA-256 hashed identifiers of a customer
+// as expected by ad platform list matching. Empty fields were missing or lacked consent.
+type audienceMember struct {
+	CustomerID string
+	EmailHash  string
+	PhoneHash  string
+}
+
+// memberError is a customer the provider rejected while accepting the rest of the upload.
+type memberError struct {
+	CustomerID string `json:"customerId"`
+	Error      string `json:"error"`
+}
+
+func audiencesCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "audiences",
+		Usage: "Sync segment members to ad platform audiences",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "push",
+				Usage: "Upload hashed emails and phone numbers of segment members to an audience",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "provider", Required: true, Usage: "Audience provider (meta, google)"},
+					&cli.StringFlag{Name: "audience-id", Usage: "ID of the audience to upload to"},
+					&cli.StringFlag{Name: "audience-name", Usage: "Name of the audience to create with --mode create"},
+					&cli.StringFlag{Name: "mode", Value: "append", Usage: "Upload mode: append, replace, or create"},
+					&cli.BoolFlag{Name: "skip-consent", Usage: "Include identifiers of customers without marketing consent"},
+					&cli.StringFlag{Name: "errors-report", Usage: "Write customers rejected by the provider to this CSV file, or JSON with a .json extension"},
+				},
+				Action: func(c *cli.Context) error {
+					ctx, cancel := exportContext(c.Duration("timeout"))
+					defer cancel()
+					return pushAudience(ctx, c)
+				},
+			},
+		},
+	}
+}
+
+func pushAudience(ctx context.Context, c *cli.Context) error {
+	customers, err := fetchCustomers(ctx, c)
+	partialErr := err
+	if err != nil && !errors.Is(err, errPartialData) {
+		return err
+	}
+
+	mode := c.String("mode")
+	if mode != "append" && mode != "replace" && mode != "create" {
+		return fmt.Errorf("invalid --mode %q, expected append, replace, or create", mode)
+	}
+
+	audit, err := openAuditLog(c, "audiences push")
+	if err != nil {
+		return err
+	}
+	defer audit.Close()
+
+	var members []audienceMember
+	var rejected []memberError
+	provider := c.String("provider")
+	changes := newAudienceAudit(audit, provider, mode, c.String("audience-id"), c.String("audience-name"))
+	switch provider {
+	case "meta":
+		if mode != "append" {
+			return fmt.Errorf("the meta provider only supports --mode append")
+		}
+		members = audienceMembers(customers, !c.Bool("skip-consent"), normalizeEmail, normalizePhone)
+		err = pushMetaAudience(ctx, c.String("audience-id"), members, changes)
+	case "google":
+		members = audienceMembers(customers, !c.Bool("skip-consent"), normalizeGoogleEmail, normalizeE164)
+		rejected, err = pushGoogleAudience(ctx, mode, c.String("audience-id"), c.String("audience-name"), members, changes)
+	default:
+		return fmt.Errorf("unsupported audience provider %q", provider)
+	}
+	if len(rejected) > 0 {
+		log.Printf("%s rejected %d customers", provider, len(rejected))
+		if path := c.String("errors-report"); path != "" {
+			if reportErr := writeMemberErrors(path, rejected); reportErr != nil {
+				return reportErr
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to push %s audience: %w", provider, err)
+	}
+
+	fmt.Printf("Successfully pushed %d of %d customers to %s audience\n", len(members)-len(rejected), len(customers), provider)
+	return partialErr
+}
+
+// acceptedMembers returns members without the rejected customers.
+func acceptedMembers(members []audienceMember, rejected []memberError) []audienceMember {
+	if len(rejected) == 0 {
+		return members
+	}
+	skip := make(map[string]bool, len(rejected))
+	for _, r := range rejected {
+		skip[r.CustomerID] = true
+	}
+	var accepted []audienceMember
+	for _, m := range members {
+		if !skip[m.CustomerID] {
+			accepted = append(accepted, m)
+		}
+	}
+	return accepted
+}
+
+// writeMemberErrors writes the rejected customers as JSON if path ends in .json,
+// otherwise as CSV.
+func writeMemberErrors(path string, errs []memberError) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create errors report: %w", err)
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(errs)
+	} else {
+		w := csv.NewWriter(f)
+		w.Write([]string{"Customer ID", "Error"})
+		for _, e := range errs {
+			w.Write([]string{e.CustomerID, e.Error})
+		}
+		w.Flush()
+		err = w.Error()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write errors report: %w", err)
+	}
+	return f.Close()
+}
+
+// audienceAudit records the changes of an audience push in the audit log as
+// each upload succeeds or fails, so the members of batches uploaded before a
+// failure are still logged as applied.
+type audienceAudit struct {
+	log    *auditLog
+	target string
+}
+
+func newAudienceAudit(log *auditLog, provider, mode, audienceID, audienceName string) *audienceAudit {
+	target := provider + " audience " + audienceID
+	if mode == "create" {
+		target = fmt.Sprintf("%s audience %q", provider, audienceName)
+	}
+	return &audienceAudit{log: log, target: target}
+}
+
+// created records creating the audience with the outcome err.
+func (a *audienceAudit) created(err error) error {
+	return a.log.record([]auditEntry{{Action: "audience.create", Target: a.target}}, err)
+}
+
+// replaced records replacing the members of the audience with members.
+func (a *audienceAudit) replaced(members int, err error) error {
+	return a.log.record([]auditEntry{{Action: "audience.replace", Target: a.target, Before: "existing members", After: fmt.Sprintf("%d members", members)}}, err)
+}
+
+// added records one entry per uploaded customer with the outcome err of its
+// upload.
+func (a *audienceAudit) added(members []audienceMember, err error) error {
+	var entries []auditEntry
+	for _, m := range members {
+		var identifiers []string
+		if m.EmailHash != "" {
+			identifiers = append(identifiers, "email")
+		}
+		if m.PhoneHash != "" {
+			identifiers = append(identifiers, "phone")
+		}
+		entries = append(entries, auditEntry{
+			Action:     "audience.add_member",
+			Target:     a.target,
+			CustomerID: m.CustomerID,
+			After:      map[string]interface{}{"identifiers": identifiers},
+		})
+	}
+	return a.log.record(entries, err)
+}
+
+// audienceMembers hashes the identifiers of each customer. When requireConsent is set,
+// emails and phone numbers are only included if their marketing state is SUBSCRIBED,
+// and customers left without any identifier are dropped. Identifiers are normalized
+// with the provider's rules before hashing.
+func audienceMembers(customers []CustomerSegmentMember, requireConsent bool, emailNorm, phoneNorm func(string) string) []audienceMember {
+	var members []audienceMember
+	for _, c := range customers {
+		m := audienceMember{CustomerID: c.Node.Id}
+		if e := c.Node.DefaultEmailAddress; e != nil && (!requireConsent || e.MarketingState == CustomerEmailAddressMarketingStateSubscribed) {
+			m.EmailHash = hashIdentifier(emailNorm(e.EmailAddress))
+		}
+		if p := c.Node.DefaultPhoneNumber; p != nil && (!requireConsent || p.MarketingState == CustomerSmsMarketingStateSubscribed) {
+			m.PhoneHash = hashIdentifier(phoneNorm(p.PhoneNumber))
+		}
+		if m.EmailHash != "" || m.PhoneHash != "" {
+			members = append(members, m)
+		}
+	}
+	return members
+}
+
+// normalizeEmail trims surrounding whitespace and lowercases the address.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// normalizePhone keeps only digits and strips leading zeros. Shopify stores phone
+// numbers in E.164, so the result includes the country code.
+func normalizePhone(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return strings.TrimLeft(b.String(), "0")
+}
+
+// normalizeGoogleEmail applies normalizeEmail and also removes the periods before
+// the domain of gmail.com and googlemail.com addresses, as Customer Match requires.
+func normalizeGoogleEmail(email string) string {
+	email = normalizeEmail(email)
+	local, domain, ok := strings.Cut(email, "@")
+	if ok && (domain == "gmail.com" || domain == "googlemail.com") {
+		email = strings.ReplaceAll(local, ".", "") + "@" + domain
+	}
+	return email
+}
+
+// normalizeE164 formats a phone number as "+" followed by normalizePhone's digits.
+func normalizeE164(phone string) string {
+	if digits := normalizePhone(phone); digits != "" {
+		return "+" + digits
+	}
+	return ""
+}
+
+// hashIdentifier returns the lowercase hex SHA-256 of s, or "" for an empty identifier.
+func hashIdentifier(s string) string {
+	if s == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// maxBackfillShards guards against a --shard far too fine for the range.
+const maxBackfillShards = 10000
+
+// backfillConflicts are the root flags for a single export, which cannot apply
+// to the shards of a backfill.
+var backfillConflicts = []string{"queries-file", "journal", "report", "summary", "quality-report", "domain-report", "removed-file"}
+
+// orPattern finds OR in a segment query, which must be parenthesized before
+// another condition is added with AND.
+var orPattern = regexp.MustCompile(`(?i)\bOR\b`)
+
+func backfillCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "backfill",
+		Usage: "Export the segment of --query in date shards, each to its own file",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "from", Required: true, Usage: "First period of the backfill: a year (2022), month (2022-01) or date (2022-01-15)"},
+			&cli.StringFlag{Name: "to", Required: true, Usage: "Last period of the backfill, included, in the same formats"},
+			&cli.StringFlag{Name: "shard", Value: "month", Usage: "Period of each shard: day, week, month, quarter or year"},
+			&cli.StringFlag{Name: "date-field", Value: "customer_added_date", Usage: "Date attribute of the segment query the shards split on, such as last_order_date"},
+			&cli.IntFlag{Name: "concurrency", Value: 1, Usage: "Shards exported in parallel"},
+			&cli.BoolFlag{Name: "skip-existing", Usage: "Skip shards whose output file exists, to resume a backfill"},
+		},
+		Action: func(c *cli.Context) error {
+			for _, name := range backfillConflicts {
+				if c.IsSet(name) {
+					return fmt.Errorf("--%s cannot be combined with backfill", name)
+				}
+			}
+			output := c.String("output")
+			if !isFileOutput(output) {
+				return fmt.Errorf("backfill requires --output to be a CSV file, which is written once per shard")
+			}
+			shards, err := backfillShards(c.String("from"), c.String("to"), c.String("shard"))
+			if err != nil {
+				return err
+			}
+
+			defaults := segmentQueryFromFlags(c)
+			// --first limits each shard; without it shards are exported in full.
+			if !c.IsSet("first") {
+				defaults.First = math.MaxInt32
+			}
+			var jobs []segmentJob
+			for _, s := range shards {
+				job := segmentJob{
+					Output: partitionPath(output, s.key),
+					Query:  shardQuery(defaults.Query, c.String("date-field"), s.from, s.to),
+				}
+				if c.Bool("skip-existing") {
+					if _, err := os.Stat(job.Output); err == nil {
+						fmt.Fprintf(statusOutput(job.Output), "Skipped %s, which exists\n", job.Output)
+						continue
+					}
+				}
+				jobs = append(jobs, job)
+			}
+			if len(jobs) == 0 {
+				return nil
+			}
+			// Shards of a long history can take a while, so they have no
+			// timeout unless --timeout is given.
+			var timeout time.Duration
+			if c.IsSet("timeout") {
+				timeout = c.Duration("timeout")
+			}
+			return exportJobs(c, jobs, defaults, timeout)
+		},
+	}
+}
+
+// backfillShard is the period of one shard, from and to included.
+type backfillShard struct {
+	key      string
+	from, to time.Time
+}
+
+// backfillShards splits the periods from from to to, included, into shards of
+// whole calendar periods, the first and last cut to the range.
+func backfillShards(from, to, unit string) ([]backfillShard, error) {
+	start, _, err := parsePeriod(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --from: %w", err)
+	}
+	_, end, err := parsePeriod(to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --to: %w", err)
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("--to %s is before --from %s", to, from)
+	}
+	switch unit {
+	case "day", "week", "month", "quarter", "year":
+	default:
+		return nil, fmt.Errorf("invalid --shard %q, expected day, week, month, quarter or year", unit)
+	}
+
+	var shards []backfillShard
+	for p := periodStart(start, unit); !p.After(end); p = nextPeriod(p, unit) {
+		if len(shards) == maxBackfillShards {
+			return nil, fmt.Errorf("the range has more than %d %s shards, use a longer --shard", maxBackfillShards, unit)
+		}
+		s := backfillShard{key: periodKey(p, unit), from: p, to: nextPeriod(p, unit).AddDate(0, 0, -1)}
+		if s.from.Before(start) {
+			s.from = start
+		}
+		if s.to.After(end) {
+			s.to = end
+		}
+		shards = append(shards, s)
+	}
+	return shards, nil
+}
+
+// parsePeriod returns the first and last day of a year, month or date.
+func parsePeriod(s string) (time.Time, time.Time, error) {
+	for _, f := range []struct {
+		layout              string
+		years, months, days int
+	}{{"2006", 1, 0, 0}, {"2006-01", 0, 1, 0}, {time.DateOnly, 0, 0, 1}} {
+		if t, err := time.Parse(f.layout, s); err == nil {
+			return t, t.AddDate(f.years, f.months, f.days-1), nil
+		}
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("%q is not a year (2022), month (2022-01) or date (2022-01-15)", s)
+}
+
+// periodStart returns the first day of the period containing t. Weeks start
+// on Monday, as ISO weeks do.
+func periodStart(t time.Time, unit string) time.Time {
+	y, m, d := t.Date()
+	switch unit {
+	case "week":
+		return t.AddDate(0, 0, -(int(t.Weekday())+6)%7)
+	case "month":
+		return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	case "quarter":
+		return time.Date(y, (m-1)/3*3+1, 1, 0, 0, 0, 0, time.UTC)
+	case "year":
+		return time.Date(y, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func nextPeriod(t time.Time, unit string) time.Time {
+	switch unit {
+	case "week":
+		return t.AddDate(0, 0, 7)
+	case "month":
+		return t.AddDate(0, 1, 0)
+	case "quarter":
+		return t.AddDate(0, 3, 0)
+	case "year":
+		return t.AddDate(1, 0, 0)
+	}
+	return t.AddDate(0, 0, 1)
+}
+
+// periodKey names the shard of the period starting at t in its output file,
+// such as 2022, 2022-Q1, 2022-01, 2022-W01 or 2022-01-15.
+func periodKey(t time.Time, unit string) string {
+	switch unit {
+	case "week":
+		y, w := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", y, w)
+	case "month":
+		return t.Format("2006-01")
+	case "quarter":
+		return fmt.Sprintf("%d-Q%d", t.Year(), (int(t.Month())-1)/3+1)
+	case "year":
+		return t.Format("2006")
+	}
+	return t.Format(time.DateOnly)
+}
+
+// shardQuery restricts a segment query to the dates from and to, included.
+func shardQuery(query, field string, from, to time.Time) string {
+	condition := fmt.Sprintf("%s BETWEEN %s AND %s", field, from.Format(time.DateOnly), to.Format(time.DateOnly))
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return condition
+	}
+	if orPattern.MatchString(query) {
+		query = "(" + query + ")"
+	}
+	return query + " AND " + condition
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"sultans/shopify"
+)
+
+// responseCache stores GraphQL responses on disk, one file per request, and
+// treats files older than ttl as expired.
+type responseCache struct {
+	dir string
+	ttl time.Duration
+}
+
+// newResponseCache uses dir, or a directory under the user cache directory when empty.
+func newResponseCache(dir string, ttl time.Duration) (*responseCache, error) {
+	if dir == "" {
+		base, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate cache directory: %w", err)
+		}
+		dir = filepath.Join(base, "shopify-customers", "responses")
+	}
+	// Responses contain customer data, so keep them private to the user.
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return &responseCache{dir: dir, ttl: ttl}, nil
+}
+
+// Key identifies a request by endpoint (shop, API and version), query and variables.
+func (c *responseCache) Key(endpoint string, request []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n", endpoint)
+	h.Write(request)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *responseCache) Get(key string) (*shopify.CachedResponse, bool) {
+	path := filepath.Join(c.dir, key+".json")
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	// Responses cached without --encrypt-stores, or with another key, are misses.
+	if b, err = stores.open(b); err != nil {
+		return nil, false
+	}
+	var r shopify.CachedResponse
+	if json.Unmarshal(b, &r) != nil || len(r.Body) == 0 {
+		return nil, false
+	}
+	return &r, time.Since(info.ModTime()) <= c.ttl
+}
+
+// Put writes r, whose file's modification time starts its TTL again.
+func (c *responseCache) Put(key string, r *shopify.CachedResponse) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if body, err = stores.seal(body); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(c.dir, key+".json"))
+}
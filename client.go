@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"sultans/shopify"
+)
+
+// newShopifyClient creates a client from the environment credentials and the root flags.
+func newShopifyClient(c *cli.Context) (*shopify.Client, error) {
+	record, replay := c.String("record"), c.String("replay")
+	if record != "" && replay != "" {
+		return nil, fmt.Errorf("--record and --replay cannot be combined")
+	}
+
+	storefront := c.String("api") == storefrontAPI
+	var secret *cachedSecret
+	var domain, accessToken string
+	var err error
+	if backend := c.String("secret-backend"); backend != "" && replay == "" {
+		if domain = os.Getenv("SHOPIFY_DOMAIN"); domain == "" {
+			return nil, fmt.Errorf("SHOPIFY_DOMAIN must be set")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), secretBackendTimeout)
+		defer cancel()
+		if secret, err = secretBackend(ctx, backend, c.Duration("secret-ttl")); err != nil {
+			return nil, err
+		}
+	} else if domain, accessToken, err = shopifyCredentials(c.String("token-file"), storefront); err != nil {
+		// Replayed runs never reach Shopify, so they work without store credentials.
+		if replay == "" {
+			return nil, err
+		}
+		domain, accessToken = "replay.invalid", ""
+	}
+	client, err := newShopClient(c, domain, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	// --secret-backend supplies the access token instead.
+	if secret != nil {
+		client.Tokens = secret
+	}
+	return client, nil
+}
+
+// newShopClient creates a client of the shop at domain from the root flags.
+func newShopClient(c *cli.Context, domain, accessToken string) (*shopify.Client, error) {
+	record, replay := c.String("record"), c.String("replay")
+	retry, err := shopify.NewRetryPolicy(c.Int("max-retries"), c.Duration("retry-backoff"), c.Duration("retry-max-wait"), c.String("retry-on"))
+	if err != nil {
+		return nil, err
+	}
+
+	headers, err := shopifyHeaders(c.StringSlice("shopify-header"))
+	if err != nil {
+		return nil, err
+	}
+	userAgent := c.String("user-agent")
+	if userAgent == "" {
+		userAgent = defaultUserAgent
+	}
+
+	client := &shopify.Client{
+		Domain:      domain,
+		AccessToken: accessToken,
+		Storefront:  c.String("api") == storefrontAPI,
+		Breaker:     shopify.NewCircuitBreaker(c.Int("breaker-threshold"), c.Duration("breaker-cooldown")),
+		Retry:       retry,
+		Limiter:     shopify.NewRateLimiter(c.Float64("max-rps")),
+		HTTP:        httpClient,
+		UserAgent:   userAgent,
+		Headers:     headers,
+	}
+	if dir := record + replay; dir != "" {
+		transport, err := newVCRTransport(dir, replay != "", httpClient.Transport)
+		if err != nil {
+			return nil, err
+		}
+		client.HTTP = &http.Client{Transport: transport}
+	}
+	if c.Bool("cache") && !c.Bool("no-cache") {
+		cache, err := newResponseCache(c.String("cache-dir"), c.Duration("cache-ttl"))
+		if err != nil {
+			return nil, err
+		}
+		client.Cache = cache
+	}
+	return client, nil
+}
+
+// shopifyCredentials returns the shop domain from the environment and the access
+// token from tokenFile, SHOPIFY_ACCESS_TOKEN_FILE or SHOPIFY_ACCESS_TOKEN, in that
+// order. For the Storefront API the variables are SHOPIFY_STOREFRONT_ACCESS_TOKEN
+// and SHOPIFY_STOREFRONT_ACCESS_TOKEN_FILE.
+func shopifyCredentials(tokenFile string, storefront bool) (domain, accessToken string, err error) {
+	env := "SHOPIFY_ACCESS_TOKEN"
+	if storefront {
+		env = "SHOPIFY_STOREFRONT_ACCESS_TOKEN"
+	}
+	domain = os.Getenv("SHOPIFY_DOMAIN")
+	if tokenFile != "" {
+		accessToken, err = readSecretFile(tokenFile)
+	} else {
+		accessToken, err = secretEnv(env)
+	}
+	if err != nil {
+		return "", "", err
+	}
+	if domain == "" || accessToken == "" {
+		return "", "", fmt.Errorf("SHOPIFY_DOMAIN and %s (or %s_FILE) must be set", env, env)
+	}
+	return domain, accessToken, nil
+}
+
+// storefrontAPI is the --api value of the Storefront API.
+const storefrontAPI = "storefront"
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// clipboardOutput is the --output value that copies CSV output to the system
+// clipboard instead of writing a file.
+const clipboardOutput = "clipboard"
+
+// clipboardCommands lists the clipboard tools tried in order on each platform.
+func clipboardCommands() [][]string {
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{{"pbcopy"}}
+	case "windows":
+		return [][]string{{"clip.exe"}}
+	}
+	commands := [][]string{{"xclip", "-selection", "clipboard"}, {"xsel", "--clipboard", "--input"}}
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		commands = append([][]string{{"wl-copy"}}, commands...)
+	}
+	return commands
+}
+
+// copyToClipboard copies b to the system clipboard with the first available tool.
+func copyToClipboard(b []byte) error {
+	var tried []string
+	for _, args := range clipboardCommands() {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			tried = append(tried, args[0])
+			continue
+		}
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stdin = bytes.NewReader(b)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to copy to clipboard with %s: %w: %s", args[0], err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+	return fmt.Errorf("no clipboard tool found (tried %s)", strings.Join(tried, ", "))
+}
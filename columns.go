@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// column is a computed column appended to CSV file exports after csvHeader.
+type column struct {
+	Header string
+	Value  func(CustomerSegmentMember) string
+	// Explode, if set instead of Value, writes one row per value, or a single row
+	// with nullValue when there are none.
+	Explode func(CustomerSegmentMember) []string
+	// Group explodes columns together, such as the fields of an order: row i has
+	// value i of each. Columns exploded separately multiply the rows.
+	Group string
+}
+
+// extraColumns are added by flags such as --split-groups.
+var extraColumns []column
+
+// csvFields names the csvHeader columns for --exclude-fields, in the same order.
+var csvFields = []string{"id", "displayName", "email", "amountSpent", "currencyCode"}
+
+// attributeFields maps customerAttributes keys to their csvFields name.
+var attributeFields = map[string]string{
+	"display_name":  "displayName",
+	"email":         "email",
+	"amount_spent":  "amountSpent",
+	"currency_code": "currencyCode",
+}
+
+// piiFields are the direct identifiers dropped by --no-pii. The phone number is
+// not a CSV column but is part of the customer sent to some destinations.
+var piiFields = []string{"displayName", "email", "phone"}
+
+// excludedFields are the csvFields left out of CSV exports and destination
+// attributes, set by --exclude-fields and --no-pii.
+var excludedFields = map[string]bool{}
+
+// parseExcludedFields accepts csvFields names or their snake_case attribute names.
+func parseExcludedFields(s string) (map[string]bool, error) {
+	excluded := map[string]bool{}
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if field, ok := attributeFields[name]; ok {
+			name = field
+		}
+		known := name == "phone"
+		for _, f := range csvFields {
+			known = known || f == name
+		}
+		if !known {
+			return nil, fmt.Errorf("invalid --exclude-fields field %q, expected one of %s, phone", name, strings.Join(csvFields, ", "))
+		}
+		excluded[name] = true
+	}
+	return excluded, nil
+}
+
+// stripExcluded clears excluded identifying fields from the customer itself, so
+// they also stay out of destinations that send the whole customer node.
+func stripExcluded(c *CustomerSegmentMember) {
+	if excludedFields["displayName"] {
+		c.Node.DisplayName = ""
+	}
+	if excludedFields["email"] {
+		c.Node.DefaultEmailAddress = nil
+	}
+	if excludedFields["phone"] {
+		c.Node.DefaultPhoneNumber = nil
+	}
+}
+
+func outputHeader() []string {
+	var header []string
+	for i, h := range csvHeader {
+		if !excludedFields[csvFields[i]] {
+			header = append(header, h)
+		}
+	}
+	for _, col := range extraColumns {
+		header = append(header, col.Header)
+	}
+	return header
+}
+
+// outputRecords returns the CSV rows of a customer: one, or one per combination
+// of the values of exploded columns.
+func outputRecords(c CustomerSegmentMember) [][]string {
+	records := [][]string{outputRecord(c)}
+	offset := len(records[0]) - len(extraColumns)
+	exploded := map[int]bool{}
+	for i, col := range extraColumns {
+		if col.Explode == nil || exploded[i] {
+			continue
+		}
+		group := []int{i}
+		for j := i + 1; j < len(extraColumns); j++ {
+			if col.Group != "" && extraColumns[j].Explode != nil && extraColumns[j].Group == col.Group {
+				group = append(group, j)
+			}
+		}
+		values := make([][]string, len(group))
+		n := 1
+		for k, j := range group {
+			exploded[j] = true
+			values[k] = extraColumns[j].Explode(c)
+			n = max(n, len(values[k]))
+		}
+
+		var out [][]string
+		for _, record := range records {
+			for v := 0; v < n; v++ {
+				r := append([]string(nil), record...)
+				for k, j := range group {
+					r[offset+j] = nullValue
+					if v < len(values[k]) {
+						r[offset+j] = values[k][v]
+					}
+				}
+				out = append(out, r)
+			}
+		}
+		records = out
+	}
+	return records
+}
+
+// outputColumns returns the table columns of outputHeader for the warehouse
+// destinations: names holds one per csvHeader column, and extra columns are
+// named after their header in snake case, such as country_code.
+func outputColumns(names []string) []string {
+	var columns []string
+	for i, name := range names {
+		if !excludedFields[csvFields[i]] {
+			columns = append(columns, name)
+		}
+	}
+	for _, col := range extraColumns {
+		name := nonIdentifierChars.ReplaceAllString(strings.ToLower(col.Header), "_")
+		columns = append(columns, strings.Trim(name, "_"))
+	}
+	return columns
+}
+
+var nonIdentifierChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+func outputRecord(c CustomerSegmentMember) []string {
+	var record []string
+	for i, v := range csvRecord(c) {
+		if excludedFields[csvFields[i]] {
+			continue
+		}
+		if minorUnits && csvFields[i] == "amountSpent" {
+			v = minorUnitAmount(c.Node.AmountSpent.Amount, string(c.Node.AmountSpent.CurrencyCode))
+		}
+		record = append(record, v)
+	}
+	for _, col := range extraColumns {
+		if col.Value == nil {
+			record = append(record, "")
+			continue
+		}
+		record = append(record, col.Value(c))
+	}
+	return record
+}
@@ -0,0 +1,24 @@
+package main
+
+// consent restricts exports to customers who accepted marketing, set by
+// --consented-only, nil without it.
+var consent *consentFilter
+
+type consentFilter struct {
+	// sms also requires SMS marketing consent, with --sms-consent.
+	sms bool
+}
+
+// keep reports whether c's email, and with sms its phone number, is SUBSCRIBED.
+// Customers without an email address, or a phone number with sms, are dropped.
+func (f *consentFilter) keep(c CustomerSegmentMember) bool {
+	e := c.Node.DefaultEmailAddress
+	if e == nil || e.MarketingState != CustomerEmailAddressMarketingStateSubscribed {
+		return false
+	}
+	if f.sms {
+		p := c.Node.DefaultPhoneNumber
+		return p != nil && p.MarketingState == CustomerSmsMarketingStateSubscribed
+	}
+	return true
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"sultans/shopify"
+)
+
+// maxSingleQueryCost is the largest requested cost Shopify accepts for one
+// query; larger queries fail with MAX_COST_EXCEEDED.
+const maxSingleQueryCost = 1000
+
+const segmentCountQuery = `query CountCustomerSegmentMembers($query: String!) {
+	customerSegmentMembers(first: 1, query: $query) {
+		totalCount
+	}
+}`
+
+// queryCost is the cost extension of Shopify GraphQL responses.
+type queryCost struct {
+	RequestedQueryCost float64 `json:"requestedQueryCost"`
+	ActualQueryCost    float64 `json:"actualQueryCost"`
+	ThrottleStatus     struct {
+		MaximumAvailable   float64 `json:"maximumAvailable"`
+		CurrentlyAvailable float64 `json:"currentlyAvailable"`
+		RestoreRate        float64 `json:"restoreRate"`
+	} `json:"throttleStatus"`
+}
+
+// costResponse is a GraphQL response with its cost.
+type costResponse struct {
+	Data       json.RawMessage `json:"data"`
+	Errors     gqlerror.List   `json:"errors,omitempty"`
+	Extensions struct {
+		Cost *queryCost `json:"cost"`
+	} `json:"extensions"`
+}
+
+// costEstimate projects the query cost and duration of an export from the
+// segment size and the cost and latency of one page.
+type costEstimate struct {
+	Members       int     `json:"members"`
+	Customers     int     `json:"customers"`
+	PageSize      int     `json:"pageSize"`
+	Pages         int     `json:"pages"`
+	RequestedCost float64 `json:"requestedCostPerPage"`
+	ActualCost    float64 `json:"actualCostPerPage"`
+	TotalCost     float64 `json:"totalCost"`
+	// TotalRequestedCost is what the rate limit must have available over the
+	// export, which can exceed TotalCost.
+	TotalRequestedCost float64 `json:"totalRequestedCost"`
+	BucketSize         float64 `json:"bucketSize"`
+	RestoreRate        float64 `json:"restoreRate"`
+	PageLatencyMs      int64   `json:"pageLatencyMs"`
+	DurationSeconds    float64 `json:"durationSeconds"`
+	// LimitedBy is what bounds the duration: "latency", "cost" or "max-rps".
+	LimitedBy string `json:"limitedBy"`
+}
+
+func costCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "cost",
+		Usage: "Estimate the Shopify API cost of exports",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "estimate",
+				Usage: "Project the query cost and duration of exporting the segment of --query with the root flags",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "format", Value: "text", Usage: "Output format: text or json"},
+				},
+				Action: func(c *cli.Context) error {
+					format := c.String("format")
+					if format != "text" && format != "json" {
+						return fmt.Errorf("unsupported format %q, expected text or json", format)
+					}
+					client, err := newShopifyClient(c)
+					if err != nil {
+						return err
+					}
+					ctx, cancel := exportContext(c.Duration("timeout"))
+					defer cancel()
+					e, err := estimateCost(ctx, client, segmentQueryFromFlags(c), c.Float64("max-rps"))
+					if err != nil {
+						return err
+					}
+					if format == "json" {
+						enc := json.NewEncoder(os.Stdout)
+						enc.SetIndent("", "  ")
+						return enc.Encode(e)
+					}
+					return writeCostEstimate(os.Stdout, e)
+				},
+			},
+		},
+	}
+}
+
+// estimateCost counts the members of the segment and fetches its first page,
+// whose requested and actual cost every full page of the export shares.
+func estimateCost(ctx context.Context, client *shopify.Client, q SegmentQuery, maxRPS float64) (costEstimate, error) {
+	pageSize := q.PageSize
+	if pageSize <= 0 || pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	var e costEstimate
+	// --max-rps is accounted for below rather than applied to these two
+	// requests, so the page latency is Shopify's.
+	unlimited := *client
+	unlimited.Limiter = nil
+	client = &unlimited
+
+	var count struct {
+		CustomerSegmentMembers struct {
+			TotalCount int `json:"totalCount"`
+		} `json:"customerSegmentMembers"`
+	}
+	if _, err := costQuery(ctx, client, segmentCountQuery, map[string]interface{}{"query": q.Query}, &count); err != nil {
+		return e, fmt.Errorf("failed to count segment members: %w", err)
+	}
+	e.Members = count.CustomerSegmentMembers.TotalCount
+	e.Customers = min(e.Members, q.First)
+	e.PageSize = min(pageSize, max(q.First, 1))
+	e.Pages = (e.Customers + e.PageSize - 1) / e.PageSize
+
+	variables := map[string]interface{}{"first": e.PageSize, "query": q.Query, "reverse": q.Reverse}
+	if q.SortKey != "" {
+		variables["sortKey"] = q.SortKey
+	}
+	start := time.Now()
+	cost, err := costQuery(ctx, client, GetCustomerSegmentMembers_Operation, variables, nil)
+	if err != nil {
+		return e, fmt.Errorf("failed to fetch a page: %w", err)
+	}
+	latency := time.Since(start)
+	if cost == nil {
+		return e, fmt.Errorf("the response has no cost extension to estimate from")
+	}
+
+	e.RequestedCost = cost.RequestedQueryCost
+	e.ActualCost = cost.ActualQueryCost
+	e.TotalCost = e.ActualCost * float64(e.Pages)
+	e.TotalRequestedCost = e.RequestedCost * float64(e.Pages)
+	e.BucketSize = cost.ThrottleStatus.MaximumAvailable
+	e.RestoreRate = cost.ThrottleStatus.RestoreRate
+	e.PageLatencyMs = latency.Milliseconds()
+
+	// Pages are fetched one after another, so the export takes at least a page
+	// latency per page; the cost beyond what is available at the start is
+	// restored at the restore rate; and --max-rps spaces out the requests.
+	durations := map[string]float64{"latency": latency.Seconds() * float64(e.Pages)}
+	if e.RestoreRate > 0 {
+		durations["cost"] = math.Max(e.TotalCost-cost.ThrottleStatus.CurrentlyAvailable, 0) / e.RestoreRate
+	}
+	if maxRPS > 0 {
+		durations["max-rps"] = float64(e.Pages) / maxRPS
+	}
+	for _, limit := range []string{"latency", "cost", "max-rps"} {
+		if d, ok := durations[limit]; ok && (e.LimitedBy == "" || d > e.DurationSeconds) {
+			e.DurationSeconds, e.LimitedBy = d, limit
+		}
+	}
+	return e, nil
+}
+
+// costQuery sends a query and returns the cost of its response. out, if
+// non-nil, receives the data.
+func costQuery(ctx context.Context, client *shopify.Client, query string, variables map[string]interface{}, out interface{}) (*queryCost, error) {
+	var resp costResponse
+	requestID, err := client.Execute(ctx, shopify.Request{Query: query, Variables: variables}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Errors) > 0 {
+		return nil, shopify.WithRequestID(fmt.Errorf("GraphQL errors: %w", shopify.GraphQLErrors{List: resp.Errors}), requestID)
+	}
+	if out != nil {
+		if err := json.Unmarshal(resp.Data, out); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return resp.Extensions.Cost, nil
+}
+
+func writeCostEstimate(w io.Writer, e costEstimate) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Segment members:\t%d\n", e.Members)
+	fmt.Fprintf(tw, "Customers exported:\t%d (--first %s)\n", e.Customers, limitText(e.Customers < e.Members))
+	fmt.Fprintf(tw, "Pages:\t%d of up to %d customers\n", e.Pages, e.PageSize)
+	fmt.Fprintf(tw, "Cost per page:\t%.0f points (%.0f requested)\n", e.ActualCost, e.RequestedCost)
+	fmt.Fprintf(tw, "Total cost:\t%.0f points (%.0f requested)\n", e.TotalCost, e.TotalRequestedCost)
+	fmt.Fprintf(tw, "Rate limit:\t%.0f point bucket, restoring %.0f points/s\n", e.BucketSize, e.RestoreRate)
+	fmt.Fprintf(tw, "Page latency:\t%s\n", time.Duration(e.PageLatencyMs)*time.Millisecond)
+	fmt.Fprintf(tw, "Estimated duration:\t%s (limited by %s)\n", roundDuration(time.Duration(e.DurationSeconds*float64(time.Second))), e.LimitedBy)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if e.RequestedCost > maxSingleQueryCost {
+		fmt.Fprintf(w, "\nwarning: a page requests %.0f points, more than the %d Shopify allows per query; lower --page-size\n", e.RequestedCost, maxSingleQueryCost)
+	}
+	return nil
+}
+
+// roundDuration rounds to seconds, or tenths of a second below a minute.
+func roundDuration(d time.Duration) time.Duration {
+	if d < time.Minute {
+		return d.Round(100 * time.Millisecond)
+	}
+	return d.Round(time.Second)
+}
+
+func limitText(limited bool) string {
+	if limited {
+		return "limits the export"
+	}
+	return "covers the segment"
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"log"
+	"strings"
+	"sync"
+)
+
+// iso4217 lists the active ISO 4217 currency codes.
+var iso4217 = map[string]bool{}
+
+func init() {
+	for _, code := range strings.Fields(`
+		AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BHD BIF BMD BND BOB
+		BOV BRL BSD BTN BWP BYN BZD CAD CDF CHE CHF CHW CLF CLP CNY COP COU CRC CUC
+		CUP CVE CZK DJF DKK DOP DZD EGP ERN ETB EUR FJD FKP GBP GEL GHS GIP GMD GNF
+		GTQ GYD HKD HNL HTG HUF IDR ILS INR IQD IRR ISK JMD JOD JPY KES KGS KHR KMF
+		KPW KRW KWD KYD KZT LAK LBP LKR LRD LSL LYD MAD MDL MGA MKD MMK MNT MOP MRU
+		MUR MVR MWK MXN MXV MYR MZN NAD NGN NIO NOK NPR NZD OMR PAB PEN PGK PHP PKR
+		PLN PYG QAR RON RSD RUB RWF SAR SBD SCR SDG SEK SGD SHP SLE SLL SOS SRD SSP
+		STN SVC SYP SZL THB TJS TMT TND TOP TRY TTD TWD TZS UAH UGX USD USN UYI UYU
+		UYW UZS VED VES VND VUV WST XAF XCD XCG XDR XOF XPF XSU XUA YER ZAR ZMW ZWG ZWL`) {
+		iso4217[code] = true
+	}
+}
+
+// currencyExponents lists the ISO 4217 currencies whose minor unit is not a
+// hundredth: how many decimal places their amounts have.
+var currencyExponents = map[string]int32{
+	"BHD": 3, "BIF": 0, "CLF": 4, "CLP": 0, "DJF": 0, "GNF": 0, "IQD": 3,
+	"ISK": 0, "JOD": 3, "JPY": 0, "KMF": 0, "KRW": 0, "KWD": 3, "LYD": 3,
+	"OMR": 3, "PYG": 0, "RWF": 0, "TND": 3, "UGX": 0, "UYI": 0, "UYW": 4,
+	"VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+}
+
+// currencyExponent returns the number of decimals of the minor unit of code,
+// such as 2 for USD cents and 0 for JPY. Unknown codes are assumed to have 2.
+func currencyExponent(code string) int32 {
+	if exp, ok := currencyExponents[code]; ok {
+		return exp
+	}
+	return 2
+}
+
+// warnedCurrencies remembers the unknown codes already logged.
+var warnedCurrencies sync.Map
+
+// checkCurrency logs a warning the first time an amount has a currency code that
+// is not in ISO 4217, since its decimals are then only assumed.
+func checkCurrency(code string) {
+	if iso4217[code] {
+		return
+	}
+	if _, warned := warnedCurrencies.LoadOrStore(code, true); !warned {
+		log.Printf("warning: unknown ISO 4217 currency code %q, assuming 2 decimals", code)
+	}
+}
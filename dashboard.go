@@ -0,0 +1,386 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Limits of what the dashboard lists.
+const (
+	dashboardJobs   = 50
+	dashboardErrors = 20
+)
+
+// dashboardJob is an export recorded in the run history, such as the runs of a
+// cron job, identified by its shop, query and output.
+type dashboardJob struct {
+	ID       string     `json:"id"`
+	Shop     string     `json:"shop,omitempty"`
+	Query    string     `json:"query"`
+	Output   string     `json:"output"`
+	Last     historyRun `json:"last"`
+	Runs     int        `json:"runs"`
+	Failures int        `json:"failures"`
+	Running  bool       `json:"running"`
+	// Runnable is whether the dashboard can run the job: its shop is served
+	// and its output is a file or destination.
+	Runnable bool `json:"runnable"`
+}
+
+// exportError is a failed on-demand export of serve.
+type exportError struct {
+	Time  time.Time `json:"time"`
+	Shop  string    `json:"shop,omitempty"`
+	Error string    `json:"error"`
+}
+
+// serveErrors keeps the latest on-demand export errors for the dashboard.
+var serveErrors struct {
+	sync.Mutex
+	list []exportError
+}
+
+func recordExportError(shop string, err error) {
+	serveErrors.Lock()
+	defer serveErrors.Unlock()
+	serveErrors.list = append(serveErrors.list, exportError{Time: time.Now().UTC(), Shop: shop, Error: redaction.redact(err.Error())})
+	if len(serveErrors.list) > dashboardErrors {
+		serveErrors.list = serveErrors.list[1:]
+	}
+}
+
+// dashboard serves a web UI of the jobs in the run history, their last runs and
+// recent errors, with buttons to run a job now.
+type dashboard struct {
+	c       *cli.Context
+	token   string
+	tenants map[string]*tenant
+	health  *serveHealth
+
+	mu      sync.Mutex
+	running map[string]bool
+}
+
+func newDashboard(c *cli.Context, token string, tenants []*tenant, h *serveHealth) *dashboard {
+	d := &dashboard{c: c, token: token, tenants: map[string]*tenant{}, health: h, running: map[string]bool{}}
+	for _, t := range tenants {
+		d.tenants[t.name] = t
+	}
+	return d
+}
+
+// register adds GET /dashboard, with ?format=json for scripts, and POST
+// /dashboard/run to mux.
+func (d *dashboard) register(mux *http.ServeMux) {
+	mux.Handle("/dashboard", d.requireToken(http.HandlerFunc(d.serveDashboard)))
+	mux.Handle("/dashboard/run", d.requireToken(http.HandlerFunc(d.serveRun)))
+}
+
+// requireToken accepts the dashboard token as the password of HTTP basic
+// authentication, for browsers, or as a bearer token. Form posts from other
+// sites are rejected, since browsers resend basic credentials with them.
+func (d *dashboard) requireToken(next http.Handler) http.Handler {
+	bearer := []byte("Bearer " + d.token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, password, basic := r.BasicAuth()
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), bearer) != 1 &&
+			!(basic && subtle.ConstantTimeCompare([]byte(password), []byte(d.token)) == 1) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="shopify-customers"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodPost && !sameOrigin(r) {
+			http.Error(w, "cross-origin request", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func sameOrigin(r *http.Request) bool {
+	if site := r.Header.Get("Sec-Fetch-Site"); site != "" && site != "same-origin" && site != "none" {
+		return false
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		return err == nil && u.Host == r.Host
+	}
+	return true
+}
+
+func (d *dashboard) serveDashboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	jobs, failed, err := d.jobs()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read run history: %v", err), http.StatusInternalServerError)
+		return
+	}
+	serveErrors.Lock()
+	exportErrors := append([]exportError(nil), serveErrors.list...)
+	serveErrors.Unlock()
+	sort.Slice(exportErrors, func(i, j int) bool { return exportErrors[i].Time.After(exportErrors[j].Time) })
+
+	var shops []dashboardShop
+	for _, t := range d.health.tenants {
+		s := dashboardShop{Name: t.name, Domain: t.client.Domain, Status: "ready"}
+		if t.client.Breaker.IsOpen() {
+			s.Status = "circuit open"
+		}
+		shops = append(shops, s)
+	}
+	data := dashboardData{
+		Generated:    time.Now().UTC(),
+		Shops:        shops,
+		Jobs:         jobs,
+		FailedRuns:   failed,
+		ExportErrors: exportErrors,
+	}
+	// The result of a Run now button, identified by the job.
+	for _, job := range jobs {
+		switch job.ID {
+		case r.URL.Query().Get("started"):
+			data.Message = "Started the export to " + outputName(job.Output) + "."
+		case r.URL.Query().Get("busy"):
+			data.Message = "The export to " + outputName(job.Output) + " is already running."
+		}
+	}
+	if r.URL.Query().Get("format") == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(data)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := dashboardTemplate.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+type dashboardShop struct {
+	Name   string `json:"name,omitempty"`
+	Domain string `json:"domain"`
+	Status string `json:"status"`
+}
+
+type dashboardData struct {
+	Generated    time.Time       `json:"generated"`
+	Shops        []dashboardShop `json:"shops"`
+	Jobs         []dashboardJob  `json:"jobs"`
+	FailedRuns   []historyRun    `json:"failedRuns"`
+	ExportErrors []exportError   `json:"exportErrors"`
+	Message      string          `json:"-"`
+}
+
+// jobs groups the export runs of the history into jobs, most recently run
+// first, and returns the latest failed runs.
+func (d *dashboard) jobs() ([]dashboardJob, []historyRun, error) {
+	runs, err := readHistory(d.c.String("history-file"))
+	if err != nil {
+		return nil, nil, err
+	}
+	byID := map[string]*dashboardJob{}
+	var failed []historyRun
+	for _, run := range runs {
+		if run.Command != "export" {
+			continue
+		}
+		id := dashboardJobID(run.Shop, run.Query, run.Output)
+		job := byID[id]
+		if job == nil {
+			job = &dashboardJob{ID: id, Shop: run.Shop, Query: run.Query, Output: run.Output}
+			byID[id] = job
+		}
+		job.Runs++
+		if run.Status == "failed" {
+			job.Failures++
+			failed = append(failed, run)
+		}
+		if !run.StartedAt.Before(job.Last.StartedAt) {
+			job.Last = run
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	jobs := make([]dashboardJob, 0, len(byID))
+	for _, job := range byID {
+		job.Running = d.running[job.ID]
+		job.Runnable = d.tenants[job.Shop] != nil && job.Output != "" && job.Output != clipboardOutput
+		jobs = append(jobs, *job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Last.StartedAt.After(jobs[j].Last.StartedAt) })
+	if len(jobs) > dashboardJobs {
+		jobs = jobs[:dashboardJobs]
+	}
+	sort.Slice(failed, func(i, j int) bool { return failed[i].StartedAt.After(failed[j].StartedAt) })
+	if len(failed) > dashboardErrors {
+		failed = failed[:dashboardErrors]
+	}
+	return jobs, failed, nil
+}
+
+func dashboardJobID(shop, query, output string) string {
+	sum := sha256.Sum256([]byte(shop + "\x00" + query + "\x00" + output))
+	return hex.EncodeToString(sum[:8])
+}
+
+// serveRun starts the job of the "job" form value in the background and
+// redirects back to the dashboard.
+func (d *dashboard) serveRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	jobs, _, err := d.jobs()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read run history: %v", err), http.StatusInternalServerError)
+		return
+	}
+	id := r.FormValue("job")
+	var job *dashboardJob
+	for i := range jobs {
+		if jobs[i].ID == id {
+			job = &jobs[i]
+		}
+	}
+	result := "started"
+	switch {
+	case job == nil:
+		http.Error(w, "unknown job", http.StatusNotFound)
+		return
+	case !job.Runnable:
+		http.Error(w, "the job cannot be run from the dashboard", http.StatusBadRequest)
+		return
+	case !d.start(*job):
+		result = "busy"
+	}
+	http.Redirect(w, r, "/dashboard?"+result+"="+job.ID, http.StatusSeeOther)
+}
+
+// start runs job in the background like an export of the CLI, with the root
+// flags of serve, and reports false if it is already running.
+func (d *dashboard) start(job dashboardJob) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.running[job.ID] {
+		return false
+	}
+	d.running[job.ID] = true
+
+	go func() {
+		defer func() {
+			d.mu.Lock()
+			delete(d.running, job.ID)
+			d.mu.Unlock()
+		}()
+		t := d.tenants[job.Shop]
+		// Runs recorded before segments were kept use the serve defaults.
+		q := t.defaults
+		if job.Last.Segment != nil {
+			q = *job.Last.Segment
+		}
+		q.Query = job.Query
+
+		run := startRun("export", q, job.Output)
+		run.Shop = job.Shop
+		exported, err := 0, runHook(d.c, "pre-hook", run)
+		if err == nil {
+			var lock *runLock
+			if lock, err = acquireRunLock(d.c, job.Output); err == nil {
+				ctx, cancel := exportContext(t.timeout)
+				exported, err = exportSegment(ctx, d.c, t.client, nil, q, job.Output, nil)
+				cancel()
+				lock.release()
+			}
+		}
+		if err = runPostHook(d.c, recordRun(d.c, run, exported, err), err); err != nil {
+			t.logf("dashboard run of the export to %s failed: %v", outputName(job.Output), err)
+			return
+		}
+		t.logf("dashboard run exported %d customers to %s", exported, outputName(job.Output))
+	}()
+	return true
+}
+
+var dashboardTemplate = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"time": func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.UTC().Format("2006-01-02 15:04:05 UTC")
+	},
+	"duration": func(ms int64) string { return roundDuration(time.Duration(ms) * time.Millisecond).String() },
+	"output":   outputName,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="10">
+<title>shopify-customers</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em auto; max-width: 1100px; color: #222; }
+h1 { margin-bottom: 0; }
+.meta { color: #666; margin-top: 0.3em; }
+.message { background: #eef6ee; padding: 0.5em 1em; }
+table { border-collapse: collapse; margin: 1em 0; width: 100%; }
+th, td { padding: 4px 12px; text-align: left; border-bottom: 1px solid #ddd; vertical-align: top; }
+td.number { text-align: right; }
+.success { color: #2a7d2a; }
+.partial { color: #b07800; }
+.failed, .open { color: #b42318; }
+code { font-size: 0.9em; }
+</style>
+</head>
+<body>
+<h1>shopify-customers</h1>
+<p class="meta">Updated {{time .Generated}}, refreshed every 10 seconds.</p>
+{{if .Message}}<p class="message">{{.Message}}</p>{{end}}
+
+<h2>Shops</h2>
+<table>
+<tr><th>Shop</th><th>Domain</th><th>Status</th></tr>
+{{range .Shops}}<tr><td>{{or .Name "default"}}</td><td>{{.Domain}}</td><td class="{{if eq .Status "ready"}}success{{else}}open{{end}}">{{.Status}}</td></tr>
+{{end}}</table>
+
+<h2>Jobs</h2>
+{{if .Jobs}}<table>
+<tr><th>Query</th><th>Output</th><th>Last run</th><th>Status</th><th>Rows</th><th>Duration</th><th>Runs</th><th>Failures</th><th></th></tr>
+{{range .Jobs}}<tr>
+<td>{{if .Shop}}{{.Shop}}: {{end}}<code>{{.Query}}</code></td>
+<td>{{output .Output}}</td>
+<td>{{time .Last.StartedAt}}</td>
+<td class="{{.Last.Status}}">{{.Last.Status}}{{if .Last.Error}}<br><small>{{.Last.Error}}</small>{{end}}</td>
+<td class="number">{{.Last.Rows}}</td>
+<td class="number">{{duration .Last.DurationMs}}</td>
+<td class="number">{{.Runs}}</td>
+<td class="number">{{.Failures}}</td>
+<td>{{if .Running}}running…{{else if .Runnable}}<form method="post" action="/dashboard/run"><input type="hidden" name="job" value="{{.ID}}"><button>Run now</button></form>{{end}}</td>
+</tr>
+{{end}}</table>
+{{else}}<p>No exports in the run history yet.</p>
+{{end}}
+
+<h2>Recent errors</h2>
+{{if or .FailedRuns .ExportErrors}}<table>
+<tr><th>Time</th><th>Export</th><th>Error</th></tr>
+{{range .FailedRuns}}<tr><td>{{time .StartedAt}}</td><td>{{if .Shop}}{{.Shop}}: {{end}}{{output .Output}}</td><td class="failed">{{.Error}}</td></tr>
+{{end}}{{range .ExportErrors}}<tr><td>{{time .Time}}</td><td>{{if .Shop}}{{.Shop}}: {{end}}on-demand export</td><td class="failed">{{.Error}}</td></tr>
+{{end}}</table>
+{{else}}<p>No recent errors.</p>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	// Embedded so shop time zones load on systems without a zoneinfo database.
+	_ "time/tzdata"
+
+	"github.com/urfave/cli/v2"
+)
+
+// dateLocation and dateLayout format exported timestamps, set by --timezone and
+// --date-format. dateLocation is nil until resolveDateLocation, which only calls
+// the API for the shop's zone when an export actually has timestamps.
+var (
+	dateLocation *time.Location
+	dateLayout   = time.RFC3339
+)
+
+// unixLayout is the --date-format of Unix timestamps in seconds.
+const unixLayout = "unix"
+
+// parseDateFormat returns the layout of a --date-format: rfc3339, date, unix or
+// a Go time layout such as "02.01.2006 15:04".
+func parseDateFormat(s string) (string, error) {
+	switch strings.ToLower(s) {
+	case "", "rfc3339":
+		return time.RFC3339, nil
+	case "date":
+		return time.DateOnly, nil
+	case "datetime":
+		return time.DateTime, nil
+	case unixLayout:
+		return unixLayout, nil
+	}
+	// A layout without any reference time element would write the same text for
+	// every timestamp.
+	if time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC).Format(s) == s {
+		return "", fmt.Errorf("invalid --date-format %q, expected rfc3339, date, datetime, unix or a Go layout such as 2006-01-02 15:04", s)
+	}
+	return s, nil
+}
+
+// resolveDateLocation loads --timezone or, without it, the shop's IANA time zone,
+// so dates line up with the store's reporting day.
+func resolveDateLocation(ctx context.Context, c *cli.Context) error {
+	if dateLocation != nil {
+		return nil
+	}
+	name := c.String("timezone")
+	if name == "" {
+		client, err := newShopifyClient(c)
+		if err != nil {
+			return err
+		}
+		resp, err := GetShop(ctx, client)
+		if err != nil {
+			return fmt.Errorf("failed to fetch the shop time zone (set --timezone to skip): %w", err)
+		}
+		name = resp.Shop.IanaTimezone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return fmt.Errorf("invalid time zone %q: %w", name, err)
+	}
+	dateLocation = loc
+	return nil
+}
+
+// exportLocation returns dateLocation, or UTC before it is resolved.
+func exportLocation() *time.Location {
+	if dateLocation == nil {
+		return time.UTC
+	}
+	return dateLocation
+}
+
+// formatTime writes t in the export time zone with dateLayout.
+func formatTime(t time.Time) string {
+	if dateLayout == unixLayout {
+		return strconv.FormatInt(t.Unix(), 10)
+	}
+	return t.In(exportLocation()).Format(dateLayout)
+}
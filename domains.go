@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/shopspring/decimal"
+	"github.com/urfave/cli/v2"
+)
+
+// domains aggregates the exported customers by email domain for --domain-report,
+// nil without it.
+var domains *domainReport
+
+// freeMailDomains are the consumer email providers. Other domains are assumed
+// to belong to a company.
+var freeMailDomains = map[string]bool{}
+
+func init() {
+	for _, d := range strings.Fields(`
+		gmail.com googlemail.com
+		yahoo.com yahoo.co.uk yahoo.co.jp yahoo.fr yahoo.de yahoo.es yahoo.it yahoo.ca yahoo.com.au yahoo.com.br yahoo.co.in ymail.com rocketmail.com
+		hotmail.com hotmail.co.uk hotmail.fr hotmail.de hotmail.es hotmail.it outlook.com outlook.fr outlook.de live.com live.co.uk live.fr live.de msn.com
+		icloud.com me.com mac.com aol.com aim.com
+		proton.me protonmail.com pm.me tutanota.com tuta.io zoho.com mail.com email.com fastmail.com hushmail.com
+		gmx.com gmx.de gmx.net gmx.at gmx.ch web.de t-online.de freenet.de posteo.de
+		orange.fr wanadoo.fr free.fr laposte.net sfr.fr
+		libero.it virgilio.it tiscali.it
+		mail.ru yandex.ru yandex.com rambler.ru inbox.ru list.ru bk.ru
+		qq.com 163.com 126.com sina.com yeah.net
+		naver.com hanmail.net daum.net
+		comcast.net verizon.net att.net sbcglobal.net cox.net charter.net bellsouth.net earthlink.net
+		btinternet.com sky.com virginmedia.com ntlworld.com talktalk.net
+		shaw.ca rogers.com sympatico.ca bigpond.com optusnet.com.au
+		seznam.cz wp.pl o2.pl onet.pl interia.pl
+		uol.com.br bol.com.br terra.com.br`) {
+		freeMailDomains[d] = true
+	}
+}
+
+// domainReport counts the customers and sums their spend per email domain, so
+// company accounts in consumer segments can be found by their corporate domain.
+type domainReport struct {
+	path string
+
+	customers int
+	withEmail int
+	domains   map[string]*domainStats
+}
+
+type domainStats struct {
+	customers int
+	// totals are per currency, since amounts in different currencies cannot be
+	// added up.
+	totals map[string]decimal.Decimal
+}
+
+func newDomainReport(c *cli.Context) (*domainReport, error) {
+	path := c.String("domain-report")
+	if path == "" {
+		return nil, nil
+	}
+	if c.String("queries-file") != "" {
+		return nil, fmt.Errorf("--domain-report cannot be combined with --queries-file")
+	}
+	return &domainReport{path: path}, nil
+}
+
+// start returns an empty report of one export for the same file as r, or nil
+// without --domain-report.
+func (r *domainReport) start() *domainReport {
+	if r == nil {
+		return nil
+	}
+	return &domainReport{path: r.path, domains: map[string]*domainStats{}}
+}
+
+// wrap records the members of in as they pass through.
+func (r *domainReport) wrap(ctx context.Context, in *segmentStream) *segmentStream {
+	return filterStream(ctx, in, func(c CustomerSegmentMember) bool {
+		r.add(c)
+		return true
+	})
+}
+
+func (r *domainReport) add(c CustomerSegmentMember) {
+	r.customers++
+	e := c.Node.DefaultEmailAddress
+	if e == nil {
+		return
+	}
+	_, domain, ok := strings.Cut(normalizeEmail(e.EmailAddress), "@")
+	if !ok || domain == "" {
+		return
+	}
+	r.withEmail++
+	s := r.domains[domain]
+	if s == nil {
+		s = &domainStats{totals: map[string]decimal.Decimal{}}
+		r.domains[domain] = s
+	}
+	s.customers++
+	currency := string(c.Node.AmountSpent.CurrencyCode)
+	s.totals[currency] = s.totals[currency].Add(c.Node.AmountSpent.Amount)
+}
+
+type domainSummary struct {
+	Domain    string            `json:"domain"`
+	Type      string            `json:"type"`
+	Customers int               `json:"customers"`
+	Spend     map[string]string `json:"spend"`
+}
+
+type domainReportSummary struct {
+	Customers int             `json:"customers"`
+	WithEmail int             `json:"withEmail"`
+	Corporate int             `json:"corporateCustomers"`
+	Domains   []domainSummary `json:"domains"`
+}
+
+// summarize lists the domains by number of customers, most first.
+func (r *domainReport) summarize() domainReportSummary {
+	s := domainReportSummary{Customers: r.customers, WithEmail: r.withEmail}
+	for domain, stats := range r.domains {
+		d := domainSummary{Domain: domain, Type: "corporate", Customers: stats.customers, Spend: map[string]string{}}
+		if freeMailDomains[domain] {
+			d.Type = "free"
+		} else {
+			s.Corporate += stats.customers
+		}
+		for currency, total := range stats.totals {
+			d.Spend[currency] = total.StringFixed(2)
+		}
+		s.Domains = append(s.Domains, d)
+	}
+	sort.Slice(s.Domains, func(i, j int) bool {
+		a, b := s.Domains[i], s.Domains[j]
+		if a.Customers != b.Customers {
+			return a.Customers > b.Customers
+		}
+		return a.Domain < b.Domain
+	})
+	return s
+}
+
+// spend joins the totals of a domain, in currency order, e.g. "120.00 EUR;
+// 4,310.50 USD".
+func (d domainSummary) spend(format func(decimal.Decimal, string) string) string {
+	var currencies []string
+	for currency := range d.Spend {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	parts := make([]string, len(currencies))
+	for i, currency := range currencies {
+		amount, _ := decimal.NewFromString(d.Spend[currency])
+		parts[i] = format(amount, currency)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// write saves the report as JSON if its file ends in .json, CSV for .csv and
+// otherwise a text table.
+func (r *domainReport) write() error {
+	file, err := os.Create(r.path)
+	if err != nil {
+		return fmt.Errorf("failed to write domain report: %w", err)
+	}
+	s := r.summarize()
+	switch strings.ToLower(filepath.Ext(r.path)) {
+	case ".json":
+		enc := json.NewEncoder(file)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(s)
+	case ".csv":
+		err = writeDomainCSV(file, s)
+	default:
+		err = writeDomainText(file, s)
+	}
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write domain report: %w", err)
+	}
+	return file.Close()
+}
+
+func writeDomainCSV(w io.Writer, s domainReportSummary) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"Domain", "Type", "Customers", "Spend"})
+	for _, d := range s.Domains {
+		cw.Write([]string{d.Domain, d.Type, strconv.Itoa(d.Customers), d.spend(func(amount decimal.Decimal, currency string) string {
+			return amount.StringFixed(2) + " " + currency
+		})})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeDomainText(w io.Writer, s domainReportSummary) error {
+	fmt.Fprintf(w, "Customers: %d\nWith an email address: %d\nAt corporate domains: %d (%.1f%%)\n\n", s.Customers, s.WithEmail, s.Corporate, percentOf(s.Corporate, s.WithEmail))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DOMAIN\tTYPE\tCUSTOMERS\tSPEND")
+	for _, d := range s.Domains {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", d.Domain, d.Type, d.Customers, d.spend(humanAmounts.formatWithCode))
+	}
+	return tw.Flush()
+}
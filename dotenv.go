@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/joho/godotenv"
+	"github.com/urfave/cli/v2"
+)
+
+// loadEnvFiles sets the variables of the --env-file files, later files
+// overriding earlier ones, or of ./.env if it exists and no file is given.
+// Variables already set in the environment always win. --no-env-file loads
+// nothing, so a stray .env in the working directory cannot select a store.
+func loadEnvFiles(c *cli.Context) error {
+	files := c.StringSlice("env-file")
+	if c.Bool("no-env-file") {
+		if len(files) > 0 {
+			return fmt.Errorf("--env-file and --no-env-file cannot be combined")
+		}
+		return nil
+	}
+	if len(files) == 0 {
+		if _, err := os.Stat(".env"); errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		files = []string{".env"}
+	}
+
+	vars := map[string]string{}
+	for _, file := range files {
+		fileVars, err := godotenv.Read(file)
+		if err != nil {
+			return fmt.Errorf("failed to load env file: %w", err)
+		}
+		for k, v := range fileVars {
+			vars[k] = v
+		}
+	}
+	for k, v := range vars {
+		if _, ok := os.LookupEnv(k); !ok {
+			os.Setenv(k, v)
+			if isSecretEnv(k) {
+				redaction.addSecret(v)
+			}
+		}
+	}
+	return nil
+}
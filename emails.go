@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/mail"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/urfave/cli/v2"
+)
+
+// emails validates the emails of fetched customers when --validate-emails is set.
+var emails *emailValidator
+
+// emailValidator trims and lowercases email addresses and removes syntactically
+// invalid ones, which are exported as missing and optionally written to a
+// rejects file. It is shared by concurrent exports.
+type emailValidator struct {
+	stripPlus bool
+
+	mu       sync.Mutex
+	file     *os.File
+	rejects  *csv.Writer
+	rejected int
+}
+
+func newEmailValidator(c *cli.Context) (*emailValidator, error) {
+	if !c.Bool("validate-emails") {
+		return nil, nil
+	}
+	v := &emailValidator{stripPlus: c.Bool("strip-plus-addressing")}
+	if path := c.String("email-rejects"); path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create email rejects file: %w", err)
+		}
+		v.file = f
+		v.rejects = csv.NewWriter(f)
+		if err := v.rejects.Write([]string{"ID", "Display Name", "Email Address", "Reason"}); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to write email rejects file: %w", err)
+		}
+	}
+	return v, nil
+}
+
+// apply normalizes the email of c, clearing it if it is invalid.
+func (v *emailValidator) apply(c *CustomerSegmentMember) error {
+	e := c.Node.DefaultEmailAddress
+	if e == nil {
+		return nil
+	}
+	email, reason := v.normalize(e.EmailAddress)
+	if reason == "" {
+		e.EmailAddress = email
+		return nil
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.rejected++
+	if v.rejects != nil {
+		if err := v.rejects.Write([]string{c.Node.Id, c.Node.DisplayName, e.EmailAddress, reason}); err != nil {
+			return fmt.Errorf("failed to write email rejects file: %w", err)
+		}
+	}
+	c.Node.DefaultEmailAddress = nil
+	return nil
+}
+
+// normalize returns the normalized address, or the reason it is invalid.
+func (v *emailValidator) normalize(email string) (string, string) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || addr.Name != "" {
+		return "", "invalid syntax"
+	}
+	local, domain, _ := strings.Cut(email, "@")
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return "", "invalid domain"
+	}
+	if v.stripPlus {
+		if before, _, ok := strings.Cut(local, "+"); ok && before != "" {
+			email = before + "@" + domain
+		}
+	}
+	return email, ""
+}
+
+// Close flushes the rejects file and logs how many addresses were rejected.
+func (v *emailValidator) Close() error {
+	if v.rejected > 0 {
+		log.Printf("%d invalid email addresses were removed", v.rejected)
+	}
+	if v.file == nil {
+		return nil
+	}
+	v.rejects.Flush()
+	if err := v.rejects.Error(); err != nil {
+		v.file.Close()
+		return fmt.Errorf("failed to write email rejects file: %w", err)
+	}
+	return v.file.Close()
+}
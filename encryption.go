@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/99designs/keyring"
+)
+
+// storeKeyEnv holds the base64 encoded 32-byte key of --encrypt-stores. Without
+// it the key is kept in the OS keychain.
+const storeKeyEnv = "SHOPIFY_CUSTOMERS_STORE_KEY"
+
+// storeKeyItem is the keychain item of the store key.
+const storeKeyItem = "store-key"
+
+// sealedPrefix marks values encrypted by storeCipher, so plaintext left from runs
+// without --encrypt-stores is recognized rather than read as garbage.
+var sealedPrefix = []byte("SCE1")
+
+// stores encrypts the state database, response cache and checkpoint journals
+// with --encrypt-stores. A nil stores leaves them in plaintext.
+var stores *storeCipher
+
+// storeCipher encrypts local stores with AES-256-GCM and derives opaque names
+// for the keys they look values up by, such as customer IDs.
+type storeCipher struct {
+	aead cipher.AEAD
+	// nameKey is the HMAC key of name, derived from the store key so the two
+	// uses do not share a key.
+	nameKey []byte
+}
+
+func newStoreCipher(key []byte) (*storeCipher, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("invalid store key: expected 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("names"))
+	return &storeCipher{aead: aead, nameKey: mac.Sum(nil)}, nil
+}
+
+// loadStoreCipher reads the key from storeKeyEnv (or storeKeyEnv_FILE), or else
+// from the OS keychain, where a new key is created on first use.
+func loadStoreCipher() (*storeCipher, error) {
+	encoded, err := secretEnv(storeKeyEnv)
+	if err != nil {
+		return nil, err
+	}
+	if encoded == "" {
+		if encoded, err = keychainStoreKey(); err != nil {
+			return nil, err
+		}
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("invalid store key: expected base64, such as the output of openssl rand -base64 32")
+	}
+	return newStoreCipher(key)
+}
+
+func keychainStoreKey() (string, error) {
+	ring, err := keyring.Open(keyring.Config{
+		ServiceName: "shopify-customers",
+		// Backends that would prompt for a password or forget the key on reboot
+		// are left out; set the key in the environment there instead.
+		AllowedBackends: []keyring.BackendType{keyring.KeychainBackend, keyring.WinCredBackend, keyring.SecretServiceBackend, keyring.KWalletBackend},
+	})
+	if err != nil {
+		return "", fmt.Errorf("no OS keychain for the store key, set %s instead: %w", storeKeyEnv, err)
+	}
+	item, err := ring.Get(storeKeyItem)
+	if err == nil {
+		return string(item.Data), nil
+	}
+	if !errors.Is(err, keyring.ErrKeyNotFound) {
+		return "", fmt.Errorf("failed to read the store key from the keychain: %w", err)
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	encoded := base64.StdEncoding.EncodeToString(key)
+	if err := ring.Set(keyring.Item{Key: storeKeyItem, Data: []byte(encoded), Label: "shopify-customers store key"}); err != nil {
+		return "", fmt.Errorf("failed to save the store key to the keychain: %w", err)
+	}
+	log.Printf("Created a store key in the OS keychain; stores encrypted with it cannot be read without it")
+	return encoded, nil
+}
+
+// seal encrypts plaintext with a random nonce.
+func (s *storeCipher) seal(plaintext []byte) ([]byte, error) {
+	if s == nil {
+		return plaintext, nil
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := append(append([]byte(nil), sealedPrefix...), nonce...)
+	return s.aead.Seal(out, nonce, plaintext, nil), nil
+}
+
+// open decrypts a sealed value.
+func (s *storeCipher) open(sealed []byte) ([]byte, error) {
+	if s == nil {
+		return sealed, nil
+	}
+	if !bytes.HasPrefix(sealed, sealedPrefix) {
+		return nil, errNotEncrypted
+	}
+	sealed = sealed[len(sealedPrefix):]
+	if len(sealed) < s.aead.NonceSize() {
+		return nil, fmt.Errorf("failed to decrypt: value is truncated")
+	}
+	nonce, ciphertext := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+	plaintext, err := s.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt, was it encrypted with another store key? %w", err)
+	}
+	return plaintext, nil
+}
+
+// errNotEncrypted is returned for values written without --encrypt-stores.
+var errNotEncrypted = errors.New("not encrypted; remove it or run without --encrypt-stores")
+
+// errEncrypted is returned for values written with --encrypt-stores when it is
+// not set.
+var errEncrypted = errors.New("encrypted; run with --encrypt-stores")
+
+// name returns an opaque, stable stand-in for a lookup key: its HMAC-SHA256.
+func (s *storeCipher) name(key []byte) []byte {
+	if s == nil {
+		return key
+	}
+	mac := hmac.New(sha256.New, s.nameKey)
+	mac.Write(key)
+	return mac.Sum(nil)
+}
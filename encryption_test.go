@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testStoreCipher returns a store cipher whose key is 32 bytes of b.
+func testStoreCipher(t *testing.T, b byte) *storeCipher {
+	t.Helper()
+	s, err := newStoreCipher(bytes.Repeat([]byte{b}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestNewStoreCipher(t *testing.T) {
+	for _, n := range []int{0, 16, 31, 33} {
+		if _, err := newStoreCipher(make([]byte, n)); err == nil {
+			t.Errorf("newStoreCipher() accepted a %d-byte key", n)
+		}
+	}
+}
+
+func TestStoreCipherSealOpen(t *testing.T) {
+	s := testStoreCipher(t, 1)
+	for _, plaintext := range []string{"", "customer", strings.Repeat("x", 10000)} {
+		sealed, err := s.seal([]byte(plaintext))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if plaintext != "" && bytes.Contains(sealed, []byte(plaintext)) {
+			t.Errorf("seal(%.10q) contains the plaintext", plaintext)
+		}
+		again, err := s.seal([]byte(plaintext))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(sealed, again) {
+			t.Errorf("seal(%.10q) is the same twice; nonces must be random", plaintext)
+		}
+		opened, err := s.open(sealed)
+		if err != nil {
+			t.Fatalf("open(seal(%.10q)) failed: %v", plaintext, err)
+		}
+		if string(opened) != plaintext {
+			t.Errorf("open(seal(%.10q)) = %.10q", plaintext, opened)
+		}
+	}
+}
+
+func TestStoreCipherOpenErrors(t *testing.T) {
+	s := testStoreCipher(t, 1)
+	sealed, err := s.seal([]byte("customer"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := append([]byte(nil), sealed...)
+	tampered[len(tampered)-1] ^= 1
+
+	tests := []struct {
+		name    string
+		cipher  *storeCipher
+		sealed  []byte
+		wantErr error
+	}{
+		{name: "plaintext", cipher: s, sealed: []byte(`{"node":{}}`), wantErr: errNotEncrypted},
+		{name: "truncated", cipher: s, sealed: sealed[:len(sealedPrefix)+4]},
+		{name: "tampered", cipher: s, sealed: tampered},
+		{name: "other key", cipher: testStoreCipher(t, 2), sealed: sealed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.cipher.open(tt.sealed)
+			if err == nil {
+				t.Fatal("open() succeeded")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("open() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStoreCipherNil(t *testing.T) {
+	var s *storeCipher
+	sealed, err := s.seal([]byte("customer"))
+	if err != nil || string(sealed) != "customer" {
+		t.Errorf("nil seal() = %q, %v", sealed, err)
+	}
+	opened, err := s.open([]byte("customer"))
+	if err != nil || string(opened) != "customer" {
+		t.Errorf("nil open() = %q, %v", opened, err)
+	}
+	if name := s.name([]byte("customer")); string(name) != "customer" {
+		t.Errorf("nil name() = %q", name)
+	}
+}
+
+func TestStoreCipherName(t *testing.T) {
+	s, other := testStoreCipher(t, 1), testStoreCipher(t, 2)
+	id := []byte("gid://shopify/CustomerSegmentMember/1")
+	if !bytes.Equal(s.name(id), s.name(id)) {
+		t.Error("name() is not stable")
+	}
+	if bytes.Contains(s.name(id), id) {
+		t.Error("name() contains the key")
+	}
+	if bytes.Equal(s.name(id), s.name([]byte("gid://shopify/CustomerSegmentMember/2"))) {
+		t.Error("name() is the same for different keys")
+	}
+	if bytes.Equal(s.name(id), other.name(id)) {
+		t.Error("name() is the same with different store keys")
+	}
+}
+
+func TestJournalLineEncryption(t *testing.T) {
+	key := testStoreCipher(t, 1)
+	defer func(s *storeCipher) { stores = s }(stores)
+	line := []byte(`{"event":"start"}`)
+
+	tests := []struct {
+		name    string
+		write   *storeCipher
+		read    *storeCipher
+		wantErr error
+	}{
+		{name: "plaintext"},
+		{name: "encrypted", write: key, read: key},
+		{name: "read without key", write: key, wantErr: errEncrypted},
+		{name: "read plaintext with key", read: key, wantErr: errNotEncrypted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stores = tt.write
+			sealed, err := sealJournalLine(line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bytes.ContainsAny(sealed, "\n") {
+				t.Errorf("sealJournalLine() = %q contains a newline", sealed)
+			}
+			stores = tt.read
+			opened, err := openJournalLine(sealed)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("openJournalLine() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !bytes.Equal(opened, line) {
+				t.Errorf("openJournalLine() = %q, %v, want %q", opened, err, line)
+			}
+		})
+	}
+}
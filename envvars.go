@@ -0,0 +1,104 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/urfave/cli/v2"
+)
+
+// envPrefix starts the environment variable of every flag:
+// SHOPIFY_CUSTOMERS_MAX_RPS for the root --max-rps, and
+// SHOPIFY_CUSTOMERS_AUDIENCES_PUSH_PROVIDER for --provider of `audiences push`.
+const envPrefix = "SHOPIFY_CUSTOMERS_"
+
+// bindEnvVars gives every flag of app and its commands without one an
+// environment variable, which urfave/cli reads when the flag is not passed.
+func bindEnvVars(app *cli.App) {
+	bindFlagEnvVars(envPrefix, app.Flags)
+	var walk func(prefix string, commands []*cli.Command)
+	walk = func(prefix string, commands []*cli.Command) {
+		for _, cmd := range commands {
+			p := prefix + envName(cmd.Name) + "_"
+			bindFlagEnvVars(p, cmd.Flags)
+			walk(p, cmd.Subcommands)
+		}
+	}
+	walk(envPrefix, app.Commands)
+}
+
+func bindFlagEnvVars(prefix string, flags []cli.Flag) {
+	for _, f := range flags {
+		var envVars *[]string
+		switch f := f.(type) {
+		case *cli.StringFlag:
+			envVars = &f.EnvVars
+		case *cli.BoolFlag:
+			envVars = &f.EnvVars
+		case *cli.IntFlag:
+			envVars = &f.EnvVars
+		case *cli.Int64Flag:
+			envVars = &f.EnvVars
+		case *cli.Float64Flag:
+			envVars = &f.EnvVars
+		case *cli.DurationFlag:
+			envVars = &f.EnvVars
+		case *cli.StringSliceFlag:
+			envVars = &f.EnvVars
+		default:
+			continue
+		}
+		if len(*envVars) == 0 {
+			*envVars = []string{prefix + envName(f.Names()[0])}
+		}
+	}
+}
+
+// envName converts a flag or command name such as "max-rps" or "sortKey" to
+// MAX_RPS or SORT_KEY.
+func envName(name string) string {
+	var b strings.Builder
+	prev := rune(0)
+	for _, r := range name {
+		switch {
+		case r == '-' || r == '.':
+			r = '_'
+		case unicode.IsUpper(r) && unicode.IsLower(prev):
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+		prev = r
+	}
+	return b.String()
+}
+
+// applyEnvFileVars sets the root flags that were not passed from environment
+// variables added by env files, which are loaded after the root flags are
+// parsed. Command flags are parsed later and see them directly.
+func applyEnvFileVars(c *cli.Context) error {
+	for _, f := range c.App.Flags {
+		name := f.Names()[0]
+		envFlag, ok := f.(interface{ GetEnvVars() []string })
+		if !ok || c.IsSet(name) {
+			continue
+		}
+		for _, env := range envFlag.GetEnvVars() {
+			value, ok := os.LookupEnv(env)
+			if !ok || value == "" {
+				continue
+			}
+			if _, slice := f.(*cli.StringSliceFlag); slice {
+				for _, v := range strings.Split(value, ",") {
+					if err := c.Set(name, strings.TrimSpace(v)); err != nil {
+						return err
+					}
+				}
+			} else if err := c.Set(name, value); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build goexperiment.boringcrypto
+
+package main
+
+// A BoringCrypto build only negotiates FIPS-approved TLS versions, cipher suites
+// and curves, for every TLS connection of the process, and implies --fips.
+import _ "crypto/tls/fipsonly"
+
+const fipsBuild = true
@@ -0,0 +1,6 @@
+//go:build !goexperiment.boringcrypto
+
+package main
+
+// fipsBuild reports whether the binary was built with GOEXPERIMENT=boringcrypto.
+const fipsBuild = false
@@ -0,0 +1,348 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.35.2
+// 	protoc        (unknown)
+// source: customers/v1/customers.proto
+
+package customersv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type StreamSegmentMembersRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Segment query, e.g. "customer_tags CONTAINS 'vip'". Defaults to the server's --query.
+	Query string `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	// Number of customers to fetch. Defaults to the server's --first.
+	First int32 `protobuf:"varint,2,opt,name=first,proto3" json:"first,omitempty"`
+	// Sort key for the results. Defaults to the server's --sortKey.
+	SortKey string `protobuf:"bytes,3,opt,name=sort_key,json=sortKey,proto3" json:"sort_key,omitempty"`
+	// Reverse sort order. Only applied when set; defaults to the server's --reverse.
+	Reverse *bool `protobuf:"varint,4,opt,name=reverse,proto3,oneof" json:"reverse,omitempty"`
+}
+
+func (x *StreamSegmentMembersRequest) Reset() {
+	*x = StreamSegmentMembersRequest{}
+	mi := &file_customers_v1_customers_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamSegmentMembersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamSegmentMembersRequest) ProtoMessage() {}
+
+func (x *StreamSegmentMembersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_customers_v1_customers_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamSegmentMembersRequest.ProtoReflect.Descriptor instead.
+func (*StreamSegmentMembersRequest) Descriptor() ([]byte, []int) {
+	return file_customers_v1_customers_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *StreamSegmentMembersRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *StreamSegmentMembersRequest) GetFirst() int32 {
+	if x != nil {
+		return x.First
+	}
+	return 0
+}
+
+func (x *StreamSegmentMembersRequest) GetSortKey() string {
+	if x != nil {
+		return x.SortKey
+	}
+	return ""
+}
+
+func (x *StreamSegmentMembersRequest) GetReverse() bool {
+	if x != nil && x.Reverse != nil {
+		return *x.Reverse
+	}
+	return false
+}
+
+type CustomerSegmentMember struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Shopify GID, e.g. "gid://shopify/Customer/123".
+	Id                  string          `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	DisplayName         string          `protobuf:"bytes,2,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	Email               string          `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
+	EmailMarketingState string          `protobuf:"bytes,4,opt,name=email_marketing_state,json=emailMarketingState,proto3" json:"email_marketing_state,omitempty"`
+	Phone               string          `protobuf:"bytes,5,opt,name=phone,proto3" json:"phone,omitempty"`
+	SmsMarketingState   string          `protobuf:"bytes,6,opt,name=sms_marketing_state,json=smsMarketingState,proto3" json:"sms_marketing_state,omitempty"`
+	AmountSpent         *MonetaryAmount `protobuf:"bytes,7,opt,name=amount_spent,json=amountSpent,proto3" json:"amount_spent,omitempty"`
+}
+
+func (x *CustomerSegmentMember) Reset() {
+	*x = CustomerSegmentMember{}
+	mi := &file_customers_v1_customers_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CustomerSegmentMember) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CustomerSegmentMember) ProtoMessage() {}
+
+func (x *CustomerSegmentMember) ProtoReflect() protoreflect.Message {
+	mi := &file_customers_v1_customers_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CustomerSegmentMember.ProtoReflect.Descriptor instead.
+func (*CustomerSegmentMember) Descriptor() ([]byte, []int) {
+	return file_customers_v1_customers_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CustomerSegmentMember) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *CustomerSegmentMember) GetDisplayName() string {
+	if x != nil {
+		return x.DisplayName
+	}
+	return ""
+}
+
+func (x *CustomerSegmentMember) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *CustomerSegmentMember) GetEmailMarketingState() string {
+	if x != nil {
+		return x.EmailMarketingState
+	}
+	return ""
+}
+
+func (x *CustomerSegmentMember) GetPhone() string {
+	if x != nil {
+		return x.Phone
+	}
+	return ""
+}
+
+func (x *CustomerSegmentMember) GetSmsMarketingState() string {
+	if x != nil {
+		return x.SmsMarketingState
+	}
+	return ""
+}
+
+func (x *CustomerSegmentMember) GetAmountSpent() *MonetaryAmount {
+	if x != nil {
+		return x.AmountSpent
+	}
+	return nil
+}
+
+type MonetaryAmount struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Decimal amount as a string to avoid floating point rounding, e.g. "120.50".
+	Amount       string `protobuf:"bytes,1,opt,name=amount,proto3" json:"amount,omitempty"`
+	CurrencyCode string `protobuf:"bytes,2,opt,name=currency_code,json=currencyCode,proto3" json:"currency_code,omitempty"`
+}
+
+func (x *MonetaryAmount) Reset() {
+	*x = MonetaryAmount{}
+	mi := &file_customers_v1_customers_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MonetaryAmount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MonetaryAmount) ProtoMessage() {}
+
+func (x *MonetaryAmount) ProtoReflect() protoreflect.Message {
+	mi := &file_customers_v1_customers_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MonetaryAmount.ProtoReflect.Descriptor instead.
+func (*MonetaryAmount) Descriptor() ([]byte, []int) {
+	return file_customers_v1_customers_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *MonetaryAmount) GetAmount() string {
+	if x != nil {
+		return x.Amount
+	}
+	return ""
+}
+
+func (x *MonetaryAmount) GetCurrencyCode() string {
+	if x != nil {
+		return x.CurrencyCode
+	}
+	return ""
+}
+
+var File_customers_v1_customers_proto protoreflect.FileDescriptor
+
+var file_customers_v1_customers_proto_rawDesc = []byte{
+	0x0a, 0x1c, 0x63, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x65, 0x72, 0x73, 0x2f, 0x76, 0x31, 0x2f, 0x63,
+	0x75, 0x73, 0x74, 0x6f, 0x6d, 0x65, 0x72, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0c,
+	0x63, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x65, 0x72, 0x73, 0x2e, 0x76, 0x31, 0x22, 0x8f, 0x01, 0x0a,
+	0x1b, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x53, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x4d, 0x65,
+	0x6d, 0x62, 0x65, 0x72, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05,
+	0x71, 0x75, 0x65, 0x72, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x71, 0x75, 0x65,
+	0x72, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x66, 0x69, 0x72, 0x73, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x05, 0x66, 0x69, 0x72, 0x73, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x73, 0x6f, 0x72, 0x74,
+	0x5f, 0x6b, 0x65, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x6f, 0x72, 0x74,
+	0x4b, 0x65, 0x79, 0x12, 0x1d, 0x0a, 0x07, 0x72, 0x65, 0x76, 0x65, 0x72, 0x73, 0x65, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52, 0x07, 0x72, 0x65, 0x76, 0x65, 0x72, 0x73, 0x65, 0x88,
+	0x01, 0x01, 0x42, 0x0a, 0x0a, 0x08, 0x5f, 0x72, 0x65, 0x76, 0x65, 0x72, 0x73, 0x65, 0x22, 0x9b,
+	0x02, 0x0a, 0x15, 0x43, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x65, 0x72, 0x53, 0x65, 0x67, 0x6d, 0x65,
+	0x6e, 0x74, 0x4d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x21, 0x0a, 0x0c, 0x64, 0x69, 0x73, 0x70,
+	0x6c, 0x61, 0x79, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b,
+	0x64, 0x69, 0x73, 0x70, 0x6c, 0x61, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x65,
+	0x6d, 0x61, 0x69, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x6d, 0x61, 0x69,
+	0x6c, 0x12, 0x32, 0x0a, 0x15, 0x65, 0x6d, 0x61, 0x69, 0x6c, 0x5f, 0x6d, 0x61, 0x72, 0x6b, 0x65,
+	0x74, 0x69, 0x6e, 0x67, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x13, 0x65, 0x6d, 0x61, 0x69, 0x6c, 0x4d, 0x61, 0x72, 0x6b, 0x65, 0x74, 0x69, 0x6e, 0x67,
+	0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x70, 0x68, 0x6f, 0x6e, 0x65, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x70, 0x68, 0x6f, 0x6e, 0x65, 0x12, 0x2e, 0x0a, 0x13, 0x73,
+	0x6d, 0x73, 0x5f, 0x6d, 0x61, 0x72, 0x6b, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x5f, 0x73, 0x74, 0x61,
+	0x74, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x11, 0x73, 0x6d, 0x73, 0x4d, 0x61, 0x72,
+	0x6b, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x3f, 0x0a, 0x0c, 0x61,
+	0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x73, 0x70, 0x65, 0x6e, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1c, 0x2e, 0x63, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x65, 0x72, 0x73, 0x2e, 0x76, 0x31,
+	0x2e, 0x4d, 0x6f, 0x6e, 0x65, 0x74, 0x61, 0x72, 0x79, 0x41, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x52,
+	0x0b, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x53, 0x70, 0x65, 0x6e, 0x74, 0x22, 0x4d, 0x0a, 0x0e,
+	0x4d, 0x6f, 0x6e, 0x65, 0x74, 0x61, 0x72, 0x79, 0x41, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x16,
+	0x0a, 0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x23, 0x0a, 0x0d, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e,
+	0x63, 0x79, 0x5f, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x63,
+	0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x43, 0x6f, 0x64, 0x65, 0x32, 0x81, 0x01, 0x0a, 0x15,
+	0x43, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x65, 0x72, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x53, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x68, 0x0a, 0x14, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x53,
+	0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x4d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x73, 0x12, 0x29, 0x2e,
+	0x63, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x65, 0x72, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x72,
+	0x65, 0x61, 0x6d, 0x53, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x4d, 0x65, 0x6d, 0x62, 0x65, 0x72,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x23, 0x2e, 0x63, 0x75, 0x73, 0x74, 0x6f,
+	0x6d, 0x65, 0x72, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x65, 0x72,
+	0x53, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x4d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x30, 0x01, 0x42,
+	0x26, 0x5a, 0x24, 0x73, 0x75, 0x6c, 0x74, 0x61, 0x6e, 0x73, 0x2f, 0x67, 0x65, 0x6e, 0x2f, 0x63,
+	0x75, 0x73, 0x74, 0x6f, 0x6d, 0x65, 0x72, 0x73, 0x2f, 0x76, 0x31, 0x3b, 0x63, 0x75, 0x73, 0x74,
+	0x6f, 0x6d, 0x65, 0x72, 0x73, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_customers_v1_customers_proto_rawDescOnce sync.Once
+	file_customers_v1_customers_proto_rawDescData = file_customers_v1_customers_proto_rawDesc
+)
+
+func file_customers_v1_customers_proto_rawDescGZIP() []byte {
+	file_customers_v1_customers_proto_rawDescOnce.Do(func() {
+		file_customers_v1_customers_proto_rawDescData = protoimpl.X.CompressGZIP(file_customers_v1_customers_proto_rawDescData)
+	})
+	return file_customers_v1_customers_proto_rawDescData
+}
+
+var file_customers_v1_customers_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_customers_v1_customers_proto_goTypes = []any{
+	(*StreamSegmentMembersRequest)(nil), // 0: customers.v1.StreamSegmentMembersRequest
+	(*CustomerSegmentMember)(nil),       // 1: customers.v1.CustomerSegmentMember
+	(*MonetaryAmount)(nil),              // 2: customers.v1.MonetaryAmount
+}
+var file_customers_v1_customers_proto_depIdxs = []int32{
+	2, // 0: customers.v1.CustomerSegmentMember.amount_spent:type_name -> customers.v1.MonetaryAmount
+	0, // 1: customers.v1.CustomerExportService.StreamSegmentMembers:input_type -> customers.v1.StreamSegmentMembersRequest
+	1, // 2: customers.v1.CustomerExportService.StreamSegmentMembers:output_type -> customers.v1.CustomerSegmentMember
+	2, // [2:3] is the sub-list for method output_type
+	1, // [1:2] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_customers_v1_customers_proto_init() }
+func file_customers_v1_customers_proto_init() {
+	if File_customers_v1_customers_proto != nil {
+		return
+	}
+	file_customers_v1_customers_proto_msgTypes[0].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_customers_v1_customers_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_customers_v1_customers_proto_goTypes,
+		DependencyIndexes: file_customers_v1_customers_proto_depIdxs,
+		MessageInfos:      file_customers_v1_customers_proto_msgTypes,
+	}.Build()
+	File_customers_v1_customers_proto = out.File
+	file_customers_v1_customers_proto_rawDesc = nil
+	file_customers_v1_customers_proto_goTypes = nil
+	file_customers_v1_customers_proto_depIdxs = nil
+}
@@ -0,0 +1,130 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: customers/v1/customers.proto
+
+package customersv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	CustomerExportService_StreamSegmentMembers_FullMethodName = "/customers.v1.CustomerExportService/StreamSegmentMembers"
+)
+
+// CustomerExportServiceClient is the client API for CustomerExportService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// CustomerExportService streams Shopify customer segment members.
+type CustomerExportServiceClient interface {
+	// StreamSegmentMembers fetches the members of a segment query and streams them one by one.
+	StreamSegmentMembers(ctx context.Context, in *StreamSegmentMembersRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CustomerSegmentMember], error)
+}
+
+type customerExportServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCustomerExportServiceClient(cc grpc.ClientConnInterface) CustomerExportServiceClient {
+	return &customerExportServiceClient{cc}
+}
+
+func (c *customerExportServiceClient) StreamSegmentMembers(ctx context.Context, in *StreamSegmentMembersRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CustomerSegmentMember], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CustomerExportService_ServiceDesc.Streams[0], CustomerExportService_StreamSegmentMembers_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamSegmentMembersRequest, CustomerSegmentMember]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CustomerExportService_StreamSegmentMembersClient = grpc.ServerStreamingClient[CustomerSegmentMember]
+
+// CustomerExportServiceServer is the server API for CustomerExportService service.
+// All implementations must embed UnimplementedCustomerExportServiceServer
+// for forward compatibility.
+//
+// CustomerExportService streams Shopify customer segment members.
+type CustomerExportServiceServer interface {
+	// StreamSegmentMembers fetches the members of a segment query and streams them one by one.
+	StreamSegmentMembers(*StreamSegmentMembersRequest, grpc.ServerStreamingServer[CustomerSegmentMember]) error
+	mustEmbedUnimplementedCustomerExportServiceServer()
+}
+
+// UnimplementedCustomerExportServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCustomerExportServiceServer struct{}
+
+func (UnimplementedCustomerExportServiceServer) StreamSegmentMembers(*StreamSegmentMembersRequest, grpc.ServerStreamingServer[CustomerSegmentMember]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamSegmentMembers not implemented")
+}
+func (UnimplementedCustomerExportServiceServer) mustEmbedUnimplementedCustomerExportServiceServer() {}
+func (UnimplementedCustomerExportServiceServer) testEmbeddedByValue()                               {}
+
+// UnsafeCustomerExportServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CustomerExportServiceServer will
+// result in compilation errors.
+type UnsafeCustomerExportServiceServer interface {
+	mustEmbedUnimplementedCustomerExportServiceServer()
+}
+
+func RegisterCustomerExportServiceServer(s grpc.ServiceRegistrar, srv CustomerExportServiceServer) {
+	// If the following call pancis, it indicates UnimplementedCustomerExportServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CustomerExportService_ServiceDesc, srv)
+}
+
+func _CustomerExportService_StreamSegmentMembers_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamSegmentMembersRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CustomerExportServiceServer).StreamSegmentMembers(m, &grpc.GenericServerStream[StreamSegmentMembersRequest, CustomerSegmentMember]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CustomerExportService_StreamSegmentMembersServer = grpc.ServerStreamingServer[CustomerSegmentMember]
+
+// CustomerExportService_ServiceDesc is the grpc.ServiceDesc for CustomerExportService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CustomerExportService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "customers.v1.CustomerExportService",
+	HandlerType: (*CustomerExportServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamSegmentMembers",
+			Handler:       _CustomerExportService_StreamSegmentMembers_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "customers/v1/customers.proto",
+}
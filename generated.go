@@ -0,0 +1,2119 @@
+// Code generated by github.com/Khan/genqlient, DO NOT EDIT.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/shopspring/decimal"
+)
+
+// ISO 3166-1 alpha-2 country codes. Abbreviated; see the full schema for all values.
+type CountryCode string
+
+const (
+	CountryCodeAu CountryCode = "AU"
+	CountryCodeBr CountryCode = "BR"
+	CountryCodeCa CountryCode = "CA"
+	CountryCodeCh CountryCode = "CH"
+	CountryCodeDe CountryCode = "DE"
+	CountryCodeEs CountryCode = "ES"
+	CountryCodeFr CountryCode = "FR"
+	CountryCodeGb CountryCode = "GB"
+	CountryCodeIe CountryCode = "IE"
+	CountryCodeIn CountryCode = "IN"
+	CountryCodeIt CountryCode = "IT"
+	CountryCodeJp CountryCode = "JP"
+	CountryCodeMx CountryCode = "MX"
+	CountryCodeNl CountryCode = "NL"
+	CountryCodeNz CountryCode = "NZ"
+	CountryCodeSe CountryCode = "SE"
+	CountryCodeSg CountryCode = "SG"
+	CountryCodeUs CountryCode = "US"
+)
+
+// ISO 4217 currency codes. Abbreviated; see the full schema for all values.
+type CurrencyCode string
+
+const (
+	CurrencyCodeAud CurrencyCode = "AUD"
+	CurrencyCodeBrl CurrencyCode = "BRL"
+	CurrencyCodeCad CurrencyCode = "CAD"
+	CurrencyCodeChf CurrencyCode = "CHF"
+	CurrencyCodeCny CurrencyCode = "CNY"
+	CurrencyCodeDkk CurrencyCode = "DKK"
+	CurrencyCodeEur CurrencyCode = "EUR"
+	CurrencyCodeGbp CurrencyCode = "GBP"
+	CurrencyCodeHkd CurrencyCode = "HKD"
+	CurrencyCodeInr CurrencyCode = "INR"
+	CurrencyCodeJpy CurrencyCode = "JPY"
+	CurrencyCodeKrw CurrencyCode = "KRW"
+	CurrencyCodeMxn CurrencyCode = "MXN"
+	CurrencyCodeNok CurrencyCode = "NOK"
+	CurrencyCodeNzd CurrencyCode = "NZD"
+	CurrencyCodeSek CurrencyCode = "SEK"
+	CurrencyCodeSgd CurrencyCode = "SGD"
+	CurrencyCodeUsd CurrencyCode = "USD"
+)
+
+// CustomerDatesNode includes the requested fields of the GraphQL interface Node.
+//
+// CustomerDatesNode is implemented by the following types:
+// CustomerDatesNodeCustomer
+// CustomerDatesNodeOrder
+// The GraphQL type's documentation follows.
+//
+// An object with an ID field to support global identification.
+type CustomerDatesNode interface {
+	implementsGraphQLInterfaceCustomerDatesNode()
+	// GetTypename returns the receiver's concrete GraphQL type-name (see interface doc for possible values).
+	GetTypename() string
+	// GetId returns the interface-field "id" from its implementation.
+	GetId() string
+}
+
+func (v *CustomerDatesNodeCustomer) implementsGraphQLInterfaceCustomerDatesNode() {}
+func (v *CustomerDatesNodeOrder) implementsGraphQLInterfaceCustomerDatesNode()    {}
+
+func __unmarshalCustomerDatesNode(b []byte, v *CustomerDatesNode) error {
+	if string(b) == "null" {
+		return nil
+	}
+
+	var tn struct {
+		TypeName string `json:"__typename"`
+	}
+	err := json.Unmarshal(b, &tn)
+	if err != nil {
+		return err
+	}
+
+	switch tn.TypeName {
+	case "Customer":
+		*v = new(CustomerDatesNodeCustomer)
+		return json.Unmarshal(b, *v)
+	case "Order":
+		*v = new(CustomerDatesNodeOrder)
+		return json.Unmarshal(b, *v)
+	case "":
+		return fmt.Errorf(
+			"response was missing Node.__typename")
+	default:
+		return fmt.Errorf(
+			`unexpected concrete type for CustomerDatesNode: "%v"`, tn.TypeName)
+	}
+}
+
+func __marshalCustomerDatesNode(v *CustomerDatesNode) ([]byte, error) {
+
+	var typename string
+	switch v := (*v).(type) {
+	case *CustomerDatesNodeCustomer:
+		typename = "Customer"
+
+		result := struct {
+			TypeName string `json:"__typename"`
+			*CustomerDatesNodeCustomer
+		}{typename, v}
+		return json.Marshal(result)
+	case *CustomerDatesNodeOrder:
+		typename = "Order"
+
+		result := struct {
+			TypeName string `json:"__typename"`
+			*CustomerDatesNodeOrder
+		}{typename, v}
+		return json.Marshal(result)
+	case nil:
+		return []byte("null"), nil
+	default:
+		return nil, fmt.Errorf(
+			`unexpected concrete type for CustomerDatesNode: "%T"`, v)
+	}
+}
+
+// CustomerDatesNodeCustomer includes the requested fields of the GraphQL type Customer.
+type CustomerDatesNodeCustomer struct {
+	Typename  string      `json:"__typename"`
+	Id        string      `json:"id"`
+	CreatedAt time.Time   `json:"createdAt"`
+	UpdatedAt time.Time   `json:"updatedAt"`
+	LastOrder *OrderDate  `json:"lastOrder"`
+	Orders    FirstOrders `json:"orders"`
+}
+
+// GetTypename returns CustomerDatesNodeCustomer.Typename, and is useful for accessing the field via an interface.
+func (v *CustomerDatesNodeCustomer) GetTypename() string { return v.Typename }
+
+// GetId returns CustomerDatesNodeCustomer.Id, and is useful for accessing the field via an interface.
+func (v *CustomerDatesNodeCustomer) GetId() string { return v.Id }
+
+// GetCreatedAt returns CustomerDatesNodeCustomer.CreatedAt, and is useful for accessing the field via an interface.
+func (v *CustomerDatesNodeCustomer) GetCreatedAt() time.Time { return v.CreatedAt }
+
+// GetUpdatedAt returns CustomerDatesNodeCustomer.UpdatedAt, and is useful for accessing the field via an interface.
+func (v *CustomerDatesNodeCustomer) GetUpdatedAt() time.Time { return v.UpdatedAt }
+
+// GetLastOrder returns CustomerDatesNodeCustomer.LastOrder, and is useful for accessing the field via an interface.
+func (v *CustomerDatesNodeCustomer) GetLastOrder() *OrderDate { return v.LastOrder }
+
+// GetOrders returns CustomerDatesNodeCustomer.Orders, and is useful for accessing the field via an interface.
+func (v *CustomerDatesNodeCustomer) GetOrders() FirstOrders { return v.Orders }
+
+// CustomerDatesNodeOrder includes the requested fields of the GraphQL type Order.
+type CustomerDatesNodeOrder struct {
+	Typename string `json:"__typename"`
+	Id       string `json:"id"`
+}
+
+// GetTypename returns CustomerDatesNodeOrder.Typename, and is useful for accessing the field via an interface.
+func (v *CustomerDatesNodeOrder) GetTypename() string { return v.Typename }
+
+// GetId returns CustomerDatesNodeOrder.Id, and is useful for accessing the field via an interface.
+func (v *CustomerDatesNodeOrder) GetId() string { return v.Id }
+
+type CustomerEmailAddressMarketingState string
+
+const (
+	CustomerEmailAddressMarketingStateInvalid       CustomerEmailAddressMarketingState = "INVALID"
+	CustomerEmailAddressMarketingStateNotSubscribed CustomerEmailAddressMarketingState = "NOT_SUBSCRIBED"
+	CustomerEmailAddressMarketingStatePending       CustomerEmailAddressMarketingState = "PENDING"
+	CustomerEmailAddressMarketingStateSubscribed    CustomerEmailAddressMarketingState = "SUBSCRIBED"
+	CustomerEmailAddressMarketingStateUnsubscribed  CustomerEmailAddressMarketingState = "UNSUBSCRIBED"
+)
+
+type CustomerMergeErrorFieldType string
+
+const (
+	CustomerMergeErrorFieldTypeCompanyContact         CustomerMergeErrorFieldType = "COMPANY_CONTACT"
+	CustomerMergeErrorFieldTypeCustomerPaymentMethods CustomerMergeErrorFieldType = "CUSTOMER_PAYMENT_METHODS"
+	CustomerMergeErrorFieldTypeDeletedAt              CustomerMergeErrorFieldType = "DELETED_AT"
+	CustomerMergeErrorFieldTypeGiftCards              CustomerMergeErrorFieldType = "GIFT_CARDS"
+	CustomerMergeErrorFieldTypeMergeInProgress        CustomerMergeErrorFieldType = "MERGE_IN_PROGRESS"
+	CustomerMergeErrorFieldTypeMultipassIdentifier    CustomerMergeErrorFieldType = "MULTIPASS_IDENTIFIER"
+	CustomerMergeErrorFieldTypePendingDataRequest     CustomerMergeErrorFieldType = "PENDING_DATA_REQUEST"
+	CustomerMergeErrorFieldTypeRedactedAt             CustomerMergeErrorFieldType = "REDACTED_AT"
+	CustomerMergeErrorFieldTypeSubscriptions          CustomerMergeErrorFieldType = "SUBSCRIPTIONS"
+)
+
+// CustomerOrder includes the requested fields of the GraphQL type Order.
+type CustomerOrder struct {
+	Id            string     `json:"id"`
+	Name          string     `json:"name"`
+	ProcessedAt   time.Time  `json:"processedAt"`
+	TotalPriceSet OrderTotal `json:"totalPriceSet"`
+}
+
+// GetId returns CustomerOrder.Id, and is useful for accessing the field via an interface.
+func (v *CustomerOrder) GetId() string { return v.Id }
+
+// GetName returns CustomerOrder.Name, and is useful for accessing the field via an interface.
+func (v *CustomerOrder) GetName() string { return v.Name }
+
+// GetProcessedAt returns CustomerOrder.ProcessedAt, and is useful for accessing the field via an interface.
+func (v *CustomerOrder) GetProcessedAt() time.Time { return v.ProcessedAt }
+
+// GetTotalPriceSet returns CustomerOrder.TotalPriceSet, and is useful for accessing the field via an interface.
+func (v *CustomerOrder) GetTotalPriceSet() OrderTotal { return v.TotalPriceSet }
+
+// CustomerOrders includes the requested fields of the GraphQL type OrderConnection.
+type CustomerOrders struct {
+	Nodes    []CustomerOrder `json:"nodes"`
+	PageInfo OrdersPageInfo  `json:"pageInfo"`
+}
+
+// GetNodes returns CustomerOrders.Nodes, and is useful for accessing the field via an interface.
+func (v *CustomerOrders) GetNodes() []CustomerOrder { return v.Nodes }
+
+// GetPageInfo returns CustomerOrders.PageInfo, and is useful for accessing the field via an interface.
+func (v *CustomerOrders) GetPageInfo() OrdersPageInfo { return v.PageInfo }
+
+// CustomerOrdersNode includes the requested fields of the GraphQL interface Node.
+//
+// CustomerOrdersNode is implemented by the following types:
+// CustomerOrdersNodeCustomer
+// CustomerOrdersNodeOrder
+// The GraphQL type's documentation follows.
+//
+// An object with an ID field to support global identification.
+type CustomerOrdersNode interface {
+	implementsGraphQLInterfaceCustomerOrdersNode()
+	// GetTypename returns the receiver's concrete GraphQL type-name (see interface doc for possible values).
+	GetTypename() string
+	// GetId returns the interface-field "id" from its implementation.
+	GetId() string
+}
+
+func (v *CustomerOrdersNodeCustomer) implementsGraphQLInterfaceCustomerOrdersNode() {}
+func (v *CustomerOrdersNodeOrder) implementsGraphQLInterfaceCustomerOrdersNode()    {}
+
+func __unmarshalCustomerOrdersNode(b []byte, v *CustomerOrdersNode) error {
+	if string(b) == "null" {
+		return nil
+	}
+
+	var tn struct {
+		TypeName string `json:"__typename"`
+	}
+	err := json.Unmarshal(b, &tn)
+	if err != nil {
+		return err
+	}
+
+	switch tn.TypeName {
+	case "Customer":
+		*v = new(CustomerOrdersNodeCustomer)
+		return json.Unmarshal(b, *v)
+	case "Order":
+		*v = new(CustomerOrdersNodeOrder)
+		return json.Unmarshal(b, *v)
+	case "":
+		return fmt.Errorf(
+			"response was missing Node.__typename")
+	default:
+		return fmt.Errorf(
+			`unexpected concrete type for CustomerOrdersNode: "%v"`, tn.TypeName)
+	}
+}
+
+func __marshalCustomerOrdersNode(v *CustomerOrdersNode) ([]byte, error) {
+
+	var typename string
+	switch v := (*v).(type) {
+	case *CustomerOrdersNodeCustomer:
+		typename = "Customer"
+
+		result := struct {
+			TypeName string `json:"__typename"`
+			*CustomerOrdersNodeCustomer
+		}{typename, v}
+		return json.Marshal(result)
+	case *CustomerOrdersNodeOrder:
+		typename = "Order"
+
+		result := struct {
+			TypeName string `json:"__typename"`
+			*CustomerOrdersNodeOrder
+		}{typename, v}
+		return json.Marshal(result)
+	case nil:
+		return []byte("null"), nil
+	default:
+		return nil, fmt.Errorf(
+			`unexpected concrete type for CustomerOrdersNode: "%T"`, v)
+	}
+}
+
+// CustomerOrdersNodeCustomer includes the requested fields of the GraphQL type Customer.
+type CustomerOrdersNodeCustomer struct {
+	Typename string         `json:"__typename"`
+	Id       string         `json:"id"`
+	Orders   CustomerOrders `json:"orders"`
+}
+
+// GetTypename returns CustomerOrdersNodeCustomer.Typename, and is useful for accessing the field via an interface.
+func (v *CustomerOrdersNodeCustomer) GetTypename() string { return v.Typename }
+
+// GetId returns CustomerOrdersNodeCustomer.Id, and is useful for accessing the field via an interface.
+func (v *CustomerOrdersNodeCustomer) GetId() string { return v.Id }
+
+// GetOrders returns CustomerOrdersNodeCustomer.Orders, and is useful for accessing the field via an interface.
+func (v *CustomerOrdersNodeCustomer) GetOrders() CustomerOrders { return v.Orders }
+
+// CustomerOrdersNodeOrder includes the requested fields of the GraphQL type Order.
+type CustomerOrdersNodeOrder struct {
+	Typename string `json:"__typename"`
+	Id       string `json:"id"`
+}
+
+// GetTypename returns CustomerOrdersNodeOrder.Typename, and is useful for accessing the field via an interface.
+func (v *CustomerOrdersNodeOrder) GetTypename() string { return v.Typename }
+
+// GetId returns CustomerOrdersNodeOrder.Id, and is useful for accessing the field via an interface.
+func (v *CustomerOrdersNodeOrder) GetId() string { return v.Id }
+
+// CustomerOrdersPage includes the requested fields of the GraphQL type Customer.
+type CustomerOrdersPage struct {
+	Orders CustomerOrders `json:"orders"`
+}
+
+// GetOrders returns CustomerOrdersPage.Orders, and is useful for accessing the field via an interface.
+func (v *CustomerOrdersPage) GetOrders() CustomerOrders { return v.Orders }
+
+type CustomerPredictedSpendTier string
+
+const (
+	CustomerPredictedSpendTierHigh   CustomerPredictedSpendTier = "HIGH"
+	CustomerPredictedSpendTierLow    CustomerPredictedSpendTier = "LOW"
+	CustomerPredictedSpendTierMedium CustomerPredictedSpendTier = "MEDIUM"
+)
+
+type CustomerRfmGroup string
+
+const (
+	CustomerRfmGroupActive          CustomerRfmGroup = "ACTIVE"
+	CustomerRfmGroupAlmostLost      CustomerRfmGroup = "ALMOST_LOST"
+	CustomerRfmGroupAtRisk          CustomerRfmGroup = "AT_RISK"
+	CustomerRfmGroupChampions       CustomerRfmGroup = "CHAMPIONS"
+	CustomerRfmGroupDormant         CustomerRfmGroup = "DORMANT"
+	CustomerRfmGroupLoyal           CustomerRfmGroup = "LOYAL"
+	CustomerRfmGroupNeedsAttention  CustomerRfmGroup = "NEEDS_ATTENTION"
+	CustomerRfmGroupNew             CustomerRfmGroup = "NEW"
+	CustomerRfmGroupPreviouslyLoyal CustomerRfmGroup = "PREVIOUSLY_LOYAL"
+	CustomerRfmGroupPromising       CustomerRfmGroup = "PROMISING"
+	CustomerRfmGroupProspects       CustomerRfmGroup = "PROSPECTS"
+)
+
+type CustomerSmsMarketingState string
+
+const (
+	CustomerSmsMarketingStateNotSubscribed CustomerSmsMarketingState = "NOT_SUBSCRIBED"
+	CustomerSmsMarketingStatePending       CustomerSmsMarketingState = "PENDING"
+	CustomerSmsMarketingStateRedacted      CustomerSmsMarketingState = "REDACTED"
+	CustomerSmsMarketingStateSubscribed    CustomerSmsMarketingState = "SUBSCRIBED"
+	CustomerSmsMarketingStateUnsubscribed  CustomerSmsMarketingState = "UNSUBSCRIBED"
+)
+
+type CustomerState string
+
+const (
+	CustomerStateDeclined CustomerState = "DECLINED"
+	CustomerStateDisabled CustomerState = "DISABLED"
+	CustomerStateEnabled  CustomerState = "ENABLED"
+	CustomerStateInvited  CustomerState = "INVITED"
+)
+
+// CustomerStateNode includes the requested fields of the GraphQL interface Node.
+//
+// CustomerStateNode is implemented by the following types:
+// CustomerStateNodeCustomer
+// CustomerStateNodeOrder
+// The GraphQL type's documentation follows.
+//
+// An object with an ID field to support global identification.
+type CustomerStateNode interface {
+	implementsGraphQLInterfaceCustomerStateNode()
+	// GetTypename returns the receiver's concrete GraphQL type-name (see interface doc for possible values).
+	GetTypename() string
+	// GetId returns the interface-field "id" from its implementation.
+	GetId() string
+}
+
+func (v *CustomerStateNodeCustomer) implementsGraphQLInterfaceCustomerStateNode() {}
+func (v *CustomerStateNodeOrder) implementsGraphQLInterfaceCustomerStateNode()    {}
+
+func __unmarshalCustomerStateNode(b []byte, v *CustomerStateNode) error {
+	if string(b) == "null" {
+		return nil
+	}
+
+	var tn struct {
+		TypeName string `json:"__typename"`
+	}
+	err := json.Unmarshal(b, &tn)
+	if err != nil {
+		return err
+	}
+
+	switch tn.TypeName {
+	case "Customer":
+		*v = new(CustomerStateNodeCustomer)
+		return json.Unmarshal(b, *v)
+	case "Order":
+		*v = new(CustomerStateNodeOrder)
+		return json.Unmarshal(b, *v)
+	case "":
+		return fmt.Errorf(
+			"response was missing Node.__typename")
+	default:
+		return fmt.Errorf(
+			`unexpected concrete type for CustomerStateNode: "%v"`, tn.TypeName)
+	}
+}
+
+func __marshalCustomerStateNode(v *CustomerStateNode) ([]byte, error) {
+
+	var typename string
+	switch v := (*v).(type) {
+	case *CustomerStateNodeCustomer:
+		typename = "Customer"
+
+		result := struct {
+			TypeName string `json:"__typename"`
+			*CustomerStateNodeCustomer
+		}{typename, v}
+		return json.Marshal(result)
+	case *CustomerStateNodeOrder:
+		typename = "Order"
+
+		result := struct {
+			TypeName string `json:"__typename"`
+			*CustomerStateNodeOrder
+		}{typename, v}
+		return json.Marshal(result)
+	case nil:
+		return []byte("null"), nil
+	default:
+		return nil, fmt.Errorf(
+			`unexpected concrete type for CustomerStateNode: "%T"`, v)
+	}
+}
+
+// CustomerStateNodeCustomer includes the requested fields of the GraphQL type Customer.
+type CustomerStateNodeCustomer struct {
+	Typename string        `json:"__typename"`
+	Id       string        `json:"id"`
+	State    CustomerState `json:"state"`
+}
+
+// GetTypename returns CustomerStateNodeCustomer.Typename, and is useful for accessing the field via an interface.
+func (v *CustomerStateNodeCustomer) GetTypename() string { return v.Typename }
+
+// GetId returns CustomerStateNodeCustomer.Id, and is useful for accessing the field via an interface.
+func (v *CustomerStateNodeCustomer) GetId() string { return v.Id }
+
+// GetState returns CustomerStateNodeCustomer.State, and is useful for accessing the field via an interface.
+func (v *CustomerStateNodeCustomer) GetState() CustomerState { return v.State }
+
+// CustomerStateNodeOrder includes the requested fields of the GraphQL type Order.
+type CustomerStateNodeOrder struct {
+	Typename string `json:"__typename"`
+	Id       string `json:"id"`
+}
+
+// GetTypename returns CustomerStateNodeOrder.Typename, and is useful for accessing the field via an interface.
+func (v *CustomerStateNodeOrder) GetTypename() string { return v.Typename }
+
+// GetId returns CustomerStateNodeOrder.Id, and is useful for accessing the field via an interface.
+func (v *CustomerStateNodeOrder) GetId() string { return v.Id }
+
+// CustomerStatistics includes the requested fields of the GraphQL type CustomerStatistics.
+type CustomerStatistics struct {
+	PredictedSpendTier *CustomerPredictedSpendTier `json:"predictedSpendTier"`
+	RfmGroup           *CustomerRfmGroup           `json:"rfmGroup"`
+}
+
+// GetPredictedSpendTier returns CustomerStatistics.PredictedSpendTier, and is useful for accessing the field via an interface.
+func (v *CustomerStatistics) GetPredictedSpendTier() *CustomerPredictedSpendTier {
+	return v.PredictedSpendTier
+}
+
+// GetRfmGroup returns CustomerStatistics.RfmGroup, and is useful for accessing the field via an interface.
+func (v *CustomerStatistics) GetRfmGroup() *CustomerRfmGroup { return v.RfmGroup }
+
+// CustomerStatisticsNode includes the requested fields of the GraphQL interface Node.
+//
+// CustomerStatisticsNode is implemented by the following types:
+// CustomerStatisticsNodeCustomer
+// CustomerStatisticsNodeOrder
+// The GraphQL type's documentation follows.
+//
+// An object with an ID field to support global identification.
+type CustomerStatisticsNode interface {
+	implementsGraphQLInterfaceCustomerStatisticsNode()
+	// GetTypename returns the receiver's concrete GraphQL type-name (see interface doc for possible values).
+	GetTypename() string
+	// GetId returns the interface-field "id" from its implementation.
+	GetId() string
+}
+
+func (v *CustomerStatisticsNodeCustomer) implementsGraphQLInterfaceCustomerStatisticsNode() {}
+func (v *CustomerStatisticsNodeOrder) implementsGraphQLInterfaceCustomerStatisticsNode()    {}
+
+func __unmarshalCustomerStatisticsNode(b []byte, v *CustomerStatisticsNode) error {
+	if string(b) == "null" {
+		return nil
+	}
+
+	var tn struct {
+		TypeName string `json:"__typename"`
+	}
+	err := json.Unmarshal(b, &tn)
+	if err != nil {
+		return err
+	}
+
+	switch tn.TypeName {
+	case "Customer":
+		*v = new(CustomerStatisticsNodeCustomer)
+		return json.Unmarshal(b, *v)
+	case "Order":
+		*v = new(CustomerStatisticsNodeOrder)
+		return json.Unmarshal(b, *v)
+	case "":
+		return fmt.Errorf(
+			"response was missing Node.__typename")
+	default:
+		return fmt.Errorf(
+			`unexpected concrete type for CustomerStatisticsNode: "%v"`, tn.TypeName)
+	}
+}
+
+func __marshalCustomerStatisticsNode(v *CustomerStatisticsNode) ([]byte, error) {
+
+	var typename string
+	switch v := (*v).(type) {
+	case *CustomerStatisticsNodeCustomer:
+		typename = "Customer"
+
+		result := struct {
+			TypeName string `json:"__typename"`
+			*CustomerStatisticsNodeCustomer
+		}{typename, v}
+		return json.Marshal(result)
+	case *CustomerStatisticsNodeOrder:
+		typename = "Order"
+
+		result := struct {
+			TypeName string `json:"__typename"`
+			*CustomerStatisticsNodeOrder
+		}{typename, v}
+		return json.Marshal(result)
+	case nil:
+		return []byte("null"), nil
+	default:
+		return nil, fmt.Errorf(
+			`unexpected concrete type for CustomerStatisticsNode: "%T"`, v)
+	}
+}
+
+// CustomerStatisticsNodeCustomer includes the requested fields of the GraphQL type Customer.
+type CustomerStatisticsNodeCustomer struct {
+	Typename   string             `json:"__typename"`
+	Id         string             `json:"id"`
+	Statistics CustomerStatistics `json:"statistics"`
+}
+
+// GetTypename returns CustomerStatisticsNodeCustomer.Typename, and is useful for accessing the field via an interface.
+func (v *CustomerStatisticsNodeCustomer) GetTypename() string { return v.Typename }
+
+// GetId returns CustomerStatisticsNodeCustomer.Id, and is useful for accessing the field via an interface.
+func (v *CustomerStatisticsNodeCustomer) GetId() string { return v.Id }
+
+// GetStatistics returns CustomerStatisticsNodeCustomer.Statistics, and is useful for accessing the field via an interface.
+func (v *CustomerStatisticsNodeCustomer) GetStatistics() CustomerStatistics { return v.Statistics }
+
+// CustomerStatisticsNodeOrder includes the requested fields of the GraphQL type Order.
+type CustomerStatisticsNodeOrder struct {
+	Typename string `json:"__typename"`
+	Id       string `json:"id"`
+}
+
+// GetTypename returns CustomerStatisticsNodeOrder.Typename, and is useful for accessing the field via an interface.
+func (v *CustomerStatisticsNodeOrder) GetTypename() string { return v.Typename }
+
+// GetId returns CustomerStatisticsNodeOrder.Id, and is useful for accessing the field via an interface.
+func (v *CustomerStatisticsNodeOrder) GetId() string { return v.Id }
+
+// CustomerTagsNode includes the requested fields of the GraphQL interface Node.
+//
+// CustomerTagsNode is implemented by the following types:
+// CustomerTagsNodeCustomer
+// CustomerTagsNodeOrder
+// The GraphQL type's documentation follows.
+//
+// An object with an ID field to support global identification.
+type CustomerTagsNode interface {
+	implementsGraphQLInterfaceCustomerTagsNode()
+	// GetTypename returns the receiver's concrete GraphQL type-name (see interface doc for possible values).
+	GetTypename() string
+	// GetId returns the interface-field "id" from its implementation.
+	GetId() string
+}
+
+func (v *CustomerTagsNodeCustomer) implementsGraphQLInterfaceCustomerTagsNode() {}
+func (v *CustomerTagsNodeOrder) implementsGraphQLInterfaceCustomerTagsNode()    {}
+
+func __unmarshalCustomerTagsNode(b []byte, v *CustomerTagsNode) error {
+	if string(b) == "null" {
+		return nil
+	}
+
+	var tn struct {
+		TypeName string `json:"__typename"`
+	}
+	err := json.Unmarshal(b, &tn)
+	if err != nil {
+		return err
+	}
+
+	switch tn.TypeName {
+	case "Customer":
+		*v = new(CustomerTagsNodeCustomer)
+		return json.Unmarshal(b, *v)
+	case "Order":
+		*v = new(CustomerTagsNodeOrder)
+		return json.Unmarshal(b, *v)
+	case "":
+		return fmt.Errorf(
+			"response was missing Node.__typename")
+	default:
+		return fmt.Errorf(
+			`unexpected concrete type for CustomerTagsNode: "%v"`, tn.TypeName)
+	}
+}
+
+func __marshalCustomerTagsNode(v *CustomerTagsNode) ([]byte, error) {
+
+	var typename string
+	switch v := (*v).(type) {
+	case *CustomerTagsNodeCustomer:
+		typename = "Customer"
+
+		result := struct {
+			TypeName string `json:"__typename"`
+			*CustomerTagsNodeCustomer
+		}{typename, v}
+		return json.Marshal(result)
+	case *CustomerTagsNodeOrder:
+		typename = "Order"
+
+		result := struct {
+			TypeName string `json:"__typename"`
+			*CustomerTagsNodeOrder
+		}{typename, v}
+		return json.Marshal(result)
+	case nil:
+		return []byte("null"), nil
+	default:
+		return nil, fmt.Errorf(
+			`unexpected concrete type for CustomerTagsNode: "%T"`, v)
+	}
+}
+
+// CustomerTagsNodeCustomer includes the requested fields of the GraphQL type Customer.
+type CustomerTagsNodeCustomer struct {
+	Typename string   `json:"__typename"`
+	Id       string   `json:"id"`
+	Tags     []string `json:"tags"`
+}
+
+// GetTypename returns CustomerTagsNodeCustomer.Typename, and is useful for accessing the field via an interface.
+func (v *CustomerTagsNodeCustomer) GetTypename() string { return v.Typename }
+
+// GetId returns CustomerTagsNodeCustomer.Id, and is useful for accessing the field via an interface.
+func (v *CustomerTagsNodeCustomer) GetId() string { return v.Id }
+
+// GetTags returns CustomerTagsNodeCustomer.Tags, and is useful for accessing the field via an interface.
+func (v *CustomerTagsNodeCustomer) GetTags() []string { return v.Tags }
+
+// CustomerTagsNodeOrder includes the requested fields of the GraphQL type Order.
+type CustomerTagsNodeOrder struct {
+	Typename string `json:"__typename"`
+	Id       string `json:"id"`
+}
+
+// GetTypename returns CustomerTagsNodeOrder.Typename, and is useful for accessing the field via an interface.
+func (v *CustomerTagsNodeOrder) GetTypename() string { return v.Typename }
+
+// GetId returns CustomerTagsNodeOrder.Id, and is useful for accessing the field via an interface.
+func (v *CustomerTagsNodeOrder) GetId() string { return v.Id }
+
+// CustomerTaxNode includes the requested fields of the GraphQL interface Node.
+//
+// CustomerTaxNode is implemented by the following types:
+// CustomerTaxNodeCustomer
+// CustomerTaxNodeOrder
+// The GraphQL type's documentation follows.
+//
+// An object with an ID field to support global identification.
+type CustomerTaxNode interface {
+	implementsGraphQLInterfaceCustomerTaxNode()
+	// GetTypename returns the receiver's concrete GraphQL type-name (see interface doc for possible values).
+	GetTypename() string
+	// GetId returns the interface-field "id" from its implementation.
+	GetId() string
+}
+
+func (v *CustomerTaxNodeCustomer) implementsGraphQLInterfaceCustomerTaxNode() {}
+func (v *CustomerTaxNodeOrder) implementsGraphQLInterfaceCustomerTaxNode()    {}
+
+func __unmarshalCustomerTaxNode(b []byte, v *CustomerTaxNode) error {
+	if string(b) == "null" {
+		return nil
+	}
+
+	var tn struct {
+		TypeName string `json:"__typename"`
+	}
+	err := json.Unmarshal(b, &tn)
+	if err != nil {
+		return err
+	}
+
+	switch tn.TypeName {
+	case "Customer":
+		*v = new(CustomerTaxNodeCustomer)
+		return json.Unmarshal(b, *v)
+	case "Order":
+		*v = new(CustomerTaxNodeOrder)
+		return json.Unmarshal(b, *v)
+	case "":
+		return fmt.Errorf(
+			"response was missing Node.__typename")
+	default:
+		return fmt.Errorf(
+			`unexpected concrete type for CustomerTaxNode: "%v"`, tn.TypeName)
+	}
+}
+
+func __marshalCustomerTaxNode(v *CustomerTaxNode) ([]byte, error) {
+
+	var typename string
+	switch v := (*v).(type) {
+	case *CustomerTaxNodeCustomer:
+		typename = "Customer"
+
+		result := struct {
+			TypeName string `json:"__typename"`
+			*CustomerTaxNodeCustomer
+		}{typename, v}
+		return json.Marshal(result)
+	case *CustomerTaxNodeOrder:
+		typename = "Order"
+
+		result := struct {
+			TypeName string `json:"__typename"`
+			*CustomerTaxNodeOrder
+		}{typename, v}
+		return json.Marshal(result)
+	case nil:
+		return []byte("null"), nil
+	default:
+		return nil, fmt.Errorf(
+			`unexpected concrete type for CustomerTaxNode: "%T"`, v)
+	}
+}
+
+// CustomerTaxNodeCustomer includes the requested fields of the GraphQL type Customer.
+type CustomerTaxNodeCustomer struct {
+	Typename      string         `json:"__typename"`
+	Id            string         `json:"id"`
+	TaxExempt     bool           `json:"taxExempt"`
+	TaxExemptions []TaxExemption `json:"taxExemptions"`
+}
+
+// GetTypename returns CustomerTaxNodeCustomer.Typename, and is useful for accessing the field via an interface.
+func (v *CustomerTaxNodeCustomer) GetTypename() string { return v.Typename }
+
+// GetId returns CustomerTaxNodeCustomer.Id, and is useful for accessing the field via an interface.
+func (v *CustomerTaxNodeCustomer) GetId() string { return v.Id }
+
+// GetTaxExempt returns CustomerTaxNodeCustomer.TaxExempt, and is useful for accessing the field via an interface.
+func (v *CustomerTaxNodeCustomer) GetTaxExempt() bool { return v.TaxExempt }
+
+// GetTaxExemptions returns CustomerTaxNodeCustomer.TaxExemptions, and is useful for accessing the field via an interface.
+func (v *CustomerTaxNodeCustomer) GetTaxExemptions() []TaxExemption { return v.TaxExemptions }
+
+// CustomerTaxNodeOrder includes the requested fields of the GraphQL type Order.
+type CustomerTaxNodeOrder struct {
+	Typename string `json:"__typename"`
+	Id       string `json:"id"`
+}
+
+// GetTypename returns CustomerTaxNodeOrder.Typename, and is useful for accessing the field via an interface.
+func (v *CustomerTaxNodeOrder) GetTypename() string { return v.Typename }
+
+// GetId returns CustomerTaxNodeOrder.Id, and is useful for accessing the field via an interface.
+func (v *CustomerTaxNodeOrder) GetId() string { return v.Id }
+
+// DefaultAddress includes the requested fields of the GraphQL type MailingAddress.
+type DefaultAddress struct {
+	CountryCodeV2 CountryCode `json:"countryCodeV2"`
+	Country       string      `json:"country"`
+	Province      string      `json:"province"`
+	ProvinceCode  string      `json:"provinceCode"`
+}
+
+// GetCountryCodeV2 returns DefaultAddress.CountryCodeV2, and is useful for accessing the field via an interface.
+func (v *DefaultAddress) GetCountryCodeV2() CountryCode { return v.CountryCodeV2 }
+
+// GetCountry returns DefaultAddress.Country, and is useful for accessing the field via an interface.
+func (v *DefaultAddress) GetCountry() string { return v.Country }
+
+// GetProvince returns DefaultAddress.Province, and is useful for accessing the field via an interface.
+func (v *DefaultAddress) GetProvince() string { return v.Province }
+
+// GetProvinceCode returns DefaultAddress.ProvinceCode, and is useful for accessing the field via an interface.
+func (v *DefaultAddress) GetProvinceCode() string { return v.ProvinceCode }
+
+// DefaultEmail includes the requested fields of the GraphQL type CustomerEmailAddress.
+type DefaultEmail struct {
+	EmailAddress   string                             `json:"emailAddress"`
+	MarketingState CustomerEmailAddressMarketingState `json:"marketingState"`
+}
+
+// GetEmailAddress returns DefaultEmail.EmailAddress, and is useful for accessing the field via an interface.
+func (v *DefaultEmail) GetEmailAddress() string { return v.EmailAddress }
+
+// GetMarketingState returns DefaultEmail.MarketingState, and is useful for accessing the field via an interface.
+func (v *DefaultEmail) GetMarketingState() CustomerEmailAddressMarketingState {
+	return v.MarketingState
+}
+
+// DefaultPhone includes the requested fields of the GraphQL type CustomerPhoneNumber.
+type DefaultPhone struct {
+	PhoneNumber    string                    `json:"phoneNumber"`
+	MarketingState CustomerSmsMarketingState `json:"marketingState"`
+}
+
+// GetPhoneNumber returns DefaultPhone.PhoneNumber, and is useful for accessing the field via an interface.
+func (v *DefaultPhone) GetPhoneNumber() string { return v.PhoneNumber }
+
+// GetMarketingState returns DefaultPhone.MarketingState, and is useful for accessing the field via an interface.
+func (v *DefaultPhone) GetMarketingState() CustomerSmsMarketingState { return v.MarketingState }
+
+// FirstOrderEdge includes the requested fields of the GraphQL type OrderEdge.
+type FirstOrderEdge struct {
+	Node OrderDate `json:"node"`
+}
+
+// GetNode returns FirstOrderEdge.Node, and is useful for accessing the field via an interface.
+func (v *FirstOrderEdge) GetNode() OrderDate { return v.Node }
+
+// FirstOrders includes the requested fields of the GraphQL type OrderConnection.
+type FirstOrders struct {
+	Edges []FirstOrderEdge `json:"edges"`
+}
+
+// GetEdges returns FirstOrders.Edges, and is useful for accessing the field via an interface.
+func (v *FirstOrders) GetEdges() []FirstOrderEdge { return v.Edges }
+
+// GetCustomerDatesResponse is returned by GetCustomerDates on success.
+type GetCustomerDatesResponse struct {
+	// Returns the list of nodes with the given IDs.
+	Nodes []CustomerDatesNode `json:"-"`
+}
+
+// GetNodes returns GetCustomerDatesResponse.Nodes, and is useful for accessing the field via an interface.
+func (v *GetCustomerDatesResponse) GetNodes() []CustomerDatesNode { return v.Nodes }
+
+func (v *GetCustomerDatesResponse) UnmarshalJSON(b []byte) error {
+
+	if string(b) == "null" {
+		return nil
+	}
+
+	var firstPass struct {
+		*GetCustomerDatesResponse
+		Nodes []json.RawMessage `json:"nodes"`
+		graphql.NoUnmarshalJSON
+	}
+	firstPass.GetCustomerDatesResponse = v
+
+	err := json.Unmarshal(b, &firstPass)
+	if err != nil {
+		return err
+	}
+
+	{
+		dst := &v.Nodes
+		src := firstPass.Nodes
+		*dst = make(
+			[]CustomerDatesNode,
+			len(src))
+		for i, src := range src {
+			dst := &(*dst)[i]
+			if len(src) != 0 && string(src) != "null" {
+				err = __unmarshalCustomerDatesNode(
+					src, dst)
+				if err != nil {
+					return fmt.Errorf(
+						"unable to unmarshal GetCustomerDatesResponse.Nodes: %w", err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+type __premarshalGetCustomerDatesResponse struct {
+	Nodes []json.RawMessage `json:"nodes"`
+}
+
+func (v *GetCustomerDatesResponse) MarshalJSON() ([]byte, error) {
+	premarshaled, err := v.__premarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(premarshaled)
+}
+
+func (v *GetCustomerDatesResponse) __premarshalJSON() (*__premarshalGetCustomerDatesResponse, error) {
+	var retval __premarshalGetCustomerDatesResponse
+
+	{
+
+		dst := &retval.Nodes
+		src := v.Nodes
+		*dst = make(
+			[]json.RawMessage,
+			len(src))
+		for i, src := range src {
+			dst := &(*dst)[i]
+			var err error
+			*dst, err = __marshalCustomerDatesNode(
+				&src)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"unable to marshal GetCustomerDatesResponse.Nodes: %w", err)
+			}
+		}
+	}
+	return &retval, nil
+}
+
+// GetCustomerOrdersPageResponse is returned by GetCustomerOrdersPage on success.
+type GetCustomerOrdersPageResponse struct {
+	// Returns a Customer resource by ID.
+	Customer *CustomerOrdersPage `json:"customer"`
+}
+
+// GetCustomer returns GetCustomerOrdersPageResponse.Customer, and is useful for accessing the field via an interface.
+func (v *GetCustomerOrdersPageResponse) GetCustomer() *CustomerOrdersPage { return v.Customer }
+
+// GetCustomerOrdersResponse is returned by GetCustomerOrders on success.
+type GetCustomerOrdersResponse struct {
+	// Returns the list of nodes with the given IDs.
+	Nodes []CustomerOrdersNode `json:"-"`
+}
+
+// GetNodes returns GetCustomerOrdersResponse.Nodes, and is useful for accessing the field via an interface.
+func (v *GetCustomerOrdersResponse) GetNodes() []CustomerOrdersNode { return v.Nodes }
+
+func (v *GetCustomerOrdersResponse) UnmarshalJSON(b []byte) error {
+
+	if string(b) == "null" {
+		return nil
+	}
+
+	var firstPass struct {
+		*GetCustomerOrdersResponse
+		Nodes []json.RawMessage `json:"nodes"`
+		graphql.NoUnmarshalJSON
+	}
+	firstPass.GetCustomerOrdersResponse = v
+
+	err := json.Unmarshal(b, &firstPass)
+	if err != nil {
+		return err
+	}
+
+	{
+		dst := &v.Nodes
+		src := firstPass.Nodes
+		*dst = make(
+			[]CustomerOrdersNode,
+			len(src))
+		for i, src := range src {
+			dst := &(*dst)[i]
+			if len(src) != 0 && string(src) != "null" {
+				err = __unmarshalCustomerOrdersNode(
+					src, dst)
+				if err != nil {
+					return fmt.Errorf(
+						"unable to unmarshal GetCustomerOrdersResponse.Nodes: %w", err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+type __premarshalGetCustomerOrdersResponse struct {
+	Nodes []json.RawMessage `json:"nodes"`
+}
+
+func (v *GetCustomerOrdersResponse) MarshalJSON() ([]byte, error) {
+	premarshaled, err := v.__premarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(premarshaled)
+}
+
+func (v *GetCustomerOrdersResponse) __premarshalJSON() (*__premarshalGetCustomerOrdersResponse, error) {
+	var retval __premarshalGetCustomerOrdersResponse
+
+	{
+
+		dst := &retval.Nodes
+		src := v.Nodes
+		*dst = make(
+			[]json.RawMessage,
+			len(src))
+		for i, src := range src {
+			dst := &(*dst)[i]
+			var err error
+			*dst, err = __marshalCustomerOrdersNode(
+				&src)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"unable to marshal GetCustomerOrdersResponse.Nodes: %w", err)
+			}
+		}
+	}
+	return &retval, nil
+}
+
+// GetCustomerSegmentMembersCustomerSegmentMembersCustomerSegmentMemberConnection includes the requested fields of the GraphQL type CustomerSegmentMemberConnection.
+type GetCustomerSegmentMembersCustomerSegmentMembersCustomerSegmentMemberConnection struct {
+	Edges    []segmentEdge `json:"edges"`
+	PageInfo PageInfo      `json:"pageInfo"`
+}
+
+// GetEdges returns GetCustomerSegmentMembersCustomerSegmentMembersCustomerSegmentMemberConnection.Edges, and is useful for accessing the field via an interface.
+func (v *GetCustomerSegmentMembersCustomerSegmentMembersCustomerSegmentMemberConnection) GetEdges() []segmentEdge {
+	return v.Edges
+}
+
+// GetPageInfo returns GetCustomerSegmentMembersCustomerSegmentMembersCustomerSegmentMemberConnection.PageInfo, and is useful for accessing the field via an interface.
+func (v *GetCustomerSegmentMembersCustomerSegmentMembersCustomerSegmentMemberConnection) GetPageInfo() PageInfo {
+	return v.PageInfo
+}
+
+// GetCustomerSegmentMembersResponse is returned by GetCustomerSegmentMembers on success.
+type GetCustomerSegmentMembersResponse struct {
+	// The list of members, such as customers, that's associated with an individual segment.
+	CustomerSegmentMembers GetCustomerSegmentMembersCustomerSegmentMembersCustomerSegmentMemberConnection `json:"customerSegmentMembers"`
+}
+
+// GetCustomerSegmentMembers returns GetCustomerSegmentMembersResponse.CustomerSegmentMembers, and is useful for accessing the field via an interface.
+func (v *GetCustomerSegmentMembersResponse) GetCustomerSegmentMembers() GetCustomerSegmentMembersCustomerSegmentMembersCustomerSegmentMemberConnection {
+	return v.CustomerSegmentMembers
+}
+
+// GetCustomerStatesResponse is returned by GetCustomerStates on success.
+type GetCustomerStatesResponse struct {
+	// Returns the list of nodes with the given IDs.
+	Nodes []CustomerStateNode `json:"-"`
+}
+
+// GetNodes returns GetCustomerStatesResponse.Nodes, and is useful for accessing the field via an interface.
+func (v *GetCustomerStatesResponse) GetNodes() []CustomerStateNode { return v.Nodes }
+
+func (v *GetCustomerStatesResponse) UnmarshalJSON(b []byte) error {
+
+	if string(b) == "null" {
+		return nil
+	}
+
+	var firstPass struct {
+		*GetCustomerStatesResponse
+		Nodes []json.RawMessage `json:"nodes"`
+		graphql.NoUnmarshalJSON
+	}
+	firstPass.GetCustomerStatesResponse = v
+
+	err := json.Unmarshal(b, &firstPass)
+	if err != nil {
+		return err
+	}
+
+	{
+		dst := &v.Nodes
+		src := firstPass.Nodes
+		*dst = make(
+			[]CustomerStateNode,
+			len(src))
+		for i, src := range src {
+			dst := &(*dst)[i]
+			if len(src) != 0 && string(src) != "null" {
+				err = __unmarshalCustomerStateNode(
+					src, dst)
+				if err != nil {
+					return fmt.Errorf(
+						"unable to unmarshal GetCustomerStatesResponse.Nodes: %w", err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+type __premarshalGetCustomerStatesResponse struct {
+	Nodes []json.RawMessage `json:"nodes"`
+}
+
+func (v *GetCustomerStatesResponse) MarshalJSON() ([]byte, error) {
+	premarshaled, err := v.__premarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(premarshaled)
+}
+
+func (v *GetCustomerStatesResponse) __premarshalJSON() (*__premarshalGetCustomerStatesResponse, error) {
+	var retval __premarshalGetCustomerStatesResponse
+
+	{
+
+		dst := &retval.Nodes
+		src := v.Nodes
+		*dst = make(
+			[]json.RawMessage,
+			len(src))
+		for i, src := range src {
+			dst := &(*dst)[i]
+			var err error
+			*dst, err = __marshalCustomerStateNode(
+				&src)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"unable to marshal GetCustomerStatesResponse.Nodes: %w", err)
+			}
+		}
+	}
+	return &retval, nil
+}
+
+// GetCustomerStatisticsResponse is returned by GetCustomerStatistics on success.
+type GetCustomerStatisticsResponse struct {
+	// Returns the list of nodes with the given IDs.
+	Nodes []CustomerStatisticsNode `json:"-"`
+}
+
+// GetNodes returns GetCustomerStatisticsResponse.Nodes, and is useful for accessing the field via an interface.
+func (v *GetCustomerStatisticsResponse) GetNodes() []CustomerStatisticsNode { return v.Nodes }
+
+func (v *GetCustomerStatisticsResponse) UnmarshalJSON(b []byte) error {
+
+	if string(b) == "null" {
+		return nil
+	}
+
+	var firstPass struct {
+		*GetCustomerStatisticsResponse
+		Nodes []json.RawMessage `json:"nodes"`
+		graphql.NoUnmarshalJSON
+	}
+	firstPass.GetCustomerStatisticsResponse = v
+
+	err := json.Unmarshal(b, &firstPass)
+	if err != nil {
+		return err
+	}
+
+	{
+		dst := &v.Nodes
+		src := firstPass.Nodes
+		*dst = make(
+			[]CustomerStatisticsNode,
+			len(src))
+		for i, src := range src {
+			dst := &(*dst)[i]
+			if len(src) != 0 && string(src) != "null" {
+				err = __unmarshalCustomerStatisticsNode(
+					src, dst)
+				if err != nil {
+					return fmt.Errorf(
+						"unable to unmarshal GetCustomerStatisticsResponse.Nodes: %w", err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+type __premarshalGetCustomerStatisticsResponse struct {
+	Nodes []json.RawMessage `json:"nodes"`
+}
+
+func (v *GetCustomerStatisticsResponse) MarshalJSON() ([]byte, error) {
+	premarshaled, err := v.__premarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(premarshaled)
+}
+
+func (v *GetCustomerStatisticsResponse) __premarshalJSON() (*__premarshalGetCustomerStatisticsResponse, error) {
+	var retval __premarshalGetCustomerStatisticsResponse
+
+	{
+
+		dst := &retval.Nodes
+		src := v.Nodes
+		*dst = make(
+			[]json.RawMessage,
+			len(src))
+		for i, src := range src {
+			dst := &(*dst)[i]
+			var err error
+			*dst, err = __marshalCustomerStatisticsNode(
+				&src)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"unable to marshal GetCustomerStatisticsResponse.Nodes: %w", err)
+			}
+		}
+	}
+	return &retval, nil
+}
+
+// GetCustomerTagsResponse is returned by GetCustomerTags on success.
+type GetCustomerTagsResponse struct {
+	// Returns the list of nodes with the given IDs.
+	Nodes []CustomerTagsNode `json:"-"`
+}
+
+// GetNodes returns GetCustomerTagsResponse.Nodes, and is useful for accessing the field via an interface.
+func (v *GetCustomerTagsResponse) GetNodes() []CustomerTagsNode { return v.Nodes }
+
+func (v *GetCustomerTagsResponse) UnmarshalJSON(b []byte) error {
+
+	if string(b) == "null" {
+		return nil
+	}
+
+	var firstPass struct {
+		*GetCustomerTagsResponse
+		Nodes []json.RawMessage `json:"nodes"`
+		graphql.NoUnmarshalJSON
+	}
+	firstPass.GetCustomerTagsResponse = v
+
+	err := json.Unmarshal(b, &firstPass)
+	if err != nil {
+		return err
+	}
+
+	{
+		dst := &v.Nodes
+		src := firstPass.Nodes
+		*dst = make(
+			[]CustomerTagsNode,
+			len(src))
+		for i, src := range src {
+			dst := &(*dst)[i]
+			if len(src) != 0 && string(src) != "null" {
+				err = __unmarshalCustomerTagsNode(
+					src, dst)
+				if err != nil {
+					return fmt.Errorf(
+						"unable to unmarshal GetCustomerTagsResponse.Nodes: %w", err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+type __premarshalGetCustomerTagsResponse struct {
+	Nodes []json.RawMessage `json:"nodes"`
+}
+
+func (v *GetCustomerTagsResponse) MarshalJSON() ([]byte, error) {
+	premarshaled, err := v.__premarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(premarshaled)
+}
+
+func (v *GetCustomerTagsResponse) __premarshalJSON() (*__premarshalGetCustomerTagsResponse, error) {
+	var retval __premarshalGetCustomerTagsResponse
+
+	{
+
+		dst := &retval.Nodes
+		src := v.Nodes
+		*dst = make(
+			[]json.RawMessage,
+			len(src))
+		for i, src := range src {
+			dst := &(*dst)[i]
+			var err error
+			*dst, err = __marshalCustomerTagsNode(
+				&src)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"unable to marshal GetCustomerTagsResponse.Nodes: %w", err)
+			}
+		}
+	}
+	return &retval, nil
+}
+
+// GetCustomerTaxExemptionsResponse is returned by GetCustomerTaxExemptions on success.
+type GetCustomerTaxExemptionsResponse struct {
+	// Returns the list of nodes with the given IDs.
+	Nodes []CustomerTaxNode `json:"-"`
+}
+
+// GetNodes returns GetCustomerTaxExemptionsResponse.Nodes, and is useful for accessing the field via an interface.
+func (v *GetCustomerTaxExemptionsResponse) GetNodes() []CustomerTaxNode { return v.Nodes }
+
+func (v *GetCustomerTaxExemptionsResponse) UnmarshalJSON(b []byte) error {
+
+	if string(b) == "null" {
+		return nil
+	}
+
+	var firstPass struct {
+		*GetCustomerTaxExemptionsResponse
+		Nodes []json.RawMessage `json:"nodes"`
+		graphql.NoUnmarshalJSON
+	}
+	firstPass.GetCustomerTaxExemptionsResponse = v
+
+	err := json.Unmarshal(b, &firstPass)
+	if err != nil {
+		return err
+	}
+
+	{
+		dst := &v.Nodes
+		src := firstPass.Nodes
+		*dst = make(
+			[]CustomerTaxNode,
+			len(src))
+		for i, src := range src {
+			dst := &(*dst)[i]
+			if len(src) != 0 && string(src) != "null" {
+				err = __unmarshalCustomerTaxNode(
+					src, dst)
+				if err != nil {
+					return fmt.Errorf(
+						"unable to unmarshal GetCustomerTaxExemptionsResponse.Nodes: %w", err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+type __premarshalGetCustomerTaxExemptionsResponse struct {
+	Nodes []json.RawMessage `json:"nodes"`
+}
+
+func (v *GetCustomerTaxExemptionsResponse) MarshalJSON() ([]byte, error) {
+	premarshaled, err := v.__premarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(premarshaled)
+}
+
+func (v *GetCustomerTaxExemptionsResponse) __premarshalJSON() (*__premarshalGetCustomerTaxExemptionsResponse, error) {
+	var retval __premarshalGetCustomerTaxExemptionsResponse
+
+	{
+
+		dst := &retval.Nodes
+		src := v.Nodes
+		*dst = make(
+			[]json.RawMessage,
+			len(src))
+		for i, src := range src {
+			dst := &(*dst)[i]
+			var err error
+			*dst, err = __marshalCustomerTaxNode(
+				&src)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"unable to marshal GetCustomerTaxExemptionsResponse.Nodes: %w", err)
+			}
+		}
+	}
+	return &retval, nil
+}
+
+// GetShopResponse is returned by GetShop on success.
+type GetShopResponse struct {
+	// Returns the Shop resource corresponding to the access token used in the request.
+	Shop GetShopShop `json:"shop"`
+}
+
+// GetShop returns GetShopResponse.Shop, and is useful for accessing the field via an interface.
+func (v *GetShopResponse) GetShop() GetShopShop { return v.Shop }
+
+// GetShopShop includes the requested fields of the GraphQL type Shop.
+type GetShopShop struct {
+	Name         string       `json:"name"`
+	CurrencyCode CurrencyCode `json:"currencyCode"`
+	IanaTimezone string       `json:"ianaTimezone"`
+}
+
+// GetName returns GetShopShop.Name, and is useful for accessing the field via an interface.
+func (v *GetShopShop) GetName() string { return v.Name }
+
+// GetCurrencyCode returns GetShopShop.CurrencyCode, and is useful for accessing the field via an interface.
+func (v *GetShopShop) GetCurrencyCode() CurrencyCode { return v.CurrencyCode }
+
+// GetIanaTimezone returns GetShopShop.IanaTimezone, and is useful for accessing the field via an interface.
+func (v *GetShopShop) GetIanaTimezone() string { return v.IanaTimezone }
+
+// MergeableStatus includes the requested fields of the GraphQL type CustomerMergeable.
+type MergeableStatus struct {
+	IsMergeable bool                          `json:"isMergeable"`
+	ErrorFields []CustomerMergeErrorFieldType `json:"errorFields"`
+}
+
+// GetIsMergeable returns MergeableStatus.IsMergeable, and is useful for accessing the field via an interface.
+func (v *MergeableStatus) GetIsMergeable() bool { return v.IsMergeable }
+
+// GetErrorFields returns MergeableStatus.ErrorFields, and is useful for accessing the field via an interface.
+func (v *MergeableStatus) GetErrorFields() []CustomerMergeErrorFieldType { return v.ErrorFields }
+
+// MonetaryAmount includes the requested fields of the GraphQL type MoneyV2.
+type MonetaryAmount struct {
+	Amount       decimal.Decimal `json:"amount"`
+	CurrencyCode CurrencyCode    `json:"currencyCode"`
+}
+
+// GetAmount returns MonetaryAmount.Amount, and is useful for accessing the field via an interface.
+func (v *MonetaryAmount) GetAmount() decimal.Decimal { return v.Amount }
+
+// GetCurrencyCode returns MonetaryAmount.CurrencyCode, and is useful for accessing the field via an interface.
+func (v *MonetaryAmount) GetCurrencyCode() CurrencyCode { return v.CurrencyCode }
+
+// Node includes the requested fields of the GraphQL type CustomerSegmentMember.
+// The GraphQL type's documentation follows.
+//
+// The member of a segment.
+type Node struct {
+	Id                  string           `json:"id"`
+	DisplayName         string           `json:"displayName"`
+	DefaultEmailAddress *DefaultEmail    `json:"defaultEmailAddress"`
+	DefaultPhoneNumber  *DefaultPhone    `json:"defaultPhoneNumber"`
+	DefaultAddress      *DefaultAddress  `json:"defaultAddress"`
+	AmountSpent         MonetaryAmount   `json:"amountSpent"`
+	Mergeable           *MergeableStatus `json:"mergeable"`
+}
+
+// GetId returns Node.Id, and is useful for accessing the field via an interface.
+func (v *Node) GetId() string { return v.Id }
+
+// GetDisplayName returns Node.DisplayName, and is useful for accessing the field via an interface.
+func (v *Node) GetDisplayName() string { return v.DisplayName }
+
+// GetDefaultEmailAddress returns Node.DefaultEmailAddress, and is useful for accessing the field via an interface.
+func (v *Node) GetDefaultEmailAddress() *DefaultEmail { return v.DefaultEmailAddress }
+
+// GetDefaultPhoneNumber returns Node.DefaultPhoneNumber, and is useful for accessing the field via an interface.
+func (v *Node) GetDefaultPhoneNumber() *DefaultPhone { return v.DefaultPhoneNumber }
+
+// GetDefaultAddress returns Node.DefaultAddress, and is useful for accessing the field via an interface.
+func (v *Node) GetDefaultAddress() *DefaultAddress { return v.DefaultAddress }
+
+// GetAmountSpent returns Node.AmountSpent, and is useful for accessing the field via an interface.
+func (v *Node) GetAmountSpent() MonetaryAmount { return v.AmountSpent }
+
+// GetMergeable returns Node.Mergeable, and is useful for accessing the field via an interface.
+func (v *Node) GetMergeable() *MergeableStatus { return v.Mergeable }
+
+// OrderDate includes the requested fields of the GraphQL type Order.
+type OrderDate struct {
+	ProcessedAt time.Time `json:"processedAt"`
+}
+
+// GetProcessedAt returns OrderDate.ProcessedAt, and is useful for accessing the field via an interface.
+func (v *OrderDate) GetProcessedAt() time.Time { return v.ProcessedAt }
+
+// OrderMoney includes the requested fields of the GraphQL type MoneyV2.
+type OrderMoney struct {
+	Amount       decimal.Decimal `json:"amount"`
+	CurrencyCode CurrencyCode    `json:"currencyCode"`
+}
+
+// GetAmount returns OrderMoney.Amount, and is useful for accessing the field via an interface.
+func (v *OrderMoney) GetAmount() decimal.Decimal { return v.Amount }
+
+// GetCurrencyCode returns OrderMoney.CurrencyCode, and is useful for accessing the field via an interface.
+func (v *OrderMoney) GetCurrencyCode() CurrencyCode { return v.CurrencyCode }
+
+// OrderTotal includes the requested fields of the GraphQL type MoneyBag.
+type OrderTotal struct {
+	ShopMoney OrderMoney `json:"shopMoney"`
+}
+
+// GetShopMoney returns OrderTotal.ShopMoney, and is useful for accessing the field via an interface.
+func (v *OrderTotal) GetShopMoney() OrderMoney { return v.ShopMoney }
+
+// OrdersPageInfo includes the requested fields of the GraphQL type PageInfo.
+type OrdersPageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+// GetHasNextPage returns OrdersPageInfo.HasNextPage, and is useful for accessing the field via an interface.
+func (v *OrdersPageInfo) GetHasNextPage() bool { return v.HasNextPage }
+
+// GetEndCursor returns OrdersPageInfo.EndCursor, and is useful for accessing the field via an interface.
+func (v *OrdersPageInfo) GetEndCursor() string { return v.EndCursor }
+
+// PageInfo includes the requested fields of the GraphQL type PageInfo.
+type PageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+// GetHasNextPage returns PageInfo.HasNextPage, and is useful for accessing the field via an interface.
+func (v *PageInfo) GetHasNextPage() bool { return v.HasNextPage }
+
+// GetEndCursor returns PageInfo.EndCursor, and is useful for accessing the field via an interface.
+func (v *PageInfo) GetEndCursor() string { return v.EndCursor }
+
+type TaxExemption string
+
+const (
+	TaxExemptionCaBcResellerExemption        TaxExemption = "CA_BC_RESELLER_EXEMPTION"
+	TaxExemptionCaDiplomatExemption          TaxExemption = "CA_DIPLOMAT_EXEMPTION"
+	TaxExemptionCaStatusCardExemption        TaxExemption = "CA_STATUS_CARD_EXEMPTION"
+	TaxExemptionEuReverseChargeExemptionRule TaxExemption = "EU_REVERSE_CHARGE_EXEMPTION_RULE"
+	TaxExemptionUsCaResellerExemption        TaxExemption = "US_CA_RESELLER_EXEMPTION"
+	TaxExemptionUsNyResellerExemption        TaxExemption = "US_NY_RESELLER_EXEMPTION"
+	TaxExemptionUsTxResellerExemption        TaxExemption = "US_TX_RESELLER_EXEMPTION"
+)
+
+// __GetCustomerDatesInput is used internally by genqlient
+type __GetCustomerDatesInput struct {
+	Ids []string `json:"ids"`
+}
+
+// GetIds returns __GetCustomerDatesInput.Ids, and is useful for accessing the field via an interface.
+func (v *__GetCustomerDatesInput) GetIds() []string { return v.Ids }
+
+// __GetCustomerOrdersInput is used internally by genqlient
+type __GetCustomerOrdersInput struct {
+	Ids   []string `json:"ids"`
+	First int      `json:"first"`
+	Query string   `json:"query,omitempty"`
+}
+
+// GetIds returns __GetCustomerOrdersInput.Ids, and is useful for accessing the field via an interface.
+func (v *__GetCustomerOrdersInput) GetIds() []string { return v.Ids }
+
+// GetFirst returns __GetCustomerOrdersInput.First, and is useful for accessing the field via an interface.
+func (v *__GetCustomerOrdersInput) GetFirst() int { return v.First }
+
+// GetQuery returns __GetCustomerOrdersInput.Query, and is useful for accessing the field via an interface.
+func (v *__GetCustomerOrdersInput) GetQuery() string { return v.Query }
+
+// __GetCustomerOrdersPageInput is used internally by genqlient
+type __GetCustomerOrdersPageInput struct {
+	Id    string `json:"id"`
+	First int    `json:"first"`
+	Query string `json:"query,omitempty"`
+	After string `json:"after"`
+}
+
+// GetId returns __GetCustomerOrdersPageInput.Id, and is useful for accessing the field via an interface.
+func (v *__GetCustomerOrdersPageInput) GetId() string { return v.Id }
+
+// GetFirst returns __GetCustomerOrdersPageInput.First, and is useful for accessing the field via an interface.
+func (v *__GetCustomerOrdersPageInput) GetFirst() int { return v.First }
+
+// GetQuery returns __GetCustomerOrdersPageInput.Query, and is useful for accessing the field via an interface.
+func (v *__GetCustomerOrdersPageInput) GetQuery() string { return v.Query }
+
+// GetAfter returns __GetCustomerOrdersPageInput.After, and is useful for accessing the field via an interface.
+func (v *__GetCustomerOrdersPageInput) GetAfter() string { return v.After }
+
+// __GetCustomerSegmentMembersInput is used internally by genqlient
+type __GetCustomerSegmentMembersInput struct {
+	First     int    `json:"first"`
+	Query     string `json:"query"`
+	SortKey   string `json:"sortKey"`
+	Reverse   bool   `json:"reverse"`
+	After     string `json:"after,omitempty"`
+	Mergeable bool   `json:"mergeable"`
+}
+
+// GetFirst returns __GetCustomerSegmentMembersInput.First, and is useful for accessing the field via an interface.
+func (v *__GetCustomerSegmentMembersInput) GetFirst() int { return v.First }
+
+// GetQuery returns __GetCustomerSegmentMembersInput.Query, and is useful for accessing the field via an interface.
+func (v *__GetCustomerSegmentMembersInput) GetQuery() string { return v.Query }
+
+// GetSortKey returns __GetCustomerSegmentMembersInput.SortKey, and is useful for accessing the field via an interface.
+func (v *__GetCustomerSegmentMembersInput) GetSortKey() string { return v.SortKey }
+
+// GetReverse returns __GetCustomerSegmentMembersInput.Reverse, and is useful for accessing the field via an interface.
+func (v *__GetCustomerSegmentMembersInput) GetReverse() bool { return v.Reverse }
+
+// GetAfter returns __GetCustomerSegmentMembersInput.After, and is useful for accessing the field via an interface.
+func (v *__GetCustomerSegmentMembersInput) GetAfter() string { return v.After }
+
+// GetMergeable returns __GetCustomerSegmentMembersInput.Mergeable, and is useful for accessing the field via an interface.
+func (v *__GetCustomerSegmentMembersInput) GetMergeable() bool { return v.Mergeable }
+
+// __GetCustomerStatesInput is used internally by genqlient
+type __GetCustomerStatesInput struct {
+	Ids []string `json:"ids"`
+}
+
+// GetIds returns __GetCustomerStatesInput.Ids, and is useful for accessing the field via an interface.
+func (v *__GetCustomerStatesInput) GetIds() []string { return v.Ids }
+
+// __GetCustomerStatisticsInput is used internally by genqlient
+type __GetCustomerStatisticsInput struct {
+	Ids []string `json:"ids"`
+}
+
+// GetIds returns __GetCustomerStatisticsInput.Ids, and is useful for accessing the field via an interface.
+func (v *__GetCustomerStatisticsInput) GetIds() []string { return v.Ids }
+
+// __GetCustomerTagsInput is used internally by genqlient
+type __GetCustomerTagsInput struct {
+	Ids []string `json:"ids"`
+}
+
+// GetIds returns __GetCustomerTagsInput.Ids, and is useful for accessing the field via an interface.
+func (v *__GetCustomerTagsInput) GetIds() []string { return v.Ids }
+
+// __GetCustomerTaxExemptionsInput is used internally by genqlient
+type __GetCustomerTaxExemptionsInput struct {
+	Ids []string `json:"ids"`
+}
+
+// GetIds returns __GetCustomerTaxExemptionsInput.Ids, and is useful for accessing the field via an interface.
+func (v *__GetCustomerTaxExemptionsInput) GetIds() []string { return v.Ids }
+
+// segmentEdge includes the requested fields of the GraphQL type CustomerSegmentMemberEdge.
+type segmentEdge struct {
+	Node Node `json:"node"`
+}
+
+// GetNode returns segmentEdge.Node, and is useful for accessing the field via an interface.
+func (v *segmentEdge) GetNode() Node { return v.Node }
+
+// The query or mutation executed by GetCustomerDates.
+const GetCustomerDates_Operation = `
+query GetCustomerDates ($ids: [ID!]!) {
+	nodes(ids: $ids) {
+		__typename
+		id
+		... on Customer {
+			createdAt
+			updatedAt
+			lastOrder {
+				processedAt
+			}
+			orders(first: 1, sortKey: PROCESSED_AT) {
+				edges {
+					node {
+						processedAt
+					}
+				}
+			}
+		}
+	}
+}
+`
+
+func GetCustomerDates(
+	ctx_ context.Context,
+	client_ graphql.Client,
+	ids []string,
+) (*GetCustomerDatesResponse, error) {
+	req_ := &graphql.Request{
+		OpName: "GetCustomerDates",
+		Query:  GetCustomerDates_Operation,
+		Variables: &__GetCustomerDatesInput{
+			Ids: ids,
+		},
+	}
+	var err_ error
+
+	var data_ GetCustomerDatesResponse
+	resp_ := &graphql.Response{Data: &data_}
+
+	err_ = client_.MakeRequest(
+		ctx_,
+		req_,
+		resp_,
+	)
+
+	return &data_, err_
+}
+
+// The query or mutation executed by GetCustomerOrders.
+const GetCustomerOrders_Operation = `
+query GetCustomerOrders ($ids: [ID!]!, $first: Int!, $query: String) {
+	nodes(ids: $ids) {
+		__typename
+		id
+		... on Customer {
+			orders(first: $first, query: $query, sortKey: PROCESSED_AT, reverse: true) {
+				nodes {
+					id
+					name
+					processedAt
+					totalPriceSet {
+						shopMoney {
+							amount
+							currencyCode
+						}
+					}
+				}
+				pageInfo {
+					hasNextPage
+					endCursor
+				}
+			}
+		}
+	}
+}
+`
+
+func GetCustomerOrders(
+	ctx_ context.Context,
+	client_ graphql.Client,
+	ids []string,
+	first int,
+	query string,
+) (*GetCustomerOrdersResponse, error) {
+	req_ := &graphql.Request{
+		OpName: "GetCustomerOrders",
+		Query:  GetCustomerOrders_Operation,
+		Variables: &__GetCustomerOrdersInput{
+			Ids:   ids,
+			First: first,
+			Query: query,
+		},
+	}
+	var err_ error
+
+	var data_ GetCustomerOrdersResponse
+	resp_ := &graphql.Response{Data: &data_}
+
+	err_ = client_.MakeRequest(
+		ctx_,
+		req_,
+		resp_,
+	)
+
+	return &data_, err_
+}
+
+// The query or mutation executed by GetCustomerOrdersPage.
+const GetCustomerOrdersPage_Operation = `
+query GetCustomerOrdersPage ($id: ID!, $first: Int!, $query: String, $after: String!) {
+	customer(id: $id) {
+		orders(first: $first, query: $query, after: $after, sortKey: PROCESSED_AT, reverse: true) {
+			nodes {
+				id
+				name
+				processedAt
+				totalPriceSet {
+					shopMoney {
+						amount
+						currencyCode
+					}
+				}
+			}
+			pageInfo {
+				hasNextPage
+				endCursor
+			}
+		}
+	}
+}
+`
+
+func GetCustomerOrdersPage(
+	ctx_ context.Context,
+	client_ graphql.Client,
+	id string,
+	first int,
+	query string,
+	after string,
+) (*GetCustomerOrdersPageResponse, error) {
+	req_ := &graphql.Request{
+		OpName: "GetCustomerOrdersPage",
+		Query:  GetCustomerOrdersPage_Operation,
+		Variables: &__GetCustomerOrdersPageInput{
+			Id:    id,
+			First: first,
+			Query: query,
+			After: after,
+		},
+	}
+	var err_ error
+
+	var data_ GetCustomerOrdersPageResponse
+	resp_ := &graphql.Response{Data: &data_}
+
+	err_ = client_.MakeRequest(
+		ctx_,
+		req_,
+		resp_,
+	)
+
+	return &data_, err_
+}
+
+// The query or mutation executed by GetCustomerSegmentMembers.
+const GetCustomerSegmentMembers_Operation = `
+query GetCustomerSegmentMembers ($first: Int!, $query: String!, $sortKey: String, $reverse: Boolean!, $after: String, $mergeable: Boolean!) {
+	customerSegmentMembers(first: $first, query: $query, sortKey: $sortKey, reverse: $reverse, after: $after) {
+		edges {
+			node {
+				id
+				displayName
+				defaultEmailAddress {
+					emailAddress
+					marketingState
+				}
+				defaultPhoneNumber {
+					phoneNumber
+					marketingState
+				}
+				defaultAddress {
+					countryCodeV2
+					country
+					province
+					provinceCode
+				}
+				amountSpent {
+					amount
+					currencyCode
+				}
+				mergeable @include(if: $mergeable) {
+					isMergeable
+					errorFields
+				}
+			}
+		}
+		pageInfo {
+			hasNextPage
+			endCursor
+		}
+	}
+}
+`
+
+func GetCustomerSegmentMembers(
+	ctx_ context.Context,
+	client_ graphql.Client,
+	first int,
+	query string,
+	sortKey string,
+	reverse bool,
+	after string,
+	mergeable bool,
+) (*GetCustomerSegmentMembersResponse, error) {
+	req_ := &graphql.Request{
+		OpName: "GetCustomerSegmentMembers",
+		Query:  GetCustomerSegmentMembers_Operation,
+		Variables: &__GetCustomerSegmentMembersInput{
+			First:     first,
+			Query:     query,
+			SortKey:   sortKey,
+			Reverse:   reverse,
+			After:     after,
+			Mergeable: mergeable,
+		},
+	}
+	var err_ error
+
+	var data_ GetCustomerSegmentMembersResponse
+	resp_ := &graphql.Response{Data: &data_}
+
+	err_ = client_.MakeRequest(
+		ctx_,
+		req_,
+		resp_,
+	)
+
+	return &data_, err_
+}
+
+// The query or mutation executed by GetCustomerStates.
+const GetCustomerStates_Operation = `
+query GetCustomerStates ($ids: [ID!]!) {
+	nodes(ids: $ids) {
+		__typename
+		id
+		... on Customer {
+			state
+		}
+	}
+}
+`
+
+func GetCustomerStates(
+	ctx_ context.Context,
+	client_ graphql.Client,
+	ids []string,
+) (*GetCustomerStatesResponse, error) {
+	req_ := &graphql.Request{
+		OpName: "GetCustomerStates",
+		Query:  GetCustomerStates_Operation,
+		Variables: &__GetCustomerStatesInput{
+			Ids: ids,
+		},
+	}
+	var err_ error
+
+	var data_ GetCustomerStatesResponse
+	resp_ := &graphql.Response{Data: &data_}
+
+	err_ = client_.MakeRequest(
+		ctx_,
+		req_,
+		resp_,
+	)
+
+	return &data_, err_
+}
+
+// The query or mutation executed by GetCustomerStatistics.
+const GetCustomerStatistics_Operation = `
+query GetCustomerStatistics ($ids: [ID!]!) {
+	nodes(ids: $ids) {
+		__typename
+		id
+		... on Customer {
+			statistics {
+				predictedSpendTier
+				rfmGroup
+			}
+		}
+	}
+}
+`
+
+func GetCustomerStatistics(
+	ctx_ context.Context,
+	client_ graphql.Client,
+	ids []string,
+) (*GetCustomerStatisticsResponse, error) {
+	req_ := &graphql.Request{
+		OpName: "GetCustomerStatistics",
+		Query:  GetCustomerStatistics_Operation,
+		Variables: &__GetCustomerStatisticsInput{
+			Ids: ids,
+		},
+	}
+	var err_ error
+
+	var data_ GetCustomerStatisticsResponse
+	resp_ := &graphql.Response{Data: &data_}
+
+	err_ = client_.MakeRequest(
+		ctx_,
+		req_,
+		resp_,
+	)
+
+	return &data_, err_
+}
+
+// The query or mutation executed by GetCustomerTags.
+const GetCustomerTags_Operation = `
+query GetCustomerTags ($ids: [ID!]!) {
+	nodes(ids: $ids) {
+		__typename
+		id
+		... on Customer {
+			tags
+		}
+	}
+}
+`
+
+func GetCustomerTags(
+	ctx_ context.Context,
+	client_ graphql.Client,
+	ids []string,
+) (*GetCustomerTagsResponse, error) {
+	req_ := &graphql.Request{
+		OpName: "GetCustomerTags",
+		Query:  GetCustomerTags_Operation,
+		Variables: &__GetCustomerTagsInput{
+			Ids: ids,
+		},
+	}
+	var err_ error
+
+	var data_ GetCustomerTagsResponse
+	resp_ := &graphql.Response{Data: &data_}
+
+	err_ = client_.MakeRequest(
+		ctx_,
+		req_,
+		resp_,
+	)
+
+	return &data_, err_
+}
+
+// The query or mutation executed by GetCustomerTaxExemptions.
+const GetCustomerTaxExemptions_Operation = `
+query GetCustomerTaxExemptions ($ids: [ID!]!) {
+	nodes(ids: $ids) {
+		__typename
+		id
+		... on Customer {
+			taxExempt
+			taxExemptions
+		}
+	}
+}
+`
+
+func GetCustomerTaxExemptions(
+	ctx_ context.Context,
+	client_ graphql.Client,
+	ids []string,
+) (*GetCustomerTaxExemptionsResponse, error) {
+	req_ := &graphql.Request{
+		OpName: "GetCustomerTaxExemptions",
+		Query:  GetCustomerTaxExemptions_Operation,
+		Variables: &__GetCustomerTaxExemptionsInput{
+			Ids: ids,
+		},
+	}
+	var err_ error
+
+	var data_ GetCustomerTaxExemptionsResponse
+	resp_ := &graphql.Response{Data: &data_}
+
+	err_ = client_.MakeRequest(
+		ctx_,
+		req_,
+		resp_,
+	)
+
+	return &data_, err_
+}
+
+// The query or mutation executed by GetShop.
+const GetShop_Operation = `
+query GetShop {
+	shop {
+		name
+		currencyCode
+		ianaTimezone
+	}
+}
+`
+
+func GetShop(
+	ctx_ context.Context,
+	client_ graphql.Client,
+) (*GetShopResponse, error) {
+	req_ := &graphql.Request{
+		OpName: "GetShop",
+		Query:  GetShop_Operation,
+	}
+	var err_ error
+
+	var data_ GetShopResponse
+	resp_ := &graphql.Response{Data: &data_}
+
+	err_ = client_.MakeRequest(
+		ctx_,
+		req_,
+		resp_,
+	)
+
+	return &data_, err_
+}
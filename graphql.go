@@ -0,0 +1,354 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+func graphqlCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "graphql",
+		Usage: "Run an arbitrary Admin API GraphQL query and export the result as CSV",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "query-file", Required: true, Usage: "File containing the GraphQL query, or - to read it from stdin"},
+			&cli.StringFlag{Name: "variables-file", Usage: "JSON or YAML file of query variables; ${VAR} references are replaced with environment variables"},
+			&cli.StringFlag{Name: "variables", Usage: "Query variables as a JSON object, overriding --variables-file"},
+			&cli.StringFlag{Name: "rows", Usage: "Dot-separated path of the rows in the response data, e.g. customers.edges.node (default: one row)"},
+			&cli.StringFlag{Name: "lists", Value: "join", Usage: "How lists inside a row are written: join (one cell) or explode (one row per element)"},
+			&cli.StringFlag{Name: "list-separator", Value: ";", Usage: "Separator of joined list values"},
+			&cli.StringFlag{Name: "output", Aliases: []string{"o"}, Usage: "Output CSV filename, or clipboard to copy it (default: stdout)"},
+			&cli.StringFlag{Name: "api", Value: "admin", Usage: "API to query: admin, or storefront with SHOPIFY_STOREFRONT_ACCESS_TOKEN for public data without Admin API scopes"},
+			&cli.StringFlag{Name: "schema-file", Usage: "Also write the inferred column types (number, date, datetime, bool, string) as JSON to this file"},
+		},
+		Action: func(c *cli.Context) error {
+			if api := c.String("api"); api != "admin" && api != storefrontAPI {
+				return fmt.Errorf("invalid --api %q, expected admin or storefront", api)
+			}
+			mode := c.String("lists")
+			if mode != "join" && mode != "explode" {
+				return fmt.Errorf("invalid --lists %q, expected join or explode", mode)
+			}
+			var query []byte
+			var err error
+			if path := c.String("query-file"); path == "-" {
+				query, err = io.ReadAll(os.Stdin)
+			} else {
+				query, err = os.ReadFile(path)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read query: %w", err)
+			}
+			variables, err := readVariablesFile(c.String("variables-file"))
+			if err != nil {
+				return err
+			}
+			if v := c.String("variables"); v != "" {
+				var overrides map[string]interface{}
+				if err := json.Unmarshal([]byte(v), &overrides); err != nil {
+					return fmt.Errorf("invalid --variables: %w", err)
+				}
+				for k, v := range overrides {
+					variables[k] = v
+				}
+			}
+
+			ctx, cancel := exportContext(c.Duration("timeout"))
+			defer cancel()
+			data, err := runGraphQL(ctx, c, string(query), variables)
+			if err != nil {
+				return err
+			}
+
+			f := &flattener{explode: mode == "explode", separator: c.String("list-separator")}
+			var rows []flatRow
+			for _, v := range selectRows(data, c.String("rows")) {
+				rows = append(rows, f.rows("", v)...)
+			}
+
+			return exportFlatRows(ctx, c, f.columns, rows)
+		},
+	}
+}
+
+// exportFlatRows writes flattened rows as CSV to --output, and their column types
+// to --schema-file if set.
+func exportFlatRows(ctx context.Context, c *cli.Context, columns []string, rows []flatRow) error {
+	types := inferColumnTypes(columns, rows)
+	// The Storefront API has no shop time zone, so its timestamps are in
+	// --timezone or UTC.
+	for _, t := range types {
+		if t == kindDateTime && (c.String("api") != storefrontAPI || c.String("timezone") != "") {
+			if err := resolveDateLocation(ctx, c); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	if path := c.String("schema-file"); path != "" {
+		if err := writeSchemaFile(path, columns, types, rows); err != nil {
+			return err
+		}
+	}
+
+	w := io.Writer(os.Stdout)
+	switch output := c.String("output"); output {
+	case "":
+	case clipboardOutput:
+		var b bytes.Buffer
+		if err := writeFlatCSV(&b, columns, types, rows); err != nil {
+			return err
+		}
+		return copyToClipboard(b.Bytes())
+	default:
+		file, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	return writeFlatCSV(w, columns, types, rows)
+}
+
+// readVariablesFile reads query variables from a JSON or YAML file, which YAML
+// parsing covers both of, after replacing ${VAR} references with environment
+// variables. Unset variables are an error rather than silently empty.
+func readVariablesFile(path string) (map[string]interface{}, error) {
+	variables := map[string]interface{}{}
+	if path == "" {
+		return variables, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read variables file: %w", err)
+	}
+
+	var missing []string
+	text := os.Expand(string(b), func(name string) string {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("variables file references unset environment variables: %s", strings.Join(missing, ", "))
+	}
+	if err := yaml.Unmarshal([]byte(text), &variables); err != nil {
+		return nil, fmt.Errorf("invalid variables file: %w", err)
+	}
+	return variables, nil
+}
+
+// runGraphQL sends a query and returns its data decoded with decodeOrdered.
+func runGraphQL(ctx context.Context, c *cli.Context, query string, variables map[string]interface{}) (interface{}, error) {
+	client, err := newShopifyClient(c)
+	if err != nil {
+		return nil, err
+	}
+	data, err := client.Query(ctx, query, variables)
+	if err != nil {
+		return nil, err
+	}
+	return decodeData(data)
+}
+
+// decodeData decodes response data with decodeOrdered.
+func decodeData(data json.RawMessage) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return decodeOrdered(dec)
+}
+
+// jsonObject is a decoded JSON object that remembers the order of its keys, so
+// columns come out in the order the query selects the fields.
+type jsonObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// decodeOrdered decodes the next JSON value into a *jsonObject, []interface{},
+// json.Number, string, bool or nil.
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		obj := &jsonObject{values: map[string]interface{}{}}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj.keys = append(obj.keys, key.(string))
+			obj.values[key.(string)] = v
+		}
+		_, err := dec.Token()
+		return obj, err
+	case json.Delim('['):
+		list := []interface{}{}
+		for dec.More() {
+			v, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		_, err := dec.Token()
+		return list, err
+	}
+	return tok, nil
+}
+
+// selectRows follows a dot-separated path into data. Lists along the path are
+// traversed element by element, and a list at the end yields one row per element.
+func selectRows(data interface{}, path string) []interface{} {
+	var parts []string
+	if path != "" {
+		parts = strings.Split(path, ".")
+	}
+	var walk func(v interface{}, parts []string) []interface{}
+	walk = func(v interface{}, parts []string) []interface{} {
+		if list, ok := v.([]interface{}); ok {
+			var out []interface{}
+			for _, elem := range list {
+				out = append(out, walk(elem, parts)...)
+			}
+			return out
+		}
+		if len(parts) == 0 {
+			return []interface{}{v}
+		}
+		if obj, ok := v.(*jsonObject); ok {
+			return walk(obj.values[parts[0]], parts[1:])
+		}
+		return nil
+	}
+	return walk(data, parts)
+}
+
+// flatRow maps dot-notation column names to cells.
+type flatRow map[string]cell
+
+// flattener turns nested values into rows with dot-notation columns such as
+// "amountSpent.amount", collecting the columns in first-seen order.
+type flattener struct {
+	explode   bool
+	separator string
+	columns   []string
+	seen      map[string]bool
+}
+
+func (f *flattener) rows(prefix string, v interface{}) []flatRow {
+	switch v := v.(type) {
+	case *jsonObject:
+		rows := []flatRow{{}}
+		for _, key := range v.keys {
+			name := key
+			if prefix != "" {
+				name = prefix + "." + key
+			}
+			rows = crossRows(rows, f.rows(name, v.values[key]))
+		}
+		return rows
+	case []interface{}:
+		if len(v) == 0 {
+			return []flatRow{{}}
+		}
+		var rows []flatRow
+		for _, elem := range v {
+			rows = append(rows, f.rows(prefix, elem)...)
+		}
+		if f.explode {
+			return rows
+		}
+		// Joining keeps one row: each column holds the values of all elements. A
+		// single value keeps its type; joined values are strings.
+		joined := flatRow{}
+		for _, col := range f.columns {
+			var values []string
+			var last cell
+			for _, r := range rows {
+				if value, ok := r[col]; ok {
+					values = append(values, formatCell(value, value.kind))
+					last = value
+				}
+			}
+			switch {
+			case len(values) == 1:
+				joined[col] = last
+			case len(values) > 1:
+				joined[col] = cell{text: strings.Join(values, f.separator), kind: kindString}
+			}
+		}
+		return []flatRow{joined}
+	}
+
+	if prefix == "" {
+		prefix = "value"
+	}
+	f.addColumn(prefix)
+	return []flatRow{{prefix: newCell(v)}}
+}
+
+func (f *flattener) addColumn(name string) {
+	if f.seen == nil {
+		f.seen = map[string]bool{}
+	}
+	if !f.seen[name] {
+		f.seen[name] = true
+		f.columns = append(f.columns, name)
+	}
+}
+
+// crossRows combines every row of a with every row of b.
+func crossRows(a, b []flatRow) []flatRow {
+	out := make([]flatRow, 0, len(a)*len(b))
+	for _, ra := range a {
+		for _, rb := range b {
+			row := make(flatRow, len(ra)+len(rb))
+			for k, v := range ra {
+				row[k] = v
+			}
+			for k, v := range rb {
+				row[k] = v
+			}
+			out = append(out, row)
+		}
+	}
+	return out
+}
+
+// writeFlatCSV writes rows with every cell formatted for the type of its column.
+func writeFlatCSV(w io.Writer, columns []string, types map[string]string, rows []flatRow) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+	record := make([]string, len(columns))
+	for _, row := range rows {
+		for i, col := range columns {
+			record[i] = formatCell(row[col], types[col])
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
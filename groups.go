@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// splitGroups assigns customers to weighted groups such as A/B test cohorts, set
+// by --split-groups. Like sampling, assignment hashes the seed and customer ID, so
+// customers stay in their group across runs.
+type splitGroups struct {
+	seed    int64
+	names   []string
+	weights []float64
+	total   float64
+}
+
+// parseSplitGroups parses "A:50,B:50". Weights are relative and need not add up to 100.
+func parseSplitGroups(s string, seed int64) (*splitGroups, error) {
+	g := &splitGroups{seed: seed}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(s, ",") {
+		name, weight, ok := strings.Cut(strings.TrimSpace(part), ":")
+		name = strings.TrimSpace(name)
+		w, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(weight), "%"), 64)
+		if !ok || name == "" || err != nil || w <= 0 {
+			return nil, fmt.Errorf("invalid --split-groups entry %q, expected name:weight", part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate --split-groups group %q", name)
+		}
+		seen[name] = true
+		g.names = append(g.names, name)
+		g.weights = append(g.weights, w)
+		g.total += w
+	}
+	return g, nil
+}
+
+// assign returns the group of c.
+func (g *splitGroups) assign(c CustomerSegmentMember) string {
+	u := seededHash(strconv.FormatInt(g.seed, 10)+":split:"+c.Node.Id) * g.total
+	for i, w := range g.weights {
+		if u < w {
+			return g.names[i]
+		}
+		u -= w
+	}
+	return g.names[len(g.names)-1]
+}
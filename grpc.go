@@ -0,0 +1,159 @@
+package main
+
+//go:generate buf generate
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	customersv1 "sultans/gen/customers/v1"
+	"sultans/shopify"
+)
+
+// shopMetadata selects the shop of a request when serving several shops.
+const shopMetadata = "x-shop"
+
+// customerExportServer implements customersv1.CustomerExportServiceServer.
+type customerExportServer struct {
+	customersv1.UnimplementedCustomerExportServiceServer
+	tenants map[string]*tenant
+}
+
+// tenant returns the tenant of the shop in the metadata of ctx, or the single
+// shop when there is no --shops file.
+func (s *customerExportServer) tenant(ctx context.Context) (*tenant, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	name := ""
+	if shops := md.Get(shopMetadata); len(shops) == 1 {
+		name = shops[0]
+	}
+	t := s.tenants[name]
+	if t == nil {
+		return nil, status.Errorf(codes.NotFound, "unknown shop %q in %s metadata", name, shopMetadata)
+	}
+	return t, nil
+}
+
+func (s *customerExportServer) StreamSegmentMembers(req *customersv1.StreamSegmentMembersRequest, stream customersv1.CustomerExportService_StreamSegmentMembersServer) error {
+	t, err := s.tenant(stream.Context())
+	if err != nil {
+		return err
+	}
+	q := t.defaults
+	if req.Query != "" {
+		q.Query = req.Query
+	}
+	if req.First > 0 {
+		q.First = int(req.First)
+	}
+	if req.SortKey != "" {
+		q.SortKey = req.SortKey
+	}
+	if req.Reverse != nil {
+		q.Reverse = *req.Reverse
+	}
+
+	if !t.acquire() {
+		return status.Error(codes.ResourceExhausted, "too many concurrent exports")
+	}
+	defer t.release()
+
+	ctx, cancel := timeoutContext(stream.Context(), t.timeout)
+	defer cancel()
+
+	members := fetchSegmentStream(ctx, t.client, q, 1, nil)
+	for item := range members.Items {
+		if item.EndOfPage {
+			continue
+		}
+		if err := stream.Send(customerToProto(item.Customer)); err != nil {
+			return err
+		}
+	}
+	if err := members.Err(); err != nil {
+		t.logf("export failed: %v", err)
+		recordExportError(t.name, err)
+		return status.Error(exportCode(err), redaction.redact(err.Error()))
+	}
+	if err := members.partialErr(); err != nil {
+		t.logf("export returned partial data: %v", err)
+		stream.SetTrailer(metadata.Pairs("x-partial-data", "true"))
+	}
+	return nil
+}
+
+// exportCode returns the status code of a failed export, matching the HTTP
+// statuses of serve.
+func exportCode(err error) codes.Code {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, shopify.ErrCircuitOpen), errors.Is(err, shopify.ErrThrottled):
+		return codes.Unavailable
+	case errors.Is(err, shopify.ErrInvalidQuery):
+		return codes.InvalidArgument
+	case errors.Is(err, shopify.ErrTimeout):
+		return codes.DeadlineExceeded
+	}
+	return codes.Unknown
+}
+
+func customerToProto(c CustomerSegmentMember) *customersv1.CustomerSegmentMember {
+	m := &customersv1.CustomerSegmentMember{
+		Id:          c.Node.Id,
+		DisplayName: c.Node.DisplayName,
+		AmountSpent: &customersv1.MonetaryAmount{
+			Amount:       formatAmount(c.Node.AmountSpent.Amount, string(c.Node.AmountSpent.CurrencyCode)),
+			CurrencyCode: string(c.Node.AmountSpent.CurrencyCode),
+		},
+	}
+	if e := c.Node.DefaultEmailAddress; e != nil {
+		m.Email = e.EmailAddress
+		m.EmailMarketingState = string(e.MarketingState)
+	}
+	if p := c.Node.DefaultPhoneNumber; p != nil {
+		m.Phone = p.PhoneNumber
+		m.SmsMarketingState = string(p.MarketingState)
+	}
+	return m
+}
+
+// serveGRPC serves the CustomerExportService on lis, requiring the same bearer
+// token as the HTTP endpoints of the shop in the "authorization" metadata.
+func serveGRPC(lis net.Listener, tenants []*tenant, h *serveHealth) error {
+	exports := &customerExportServer{tenants: map[string]*tenant{}}
+	for _, t := range tenants {
+		exports.tenants[t.name] = t
+	}
+	server := grpc.NewServer(grpc.StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		// Health checks need no token, so probes can reach them.
+		if strings.HasPrefix(info.FullMethod, "/grpc.health.v1.Health/") {
+			return handler(srv, ss)
+		}
+		t, err := exports.tenant(ss.Context())
+		if err != nil {
+			return err
+		}
+		md, _ := metadata.FromIncomingContext(ss.Context())
+		auth := md.Get("authorization")
+		if len(auth) != 1 || subtle.ConstantTimeCompare([]byte(auth[0]), []byte("Bearer "+t.token)) != 1 {
+			return status.Error(codes.Unauthenticated, "unauthorized")
+		}
+		return handler(srv, ss)
+	}))
+	customersv1.RegisterCustomerExportServiceServer(server, exports)
+	healthpb.RegisterHealthServer(server, h.grpc)
+
+	log.Printf("Serving gRPC on %s", lis.Addr())
+	return server.Serve(lis)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+)
+
+// identifierHashes replaces the email and phone of CSV exports with their hashes
+// for --hash-identifiers, nil without it.
+var identifierHashes *identifierHasher
+
+// identifierHasher hashes the email address and phone number of every fetched
+// customer before the raw values are stripped, for ad platform uploads. Emails
+// are trimmed and lowercased and phone numbers written in E.164, as the ad
+// platforms require before SHA-256 hashing.
+type identifierHasher struct {
+	// stripGmailDots also removes the periods of gmail.com addresses.
+	stripGmailDots bool
+	// requireConsent only hashes identifiers whose marketing state is SUBSCRIBED.
+	requireConsent bool
+}
+
+type customerHashes struct {
+	email, phone string
+}
+
+func newIdentifierHasher(c *cli.Context) (*identifierHasher, error) {
+	algorithm := c.String("hash-identifiers")
+	if algorithm == "" {
+		if c.Bool("hash-strip-gmail-dots") || c.Bool("hash-skip-consent") {
+			return nil, fmt.Errorf("--hash-strip-gmail-dots and --hash-skip-consent require --hash-identifiers")
+		}
+		return nil, nil
+	}
+	if algorithm != "sha256" {
+		return nil, fmt.Errorf("invalid --hash-identifiers %q, expected sha256", algorithm)
+	}
+	return &identifierHasher{
+		stripGmailDots: c.Bool("hash-strip-gmail-dots"),
+		requireConsent: !c.Bool("hash-skip-consent"),
+	}, nil
+}
+
+// record hashes the identifiers of c into its looked-up fields. It must see c
+// before stripExcluded.
+func (h *identifierHasher) record(c CustomerSegmentMember) {
+	var hashes customerHashes
+	if e := c.Node.DefaultEmailAddress; e != nil && (!h.requireConsent || e.MarketingState == CustomerEmailAddressMarketingStateSubscribed) {
+		norm := normalizeEmail
+		if h.stripGmailDots {
+			norm = normalizeGoogleEmail
+		}
+		hashes.email = hashIdentifier(norm(e.EmailAddress))
+	}
+	if p := c.Node.DefaultPhoneNumber; p != nil && (!h.requireConsent || p.MarketingState == CustomerSmsMarketingStateSubscribed) {
+		hashes.phone = hashIdentifier(normalizeE164(p.PhoneNumber))
+	}
+	c.fields.hashes = hashes
+}
+
+// columns returns the Email SHA256 and Phone SHA256 columns. Customers without
+// the identifier, or without consent, get nullValue.
+func (h *identifierHasher) columns() []column {
+	orNull := func(s string) string {
+		if s == "" {
+			return nullValue
+		}
+		return s
+	}
+	return []column{
+		{Header: "Email SHA256", Value: func(c CustomerSegmentMember) string { return orNull(c.lookedUp().hashes.email) }},
+		{Header: "Phone SHA256", Value: func(c CustomerSegmentMember) string { return orNull(c.lookedUp().hashes.phone) }},
+	}
+}
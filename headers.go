@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// defaultUserAgent identifies requests to Shopify unless --user-agent is set.
+const defaultUserAgent = "shopify-customers"
+
+// parseHeaderFlags parses repeatable "Name: value" header flags.
+func parseHeaderFlags(flags []string) (map[string]string, error) {
+	headers := map[string]string{}
+	for _, h := range flags {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid header %q, expected \"Name: value\"", h)
+		}
+		headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return headers, nil
+}
+
+// shopifyHeaders parses --shopify-header, such as the partner attribution
+// headers of an agency. Headers the client sets itself cannot be overridden.
+func shopifyHeaders(flags []string) (map[string]string, error) {
+	headers, err := parseHeaderFlags(flags)
+	if err != nil {
+		return nil, err
+	}
+	for name := range headers {
+		switch http.CanonicalHeaderKey(name) {
+		case "Content-Type", "User-Agent", "X-Shopify-Access-Token", "X-Shopify-Storefront-Access-Token":
+			return nil, fmt.Errorf("--shopify-header cannot set %s", name)
+		}
+	}
+	return headers, nil
+}
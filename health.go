@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	customersv1 "sultans/gen/customers/v1"
+)
+
+// serveHealth reports the health of serve to HTTP probes, the gRPC health
+// service and systemd. The process is live while it answers, and ready while
+// the Shopify API circuit of a shop is closed, so traffic goes elsewhere during
+// outages.
+type serveHealth struct {
+	tenants []*tenant
+	grpc    *health.Server
+}
+
+func newServeHealth(tenants []*tenant) *serveHealth {
+	return &serveHealth{tenants: tenants, grpc: health.NewServer()}
+}
+
+// ready returns why the process cannot take exports, or nil. With several
+// shops, it is ready while any of them is, since the others still work.
+func (h *serveHealth) ready() error {
+	var open []*tenant
+	for _, t := range h.tenants {
+		if t.client.Breaker.IsOpen() {
+			open = append(open, t)
+		}
+	}
+	switch {
+	case len(open) < len(h.tenants):
+		return nil
+	case len(open) == 1 && open[0].name == "":
+		return fmt.Errorf("Shopify API circuit open")
+	}
+	return fmt.Errorf("Shopify API circuit open for shops %s", tenantNames(open))
+}
+
+// register adds GET /healthz and /readyz to mux. They need no token, so probes
+// can reach them.
+func (h *serveHealth) register(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if err := h.ready(); err != nil {
+			http.Error(w, "not ready: "+err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ready")
+		for _, t := range h.tenants {
+			if t.name != "" && t.client.Breaker.IsOpen() {
+				fmt.Fprintf(w, "shop %s: Shopify API circuit open\n", t.name)
+			}
+		}
+	})
+}
+
+// supervise tells systemd that serve is ready, then keeps the gRPC health
+// status current and pings the systemd watchdog, if enabled, until the process
+// exits.
+func (h *serveHealth) supervise() {
+	if err := sdNotify("READY=1"); err != nil {
+		log.Printf("warning: failed to notify systemd: %v", err)
+	}
+	interval := time.Second
+	watchdog := watchdogInterval()
+	if watchdog > 0 && watchdog/2 < interval {
+		interval = watchdog / 2
+	}
+	for {
+		status := healthpb.HealthCheckResponse_SERVING
+		if h.ready() != nil {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		h.grpc.SetServingStatus("", status)
+		h.grpc.SetServingStatus(customersv1.CustomerExportService_ServiceDesc.ServiceName, status)
+		// The watchdog checks that the process is responsive, not that
+		// Shopify is, so it is pinged during outages too.
+		if watchdog > 0 {
+			sdNotify("WATCHDOG=1")
+		}
+		time.Sleep(interval)
+	}
+}
+
+// serveHealthHTTP serves only the health endpoints, for --health.
+func serveHealthHTTP(lis net.Listener, h *serveHealth) error {
+	mux := http.NewServeMux()
+	h.register(mux)
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	log.Printf("Serving health checks on %s", lis.Addr())
+	return server.Serve(lis)
+}
+
+// sdNotify sends state to systemd's notification socket, if the process was
+// started by systemd with Type=notify.
+func sdNotify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	// A leading @ names an abstract socket.
+	if strings.HasPrefix(socket, "@") {
+		socket = "\x00" + socket[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// watchdogInterval returns the WatchdogSec of the systemd unit, or 0 when the
+// watchdog is not enabled for this process.
+func watchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
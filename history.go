@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// historyRun is one line of the run history file.
+type historyRun struct {
+	ID      string `json:"id"`
+	Command string `json:"command"`
+	// Shop is the --shops shop of runs started from the serve dashboard.
+	Shop   string `json:"shop,omitempty"`
+	Query  string `json:"query"`
+	Output string `json:"output"`
+	// Segment is the query the run exported, with its first, sortKey and
+	// other settings, so the serve dashboard can run it again.
+	Segment    *SegmentQuery `json:"segment,omitempty"`
+	StartedAt  time.Time     `json:"startedAt"`
+	DurationMs int64         `json:"durationMs"`
+	Status     string        `json:"status"` // "success", "partial" or "failed"
+	Rows       int           `json:"rows"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// historyMu serializes appends from concurrent exports in this process.
+var historyMu sync.Mutex
+
+func startRun(command string, q SegmentQuery, output string) historyRun {
+	now := time.Now().UTC()
+	return historyRun{
+		ID:        now.Format("20060102-150405.000"),
+		Command:   command,
+		Query:     q.Query,
+		Output:    output,
+		Segment:   &q,
+		StartedAt: now,
+	}
+}
+
+// recordRun appends the finished run to the history and returns it. Failing to
+// record history is logged but does not fail the export.
+func recordRun(c *cli.Context, run historyRun, rows int, err error) historyRun {
+	run.DurationMs = time.Since(run.StartedAt).Milliseconds()
+	run.Rows = rows
+	run.Status = "success"
+	if err != nil {
+		run.Status = "failed"
+		if errors.Is(err, errPartialData) {
+			run.Status = "partial"
+		}
+		run.Error = redaction.redact(err.Error())
+	}
+	if c.Bool("no-history") {
+		return run
+	}
+
+	if err := appendHistory(c.String("history-file"), run); err != nil {
+		log.Printf("warning: failed to record run history: %v", err)
+	}
+	return run
+}
+
+func historyPath(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate cache directory: %w", err)
+	}
+	return filepath.Join(dir, "shopify-customers", "history.jsonl"), nil
+}
+
+func appendHistory(path string, run historyRun) error {
+	path, err := historyPath(path)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(b, '\n'))
+	return err
+}
+
+// readHistory returns all recorded runs, oldest first.
+func readHistory(path string) ([]historyRun, error) {
+	path, err := historyPath(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var runs []historyRun
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var run historyRun
+		if err := json.Unmarshal(scanner.Bytes(), &run); err != nil {
+			continue
+		}
+		runs = append(runs, run)
+	}
+	return runs, scanner.Err()
+}
+
+func historyCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "history",
+		Usage: "Show previous export runs",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "List runs, most recent first",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "query", Usage: "Only runs whose query contains this text"},
+					&cli.StringFlag{Name: "output", Usage: "Only runs whose output contains this text"},
+					&cli.StringFlag{Name: "status", Usage: "Only runs with this status (success, failed)"},
+					&cli.IntFlag{Name: "limit", Value: 20, Usage: "Maximum runs to list (0 for all)"},
+				},
+				Action: func(c *cli.Context) error {
+					runs, err := readHistory(c.String("history-file"))
+					if err != nil {
+						return err
+					}
+
+					w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+					fmt.Fprintln(w, "ID\tSTARTED\tSTATUS\tROWS\tDURATION\tOUTPUT\tQUERY")
+					listed := 0
+					for i := len(runs) - 1; i >= 0; i-- {
+						run := runs[i]
+						if !strings.Contains(run.Query, c.String("query")) ||
+							!strings.Contains(run.Output, c.String("output")) ||
+							(c.String("status") != "" && run.Status != c.String("status")) {
+							continue
+						}
+						if limit := c.Int("limit"); limit > 0 && listed == limit {
+							break
+						}
+						output := run.Output
+						if output == "" {
+							output = "(stdout)"
+						}
+						fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\n", run.ID, run.StartedAt.Local().Format(time.DateTime), run.Status, run.Rows, time.Duration(run.DurationMs)*time.Millisecond, output, run.Query)
+						listed++
+					}
+					return w.Flush()
+				},
+			},
+			{
+				Name:      "show",
+				Usage:     "Show one run in full",
+				ArgsUsage: "<id>",
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 1 {
+						return fmt.Errorf("usage: history show <id>")
+					}
+					runs, err := readHistory(c.String("history-file"))
+					if err != nil {
+						return err
+					}
+					for i := len(runs) - 1; i >= 0; i-- {
+						if runs[i].ID == c.Args().First() {
+							enc := json.NewEncoder(os.Stdout)
+							enc.SetIndent("", "  ")
+							return enc.Encode(runs[i])
+						}
+					}
+					return fmt.Errorf("no run with ID %q", c.Args().First())
+				},
+			},
+		},
+	}
+}
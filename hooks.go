@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// runHook runs the shell command of the --pre-hook or --post-hook flag, if set,
+// with the run's metadata in HOOK_* environment variables. Its output goes to
+// stderr, so it is not mixed into CSV written to stdout.
+func runHook(c *cli.Context, flag string, run historyRun) error {
+	command := c.String(flag)
+	if command == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.Duration("hook-timeout"))
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(), hookEnv(flag, run)...)
+	cmd.Stdout = redactingWriter{os.Stderr}
+	cmd.Stderr = redactingWriter{os.Stderr}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("--%s failed: %w", flag, err)
+	}
+	return nil
+}
+
+func hookEnv(flag string, run historyRun) []string {
+	env := []string{
+		"HOOK_PHASE=" + strings.TrimSuffix(flag, "-hook"),
+		"HOOK_RUN_ID=" + run.ID,
+		"HOOK_COMMAND=" + run.Command,
+		"HOOK_QUERY=" + run.Query,
+		"HOOK_OUTPUT=" + run.Output,
+	}
+	if run.Status != "" {
+		env = append(env,
+			"HOOK_STATUS="+run.Status,
+			"HOOK_ROWS="+strconv.Itoa(run.Rows),
+			"HOOK_DURATION_MS="+strconv.FormatInt(run.DurationMs, 10),
+			"HOOK_ERROR="+run.Error,
+		)
+	}
+	return env
+}
+
+// runPostHook runs --post-hook after a run that ended with err and returns err.
+// A failing hook fails a successful run; after a failed run it is only logged,
+// so the export error is what gets reported.
+func runPostHook(c *cli.Context, run historyRun, err error) error {
+	hookErr := runHook(c, "post-hook", run)
+	if hookErr == nil {
+		return err
+	}
+	if err != nil && !errors.Is(err, errPartialData) {
+		log.Printf("warning: %v", hookErr)
+		return err
+	}
+	return hookErr
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// httpClient is shared by all Shopify and destination requests so connections are
+// reused across pages and batches instead of re-dialing TLS for every call.
+var httpClient = newHTTPClient()
+
+func newHTTPClient() *http.Client {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		// Compression stays enabled: the transport sends "Accept-Encoding: gzip" and
+		// transparently decompresses responses, which shrinks large GraphQL pages.
+		DisableCompression: false,
+	}
+	// No Client.Timeout: requests are bounded by their contexts, so long
+	// destination uploads are not cut off after a fixed time.
+	return &http.Client{Transport: transport}
+}
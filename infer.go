@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+)
+
+// cell is a flattened value with the type it was decoded or recognized as.
+type cell struct {
+	text string
+	kind string
+}
+
+// Cell and column types of generic exports. Null cells do not affect the type
+// of their column.
+const (
+	kindNull     = "null"
+	kindNumber   = "number"
+	kindBool     = "bool"
+	kindDate     = "date"
+	kindDateTime = "datetime"
+	kindString   = "string"
+)
+
+// numericString matches decimals without leading zeros, as the Admin API
+// serializes Decimal, Money and UnsignedInt64 values. Zip codes such as "01234"
+// stay strings.
+var numericString = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?$`)
+
+// newCell classifies a decoded JSON scalar. Strings holding ISO 8601 dates or
+// timestamps, as GraphQL Date and DateTime values are serialized, are dates, and
+// strings holding decimals are numbers.
+func newCell(v interface{}) cell {
+	switch v := v.(type) {
+	case nil:
+		return cell{kind: kindNull}
+	case json.Number:
+		return cell{text: v.String(), kind: kindNumber}
+	case bool:
+		return cell{text: fmt.Sprint(v), kind: kindBool}
+	case string:
+		if numericString.MatchString(v) {
+			return cell{text: v, kind: kindNumber}
+		}
+		if _, err := time.Parse(time.DateOnly, v); err == nil {
+			return cell{text: v, kind: kindDate}
+		}
+		if _, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return cell{text: v, kind: kindDateTime}
+		}
+		return cell{text: v, kind: kindString}
+	}
+	return cell{text: fmt.Sprint(v), kind: kindString}
+}
+
+// inferColumnTypes returns the type of every column: the common type of its
+// non-null cells, datetime for a mix of dates and timestamps, string for any
+// other mix, and string for columns that are always null.
+func inferColumnTypes(columns []string, rows []flatRow) map[string]string {
+	types := make(map[string]string, len(columns))
+	for _, col := range columns {
+		t := ""
+		for _, row := range rows {
+			k := row[col].kind
+			switch {
+			case k == "" || k == kindNull:
+			case t == "" || t == k:
+				t = k
+			case (t == kindDate && k == kindDateTime) || (t == kindDateTime && k == kindDate):
+				t = kindDateTime
+			default:
+				t = kindString
+			}
+		}
+		if t == "" {
+			t = kindString
+		}
+		types[col] = t
+	}
+	return types
+}
+
+// formatCell renders c for a column of type t, so every value of a column has
+// the same format: timestamps in datetime columns are written in the --timezone
+// with --date-format, with dates as midnight.
+func formatCell(c cell, t string) string {
+	if c.kind == "" || c.kind == kindNull {
+		return nullValue
+	}
+	if t == kindDateTime {
+		ts, err := time.Parse(time.RFC3339Nano, c.text)
+		if c.kind == kindDate {
+			ts, err = time.ParseInLocation(time.DateOnly, c.text, exportLocation())
+		}
+		if err == nil {
+			return formatTime(ts)
+		}
+	}
+	return c.text
+}
+
+// writeSchemaFile writes the inferred column types as JSON for loaders that need
+// a schema: {"columns": [{"name": ..., "type": ..., "nullable": ...}]}.
+func writeSchemaFile(path string, columns []string, types map[string]string, rows []flatRow) error {
+	type schemaColumn struct {
+		Name     string `json:"name"`
+		Type     string `json:"type"`
+		Nullable bool   `json:"nullable"`
+	}
+	schema := struct {
+		Columns []schemaColumn `json:"columns"`
+	}{Columns: []schemaColumn{}}
+	for _, col := range columns {
+		nullable := false
+		for _, row := range rows {
+			if k := row[col].kind; k == "" || k == kindNull {
+				nullable = true
+				break
+			}
+		}
+		schema.Columns = append(schema.Columns, schemaColumn{Name: col, Type: types[col], Nullable: nullable})
+	}
+
+	b, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(b, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write schema file: %w", err)
+	}
+	return nil
+}
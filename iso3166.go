@@ -0,0 +1,428 @@
+package main
+
+// isoCountries are the ISO 3166-1 countries, from the iso-codes project, with
+// their --region-columns region. Countries without a region, such as Antarctica,
+// have none.
+var isoCountries = []isoCountry{
+	{"AD", "AND", "EMEA", []string{"Andorra", "Principality of Andorra"}},
+	{"AE", "ARE", "EMEA", []string{"United Arab Emirates"}},
+	{"AF", "AFG", "APAC", []string{"Afghanistan", "Islamic Republic of Afghanistan"}},
+	{"AG", "ATG", "LATAM", []string{"Antigua and Barbuda"}},
+	{"AI", "AIA", "LATAM", []string{"Anguilla"}},
+	{"AL", "ALB", "EMEA", []string{"Albania", "Republic of Albania"}},
+	{"AM", "ARM", "EMEA", []string{"Armenia", "Republic of Armenia"}},
+	{"AO", "AGO", "EMEA", []string{"Angola", "Republic of Angola"}},
+	{"AQ", "ATA", "", []string{"Antarctica"}},
+	{"AR", "ARG", "LATAM", []string{"Argentina", "Argentine Republic"}},
+	{"AS", "ASM", "APAC", []string{"American Samoa"}},
+	{"AT", "AUT", "EU", []string{"Austria", "Republic of Austria"}},
+	{"AU", "AUS", "APAC", []string{"Australia"}},
+	{"AW", "ABW", "LATAM", []string{"Aruba"}},
+	{"AX", "ALA", "EMEA", []string{"Åland Islands"}},
+	{"AZ", "AZE", "EMEA", []string{"Azerbaijan", "Republic of Azerbaijan"}},
+	{"BA", "BIH", "EMEA", []string{"Bosnia and Herzegovina", "Republic of Bosnia and Herzegovina"}},
+	{"BB", "BRB", "LATAM", []string{"Barbados"}},
+	{"BD", "BGD", "APAC", []string{"Bangladesh", "People's Republic of Bangladesh"}},
+	{"BE", "BEL", "EU", []string{"Belgium", "Kingdom of Belgium"}},
+	{"BF", "BFA", "EMEA", []string{"Burkina Faso"}},
+	{"BG", "BGR", "EU", []string{"Bulgaria", "Republic of Bulgaria"}},
+	{"BH", "BHR", "EMEA", []string{"Bahrain", "Kingdom of Bahrain"}},
+	{"BI", "BDI", "EMEA", []string{"Burundi", "Republic of Burundi"}},
+	{"BJ", "BEN", "EMEA", []string{"Benin", "Republic of Benin"}},
+	{"BL", "BLM", "LATAM", []string{"Saint Barthélemy"}},
+	{"BM", "BMU", "NA", []string{"Bermuda"}},
+	{"BN", "BRN", "APAC", []string{"Brunei Darussalam"}},
+	{"BO", "BOL", "LATAM", []string{"Bolivia, Plurinational State of", "Bolivia", "Plurinational State of Bolivia"}},
+	{"BQ", "BES", "LATAM", []string{"Bonaire, Sint Eustatius and Saba"}},
+	{"BR", "BRA", "LATAM", []string{"Brazil", "Federative Republic of Brazil"}},
+	{"BS", "BHS", "LATAM", []string{"Bahamas", "Commonwealth of the Bahamas"}},
+	{"BT", "BTN", "APAC", []string{"Bhutan", "Kingdom of Bhutan"}},
+	{"BV", "BVT", "", []string{"Bouvet Island"}},
+	{"BW", "BWA", "EMEA", []string{"Botswana", "Republic of Botswana"}},
+	{"BY", "BLR", "EMEA", []string{"Belarus", "Republic of Belarus"}},
+	{"BZ", "BLZ", "LATAM", []string{"Belize"}},
+	{"CA", "CAN", "NA", []string{"Canada"}},
+	{"CC", "CCK", "APAC", []string{"Cocos (Keeling) Islands"}},
+	{"CD", "COD", "EMEA", []string{"Congo, The Democratic Republic of the"}},
+	{"CF", "CAF", "EMEA", []string{"Central African Republic"}},
+	{"CG", "COG", "EMEA", []string{"Congo", "Republic of the Congo"}},
+	{"CH", "CHE", "EMEA", []string{"Switzerland", "Swiss Confederation"}},
+	{"CI", "CIV", "EMEA", []string{"Côte d'Ivoire", "Republic of Côte d'Ivoire"}},
+	{"CK", "COK", "APAC", []string{"Cook Islands"}},
+	{"CL", "CHL", "LATAM", []string{"Chile", "Republic of Chile"}},
+	{"CM", "CMR", "EMEA", []string{"Cameroon", "Republic of Cameroon"}},
+	{"CN", "CHN", "APAC", []string{"China", "People's Republic of China"}},
+	{"CO", "COL", "LATAM", []string{"Colombia", "Republic of Colombia"}},
+	{"CR", "CRI", "LATAM", []string{"Costa Rica", "Republic of Costa Rica"}},
+	{"CU", "CUB", "LATAM", []string{"Cuba", "Republic of Cuba"}},
+	{"CV", "CPV", "EMEA", []string{"Cabo Verde", "Republic of Cabo Verde"}},
+	{"CW", "CUW", "LATAM", []string{"Curaçao"}},
+	{"CX", "CXR", "APAC", []string{"Christmas Island"}},
+	{"CY", "CYP", "EU", []string{"Cyprus", "Republic of Cyprus"}},
+	{"CZ", "CZE", "EU", []string{"Czechia", "Czech Republic"}},
+	{"DE", "DEU", "EU", []string{"Germany", "Federal Republic of Germany"}},
+	{"DJ", "DJI", "EMEA", []string{"Djibouti", "Republic of Djibouti"}},
+	{"DK", "DNK", "EU", []string{"Denmark", "Kingdom of Denmark"}},
+	{"DM", "DMA", "LATAM", []string{"Dominica", "Commonwealth of Dominica"}},
+	{"DO", "DOM", "LATAM", []string{"Dominican Republic"}},
+	{"DZ", "DZA", "EMEA", []string{"Algeria", "People's Democratic Republic of Algeria"}},
+	{"EC", "ECU", "LATAM", []string{"Ecuador", "Republic of Ecuador"}},
+	{"EE", "EST", "EU", []string{"Estonia", "Republic of Estonia"}},
+	{"EG", "EGY", "EMEA", []string{"Egypt", "Arab Republic of Egypt"}},
+	{"EH", "ESH", "EMEA", []string{"Western Sahara"}},
+	{"ER", "ERI", "EMEA", []string{"Eritrea", "the State of Eritrea"}},
+	{"ES", "ESP", "EU", []string{"Spain", "Kingdom of Spain"}},
+	{"ET", "ETH", "EMEA", []string{"Ethiopia", "Federal Democratic Republic of Ethiopia"}},
+	{"FI", "FIN", "EU", []string{"Finland", "Republic of Finland"}},
+	{"FJ", "FJI", "APAC", []string{"Fiji", "Republic of Fiji"}},
+	{"FK", "FLK", "LATAM", []string{"Falkland Islands (Malvinas)"}},
+	{"FM", "FSM", "APAC", []string{"Micronesia, Federated States of", "Federated States of Micronesia"}},
+	{"FO", "FRO", "EMEA", []string{"Faroe Islands"}},
+	{"FR", "FRA", "EU", []string{"France", "French Republic"}},
+	{"GA", "GAB", "EMEA", []string{"Gabon", "Gabonese Republic"}},
+	{"GB", "GBR", "EMEA", []string{"United Kingdom", "United Kingdom of Great Britain and Northern Ireland"}},
+	{"GD", "GRD", "LATAM", []string{"Grenada"}},
+	{"GE", "GEO", "EMEA", []string{"Georgia"}},
+	{"GF", "GUF", "LATAM", []string{"French Guiana"}},
+	{"GG", "GGY", "EMEA", []string{"Guernsey"}},
+	{"GH", "GHA", "EMEA", []string{"Ghana", "Republic of Ghana"}},
+	{"GI", "GIB", "EMEA", []string{"Gibraltar"}},
+	{"GL", "GRL", "NA", []string{"Greenland"}},
+	{"GM", "GMB", "EMEA", []string{"Gambia", "Republic of the Gambia"}},
+	{"GN", "GIN", "EMEA", []string{"Guinea", "Republic of Guinea"}},
+	{"GP", "GLP", "LATAM", []string{"Guadeloupe"}},
+	{"GQ", "GNQ", "EMEA", []string{"Equatorial Guinea", "Republic of Equatorial Guinea"}},
+	{"GR", "GRC", "EU", []string{"Greece", "Hellenic Republic"}},
+	{"GS", "SGS", "LATAM", []string{"South Georgia and the South Sandwich Islands"}},
+	{"GT", "GTM", "LATAM", []string{"Guatemala", "Republic of Guatemala"}},
+	{"GU", "GUM", "APAC", []string{"Guam"}},
+	{"GW", "GNB", "EMEA", []string{"Guinea-Bissau", "Republic of Guinea-Bissau"}},
+	{"GY", "GUY", "LATAM", []string{"Guyana", "Republic of Guyana"}},
+	{"HK", "HKG", "APAC", []string{"Hong Kong", "Hong Kong Special Administrative Region of China"}},
+	{"HM", "HMD", "APAC", []string{"Heard Island and McDonald Islands"}},
+	{"HN", "HND", "LATAM", []string{"Honduras", "Republic of Honduras"}},
+	{"HR", "HRV", "EU", []string{"Croatia", "Republic of Croatia"}},
+	{"HT", "HTI", "LATAM", []string{"Haiti", "Republic of Haiti"}},
+	{"HU", "HUN", "EU", []string{"Hungary"}},
+	{"ID", "IDN", "APAC", []string{"Indonesia", "Republic of Indonesia"}},
+	{"IE", "IRL", "EU", []string{"Ireland"}},
+	{"IL", "ISR", "EMEA", []string{"Israel", "State of Israel"}},
+	{"IM", "IMN", "EMEA", []string{"Isle of Man"}},
+	{"IN", "IND", "APAC", []string{"India", "Republic of India"}},
+	{"IO", "IOT", "APAC", []string{"British Indian Ocean Territory"}},
+	{"IQ", "IRQ", "EMEA", []string{"Iraq", "Republic of Iraq"}},
+	{"IR", "IRN", "EMEA", []string{"Iran, Islamic Republic of", "Iran", "Islamic Republic of Iran"}},
+	{"IS", "ISL", "EMEA", []string{"Iceland", "Republic of Iceland"}},
+	{"IT", "ITA", "EU", []string{"Italy", "Italian Republic"}},
+	{"JE", "JEY", "EMEA", []string{"Jersey"}},
+	{"JM", "JAM", "LATAM", []string{"Jamaica"}},
+	{"JO", "JOR", "EMEA", []string{"Jordan", "Hashemite Kingdom of Jordan"}},
+	{"JP", "JPN", "APAC", []string{"Japan"}},
+	{"KE", "KEN", "EMEA", []string{"Kenya", "Republic of Kenya"}},
+	{"KG", "KGZ", "APAC", []string{"Kyrgyzstan", "Kyrgyz Republic"}},
+	{"KH", "KHM", "APAC", []string{"Cambodia", "Kingdom of Cambodia"}},
+	{"KI", "KIR", "APAC", []string{"Kiribati", "Republic of Kiribati"}},
+	{"KM", "COM", "EMEA", []string{"Comoros", "Union of the Comoros"}},
+	{"KN", "KNA", "LATAM", []string{"Saint Kitts and Nevis"}},
+	{"KP", "PRK", "APAC", []string{"Korea, Democratic People's Republic of", "North Korea", "Democratic People's Republic of Korea"}},
+	{"KR", "KOR", "APAC", []string{"Korea, Republic of", "South Korea"}},
+	{"KW", "KWT", "EMEA", []string{"Kuwait", "State of Kuwait"}},
+	{"KY", "CYM", "LATAM", []string{"Cayman Islands"}},
+	{"KZ", "KAZ", "APAC", []string{"Kazakhstan", "Republic of Kazakhstan"}},
+	{"LA", "LAO", "APAC", []string{"Lao People's Democratic Republic", "Laos"}},
+	{"LB", "LBN", "EMEA", []string{"Lebanon", "Lebanese Republic"}},
+	{"LC", "LCA", "LATAM", []string{"Saint Lucia"}},
+	{"LI", "LIE", "EMEA", []string{"Liechtenstein", "Principality of Liechtenstein"}},
+	{"LK", "LKA", "APAC", []string{"Sri Lanka", "Democratic Socialist Republic of Sri Lanka"}},
+	{"LR", "LBR", "EMEA", []string{"Liberia", "Republic of Liberia"}},
+	{"LS", "LSO", "EMEA", []string{"Lesotho", "Kingdom of Lesotho"}},
+	{"LT", "LTU", "EU", []string{"Lithuania", "Republic of Lithuania"}},
+	{"LU", "LUX", "EU", []string{"Luxembourg", "Grand Duchy of Luxembourg"}},
+	{"LV", "LVA", "EU", []string{"Latvia", "Republic of Latvia"}},
+	{"LY", "LBY", "EMEA", []string{"Libya"}},
+	{"MA", "MAR", "EMEA", []string{"Morocco", "Kingdom of Morocco"}},
+	{"MC", "MCO", "EMEA", []string{"Monaco", "Principality of Monaco"}},
+	{"MD", "MDA", "EMEA", []string{"Moldova, Republic of", "Moldova", "Republic of Moldova"}},
+	{"ME", "MNE", "EMEA", []string{"Montenegro"}},
+	{"MF", "MAF", "LATAM", []string{"Saint Martin (French part)"}},
+	{"MG", "MDG", "EMEA", []string{"Madagascar", "Republic of Madagascar"}},
+	{"MH", "MHL", "APAC", []string{"Marshall Islands", "Republic of the Marshall Islands"}},
+	{"MK", "MKD", "EMEA", []string{"North Macedonia", "Republic of North Macedonia"}},
+	{"ML", "MLI", "EMEA", []string{"Mali", "Republic of Mali"}},
+	{"MM", "MMR", "APAC", []string{"Myanmar", "Republic of Myanmar"}},
+	{"MN", "MNG", "APAC", []string{"Mongolia"}},
+	{"MO", "MAC", "APAC", []string{"Macao", "Macao Special Administrative Region of China"}},
+	{"MP", "MNP", "APAC", []string{"Northern Mariana Islands", "Commonwealth of the Northern Mariana Islands"}},
+	{"MQ", "MTQ", "LATAM", []string{"Martinique"}},
+	{"MR", "MRT", "EMEA", []string{"Mauritania", "Islamic Republic of Mauritania"}},
+	{"MS", "MSR", "LATAM", []string{"Montserrat"}},
+	{"MT", "MLT", "EU", []string{"Malta", "Republic of Malta"}},
+	{"MU", "MUS", "EMEA", []string{"Mauritius", "Republic of Mauritius"}},
+	{"MV", "MDV", "APAC", []string{"Maldives", "Republic of Maldives"}},
+	{"MW", "MWI", "EMEA", []string{"Malawi", "Republic of Malawi"}},
+	{"MX", "MEX", "LATAM", []string{"Mexico", "United Mexican States"}},
+	{"MY", "MYS", "APAC", []string{"Malaysia"}},
+	{"MZ", "MOZ", "EMEA", []string{"Mozambique", "Republic of Mozambique"}},
+	{"NA", "NAM", "EMEA", []string{"Namibia", "Republic of Namibia"}},
+	{"NC", "NCL", "APAC", []string{"New Caledonia"}},
+	{"NE", "NER", "EMEA", []string{"Niger", "Republic of the Niger"}},
+	{"NF", "NFK", "APAC", []string{"Norfolk Island"}},
+	{"NG", "NGA", "EMEA", []string{"Nigeria", "Federal Republic of Nigeria"}},
+	{"NI", "NIC", "LATAM", []string{"Nicaragua", "Republic of Nicaragua"}},
+	{"NL", "NLD", "EU", []string{"Netherlands", "Kingdom of the Netherlands"}},
+	{"NO", "NOR", "EMEA", []string{"Norway", "Kingdom of Norway"}},
+	{"NP", "NPL", "APAC", []string{"Nepal", "Federal Democratic Republic of Nepal"}},
+	{"NR", "NRU", "APAC", []string{"Nauru", "Republic of Nauru"}},
+	{"NU", "NIU", "APAC", []string{"Niue"}},
+	{"NZ", "NZL", "APAC", []string{"New Zealand"}},
+	{"OM", "OMN", "EMEA", []string{"Oman", "Sultanate of Oman"}},
+	{"PA", "PAN", "LATAM", []string{"Panama", "Republic of Panama"}},
+	{"PE", "PER", "LATAM", []string{"Peru", "Republic of Peru"}},
+	{"PF", "PYF", "APAC", []string{"French Polynesia"}},
+	{"PG", "PNG", "APAC", []string{"Papua New Guinea", "Independent State of Papua New Guinea"}},
+	{"PH", "PHL", "APAC", []string{"Philippines", "Republic of the Philippines"}},
+	{"PK", "PAK", "APAC", []string{"Pakistan", "Islamic Republic of Pakistan"}},
+	{"PL", "POL", "EU", []string{"Poland", "Republic of Poland"}},
+	{"PM", "SPM", "NA", []string{"Saint Pierre and Miquelon"}},
+	{"PN", "PCN", "APAC", []string{"Pitcairn"}},
+	{"PR", "PRI", "NA", []string{"Puerto Rico"}},
+	{"PS", "PSE", "EMEA", []string{"Palestine, State of", "the State of Palestine"}},
+	{"PT", "PRT", "EU", []string{"Portugal", "Portuguese Republic"}},
+	{"PW", "PLW", "APAC", []string{"Palau", "Republic of Palau"}},
+	{"PY", "PRY", "LATAM", []string{"Paraguay", "Republic of Paraguay"}},
+	{"QA", "QAT", "EMEA", []string{"Qatar", "State of Qatar"}},
+	{"RE", "REU", "EMEA", []string{"Réunion"}},
+	{"RO", "ROU", "EU", []string{"Romania"}},
+	{"RS", "SRB", "EMEA", []string{"Serbia", "Republic of Serbia"}},
+	{"RU", "RUS", "EMEA", []string{"Russian Federation"}},
+	{"RW", "RWA", "EMEA", []string{"Rwanda", "Rwandese Republic"}},
+	{"SA", "SAU", "EMEA", []string{"Saudi Arabia", "Kingdom of Saudi Arabia"}},
+	{"SB", "SLB", "APAC", []string{"Solomon Islands"}},
+	{"SC", "SYC", "EMEA", []string{"Seychelles", "Republic of Seychelles"}},
+	{"SD", "SDN", "EMEA", []string{"Sudan", "Republic of the Sudan"}},
+	{"SE", "SWE", "EU", []string{"Sweden", "Kingdom of Sweden"}},
+	{"SG", "SGP", "APAC", []string{"Singapore", "Republic of Singapore"}},
+	{"SH", "SHN", "EMEA", []string{"Saint Helena, Ascension and Tristan da Cunha"}},
+	{"SI", "SVN", "EU", []string{"Slovenia", "Republic of Slovenia"}},
+	{"SJ", "SJM", "EMEA", []string{"Svalbard and Jan Mayen"}},
+	{"SK", "SVK", "EU", []string{"Slovakia", "Slovak Republic"}},
+	{"SL", "SLE", "EMEA", []string{"Sierra Leone", "Republic of Sierra Leone"}},
+	{"SM", "SMR", "EMEA", []string{"San Marino", "Republic of San Marino"}},
+	{"SN", "SEN", "EMEA", []string{"Senegal", "Republic of Senegal"}},
+	{"SO", "SOM", "EMEA", []string{"Somalia", "Federal Republic of Somalia"}},
+	{"SR", "SUR", "LATAM", []string{"Suriname", "Republic of Suriname"}},
+	{"SS", "SSD", "EMEA", []string{"South Sudan", "Republic of South Sudan"}},
+	{"ST", "STP", "EMEA", []string{"Sao Tome and Principe", "Democratic Republic of Sao Tome and Principe"}},
+	{"SV", "SLV", "LATAM", []string{"El Salvador", "Republic of El Salvador"}},
+	{"SX", "SXM", "LATAM", []string{"Sint Maarten (Dutch part)"}},
+	{"SY", "SYR", "EMEA", []string{"Syrian Arab Republic", "Syria"}},
+	{"SZ", "SWZ", "EMEA", []string{"Eswatini", "Kingdom of Eswatini"}},
+	{"TC", "TCA", "LATAM", []string{"Turks and Caicos Islands"}},
+	{"TD", "TCD", "EMEA", []string{"Chad", "Republic of Chad"}},
+	{"TF", "ATF", "", []string{"French Southern Territories"}},
+	{"TG", "TGO", "EMEA", []string{"Togo", "Togolese Republic"}},
+	{"TH", "THA", "APAC", []string{"Thailand", "Kingdom of Thailand"}},
+	{"TJ", "TJK", "APAC", []string{"Tajikistan", "Republic of Tajikistan"}},
+	{"TK", "TKL", "APAC", []string{"Tokelau"}},
+	{"TL", "TLS", "APAC", []string{"Timor-Leste", "Democratic Republic of Timor-Leste"}},
+	{"TM", "TKM", "APAC", []string{"Turkmenistan"}},
+	{"TN", "TUN", "EMEA", []string{"Tunisia", "Republic of Tunisia"}},
+	{"TO", "TON", "APAC", []string{"Tonga", "Kingdom of Tonga"}},
+	{"TR", "TUR", "EMEA", []string{"Türkiye", "Republic of Türkiye"}},
+	{"TT", "TTO", "LATAM", []string{"Trinidad and Tobago", "Republic of Trinidad and Tobago"}},
+	{"TV", "TUV", "APAC", []string{"Tuvalu"}},
+	{"TW", "TWN", "APAC", []string{"Taiwan, Province of China", "Taiwan"}},
+	{"TZ", "TZA", "EMEA", []string{"Tanzania, United Republic of", "Tanzania", "United Republic of Tanzania"}},
+	{"UA", "UKR", "EMEA", []string{"Ukraine"}},
+	{"UG", "UGA", "EMEA", []string{"Uganda", "Republic of Uganda"}},
+	{"UM", "UMI", "NA", []string{"United States Minor Outlying Islands"}},
+	{"US", "USA", "NA", []string{"United States", "United States of America"}},
+	{"UY", "URY", "LATAM", []string{"Uruguay", "Eastern Republic of Uruguay"}},
+	{"UZ", "UZB", "APAC", []string{"Uzbekistan", "Republic of Uzbekistan"}},
+	{"VA", "VAT", "EMEA", []string{"Holy See (Vatican City State)"}},
+	{"VC", "VCT", "LATAM", []string{"Saint Vincent and the Grenadines"}},
+	{"VE", "VEN", "LATAM", []string{"Venezuela, Bolivarian Republic of", "Venezuela", "Bolivarian Republic of Venezuela"}},
+	{"VG", "VGB", "LATAM", []string{"Virgin Islands, British", "British Virgin Islands"}},
+	{"VI", "VIR", "NA", []string{"Virgin Islands, U.S.", "Virgin Islands of the United States"}},
+	{"VN", "VNM", "APAC", []string{"Viet Nam", "Vietnam", "Socialist Republic of Viet Nam"}},
+	{"VU", "VUT", "APAC", []string{"Vanuatu", "Republic of Vanuatu"}},
+	{"WF", "WLF", "APAC", []string{"Wallis and Futuna"}},
+	{"WS", "WSM", "APAC", []string{"Samoa", "Independent State of Samoa"}},
+	{"YE", "YEM", "EMEA", []string{"Yemen", "Republic of Yemen"}},
+	{"YT", "MYT", "EMEA", []string{"Mayotte"}},
+	{"ZA", "ZAF", "EMEA", []string{"South Africa", "Republic of South Africa"}},
+	{"ZM", "ZMB", "EMEA", []string{"Zambia", "Republic of Zambia"}},
+	{"ZW", "ZWE", "EMEA", []string{"Zimbabwe", "Republic of Zimbabwe"}},
+}
+
+// isoSubdivisions are the ISO 3166-2 subdivisions of the countries whose addresses
+// most often have a province name but no code.
+var isoSubdivisions = []isoSubdivision{
+	{"AU-ACT", "Australian Capital Territory"},
+	{"AU-NSW", "New South Wales"},
+	{"AU-NT", "Northern Territory"},
+	{"AU-QLD", "Queensland"},
+	{"AU-SA", "South Australia"},
+	{"AU-TAS", "Tasmania"},
+	{"AU-VIC", "Victoria"},
+	{"AU-WA", "Western Australia"},
+	{"BR-AC", "Acre"},
+	{"BR-AL", "Alagoas"},
+	{"BR-AM", "Amazonas"},
+	{"BR-AP", "Amapá"},
+	{"BR-BA", "Bahia"},
+	{"BR-CE", "Ceará"},
+	{"BR-DF", "Distrito Federal"},
+	{"BR-ES", "Espírito Santo"},
+	{"BR-GO", "Goiás"},
+	{"BR-MA", "Maranhão"},
+	{"BR-MG", "Minas Gerais"},
+	{"BR-MS", "Mato Grosso do Sul"},
+	{"BR-MT", "Mato Grosso"},
+	{"BR-PA", "Pará"},
+	{"BR-PB", "Paraíba"},
+	{"BR-PE", "Pernambuco"},
+	{"BR-PI", "Piauí"},
+	{"BR-PR", "Paraná"},
+	{"BR-RJ", "Rio de Janeiro"},
+	{"BR-RN", "Rio Grande do Norte"},
+	{"BR-RO", "Rondônia"},
+	{"BR-RR", "Roraima"},
+	{"BR-RS", "Rio Grande do Sul"},
+	{"BR-SC", "Santa Catarina"},
+	{"BR-SE", "Sergipe"},
+	{"BR-SP", "São Paulo"},
+	{"BR-TO", "Tocantins"},
+	{"CA-AB", "Alberta"},
+	{"CA-BC", "British Columbia"},
+	{"CA-MB", "Manitoba"},
+	{"CA-NB", "New Brunswick"},
+	{"CA-NL", "Newfoundland and Labrador"},
+	{"CA-NS", "Nova Scotia"},
+	{"CA-NT", "Northwest Territories"},
+	{"CA-NU", "Nunavut"},
+	{"CA-ON", "Ontario"},
+	{"CA-PE", "Prince Edward Island"},
+	{"CA-QC", "Quebec"},
+	{"CA-SK", "Saskatchewan"},
+	{"CA-YT", "Yukon"},
+	{"IN-AN", "Andaman and Nicobar Islands"},
+	{"IN-AP", "Andhra Pradesh"},
+	{"IN-AR", "Arunāchal Pradesh"},
+	{"IN-AS", "Assam"},
+	{"IN-BR", "Bihār"},
+	{"IN-CH", "Chandīgarh"},
+	{"IN-CT", "Chhattīsgarh"},
+	{"IN-DH", "Dādra and Nagar Haveli and Damān and Diu"},
+	{"IN-DL", "Delhi"},
+	{"IN-GA", "Goa"},
+	{"IN-GJ", "Gujarāt"},
+	{"IN-HP", "Himāchal Pradesh"},
+	{"IN-HR", "Haryāna"},
+	{"IN-JH", "Jhārkhand"},
+	{"IN-JK", "Jammu and Kashmīr"},
+	{"IN-KA", "Karnātaka"},
+	{"IN-KL", "Kerala"},
+	{"IN-LA", "Ladākh"},
+	{"IN-LD", "Lakshadweep"},
+	{"IN-MH", "Mahārāshtra"},
+	{"IN-ML", "Meghālaya"},
+	{"IN-MN", "Manipur"},
+	{"IN-MP", "Madhya Pradesh"},
+	{"IN-MZ", "Mizoram"},
+	{"IN-NL", "Nāgāland"},
+	{"IN-OR", "Odisha"},
+	{"IN-PB", "Punjab"},
+	{"IN-PY", "Puducherry"},
+	{"IN-RJ", "Rājasthān"},
+	{"IN-SK", "Sikkim"},
+	{"IN-TG", "Telangāna"},
+	{"IN-TN", "Tamil Nādu"},
+	{"IN-TR", "Tripura"},
+	{"IN-UP", "Uttar Pradesh"},
+	{"IN-UT", "Uttarākhand"},
+	{"IN-WB", "West Bengal"},
+	{"MX-AGU", "Aguascalientes"},
+	{"MX-BCN", "Baja California"},
+	{"MX-BCS", "Baja California Sur"},
+	{"MX-CAM", "Campeche"},
+	{"MX-CHH", "Chihuahua"},
+	{"MX-CHP", "Chiapas"},
+	{"MX-CMX", "Ciudad de México"},
+	{"MX-COA", "Coahuila de Zaragoza"},
+	{"MX-COL", "Colima"},
+	{"MX-DUR", "Durango"},
+	{"MX-GRO", "Guerrero"},
+	{"MX-GUA", "Guanajuato"},
+	{"MX-HID", "Hidalgo"},
+	{"MX-JAL", "Jalisco"},
+	{"MX-MEX", "México"},
+	{"MX-MIC", "Michoacán de Ocampo"},
+	{"MX-MOR", "Morelos"},
+	{"MX-NAY", "Nayarit"},
+	{"MX-NLE", "Nuevo León"},
+	{"MX-OAX", "Oaxaca"},
+	{"MX-PUE", "Puebla"},
+	{"MX-QUE", "Querétaro"},
+	{"MX-ROO", "Quintana Roo"},
+	{"MX-SIN", "Sinaloa"},
+	{"MX-SLP", "San Luis Potosí"},
+	{"MX-SON", "Sonora"},
+	{"MX-TAB", "Tabasco"},
+	{"MX-TAM", "Tamaulipas"},
+	{"MX-TLA", "Tlaxcala"},
+	{"MX-VER", "Veracruz de Ignacio de la Llave"},
+	{"MX-YUC", "Yucatán"},
+	{"MX-ZAC", "Zacatecas"},
+	{"US-AK", "Alaska"},
+	{"US-AL", "Alabama"},
+	{"US-AR", "Arkansas"},
+	{"US-AZ", "Arizona"},
+	{"US-CA", "California"},
+	{"US-CO", "Colorado"},
+	{"US-CT", "Connecticut"},
+	{"US-DC", "District of Columbia"},
+	{"US-DE", "Delaware"},
+	{"US-FL", "Florida"},
+	{"US-GA", "Georgia"},
+	{"US-HI", "Hawaii"},
+	{"US-IA", "Iowa"},
+	{"US-ID", "Idaho"},
+	{"US-IL", "Illinois"},
+	{"US-IN", "Indiana"},
+	{"US-KS", "Kansas"},
+	{"US-KY", "Kentucky"},
+	{"US-LA", "Louisiana"},
+	{"US-MA", "Massachusetts"},
+	{"US-MD", "Maryland"},
+	{"US-ME", "Maine"},
+	{"US-MI", "Michigan"},
+	{"US-MN", "Minnesota"},
+	{"US-MO", "Missouri"},
+	{"US-MS", "Mississippi"},
+	{"US-MT", "Montana"},
+	{"US-NC", "North Carolina"},
+	{"US-ND", "North Dakota"},
+	{"US-NE", "Nebraska"},
+	{"US-NH", "New Hampshire"},
+	{"US-NJ", "New Jersey"},
+	{"US-NM", "New Mexico"},
+	{"US-NV", "Nevada"},
+	{"US-NY", "New York"},
+	{"US-OH", "Ohio"},
+	{"US-OK", "Oklahoma"},
+	{"US-OR", "Oregon"},
+	{"US-PA", "Pennsylvania"},
+	{"US-RI", "Rhode Island"},
+	{"US-SC", "South Carolina"},
+	{"US-SD", "South Dakota"},
+	{"US-TN", "Tennessee"},
+	{"US-TX", "Texas"},
+	{"US-UT", "Utah"},
+	{"US-VA", "Virginia"},
+	{"US-VT", "Vermont"},
+	{"US-WA", "Washington"},
+	{"US-WI", "Wisconsin"},
+	{"US-WV", "West Virginia"},
+	{"US-WY", "Wyoming"},
+}
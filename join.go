@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strings"
+)
+
+// joinKeys are the --join-key customer fields and how their values are compared.
+var joinKeys = map[string]func(CustomerSegmentMember) string{
+	"id": func(c CustomerSegmentMember) string { return normalizeJoinID(c.Node.Id) },
+	"email": func(c CustomerSegmentMember) string {
+		if e := c.Node.DefaultEmailAddress; e != nil {
+			return normalizeEmail(e.EmailAddress)
+		}
+		return ""
+	},
+	"phone": func(c CustomerSegmentMember) string {
+		if p := c.Node.DefaultPhoneNumber; p != nil {
+			return normalizePhone(p.PhoneNumber)
+		}
+		return ""
+	},
+}
+
+// normalizeJoinID compares customer IDs by their number, so local files can
+// have either gid://shopify/Customer/123 or 123 for the segment member
+// gid://shopify/CustomerSegmentMember/123.
+func normalizeJoinID(id string) string {
+	return strings.TrimSpace(id[strings.LastIndex(id, "/")+1:])
+}
+
+// joinColumns reads a local CSV file and returns its columns, other than the key
+// column, as columns for a left join on key: "email", "id" or "phone", matched
+// against the file column of the same name, or "email=<column>" to name it.
+// Customers without a matching row get nullValue.
+func joinColumns(path, key string, existing []string) ([]column, error) {
+	field, keyColumn, ok := strings.Cut(key, "=")
+	if !ok {
+		keyColumn = field
+	}
+	value, known := joinKeys[field]
+	if !known {
+		return nil, fmt.Errorf("invalid --join-key %q, expected id, email or phone, optionally followed by =<column>", key)
+	}
+	normalize := map[string]func(string) string{"id": normalizeJoinID, "email": normalizeEmail, "phone": normalizePhone}[field]
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open --join file: %w", err)
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read --join file header: %w", err)
+	}
+	keyIndex := slices.IndexFunc(header, func(h string) bool { return strings.EqualFold(strings.TrimSpace(h), keyColumn) })
+	if keyIndex < 0 {
+		return nil, fmt.Errorf("--join file %s has no %q column", path, keyColumn)
+	}
+
+	rows := map[string][]string{}
+	duplicates := 0
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read --join file: %w", err)
+		}
+		k := normalize(record[keyIndex])
+		if k == "" {
+			continue
+		}
+		if _, ok := rows[k]; ok {
+			duplicates++
+			continue
+		}
+		rows[k] = record
+	}
+	if duplicates > 0 {
+		log.Printf("warning: %d rows of %s repeat the %s of an earlier row and were ignored", duplicates, path, field)
+	}
+
+	var columns []column
+	for i, name := range header {
+		if i == keyIndex {
+			continue
+		}
+		if slices.Contains(existing, name) {
+			return nil, fmt.Errorf("--join file column %q is already a column of the export", name)
+		}
+		i := i
+		columns = append(columns, column{Header: name, Value: func(c CustomerSegmentMember) string {
+			if row, ok := rows[value(c)]; ok && row[i] != "" {
+				return row[i]
+			}
+			return nullValue
+		}})
+	}
+	return columns, nil
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// journalEntry is one line of a checkpoint journal.
+type journalEntry struct {
+	Event   string        `json:"event"` // "start", "checkpoint" or "complete"
+	Time    time.Time     `json:"time"`
+	Query   *SegmentQuery `json:"query,omitempty"`
+	Output  string        `json:"output,omitempty"`
+	Cursor  string        `json:"cursor,omitempty"`
+	Rows    int           `json:"rows"`
+	Fetched int           `json:"fetched,omitempty"` // members fetched, including those filtered out of Rows
+	Offset  int64         `json:"offset,omitempty"`
+}
+
+// journal is an append-only JSON Lines file recording the progress of one export:
+// the query and output, then a checkpoint with the page cursor, rows written,
+// members fetched and CSV byte offset after every page that reached the output. A nil journal records
+// nothing.
+type journal struct {
+	f     *os.File
+	start journalEntry
+	// last is the latest checkpoint of the interrupted run being resumed.
+	last *journalEntry
+}
+
+// createJournal starts a journal for a new export. An existing journal is only
+// replaced if its export completed.
+func createJournal(path string, q SegmentQuery, output string) (*journal, error) {
+	if _, last, complete, err := readJournal(path); err == nil && !complete {
+		progress := ""
+		if last != nil {
+			progress = fmt.Sprintf(" after %d rows", last.Rows)
+		}
+		return nil, fmt.Errorf("journal %s has an unfinished export%s; run \"resume %s\" or remove it", path, progress, path)
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create journal: %w", err)
+	}
+	j := &journal{f: f, start: journalEntry{Event: "start", Query: &q, Output: output}}
+	if err := j.append(j.start); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return j, nil
+}
+
+// openJournal opens the journal of an interrupted export for resuming.
+func openJournal(path string) (*journal, error) {
+	start, last, complete, err := readJournal(path)
+	if err != nil {
+		return nil, err
+	}
+	if complete {
+		return nil, fmt.Errorf("the export in journal %s already completed", path)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	return &journal{f: f, start: start, last: last}, nil
+}
+
+// readJournal returns the start entry, the latest checkpoint (nil if none) and
+// whether the export completed. A torn final line from a crash is ignored.
+func readJournal(path string) (start journalEntry, last *journalEntry, complete bool, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return start, nil, false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, err := openJournalLine(scanner.Bytes())
+		if errors.Is(err, errNotEncrypted) || errors.Is(err, errEncrypted) {
+			return start, nil, false, fmt.Errorf("journal %s is %w", path, err)
+		}
+		var e journalEntry
+		if err != nil || json.Unmarshal(line, &e) != nil {
+			break
+		}
+		switch e.Event {
+		case "start":
+			start = e
+		case "checkpoint":
+			last = &e
+		case "complete":
+			complete = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return start, nil, false, fmt.Errorf("failed to read journal: %w", err)
+	}
+	if start.Query == nil {
+		return start, nil, false, fmt.Errorf("journal %s has no start entry", path)
+	}
+	return start, last, complete, nil
+}
+
+// append writes e and syncs it to disk, so a checkpoint is never recorded for
+// data that could still be lost.
+func (j *journal) append(e journalEntry) error {
+	e.Time = time.Now().UTC()
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if b, err = sealJournalLine(b); err != nil {
+		return err
+	}
+	if _, err := j.f.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := j.f.Sync(); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return nil
+}
+
+func (j *journal) checkpoint(cursor string, rows, fetched int, offset int64) error {
+	if j == nil {
+		return nil
+	}
+	return j.append(journalEntry{Event: "checkpoint", Cursor: cursor, Rows: rows, Fetched: fetched, Offset: offset})
+}
+
+func (j *journal) finish(rows int) error {
+	if j == nil {
+		return nil
+	}
+	return j.append(journalEntry{Event: "complete", Rows: rows})
+}
+
+func (j *journal) Close() error {
+	return j.f.Close()
+}
+
+// resuming reports whether rows from an interrupted run are already in the output.
+func (j *journal) resuming() bool {
+	return j != nil && j.last != nil
+}
+
+// resumeRows returns the rows already exported by the interrupted run.
+func (j *journal) resumeRows() int {
+	if !j.resuming() {
+		return 0
+	}
+	return j.last.Rows
+}
+
+// resumeFetched returns the members fetched by the interrupted run, which count
+// against --first. Journals written before it was recorded only have the rows.
+func (j *journal) resumeFetched() int {
+	if !j.resuming() {
+		return 0
+	}
+	if j.last.Fetched == 0 {
+		return j.last.Rows
+	}
+	return j.last.Fetched
+}
+
+// openOutput opens the CSV output. When resuming, anything written after the last
+// checkpoint is truncated so the next page continues the file exactly.
+func (j *journal) openOutput(output string) (*os.File, error) {
+	if !j.resuming() {
+		return os.Create(output)
+	}
+	f, err := os.OpenFile(output, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Truncate(j.last.Offset); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(j.last.Offset, 0); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func resumeCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "resume",
+		Usage:     "Resume an interrupted export from its checkpoint journal",
+		ArgsUsage: "<journal>",
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 1 {
+				return fmt.Errorf("usage: resume <journal>")
+			}
+			j, err := openJournal(c.Args().First())
+			if err != nil {
+				return err
+			}
+			defer j.Close()
+
+			lock, err := acquireRunLock(c, j.start.Output)
+			if err != nil {
+				return err
+			}
+			defer lock.release()
+
+			q := *j.start.Query
+			if j.last != nil {
+				q.After = j.last.Cursor
+				q.First -= j.resumeFetched()
+			}
+			client, err := newShopifyClient(c)
+			if err != nil {
+				return err
+			}
+
+			run := startRun("resume", q, j.start.Output)
+			exported, err := 0, runHook(c, "pre-hook", run)
+			if err == nil {
+				ctx, cancel := exportContext(c.Duration("timeout"))
+				defer cancel()
+				exported, err = streamSegment(ctx, c, client, q, j.start.Output, j, newPipeline())
+			}
+			err = runPostHook(c, recordRun(c, run, exported, err), err)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(statusOutput(j.start.Output), "Successfully exported %d customers to %s (resumed after %d)\n", exported, j.start.Output, j.resumeRows())
+			return nil
+		},
+	}
+}
+
+// sealJournalLine encrypts a journal line with --encrypt-stores, base64 encoded
+// so the journal stays line-oriented.
+func sealJournalLine(line []byte) ([]byte, error) {
+	if stores == nil {
+		return line, nil
+	}
+	sealed, err := stores.seal(line)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(base64.StdEncoding.EncodeToString(sealed)), nil
+}
+
+func openJournalLine(line []byte) ([]byte, error) {
+	if bytes.HasPrefix(line, []byte("{")) {
+		if stores != nil {
+			return nil, errNotEncrypted
+		}
+		return line, nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(string(line))
+	if err != nil {
+		return nil, err
+	}
+	if stores == nil && bytes.HasPrefix(sealed, sealedPrefix) {
+		return nil, errEncrypted
+	}
+	return stores.open(sealed)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJournalResume(t *testing.T) {
+	tests := []struct {
+		name        string
+		checkpoints []journalEntry
+		// torn is appended after the checkpoints, as by a crash mid-write.
+		torn        string
+		wantCursor  string
+		wantRows    int
+		wantFetched int
+	}{
+		{name: "no checkpoint"},
+		{
+			name:        "checkpoints",
+			checkpoints: []journalEntry{{Cursor: "a", Rows: 2, Fetched: 3, Offset: 10}, {Cursor: "b", Rows: 4, Fetched: 6, Offset: 20}},
+			wantCursor:  "b", wantRows: 4, wantFetched: 6,
+		},
+		{
+			name:        "torn last line",
+			checkpoints: []journalEntry{{Cursor: "a", Rows: 2, Fetched: 3, Offset: 10}},
+			torn:        `{"event":"checkpoint","cursor":"b","ro`,
+			wantCursor:  "a", wantRows: 2, wantFetched: 3,
+		},
+		{
+			name:        "without fetched",
+			checkpoints: []journalEntry{{Cursor: "a", Rows: 5, Offset: 10}},
+			wantCursor:  "a", wantRows: 5, wantFetched: 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "export.journal")
+			j, err := createJournal(path, SegmentQuery{Query: "q", First: 10}, "out.csv")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range tt.checkpoints {
+				if err := j.checkpoint(e.Cursor, e.Rows, e.Fetched, e.Offset); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.torn != "" {
+				if _, err := j.f.WriteString(tt.torn); err != nil {
+					t.Fatal(err)
+				}
+			}
+			j.Close()
+
+			if _, err := createJournal(path, SegmentQuery{}, "out.csv"); err == nil || !strings.Contains(err.Error(), "unfinished export") {
+				t.Errorf("createJournal() over an unfinished export: error = %v", err)
+			}
+
+			j, err = openJournal(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer j.Close()
+			if j.start.Query.Query != "q" || j.start.Query.First != 10 || j.start.Output != "out.csv" {
+				t.Errorf("start = %+v, query %+v", j.start, *j.start.Query)
+			}
+			if j.resuming() != (len(tt.checkpoints) > 0) {
+				t.Errorf("resuming() = %v with %d checkpoints", j.resuming(), len(tt.checkpoints))
+			}
+			if j.resuming() && j.last.Cursor != tt.wantCursor {
+				t.Errorf("cursor = %q, want %q", j.last.Cursor, tt.wantCursor)
+			}
+			if got := j.resumeRows(); got != tt.wantRows {
+				t.Errorf("resumeRows() = %d, want %d", got, tt.wantRows)
+			}
+			if got := j.resumeFetched(); got != tt.wantFetched {
+				t.Errorf("resumeFetched() = %d, want %d", got, tt.wantFetched)
+			}
+		})
+	}
+}
+
+func TestJournalComplete(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.journal")
+	j, err := createJournal(path, SegmentQuery{Query: "q", First: 10}, "out.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := j.finish(10); err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+
+	if _, err := openJournal(path); err == nil || !strings.Contains(err.Error(), "already completed") {
+		t.Errorf("openJournal() of a completed export: error = %v", err)
+	}
+	j, err = createJournal(path, SegmentQuery{Query: "q", First: 10}, "out.csv")
+	if err != nil {
+		t.Fatalf("createJournal() over a completed export: %v", err)
+	}
+	j.Close()
+}
+
+// TestJournalResumeExport interrupts a journaled CSV export after its first
+// page, with part of the second page written, and resumes it, which must give
+// the same file as an uninterrupted export.
+func TestJournalResumeExport(t *testing.T) {
+	client := newMockClient(t, 10)
+	dir := t.TempDir()
+	ctx := context.Background()
+	q := SegmentQuery{First: 8, PageSize: 3, SortKey: "amount_spent", Reverse: true}
+
+	want := filepath.Join(dir, "want.csv")
+	if _, err := streamToCSV(ctx, fetchSegmentStream(ctx, client, q, 1, nil), want, nil, false); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(dir, "customers.csv")
+	path := filepath.Join(dir, "export.journal")
+	j, err := createJournal(path, q, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := q
+	first.First = first.PageSize
+	if _, err := streamToCSV(ctx, fetchSegmentStream(ctx, client, first, 1, nil), output, j, false); err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+	f, err := os.OpenFile(output, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("gid://shopify/CustomerSegmentMember/torn,")
+	f.Close()
+
+	j, err = openJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	resumed := *j.start.Query
+	resumed.After = j.last.Cursor
+	resumed.First -= j.resumeFetched()
+	exported, err := streamToCSV(ctx, fetchSegmentStream(ctx, client, resumed, 1, nil), output, j, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exported != q.First {
+		t.Errorf("exported %d rows including the resumed ones, want %d", exported, q.First)
+	}
+
+	got, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := os.ReadFile(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(expected) {
+		t.Errorf("resumed export:\n%s\nwant:\n%s", got, expected)
+	}
+}
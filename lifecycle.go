@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"sultans/shopify"
+)
+
+// lifecycle looks up the dates of exported customers for --date-columns, nil
+// without it. Segment members do not have these fields, so they are looked up
+// on the customers themselves, one batch of IDs at a time.
+var lifecycle *lifecycleDates
+
+type customerDates struct {
+	CreatedAt, UpdatedAt, FirstOrder, LastOrder time.Time
+}
+
+// dateColumns are the --date-columns names, in column order.
+var dateColumns = []struct {
+	name, header string
+	value        func(customerDates) time.Time
+}{
+	{"created_at", "Created At", func(d customerDates) time.Time { return d.CreatedAt }},
+	{"updated_at", "Updated At", func(d customerDates) time.Time { return d.UpdatedAt }},
+	{"first_order", "First Order At", func(d customerDates) time.Time { return d.FirstOrder }},
+	{"last_order", "Last Order At", func(d customerDates) time.Time { return d.LastOrder }},
+}
+
+type lifecycleDates struct{}
+
+// parseDateColumns returns the CSV columns of a --date-columns list such as
+// "created_at,last_order", or "all".
+func parseDateColumns(s string) ([]column, error) {
+	names := map[string]bool{}
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names[name] = true
+		}
+	}
+	var columns []column
+	for _, dc := range dateColumns {
+		if !names[dc.name] && !names["all"] {
+			continue
+		}
+		delete(names, dc.name)
+		value := dc.value
+		columns = append(columns, column{Header: dc.header, Value: func(c CustomerSegmentMember) string {
+			if t := value(c.lookedUp().dates); !t.IsZero() {
+				return formatTime(t)
+			}
+			return nullValue
+		}})
+	}
+	delete(names, "all")
+	for name := range names {
+		return nil, fmt.Errorf("invalid --date-columns column %q, expected created_at, updated_at, first_order, last_order or all", name)
+	}
+	return columns, nil
+}
+
+// wrap looks up the dates of every page of in before delivering it.
+func (l *lifecycleDates) wrap(ctx context.Context, client *shopify.Client, in *segmentStream) *segmentStream {
+	return lookupPages(ctx, in, "dates", func(ids []string, fields func(string) *memberFields) error {
+		resp, err := GetCustomerDates(ctx, client, ids)
+		if err != nil {
+			return err
+		}
+		for _, node := range resp.Nodes {
+			customer, ok := node.(*CustomerDatesNodeCustomer)
+			if !ok {
+				continue
+			}
+			d := customerDates{CreatedAt: customer.CreatedAt, UpdatedAt: customer.UpdatedAt}
+			if customer.LastOrder != nil {
+				d.LastOrder = customer.LastOrder.ProcessedAt
+			}
+			if len(customer.Orders.Edges) > 0 {
+				d.FirstOrder = customer.Orders.Edges[0].Node.ProcessedAt
+			}
+			fields(customer.Id).dates = d
+		}
+		return nil
+	})
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"sultans/shopify"
+)
+
+// restCallLimitHeader reports the REST leaky bucket as "used/size".
+const restCallLimitHeader = "X-Shopify-Shop-Api-Call-Limit"
+
+// throttleStatusQuery is a one-point query for the throttle status of the
+// GraphQL cost extension.
+const throttleStatusQuery = `query ThrottleStatus {
+	shop {
+		name
+	}
+}`
+
+// apiLimits is the state of the shop's GraphQL and REST rate limits.
+type apiLimits struct {
+	GraphQL *graphQLLimit `json:"graphql"`
+	REST    *restLimit    `json:"rest,omitempty"`
+	// RESTError is why the REST limit is unknown, for example a token
+	// without REST access.
+	RESTError string `json:"restError,omitempty"`
+}
+
+type graphQLLimit struct {
+	Available   float64 `json:"available"`
+	Maximum     float64 `json:"maximum"`
+	RestoreRate float64 `json:"restoreRate"`
+	// FullInSeconds is how long the bucket takes to refill when unused.
+	FullInSeconds float64 `json:"fullInSeconds"`
+}
+
+type restLimit struct {
+	Used    int `json:"used"`
+	Maximum int `json:"maximum"`
+	// LeakRate is the requests per second the bucket drains by: 2 for a
+	// 40-request bucket, 20 for the 400 of Shopify Plus.
+	LeakRate int `json:"leakRate"`
+}
+
+func limitsCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "limits",
+		Usage: "Show the shop's current GraphQL cost and REST request rate limits",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "format", Value: "text", Usage: "Output format: text or json"},
+			&cli.BoolFlag{Name: "no-rest", Usage: "Only check the GraphQL limit, e.g. for tokens without REST access"},
+		},
+		Action: func(c *cli.Context) error {
+			format := c.String("format")
+			if format != "text" && format != "json" {
+				return fmt.Errorf("unsupported format %q, expected text or json", format)
+			}
+			client, err := newShopifyClient(c)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := exportContext(c.Duration("timeout"))
+			defer cancel()
+			limits, err := fetchAPILimits(ctx, client, !c.Bool("no-rest"))
+			if err != nil {
+				return err
+			}
+			if format == "json" {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(limits)
+			}
+			return writeAPILimits(os.Stdout, limits)
+		},
+	}
+}
+
+// fetchAPILimits sends a minimal GraphQL query, and with rest a request for the
+// shop's ID, and reads the limits they report. Both count against their limit.
+func fetchAPILimits(ctx context.Context, client *shopify.Client, rest bool) (apiLimits, error) {
+	var limits apiLimits
+	cost, err := costQuery(ctx, client, throttleStatusQuery, nil, nil)
+	if err != nil {
+		return limits, fmt.Errorf("failed to query the GraphQL limit: %w", err)
+	}
+	if cost == nil {
+		return limits, fmt.Errorf("the response has no cost extension with the GraphQL limit")
+	}
+	t := cost.ThrottleStatus
+	limits.GraphQL = &graphQLLimit{Available: t.CurrentlyAvailable, Maximum: t.MaximumAvailable, RestoreRate: t.RestoreRate}
+	if t.RestoreRate > 0 {
+		limits.GraphQL.FullInSeconds = (t.MaximumAvailable - t.CurrentlyAvailable) / t.RestoreRate
+	}
+
+	if rest {
+		if limits.REST, err = fetchRESTLimit(ctx, client); err != nil {
+			limits.RESTError = redaction.redact(err.Error())
+		}
+	}
+	return limits, nil
+}
+
+func fetchRESTLimit(ctx context.Context, client *shopify.Client) (*restLimit, error) {
+	var header string
+	_, requestID, err := client.WithRetries(ctx, func() ([]byte, string, error) {
+		h, requestID, err := client.Do(ctx, "GET", client.RESTURL("shop", url.Values{"fields": {"id"}}), nil, nil)
+		header = h.Get(restCallLimitHeader)
+		return nil, requestID, err
+	})
+	if err != nil {
+		return nil, shopify.WithRequestID(fmt.Errorf("REST request failed: %w", err), requestID)
+	}
+	used, size, ok := strings.Cut(header, "/")
+	l := &restLimit{}
+	l.Used, err = strconv.Atoi(strings.TrimSpace(used))
+	if err == nil {
+		l.Maximum, err = strconv.Atoi(strings.TrimSpace(size))
+	}
+	if !ok || err != nil || l.Maximum <= 0 {
+		return nil, fmt.Errorf("invalid %s header %q", restCallLimitHeader, header)
+	}
+	// Shopify's buckets drain by a twentieth of their size per second.
+	l.LeakRate = max(l.Maximum/20, 1)
+	return l, nil
+}
+
+func writeAPILimits(w io.Writer, l apiLimits) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	g := l.GraphQL
+	fmt.Fprintf(tw, "GraphQL:\t%.0f of %.0f points available (%.0f%%), restoring %.0f points/s", g.Available, g.Maximum, percentOf(int(g.Available), int(g.Maximum)), g.RestoreRate)
+	if full := roundDuration(time.Duration(g.FullInSeconds * float64(time.Second))); full > 0 {
+		fmt.Fprintf(tw, ", full in %s", full)
+	}
+	fmt.Fprintln(tw)
+	switch {
+	case l.REST != nil:
+		fmt.Fprintf(tw, "REST:\t%d of %d requests used, draining %d requests/s\n", l.REST.Used, l.REST.Maximum, l.REST.LeakRate)
+	case l.RESTError != "":
+		fmt.Fprintf(tw, "REST:\tunknown: %s\n", l.RESTError)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// humanAmounts formats amounts in summaries and reports, set by --number-locale
+// and --currency-symbols. CSV files and destinations always get plain decimals.
+var humanAmounts amountFormatter
+
+type amountFormatter struct {
+	// printer applies the separators of --number-locale; nil for plain decimals.
+	printer *message.Printer
+	symbols bool
+}
+
+func newAmountFormatter(locale string, symbols bool) (amountFormatter, error) {
+	f := amountFormatter{symbols: symbols}
+	if locale == "" {
+		return f, nil
+	}
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return f, fmt.Errorf("invalid --number-locale %q: %w", locale, err)
+	}
+	f.printer = message.NewPrinter(tag)
+	return f, nil
+}
+
+// format returns amount with the decimals of code, the locale's separators and, with
+// --currency-symbols, the symbol of code in front ("€ 1.234,50" for de-DE).
+func (f amountFormatter) format(amount decimal.Decimal, code string) string {
+	s := formatAmount(amount, code)
+	if f.printer != nil {
+		value, _ := roundAmount(amount, code).Float64()
+		s = f.printer.Sprint(number.Decimal(value, number.Scale(int(amountDecimals(code)))))
+	}
+	if !f.symbols {
+		return s
+	}
+	symbol := code
+	if unit, err := currency.ParseISO(code); err == nil {
+		p := f.printer
+		if p == nil {
+			p = message.NewPrinter(language.Und)
+		}
+		symbol = p.Sprint(currency.Symbol(unit))
+	}
+	return symbol + " " + s
+}
+
+// formatWithCode is format followed by the currency code, unless the symbol
+// already identifies the currency.
+func (f amountFormatter) formatWithCode(amount decimal.Decimal, code string) string {
+	if f.symbols {
+		return f.format(amount, code)
+	}
+	return f.format(amount, code) + " " + code
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+)
+
+// errLocked is returned by lockFile when another process holds the lock.
+var errLocked = errors.New("locked by another process")
+
+// runLock is a set of held lock files.
+type runLock struct {
+	files []*os.File
+}
+
+// acquireRunLock takes the --lock-file lock and, unless --no-lock is set, a lock
+// next to every file output ("<output>.lock"), so overlapping runs writing the
+// same files fail instead of interleaving. Destination URLs and stdout are not
+// locked automatically.
+func acquireRunLock(c *cli.Context, outputs ...string) (*runLock, error) {
+	var paths []string
+	if path := c.String("lock-file"); path != "" {
+		paths = append(paths, path)
+	}
+	if !c.Bool("no-lock") {
+		for _, output := range outputs {
+			if isFileOutput(output) {
+				paths = append(paths, output+".lock")
+			}
+		}
+	}
+
+	l := &runLock{}
+	for _, path := range paths {
+		f, err := lockPath(path)
+		if err != nil {
+			l.release()
+			return nil, err
+		}
+		// Record the holder for operators inspecting a stuck lock.
+		f.Truncate(0)
+		fmt.Fprintf(f, "%d\n", os.Getpid())
+		l.files = append(l.files, f)
+	}
+	return l, nil
+}
+
+// lockPath opens and locks the lock file at path. Since release removes lock
+// files, the lock taken may be on a file another run has just removed; it is
+// then retried on the file now at path.
+func lockPath(path string) (*os.File, error) {
+	for {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open lock file: %w", err)
+		}
+		if err := lockFile(f); err != nil {
+			f.Close()
+			if errors.Is(err, errLocked) {
+				return nil, fmt.Errorf("another export is already running (%s is locked)", path)
+			}
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		locked, err := f.Stat()
+		if err != nil {
+			unlockFile(f)
+			f.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		if current, err := os.Stat(path); err == nil && os.SameFile(locked, current) {
+			return f, nil
+		}
+		unlockFile(f)
+		f.Close()
+	}
+}
+
+// release unlocks all held locks and removes their files, so no lock files are
+// left beside the outputs. Files are removed while still locked, so a run that
+// opens one in between retries on a new file; where open files cannot be
+// removed (Windows), they are removed after unlocking.
+func (l *runLock) release() {
+	for _, f := range l.files {
+		removed := os.Remove(f.Name()) == nil
+		unlockFile(f)
+		f.Close()
+		if !removed {
+			os.Remove(f.Name())
+		}
+	}
+	l.files = nil
+}
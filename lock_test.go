@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+// lockContext returns a context with the --lock-file and --no-lock flags of
+// args.
+func lockContext(t *testing.T, args ...string) *cli.Context {
+	t.Helper()
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	set.String("lock-file", "", "")
+	set.Bool("no-lock", false, "")
+	if err := set.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return cli.NewContext(cli.NewApp(), set, nil)
+}
+
+func TestAcquireRunLock(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "customers.csv")
+	lockFile := filepath.Join(dir, "run.lock")
+
+	tests := []struct {
+		name    string
+		args    []string
+		outputs []string
+		// locks are the lock files held, relative to dir.
+		locks []string
+	}{
+		{name: "file output", outputs: []string{output}, locks: []string{"customers.csv.lock"}},
+		{name: "lock file", args: []string{"--lock-file", lockFile}, outputs: []string{output}, locks: []string{"run.lock", "customers.csv.lock"}},
+		{name: "no lock", args: []string{"--no-lock"}, outputs: []string{output}},
+		{name: "no lock with lock file", args: []string{"--no-lock", "--lock-file", lockFile}, outputs: []string{output}, locks: []string{"run.lock"}},
+		{name: "other outputs", outputs: []string{"", clipboardOutput, "braze://rest.iad-01.braze.com", "plugin:./sink"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := lockContext(t, tt.args...)
+			l, err := acquireRunLock(c, tt.outputs...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var held []string
+			for _, f := range l.files {
+				held = append(held, filepath.Base(f.Name()))
+			}
+			if strings.Join(held, ",") != strings.Join(tt.locks, ",") {
+				t.Errorf("locks = %q, want %q", held, tt.locks)
+			}
+
+			if len(tt.locks) > 0 {
+				if _, err := acquireRunLock(c, tt.outputs...); err == nil || !strings.Contains(err.Error(), "already running") {
+					t.Errorf("second acquireRunLock() error = %v, want another export running", err)
+				}
+				b, err := os.ReadFile(filepath.Join(dir, tt.locks[0]))
+				if err != nil || strings.TrimSpace(string(b)) != strconv.Itoa(os.Getpid()) {
+					t.Errorf("lock file holds %q, %v, want the pid %d", b, err, os.Getpid())
+				}
+			}
+
+			l.release()
+			for _, name := range tt.locks {
+				if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+					t.Errorf("%s left after release: %v", name, err)
+				}
+			}
+			again, err := acquireRunLock(c, tt.outputs...)
+			if err != nil {
+				t.Fatalf("acquireRunLock() after release: %v", err)
+			}
+			again.release()
+		})
+	}
+}
+
+func TestAcquireRunLockPartial(t *testing.T) {
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "a.csv"), filepath.Join(dir, "b.csv")
+	c := lockContext(t)
+
+	held, err := acquireRunLock(c, second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer held.release()
+	if _, err := acquireRunLock(c, first, second); err == nil {
+		t.Fatal("acquireRunLock() succeeded with an output locked by another run")
+	}
+	// The locks taken before the failure are released.
+	l, err := acquireRunLock(c, first)
+	if err != nil {
+		t.Fatalf("acquireRunLock() of an output left locked by a failed acquire: %v", err)
+	}
+	l.release()
+}
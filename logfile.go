@@ -0,0 +1,223 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// logOutput is where log messages go: stderr, or the --log-file.
+var logOutput io.Writer = os.Stderr
+
+// logFile is the --log-file, if set.
+var logFile *rotatingLog
+
+// rotatingLog is a log file that is renamed to a timestamped backup when it
+// grows beyond maxSize or was opened longer than maxAge ago, so long-running
+// processes such as serve keep their logs bounded without a restart.
+type rotatingLog struct {
+	path     string
+	maxSize  int64
+	maxAge   time.Duration
+	backups  int
+	keepFor  time.Duration
+	compress bool
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+	// cleanup runs the compression and removal of backups after a rotation,
+	// one rotation at a time.
+	cleanup   sync.WaitGroup
+	cleanupMu sync.Mutex
+}
+
+// configureLogFile sends log messages to --log-file when it is set.
+func configureLogFile(c *cli.Context) error {
+	path := c.String("log-file")
+	if path == "" {
+		return nil
+	}
+	if c.Int("log-max-size") < 0 || c.Int("log-max-backups") < 0 {
+		return fmt.Errorf("--log-max-size and --log-max-backups cannot be negative")
+	}
+	l := &rotatingLog{
+		path:     path,
+		maxSize:  int64(c.Int("log-max-size")) << 20,
+		backups:  c.Int("log-max-backups"),
+		compress: c.Bool("log-compress"),
+	}
+	var err error
+	if l.maxAge, err = parseAge("log-rotate-every", c.String("log-rotate-every")); err != nil {
+		return err
+	}
+	if l.keepFor, err = parseAge("log-max-age", c.String("log-max-age")); err != nil {
+		return err
+	}
+	if err := l.open(); err != nil {
+		return fmt.Errorf("failed to open --log-file: %w", err)
+	}
+	logFile, logOutput = l, l
+	log.SetOutput(redactingWriter{logOutput})
+	return nil
+}
+
+func (l *rotatingLog) open() error {
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.file, l.size, l.opened = f, info.Size(), time.Now()
+	return nil
+}
+
+func (l *rotatingLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.size > 0 && (l.maxSize > 0 && l.size+int64(len(p)) > l.maxSize || l.maxAge > 0 && time.Since(l.opened) > l.maxAge) {
+		if err := l.rotate(); err != nil {
+			// Keep logging to the current file rather than losing messages.
+			fmt.Fprintf(os.Stderr, "warning: failed to rotate --log-file: %v\n", err)
+		}
+	}
+	n, err := l.file.Write(p)
+	l.size += int64(n)
+	return n, err
+}
+
+// rotate renames the log file to a backup with the time of the rotation and
+// opens a new one.
+func (l *rotatingLog) rotate() error {
+	backup := l.backupPath(time.Now().UTC())
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(l.path, backup); err != nil {
+		if openErr := l.open(); openErr != nil {
+			return openErr
+		}
+		return err
+	}
+	if err := l.open(); err != nil {
+		return err
+	}
+	l.cleanup.Add(1)
+	go func() {
+		defer l.cleanup.Done()
+		l.cleanupMu.Lock()
+		defer l.cleanupMu.Unlock()
+		if l.compress {
+			if err := compressFile(backup); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: failed to compress %s: %v\n", backup, err)
+			}
+		}
+		l.removeBackups()
+	}()
+	return nil
+}
+
+// backupPath returns the name of a backup rotated at t: "app.log" becomes
+// "app-20240630T020000Z.log", with a counter for rotations within a second.
+func (l *rotatingLog) backupPath(t time.Time) string {
+	ext := filepath.Ext(l.path)
+	base := strings.TrimSuffix(l.path, ext) + "-" + t.Format("20060102T150405Z")
+	backup := base + ext
+	for i := 2; ; i++ {
+		if _, err := os.Stat(backup); os.IsNotExist(err) {
+			if _, err := os.Stat(backup + ".gz"); os.IsNotExist(err) {
+				return backup
+			}
+		}
+		backup = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+}
+
+// removeBackups removes the backups beyond the newest --log-max-backups, and
+// those older than --log-max-age.
+func (l *rotatingLog) removeBackups() {
+	if l.backups == 0 && l.keepFor == 0 {
+		return
+	}
+	ext := filepath.Ext(l.path)
+	pattern := strings.TrimSuffix(l.path, ext) + "-????????T??????Z*" + ext
+	matches, _ := filepath.Glob(pattern)
+	compressed, _ := filepath.Glob(pattern + ".gz")
+	type backup struct {
+		path    string
+		modTime time.Time
+	}
+	var backups []backup
+	for _, path := range append(matches, compressed...) {
+		if info, err := os.Stat(path); err == nil {
+			backups = append(backups, backup{path, info.ModTime()})
+		}
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].modTime.After(backups[j].modTime) })
+	for i, b := range backups {
+		if l.backups > 0 && i >= l.backups || l.keepFor > 0 && time.Since(b.modTime) > l.keepFor {
+			if err := os.Remove(b.path); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: failed to remove %s: %v\n", b.path, err)
+			}
+		}
+	}
+}
+
+// wait waits for the compression and removal of rotated backups to finish.
+func (l *rotatingLog) wait() {
+	l.cleanup.Wait()
+}
+
+// compressFile replaces path with a gzip-compressed path.gz with the same
+// modification time. The original is only removed once the compressed file is
+// complete.
+func compressFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	tmp := path + ".gz.tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chtimes(tmp, info.ModTime(), info.ModTime())
+	}
+	if err == nil {
+		err = os.Rename(tmp, path+".gz")
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	in.Close()
+	return os.Remove(path)
+}
@@ -1,64 +1,37 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"encoding/csv"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
-	"net/http"
 	"os"
 	"time"
 
-	"github.com/joho/godotenv"
-	"github.com/shopspring/decimal"
 	"github.com/urfave/cli/v2"
-)
-
-type CustomerSegmentMember struct {
-	Node Node `json:"node"`
-}
-
-type Node struct {
-	ID                  string         `json:"id"`
-	DisplayName         string         `json:"displayName"`
-	DefaultEmailAddress *DefaultEmail  `json:"defaultEmailAddress,omitempty"`
-	AmountSpent         MonetaryAmount `json:"amountSpent"`
-}
-
-type DefaultEmail struct {
-	EmailAddress string `json:"emailAddress"`
-}
 
-type MonetaryAmount struct {
-	Amount       decimal.Decimal `json:"amount"`
-	CurrencyCode string          `json:"currencyCode"`
-}
+	"sultans/shopify"
+)
 
-type GraphQLResponse struct {
-	Data struct {
-		CustomerSegmentMembers struct {
-			Edges []CustomerSegmentMember `json:"edges"`
-		} `json:"customerSegmentMembers"`
-	} `json:"data"`
-	Errors []GraphQLError `json:"errors,omitempty"`
-}
+//go:generate go run github.com/Khan/genqlient
 
 type GraphQLError struct {
 	Message string `json:"message"`
 }
 
-type GraphQLRequest struct {
-	Query     string                 `json:"query"`
-	Variables map[string]interface{} `json:"variables"`
-}
+// exitEmpty is the exit code for --fail-if-empty, distinct from the 1 of other failures.
+const exitEmpty = 3
 
-func main() {
-	// Load environment variables locally
-	_ = godotenv.Load()
+// exitPartial is the exit code for exports that succeeded with --allow-partial
+// but whose responses contained GraphQL errors.
+const exitPartial = 4
 
+// har records HTTP traffic when --har is set.
+var har *harRecorder
+
+func main() {
 	app := &cli.App{
 		Name:  "shopify-customers",
 		Usage: "Fetch Shopify customer segment members and export to CSV",
@@ -67,154 +40,757 @@ func main() {
 			&cli.IntFlag{Name: "first", Value: 50, Aliases: []string{"f"}, Usage: "Number of customers to fetch"},
 			&cli.StringFlag{Name: "sortKey", Value: "amount_spent", Aliases: []string{"s"}, Usage: "Sort key for results"},
 			&cli.BoolFlag{Name: "reverse", Value: true, Aliases: []string{"r"}, Usage: "Reverse sort order"},
-			&cli.StringFlag{Name: "output", Value: "customers.csv", Aliases: []string{"o"}, Usage: "Output CSV filename (leave empty for stdout)"},
+			&cli.StringFlag{Name: "output", Value: "customers.csv", Aliases: []string{"o", "sink"}, Usage: "Output CSV filename (leave empty or - for stdout, clipboard to copy it), destination URL (customerio://, braze://, segment://, sqs://, kinesis://, pubsub://, nats://, elasticsearch://, mongodb://, clickhouse://, snowflake://, redshift://, duckdb://, https://) or plugin:<command>"},
+			&cli.StringFlag{Name: "attribute-map", Usage: "Rename destination attributes, e.g. \"display_name=first_name,amount_spent=ltv\""},
+			&cli.IntFlag{Name: "batch-size", Value: 1, Usage: "Customers per message for queue, stream and webhook destinations"},
+			&cli.StringFlag{Name: "partition-key", Usage: "Customer field used as partition/ordering key for queue and stream destinations (id, email, display_name, currency_code)"},
+			&cli.StringFlag{Name: "message-attributes", Usage: "Static message attributes for queue destinations, e.g. \"source=shopify,feed=vip\""},
+			&cli.StringFlag{Name: "index-mapping", Usage: "JSON file with index mappings used when creating an Elasticsearch/OpenSearch index"},
+			&cli.BoolFlag{Name: "merge", Usage: "Merge into the destination table, deduplicating on customer ID, instead of appending"},
+			&cli.BoolFlag{Name: "mongo-raw", Usage: "Store the raw customer node in a \"raw\" field of each MongoDB document"},
+			&cli.StringSliceFlag{Name: "header", Usage: "Extra HTTP header for webhook destinations as \"Name: value\" (repeatable)"},
+			&cli.IntFlag{Name: "webhook-retries", Value: 3, Usage: "Retries for webhook requests failing with network errors, 429 or 5xx"},
+			&cli.BoolFlag{Name: "jetstream", Usage: "Publish to NATS through JetStream and wait for stream acknowledgements"},
+			&cli.BoolFlag{Name: "cache", Usage: "Serve identical GraphQL requests from an on-disk response cache"},
+			&cli.BoolFlag{Name: "no-cache", Usage: "Disable the response cache, overriding --cache"},
+			&cli.DurationFlag{Name: "cache-ttl", Value: 10 * time.Minute, Usage: "How long cached responses are reused before they are revalidated or fetched again"},
+			&cli.StringFlag{Name: "cache-dir", Usage: "Response cache directory (default: the user cache directory)"},
+			&cli.StringFlag{Name: "state-db", Usage: "Local database of previously exported customers, used to detect added, updated and removed customers"},
+			&cli.StringFlag{Name: "mode", Value: "snapshot", Usage: "snapshot exports every customer; delta only those added, updated or removed since the previous run, with a Change column"},
+			&cli.BoolFlag{Name: "delta", Usage: "Shorthand for --mode delta"},
+			&cli.StringFlag{Name: "removed-file", Usage: "Also write the customers of the previous run that are no longer in the segment to this CSV file (requires --state-db)"},
+			&cli.IntFlag{Name: "page-size", Value: maxPageSize, Usage: "Customers requested per GraphQL page (max 250)"},
+			&cli.IntFlag{Name: "prefetch", Value: 1, Usage: "Pages fetched ahead while earlier pages are being written"},
+			&cli.StringFlag{Name: "sample", Usage: "Export a reproducible random percentage of the segment, e.g. 10%"},
+			&cli.IntFlag{Name: "sample-n", Usage: "Export a reproducible random sample of this many customers"},
+			&cli.Int64Flag{Name: "seed", Usage: "Seed for --sample, --sample-n and --split-groups; the same seed selects the same customers"},
+			&cli.StringFlag{Name: "exclude-fields", Usage: "Comma-separated fields left out of exports: id, displayName, email, amountSpent, currencyCode, phone"},
+			&cli.StringFlag{Name: "hash-identifiers", Usage: "Replace the email and phone of exports with Email SHA256 and Phone SHA256 columns for ad platform uploads, leaving out the raw identifiers: sha256"},
+			&cli.BoolFlag{Name: "hash-strip-gmail-dots", Usage: "With --hash-identifiers, remove the periods of gmail.com and googlemail.com addresses before hashing"},
+			&cli.BoolFlag{Name: "hash-skip-consent", Usage: "With --hash-identifiers, also hash identifiers without SUBSCRIBED marketing consent"},
+			&cli.BoolFlag{Name: "no-pii", Usage: "Leave out direct identifiers (displayName, email, phone), for recipients of aggregate data"},
+			&cli.StringFlag{Name: "sort-by", Usage: "Sort the whole segment after fetching by id, email, display_name, amount_spent or currency_code"},
+			&cli.BoolFlag{Name: "desc", Usage: "Sort --sort-by in descending order"},
+			&cli.StringFlag{Name: "split-groups", Usage: "Assign customers to weighted groups by a hash of --seed and their ID, e.g. A:50,B:50, written to a Group column"},
+			&cli.BoolFlag{Name: "split-files", Usage: "With --split-groups, write one file per group (customers-A.csv) instead of a Group column"},
+			&cli.StringFlag{Name: "tiers", Usage: "Add a Tier column from amount spent ranges, e.g. \"0-100:bronze,100-1000:silver,1000+:gold\""},
+			&cli.StringFlag{Name: "partition-by", Usage: "Write one CSV file per partition: email-domain, country or hash:<n> (customers-US.csv, customers-0.csv, ...)"},
+			&cli.StringFlag{Name: "unicode-normalization", Value: "nfc", Usage: "Unicode normalization form of display names and emails: nfc, nfkc or none; control characters are stripped unless none"},
+			&cli.BoolFlag{Name: "validate-emails", Usage: "Trim and lowercase email addresses and export syntactically invalid ones as missing"},
+			&cli.StringFlag{Name: "email-rejects", Usage: "CSV file listing the customers whose email --validate-emails removed"},
+			&cli.StringFlag{Name: "validation-rules", Usage: "YAML or JSON file of per-column rules (required, pattern, min, max); customers breaking one are written to --rejects-file instead of exported"},
+			&cli.StringFlag{Name: "rejects-file", Value: "rejects.csv", Usage: "CSV file of the customers rejected by --validation-rules, with the reasons"},
+			&cli.BoolFlag{Name: "normalize-phones", Usage: "Rewrite phone numbers to E.164, using the default address country for national numbers, and export unparseable ones as missing"},
+			&cli.StringFlag{Name: "phone-rejects", Usage: "CSV file listing the customers whose phone number --normalize-phones removed"},
+			&cli.BoolFlag{Name: "strip-plus-addressing", Usage: "With --validate-emails, remove +tags from addresses (jane+news@example.com becomes jane@example.com)"},
+			&cli.StringFlag{Name: "null-as", Usage: "Text written for missing values in CSV output, such as NULL or N/A (default: empty string)"},
+			&cli.BoolFlag{Name: "allow-partial", Usage: "Export the data of responses that also contain GraphQL errors, report the failed paths and exit with code 4"},
+			&cli.StringFlag{Name: "report", Usage: "Also write a one-page summary report of the export: pdf"},
+			&cli.StringFlag{Name: "report-file", Usage: "Report filename (default: the --output name with a .pdf extension, or report.pdf)"},
+			&cli.StringFlag{Name: "domain-report", Usage: "Also write the customers and spend per email domain, classified as free or corporate, to this file (JSON for .json, CSV for .csv, otherwise text)"},
+			&cli.StringFlag{Name: "quality-report", Usage: "Also write per-column missing and duplicate rates, invalid emails and the currency mix with min/max spend to this file (JSON for .json, otherwise text)"},
+			&cli.BoolFlag{Name: "summary", Usage: "Print the customers, total and average spend per currency after the export"},
+			&cli.StringFlag{Name: "rates", Usage: "Exchange rates file or URL ({\"base\": \"USD\", \"rates\": {\"EUR\": 0.92}}) for a grand total and --tiers in the shop's primary currency"},
+			&cli.IntFlag{Name: "precision", Usage: "Decimals of amounts in all outputs (default: the currency's ISO 4217 decimals, e.g. 2 for USD, 0 for JPY, 3 for KWD)"},
+			&cli.StringFlag{Name: "rounding", Value: "half-up", Usage: "Rounding of amounts to --precision: half-up (away from zero) or half-even (banker's rounding)"},
+			&cli.BoolFlag{Name: "amount-minor-units", Usage: "Write amounts as integer minor units (cents) with a Currency Exponent column, instead of decimals"},
+			&cli.StringFlag{Name: "date-columns", Usage: "Add customer date columns to CSV exports: created_at, updated_at, first_order, last_order or all"},
+			&cli.StringFlag{Name: "suppress", Usage: "CSV file of email addresses or their SHA-256 hashes (do-not-contact list) whose customers are never exported"},
+			&cli.BoolFlag{Name: "consented-only", Usage: "Only export customers whose email marketing consent is SUBSCRIBED"},
+			&cli.BoolFlag{Name: "sms-consent", Usage: "With --consented-only, also require SMS marketing consent"},
+			&cli.StringFlag{Name: "state", Usage: "Only export customers whose account is in one of these states: ENABLED, DISABLED, INVITED, DECLINED (comma-separated)"},
+			&cli.BoolFlag{Name: "state-column", Usage: "Add the customer account State column to CSV exports"},
+			&cli.StringFlag{Name: "tags", Usage: "Add a Tags column to CSV exports: join (with --tag-separator), json (a JSON array) or explode (one row per tag)"},
+			&cli.StringFlag{Name: "tag-separator", Value: ";", Usage: "Separator of tags with --tags join, and of tax exemption reasons and merge blockers"},
+			&cli.BoolFlag{Name: "tax-columns", Usage: "Add Tax Exempt and Tax Exemptions columns to CSV exports, with the reasons joined by --tag-separator"},
+			&cli.BoolFlag{Name: "region-columns", Usage: "Add Country Code and Province Code columns with the default address normalized to ISO 3166 codes, and a Region column (NA, LATAM, EU, EMEA or APAC)"},
+			&cli.BoolFlag{Name: "statistics-columns", Usage: "Add Shopify's Predicted Spend Tier and RFM Group columns to CSV exports"},
+			&cli.StringFlag{Name: "orders", Usage: "Join the recent orders of each customer: rows (one row per order) or aggregate (Order Count, Order Total, Order Currency and Last Order At columns)"},
+			&cli.IntFlag{Name: "order-limit", Value: 10, Usage: "Most recent orders joined per customer with --orders"},
+			&cli.StringFlag{Name: "orders-since", Usage: "With --orders, only join orders processed since this date (2024-01-31) or RFC 3339 timestamp"},
+			&cli.StringFlag{Name: "join", Usage: "Local CSV file whose columns are left-joined onto CSV exports by --join-key"},
+			&cli.StringFlag{Name: "join-key", Value: "email", Usage: "Customer field matched with --join rows: id, email or phone, read from the file column of the same name or <field>=<column>"},
+			&cli.BoolFlag{Name: "duplicate-columns", Usage: "Add Mergeable, Merge Blockers and Possible Duplicate Of columns to CSV exports"},
+			&cli.StringFlag{Name: "transform-plugin", Usage: "Command that every page of customers is piped through as {\"customers\": [...]} JSON, returning the customers to export"},
+			&cli.StringFlag{Name: "transform-script", Usage: "WebAssembly (WASI) module that rewrites the rows of CSV exports, read and written as {\"columns\": [...], \"rows\": [...]} JSON"},
+			&cli.StringFlag{Name: "timezone", Usage: "IANA time zone of exported timestamps, e.g. Europe/Berlin (default: the shop's time zone)"},
+			&cli.StringFlag{Name: "date-format", Value: "rfc3339", Usage: "Format of exported timestamps: rfc3339, date, datetime, unix or a Go layout such as \"02.01.2006 15:04\""},
+			&cli.StringFlag{Name: "number-locale", Usage: "Locale of amounts in summaries and reports, e.g. de-DE for 1.234,50 (CSV and destinations keep plain decimals)"},
+			&cli.BoolFlag{Name: "currency-symbols", Usage: "Show currency symbols (€, $) in front of amounts in summaries and reports"},
+			&cli.StringFlag{Name: "primary-currency", Usage: "Currency that --rates convert amounts into for the grand total and --tiers (default: the shop's primary currency)"},
+			&cli.BoolFlag{Name: "fail-if-empty", Usage: "Exit with code 3, leaving outputs untouched, when the query returns no customers"},
+			&cli.StringFlag{Name: "queries-file", Usage: "CSV file of \"output,query\" rows; every segment is exported concurrently to its own output"},
+			&cli.IntFlag{Name: "concurrency", Value: 4, Usage: "Segments exported in parallel with --queries-file"},
+			&cli.Float64Flag{Name: "max-rps", Usage: "Maximum Shopify API requests per second, shared by all concurrent exports (0 for no limit)"},
+			&cli.StringFlag{Name: "lock-file", Usage: "Lock held for the whole run, so overlapping invocations fail instead of running concurrently"},
+			&cli.BoolFlag{Name: "no-lock", Usage: "Do not lock file outputs with \"<output>.lock\""},
+			&cli.IntFlag{Name: "keep-last", Usage: "After a successful export to an --output with a {date} or {time} placeholder, remove all but the newest N of its files"},
+			&cli.StringFlag{Name: "keep-for", Usage: "After a successful export to an --output with a {date} or {time} placeholder, remove its files older than this, e.g. 30d"},
+			&cli.BoolFlag{Name: "encrypt-stores", Usage: "Encrypt the state database, response cache, checkpoint journals and --record fixtures with AES-256-GCM, using the key in $SHOPIFY_CUSTOMERS_STORE_KEY or the OS keychain"},
+			&cli.StringFlag{Name: "journal", Usage: "Checkpoint journal recording progress after every page, for \"resume\" after a crash"},
+			&cli.StringFlag{Name: "history-file", Usage: "Run history file (default: history.jsonl in the user cache directory)"},
+			&cli.BoolFlag{Name: "no-history", Usage: "Do not record this run in the history"},
+			&cli.StringFlag{Name: "pre-hook", Usage: "Shell command run before each export; a non-zero exit aborts it"},
+			&cli.StringFlag{Name: "post-hook", Usage: "Shell command run after each export with HOOK_STATUS, HOOK_ROWS, HOOK_OUTPUT and more set; a non-zero exit fails the run"},
+			&cli.DurationFlag{Name: "timeout", Value: 5 * time.Second, Usage: "Maximum run time of each export, including resumed exports and --queries-file segments, and of the Shopify requests of other commands (0 for no limit)"},
+			&cli.DurationFlag{Name: "hook-timeout", Value: 5 * time.Minute, Usage: "Maximum run time of --pre-hook and --post-hook"},
+			&cli.StringFlag{Name: "audit-log", Usage: "Audit log of changes made by write commands (default: audit.jsonl in the user cache directory)"},
+			&cli.StringFlag{Name: "secret-backend", Usage: "Fetch the access token from vault://<path>[#key], awssm://<name>[#key] or ssm://<name> instead of the environment"},
+			&cli.DurationFlag{Name: "secret-ttl", Value: 15 * time.Minute, Usage: "How long a --secret-backend token is cached before it is fetched again (0 to never renew)"},
+			&cli.StringFlag{Name: "token-file", Usage: "Read the Admin API access token from this file, such as a mounted secret, instead of SHOPIFY_ACCESS_TOKEN"},
+			&cli.StringFlag{Name: "record", Usage: "Record Shopify API interactions as fixtures in this directory"},
+			&cli.StringFlag{Name: "replay", Usage: "Replay Shopify API interactions from fixtures in this directory instead of calling Shopify"},
+			&cli.IntFlag{Name: "max-retries", Value: 2, Usage: "Retries for failed Shopify API requests"},
+			&cli.DurationFlag{Name: "retry-backoff", Value: 500 * time.Millisecond, Usage: "Initial wait between Shopify API retries, doubled on every attempt"},
+			&cli.DurationFlag{Name: "retry-max-wait", Value: 5 * time.Second, Usage: "Maximum wait between Shopify API retries, including Retry-After"},
+			&cli.StringFlag{Name: "retry-on", Value: shopify.DefaultRetryOn, Usage: "Comma-separated failures to retry: HTTP status codes, network, timeout, THROTTLED"},
+			&cli.IntFlag{Name: "breaker-threshold", Value: 5, Usage: "Consecutive Shopify API failures after which requests fail fast (0 to disable)"},
+			&cli.DurationFlag{Name: "breaker-cooldown", Value: 30 * time.Second, Usage: "How long requests fail fast before the Shopify API is tried again"},
+			&cli.StringSliceFlag{Name: "env-file", Usage: "Load environment variables from this file, later files overriding earlier ones (repeatable, default: .env if it exists)"},
+			&cli.BoolFlag{Name: "no-env-file", Usage: "Do not load any env file, not even .env in the working directory"},
+			&cli.BoolFlag{Name: "redact-emails", Usage: "Also replace email addresses in logs, errors and --har captures with REDACTED@<domain>; credentials are always redacted"},
+			&cli.StringFlag{Name: "har", Usage: "Write all HTTP requests and responses to this HAR file, with credentials redacted"},
+			&cli.StringFlag{Name: "log-file", Usage: "Write log messages to this file instead of stderr, rotated by --log-max-size and --log-rotate-every"},
+			&cli.IntFlag{Name: "log-max-size", Value: 100, Usage: "Size in megabytes at which --log-file is rotated (0 for no limit)"},
+			&cli.StringFlag{Name: "log-rotate-every", Usage: "Also rotate --log-file when it was opened this long ago, e.g. 1d"},
+			&cli.IntFlag{Name: "log-max-backups", Value: 7, Usage: "Rotated --log-file backups kept (0 to keep all)"},
+			&cli.StringFlag{Name: "log-max-age", Usage: "Remove rotated --log-file backups older than this, e.g. 30d"},
+			&cli.BoolFlag{Name: "log-compress", Usage: "Compress rotated --log-file backups with gzip"},
+			&cli.StringFlag{Name: "user-agent", Usage: "User-Agent of requests to Shopify (default \"shopify-customers\")"},
+			&cli.StringSliceFlag{Name: "shopify-header", Usage: "Extra HTTP header for requests to Shopify as \"Name: value\", such as partner attribution headers (repeatable)"},
+			&cli.StringFlag{Name: "tls-min-version", Value: "1.2", Usage: "Minimum TLS version of HTTPS connections: 1.2 or 1.3"},
+			&cli.BoolFlag{Name: "fips", Usage: "Restrict HTTPS connections to TLS 1.2 with FIPS 140 approved cipher suites and curves"},
+		},
+		Before: func(c *cli.Context) error {
+			if err := loadEnvFiles(c); err != nil {
+				return err
+			}
+			if err := applyEnvFileVars(c); err != nil {
+				return err
+			}
+			if err := configureLogFile(c); err != nil {
+				return err
+			}
+			if err := configureTLS(c); err != nil {
+				return err
+			}
+			if c.String("output") == stdoutOutput {
+				if err := c.Set("output", ""); err != nil {
+					return err
+				}
+			}
+			if err := expandOutput(c); err != nil {
+				return err
+			}
+			if c.String("har") != "" {
+				har = newHARRecorder(httpClient.Transport)
+				httpClient.Transport = har
+			}
+			return configureExport(c)
+		},
+		After: func(c *cli.Context) error {
+			if logFile != nil {
+				logFile.wait()
+			}
+			if suppression != nil {
+				suppression.logSuppressed()
+			}
+			if emails != nil {
+				if err := emails.Close(); err != nil {
+					return err
+				}
+			}
+			if phones != nil {
+				if err := phones.Close(); err != nil {
+					return err
+				}
+			}
+			if validator != nil {
+				if err := validator.Close(); err != nil {
+					return err
+				}
+			}
+			if har != nil {
+				return har.writeFile(c.String("har"))
+			}
+			return nil
 		},
 		Action: func(c *cli.Context) error {
-			// Set a global 5-second timeout
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if file := c.String("queries-file"); file != "" {
+				return exportQueriesFile(c, file)
+			}
+			ctx, cancel := exportContext(c.Duration("timeout"))
 			defer cancel()
 			return fetchAndExportCustomers(ctx, c)
 		},
+		Commands: []*cli.Command{
+			audiencesCommand(),
+			serveCommand(),
+			schemaCommand(),
+			mockServerCommand(),
+			resumeCommand(),
+			historyCommand(),
+			graphqlCommand(),
+			restCommand(),
+			multipassCommand(),
+			reportCommand(),
+			replCommand(),
+			costCommand(),
+			limitsCommand(),
+			backfillCommand(),
+		},
 	}
 
+	bindEnvVars(app)
+	log.SetOutput(redactingWriter{os.Stderr})
 	if err := app.Run(os.Args); err != nil {
+		if errors.Is(err, errEmptySegment) {
+			log.Print(err)
+			os.Exit(exitEmpty)
+		}
+		if errors.Is(err, errPartialData) {
+			log.Print(err)
+			os.Exit(exitPartial)
+		}
+		var reqErr *shopify.RequestError
+		if errors.As(err, &reqErr) {
+			log.Printf("Shopify request ID: %s (include it when contacting Shopify support)", reqErr.RequestID)
+		}
 		log.Fatal(err)
 	}
 }
 
-func fetchAndExportCustomers(ctx context.Context, c *cli.Context) error {
-	shopifyDomain := os.Getenv("SHOPIFY_DOMAIN")
-	accessToken := os.Getenv("SHOPIFY_ACCESS_TOKEN")
-	if shopifyDomain == "" || accessToken == "" {
-		return fmt.Errorf("SHOPIFY_DOMAIN and SHOPIFY_ACCESS_TOKEN must be set")
-	}
-
-	variables := map[string]interface{}{
-		"first":   c.Int("first"),
-		"query":   c.String("query"),
-		"sortKey": c.String("sortKey"),
-		"reverse": c.Bool("reverse"),
-	}
-
-	query := `
-	query GetCustomerSegmentMembers($first: Int!, $query: String!, $sortKey: String, $reverse: Boolean!) {
-		customerSegmentMembers(first: $first, query: $query, sortKey: $sortKey, reverse: $reverse) {
-			edges {
-				node {
-					id
-					displayName
-					defaultEmailAddress {
-						emailAddress
-					}
-					amountSpent {
-						amount
-						currencyCode
-					}
-				}
+// configureExport sets up the processing of fetched customers and the shape of
+// CSV output from the root flags.
+func configureExport(c *cli.Context) error {
+	// Piped output defaults to stdout, so `shopify-customers | other-tool` works
+	// without --output "".
+	if !c.IsSet("output") && stdoutIsPipe() {
+		if err := c.Set("output", ""); err != nil {
+			return err
+		}
+	}
+
+	redaction.setEmails(c.Bool("redact-emails"))
+	nullValue = c.String("null-as")
+	var err error
+	if excludedFields, err = parseExcludedFields(c.String("exclude-fields")); err != nil {
+		return err
+	}
+	if c.Bool("encrypt-stores") {
+		if stores, err = loadStoreCipher(); err != nil {
+			return err
+		}
+	}
+	if identifierHashes, err = newIdentifierHasher(c); err != nil {
+		return err
+	}
+	// Hashed exports leave out the raw identifiers, like --no-pii.
+	if c.Bool("no-pii") || identifierHashes != nil {
+		for _, field := range piiFields {
+			excludedFields[field] = true
+		}
+	}
+	if identifierHashes != nil {
+		extraColumns = append(extraColumns, identifierHashes.columns()...)
+	}
+	if minorUnits = c.Bool("amount-minor-units"); minorUnits && !excludedFields["amountSpent"] {
+		extraColumns = append(extraColumns, column{Header: "Currency Exponent", Value: func(c CustomerSegmentMember) string {
+			return fmt.Sprint(currencyExponent(string(c.Node.AmountSpent.CurrencyCode)))
+		}})
+	}
+	if texts, err = newTextNormalizer(c.String("unicode-normalization")); err != nil {
+		return err
+	}
+	if sampler, err = newCustomerSampler(c); err != nil {
+		return err
+	}
+	if field := c.String("sort-by"); field != "" {
+		if c.String("journal") != "" {
+			return fmt.Errorf("--sort-by cannot be combined with --journal")
+		}
+		if sorter, err = newCustomerSorter(field, c.Bool("desc")); err != nil {
+			return err
+		}
+	}
+	if spec := c.String("date-columns"); spec != "" {
+		lifecycle = &lifecycleDates{}
+		columns, err := parseDateColumns(spec)
+		if err != nil {
+			return err
+		}
+		extraColumns = append(extraColumns, columns...)
+	}
+	if path := c.String("suppress"); path != "" {
+		if suppression, err = loadSuppressionList(path); err != nil {
+			return err
+		}
+	}
+	if c.Bool("consented-only") {
+		consent = &consentFilter{sms: c.Bool("sms-consent")}
+	} else if c.Bool("sms-consent") {
+		return fmt.Errorf("--sms-consent requires --consented-only")
+	}
+	if c.String("state") != "" || c.Bool("state-column") {
+		if accountStates, err = newStateLookup(c.String("state")); err != nil {
+			return err
+		}
+		if c.Bool("state-column") {
+			extraColumns = append(extraColumns, accountStates.column())
+		}
+	}
+	if mode := c.String("tags"); mode != "" {
+		customerTags = &tagLookup{}
+		col, err := tagsColumn(mode, c.String("tag-separator"))
+		if err != nil {
+			return err
+		}
+		extraColumns = append(extraColumns, col)
+	}
+	if c.Bool("tax-columns") {
+		taxExemptions = &taxLookup{}
+		extraColumns = append(extraColumns, taxExemptions.columns(c.String("tag-separator"))...)
+	}
+	if c.Bool("region-columns") {
+		extraColumns = append(extraColumns, regionColumns()...)
+	}
+	if c.Bool("statistics-columns") {
+		customerStatistics = &statisticsLookup{}
+		extraColumns = append(extraColumns, customerStatistics.columns()...)
+	}
+	if mode := c.String("orders"); mode != "" {
+		if customerOrders, err = newOrderLookup(c.Int("order-limit"), c.String("orders-since")); err != nil {
+			return err
+		}
+		cols, err := ordersColumns(mode)
+		if err != nil {
+			return err
+		}
+		extraColumns = append(extraColumns, cols...)
+	}
+	if c.Bool("duplicate-columns") {
+		duplicates = &duplicateLookup{separator: c.String("tag-separator")}
+		extraColumns = append(extraColumns, duplicates.columns()...)
+	}
+	if path := c.String("transform-script"); path != "" {
+		if rowScript, err = newWASMScript(context.Background(), path); err != nil {
+			return err
+		}
+	}
+	if command := c.String("transform-plugin"); command != "" {
+		if transformPlugin, err = newExecPlugin(command); err != nil {
+			return err
+		}
+	}
+	if path := c.String("join"); path != "" {
+		columns, err := joinColumns(path, c.String("join-key"), outputHeader())
+		if err != nil {
+			return err
+		}
+		extraColumns = append(extraColumns, columns...)
+	} else if c.IsSet("join-key") {
+		return fmt.Errorf("--join-key requires --join")
+	}
+	if spec := c.String("split-groups"); spec != "" {
+		groups, err := parseSplitGroups(spec, c.Int64("seed"))
+		if err != nil {
+			return err
+		}
+		if c.Bool("split-files") {
+			partitionKey = groups.assign
+		} else {
+			extraColumns = append(extraColumns, column{Header: "Group", Value: groups.assign})
+		}
+	} else if c.Bool("split-files") {
+		return fmt.Errorf("--split-files requires --split-groups")
+	}
+	if err := configureRates(c); err != nil {
+		return err
+	}
+	if spec := c.String("tiers"); spec != "" {
+		tiers, err := parseTiers(spec)
+		if err != nil {
+			return err
+		}
+		extraColumns = append(extraColumns, column{Header: "Tier", Value: func(c CustomerSegmentMember) string {
+			if tier := tierOf(tiers, c); tier != "" {
+				return tier
 			}
+			return nullValue
+		}})
+	}
+	if by := c.String("partition-by"); by != "" {
+		if partitionKey != nil {
+			return fmt.Errorf("--partition-by cannot be combined with --split-files")
+		}
+		if partitionKey, err = parsePartitionBy(by); err != nil {
+			return err
 		}
-	}`
+	}
+	precision := -1
+	if c.IsSet("precision") {
+		precision = c.Int("precision")
+	}
+	if err := setAmountRounding(precision, c.String("rounding")); err != nil {
+		return err
+	}
+	if dateLayout, err = parseDateFormat(c.String("date-format")); err != nil {
+		return err
+	}
+	if name := c.String("timezone"); name != "" {
+		if dateLocation, err = time.LoadLocation(name); err != nil {
+			return fmt.Errorf("invalid --timezone %q: %w", name, err)
+		}
+	}
+	if humanAmounts, err = newAmountFormatter(c.String("number-locale"), c.Bool("currency-symbols")); err != nil {
+		return err
+	}
+	if report, err = newExportReport(c); err != nil {
+		return err
+	}
+	switch mode := c.String("mode"); {
+	case mode != "snapshot" && mode != "delta":
+		return fmt.Errorf("invalid --mode %q, expected snapshot or delta", mode)
+	case mode == "snapshot" && c.IsSet("mode") && c.Bool("delta"):
+		return fmt.Errorf("--delta cannot be combined with --mode snapshot")
+	}
+	if validator, err = newRowValidator(c); err != nil {
+		return err
+	}
+	if quality, err = newQualityReport(c); err != nil {
+		return err
+	}
+	if domains, err = newDomainReport(c); err != nil {
+		return err
+	}
+	if emails, err = newEmailValidator(c); err != nil {
+		return err
+	}
+	phones, err = newPhoneValidator(c)
+	return err
+}
 
-	resp, err := executeGraphQLQuery(ctx, shopifyDomain, accessToken, GraphQLRequest{
-		Query:     query,
-		Variables: variables,
-	})
-	if err != nil {
-		return fmt.Errorf("GraphQL query failed: %w", err)
+func fetchAndExportCustomers(ctx context.Context, c *cli.Context) error {
+	if err := resolvePrimaryCurrency(ctx, c); err != nil {
+		return err
+	}
+	if lifecycle != nil || customerOrders != nil {
+		if err := resolveDateLocation(ctx, c); err != nil {
+			return err
+		}
+	}
+	run := startRun("export", segmentQueryFromFlags(c), c.String("output"))
+	p := newPipeline()
+	exported, err := 0, runHook(c, "pre-hook", run)
+	if err == nil {
+		exported, err = exportFromFlags(ctx, c, p)
+	}
+	run = recordRun(c, run, exported, err)
+	if err != nil && !errors.Is(err, errPartialData) {
+		return runPostHook(c, run, err)
 	}
+	output := c.String("output")
+	fmt.Fprintf(statusOutput(output), "Successfully exported %d customers to %s\n", exported, outputName(output))
+	if p.report != nil && p.report.summary {
+		if err := p.report.writeSummary(statusOutput(output)); err != nil {
+			return err
+		}
+	}
+	if p.report != nil && p.report.path != "" {
+		if err := p.report.write(); err != nil {
+			return err
+		}
+		fmt.Fprintf(statusOutput(output), "Report written to %s\n", p.report.path)
+	}
+	if p.quality != nil {
+		if err := p.quality.write(); err != nil {
+			return err
+		}
+		fmt.Fprintf(statusOutput(output), "Quality report written to %s\n", p.quality.path)
+	}
+	if p.domains != nil {
+		if err := p.domains.write(); err != nil {
+			return err
+		}
+		fmt.Fprintf(statusOutput(output), "Domain report written to %s\n", p.domains.path)
+	}
+	if err == nil {
+		pruneExports(c, output)
+	}
+	return runPostHook(c, run, err)
+}
 
-	if len(resp.Errors) > 0 {
-		return fmt.Errorf("GraphQL errors: %v", resp.Errors)
+// stdoutIsPipe reports whether stdout is connected to a pipe.
+func stdoutIsPipe() bool {
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeNamedPipe != 0
+}
+
+// statusOutput returns where summary lines of an export to output are printed:
+// stderr when the data itself goes to stdout, so it is not mixed into the data.
+func statusOutput(output string) io.Writer {
+	if output == "" {
+		return os.Stderr
 	}
+	return os.Stdout
+}
 
-	if err := exportToCSV(ctx, resp.Data.CustomerSegmentMembers.Edges, c.String("output")); err != nil {
-		return fmt.Errorf("failed to export CSV: %w", err)
+// outputName describes an output in summary lines.
+func outputName(output string) string {
+	if output == "" {
+		return "stdout"
 	}
+	return output
+}
 
-	fmt.Printf("Successfully exported %d customers to %s\n", len(resp.Data.CustomerSegmentMembers.Edges), c.String("output"))
-	return nil
+// exportContext returns the context of an export, cancelled after timeout
+// unless it is 0.
+func exportContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	return timeoutContext(context.Background(), timeout)
 }
 
-func executeGraphQLQuery(ctx context.Context, domain, accessToken string, request GraphQLRequest) (*GraphQLResponse, error) {
-	url := fmt.Sprintf("https://%s/admin/api/2025-01/graphql.json", domain)
+// timeoutContext returns a context cancelled after timeout, unless it is 0,
+// whose cause is a shopify.ErrTimeout naming it, as requests failing on the
+// deadline report.
+func timeoutContext(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeoutCause(parent, timeout, fmt.Errorf("%w after %s", shopify.ErrTimeout, timeout))
+}
+
+// exportFromFlags runs the export described by the root flags, collecting the
+// reports of p.
+func exportFromFlags(ctx context.Context, c *cli.Context, p *pipeline) (int, error) {
+	client, err := newShopifyClient(c)
+	if err != nil {
+		return 0, err
+	}
+	state, err := openStateDBFromFlags(c)
+	if err != nil {
+		return 0, err
+	}
+	if state != nil {
+		defer state.Close()
+	}
 
-	body, err := json.Marshal(request)
+	q, output := segmentQueryFromFlags(c), c.String("output")
+	lock, err := acquireRunLock(c, output)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return 0, err
+	}
+	defer lock.release()
+
+	if path := c.String("journal"); path != "" {
+		if state != nil {
+			return 0, fmt.Errorf("--journal cannot be combined with --state-db")
+		}
+		if output == "" || output == clipboardOutput {
+			return 0, fmt.Errorf("--journal requires --output to be a file or destination")
+		}
+		if partitionKey != nil {
+			return 0, fmt.Errorf("--journal cannot be combined with partitioned exports")
+		}
+		j, err := createJournal(path, q, output)
+		if err != nil {
+			return 0, err
+		}
+		defer j.Close()
+		return streamSegment(ctx, c, client, q, output, j, p)
+	}
+	return exportSegment(ctx, c, client, state, q, output, p)
+}
+
+// openStateDBFromFlags opens the --state-db database, or the default one in delta
+// mode, returning nil when neither applies.
+func openStateDBFromFlags(c *cli.Context) (*stateDB, error) {
+	path := c.String("state-db")
+	if path == "" && deltaMode(c) {
+		var err error
+		if path, err = defaultStateDBPath(); err != nil {
+			return nil, err
+		}
+	}
+	if path == "" {
+		if c.String("removed-file") != "" {
+			return nil, fmt.Errorf("--removed-file requires --state-db")
+		}
+		return nil, nil
+	}
+	if c.String("removed-file") != "" && c.String("queries-file") != "" {
+		return nil, fmt.Errorf("--removed-file cannot be combined with --queries-file")
+	}
+	return openStateDB(path)
+}
+
+// exportSegment fetches the members of one segment and writes them to output, a CSV
+// filename or destination URL, collecting the reports of p. It returns the number
+// of customers exported.
+func exportSegment(ctx context.Context, c *cli.Context, client *shopify.Client, state *stateDB, q SegmentQuery, output string, p *pipeline) (int, error) {
+	if err := partitionedOutputCheck(output); err != nil {
+		return 0, err
+	}
+	// Change detection needs the whole segment; otherwise pages are written as they arrive.
+	if state == nil {
+		return streamSegment(ctx, c, client, q, output, nil, p)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	customers, err := fetchSegmentMembers(ctx, client, q, p)
+	partialErr := err
+	if err != nil && !errors.Is(err, errPartialData) {
+		return 0, err
+	}
+
+	// Checked before the state is touched, so an empty result is not recorded as
+	// every customer being removed.
+	if len(customers) == 0 && c.Bool("fail-if-empty") {
+		return 0, errEmptySegment
+	}
+
+	changes, err := state.diff(client.Domain, q.Query, customers)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return 0, err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Shopify-Access-Token", accessToken)
 
-	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Do(req)
+	sink, err := newSink(c, output)
 	if err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			return nil, fmt.Errorf("operation timed out after 5 seconds")
+		return 0, err
+	}
+	exported := len(customers)
+	if sink != nil {
+		// Sinks have no delete semantics, so removed customers are only reported in CSV exports.
+		if deltaMode(c) {
+			customers = changedCustomers(changes)
+			exported = len(customers)
+		}
+		if err := sink.Write(ctx, customers); err != nil {
+			return 0, fmt.Errorf("failed to export to %s: %w", output, err)
+		}
+	} else {
+		rows := changes
+		if deltaMode(c) {
+			rows = deltaChanges(changes)
+		}
+		exported = len(rows)
+		if err := exportChangesToCSV(ctx, rows, output); err != nil {
+			return 0, fmt.Errorf("failed to export CSV: %w", err)
 		}
-		return nil, fmt.Errorf("HTTP request failed: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		b, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(b))
+	if path := c.String("removed-file"); path != "" {
+		if err := writeRemovedCSV(changes, path); err != nil {
+			return 0, fmt.Errorf("failed to write removed customers: %w", err)
+		}
 	}
 
-	var graphqlResp GraphQLResponse
-	if err := json.NewDecoder(resp.Body).Decode(&graphqlResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	// The state only advances once the export succeeded, so failed runs are retried as the same delta.
+	if err := state.save(client.Domain, q.Query, currentCustomers(changes)); err != nil {
+		return 0, err
 	}
+	return exported, partialErr
+}
 
-	return &graphqlResp, nil
+// SegmentQuery selects the customer segment members to fetch.
+type SegmentQuery struct {
+	Query        string
+	First        int
+	SortKey      string
+	Reverse      bool
+	PageSize     int
+	AllowPartial bool
+	// After is the cursor to start after, used when resuming an export.
+	After string `json:",omitempty"`
 }
 
-func exportToCSV(ctx context.Context, customers []CustomerSegmentMember, filename string) error {
-	var writer *csv.Writer
-	var file *os.File
-	var err error
+// segmentQueryFromFlags builds the SegmentQuery from the root command flags.
+func segmentQueryFromFlags(c *cli.Context) SegmentQuery {
+	return SegmentQuery{
+		Query:        c.String("query"),
+		First:        c.Int("first"),
+		SortKey:      c.String("sortKey"),
+		Reverse:      c.Bool("reverse"),
+		PageSize:     c.Int("page-size"),
+		AllowPartial: c.Bool("allow-partial"),
+	}
+}
 
-	if filename == "" {
-		writer = csv.NewWriter(os.Stdout)
-	} else {
-		file, err = os.Create(filename)
-		if err != nil {
-			return err
+func fetchCustomers(ctx context.Context, c *cli.Context) ([]CustomerSegmentMember, error) {
+	client, err := newShopifyClient(c)
+	if err != nil {
+		return nil, err
+	}
+	return fetchSegmentMembers(ctx, client, segmentQueryFromFlags(c), nil)
+}
+
+// fetchSegmentMembers fetches all pages of the segment. With q.AllowPartial, the
+// customers are returned together with an errPartialData error if some fields failed.
+func fetchSegmentMembers(ctx context.Context, client *shopify.Client, q SegmentQuery, p *pipeline) ([]CustomerSegmentMember, error) {
+	stream := fetchSegmentStream(ctx, client, q, 0, p)
+	var customers []CustomerSegmentMember
+	for item := range stream.Items {
+		if !item.EndOfPage {
+			customers = append(customers, item.Customer)
 		}
-		defer file.Close()
-		writer = csv.NewWriter(file)
 	}
-	defer writer.Flush()
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+	return customers, stream.partialErr()
+}
 
-	header := []string{"ID", "Display Name", "Email Address", "Amount Spent", "Currency Code"}
-	if err := writer.Write(header); err != nil {
+func exportToCSV(ctx context.Context, customers []CustomerSegmentMember, filename string) error {
+	if filename == "" {
+		return writeCSV(ctx, customers, os.Stdout)
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := writeCSV(ctx, customers, file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+var csvHeader = []string{"ID", "Display Name", "Email Address", "Amount Spent", "Currency Code"}
+
+// writeCSV writes the outputHeader columns of customers, as staged for the
+// warehouse destinations.
+func writeCSV(ctx context.Context, customers []CustomerSegmentMember, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(outputHeader()); err != nil {
 		return err
 	}
 
 	for _, c := range customers {
-		select {
-		case <-ctx.Done():
+		if ctx.Err() != nil {
 			return fmt.Errorf("operation timed out during CSV export")
-		default:
-			email := ""
-			if c.Node.DefaultEmailAddress != nil {
-				email = c.Node.DefaultEmailAddress.EmailAddress
-			}
-			record := []string{
-				c.Node.ID,
-				c.Node.DisplayName,
-				email,
-				c.Node.AmountSpent.Amount.StringFixed(2),
-				c.Node.AmountSpent.CurrencyCode,
-			}
+		}
+		for _, record := range outputRecords(c) {
 			if err := writer.Write(record); err != nil {
 				return err
 			}
 		}
 	}
-	return nil
+	writer.Flush()
+	return writer.Error()
+}
+
+// nullValue is written in CSV output for missing values, set by --null-as.
+var nullValue string
+
+func csvRecord(c CustomerSegmentMember) []string {
+	email := nullValue
+	if c.Node.DefaultEmailAddress != nil {
+		email = c.Node.DefaultEmailAddress.EmailAddress
+	}
+	return []string{
+		c.Node.Id,
+		c.Node.DisplayName,
+		email,
+		formatAmount(c.Node.AmountSpent.Amount, string(c.Node.AmountSpent.CurrencyCode)),
+		string(c.Node.AmountSpent.CurrencyCode),
+	}
 }
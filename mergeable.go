@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// duplicates finds the likely duplicates of exported customers for
+// --duplicate-columns, nil without it. Their merge status is selected with the
+// segment members.
+var duplicates *duplicateLookup
+
+type duplicateLookup struct {
+	separator string
+}
+
+// columns returns the Mergeable and Merge Blockers columns from Shopify, and the
+// Possible Duplicate Of column, such as "gid://shopify/CustomerSegmentMember/1 (email)".
+func (l *duplicateLookup) columns() []column {
+	return []column{
+		{Header: "Mergeable", Value: func(c CustomerSegmentMember) string {
+			if m := c.Node.Mergeable; m != nil {
+				return strconv.FormatBool(m.IsMergeable)
+			}
+			return nullValue
+		}},
+		{Header: "Merge Blockers", Value: func(c CustomerSegmentMember) string {
+			m := c.Node.Mergeable
+			if m == nil {
+				return nullValue
+			}
+			blockers := make([]string, len(m.ErrorFields))
+			for i, f := range m.ErrorFields {
+				blockers[i] = string(f)
+			}
+			return strings.Join(blockers, l.separator)
+		}},
+		{Header: "Possible Duplicate Of", Value: func(c CustomerSegmentMember) string { return c.lookedUp().duplicateOf }},
+	}
+}
+
+// indexDuplicate records the email and phone number of c in firstSeen, which
+// maps them to the first customer of the export that has them, and sets the
+// first earlier customer sharing one of them as its duplicate. Fields removed by
+// --exclude-fields are not compared.
+func indexDuplicate(firstSeen map[string]string, c CustomerSegmentMember) {
+	var keys []string
+	if e := c.Node.DefaultEmailAddress; e != nil && normalizeEmail(e.EmailAddress) != "" {
+		keys = append(keys, "email:"+normalizeEmail(e.EmailAddress))
+	}
+	if p := c.Node.DefaultPhoneNumber; p != nil && normalizePhone(p.PhoneNumber) != "" {
+		keys = append(keys, "phone:"+normalizePhone(p.PhoneNumber))
+	}
+	for _, key := range keys {
+		first, ok := firstSeen[key]
+		if !ok {
+			firstSeen[key] = c.Node.Id
+			continue
+		}
+		if first != c.Node.Id && c.fields.duplicateOf == "" {
+			kind, _, _ := strings.Cut(key, ":")
+			c.fields.duplicateOf = fmt.Sprintf("%s (%s)", first, kind)
+		}
+	}
+}
+
+// wrap indexes every page of in before delivering it. Each stream has its own
+// index, so concurrent exports only compare their own customers.
+func (l *duplicateLookup) wrap(ctx context.Context, in *segmentStream) *segmentStream {
+	firstSeen := map[string]string{}
+	return pageStream(ctx, in, func(customers []CustomerSegmentMember) error {
+		for _, c := range customers {
+			indexDuplicate(firstSeen, c)
+		}
+		return nil
+	})
+}
+
+// MarshalJSON leaves out the merge status when it was not selected, so stored
+// and exported customers only have it with --duplicate-columns.
+func (n Node) MarshalJSON() ([]byte, error) {
+	type node Node
+	return json.Marshal(struct {
+		node
+		Mergeable *MergeableStatus `json:"mergeable,omitempty"`
+	}{node(n), n.Mergeable})
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// messageOptions configures how customers are published to queue and stream sinks.
+type messageOptions struct {
+	// BatchSize is the number of customers per message. With 1 each message is a
+	// single customer node; otherwise it is a JSON array of nodes.
+	BatchSize int
+	// PartitionKey names the customer field used as the partition or ordering key.
+	PartitionKey string
+	// Attributes are static message attributes added to every message.
+	Attributes map[string]string
+}
+
+// queueMessage is a single message body and its partition key.
+type queueMessage struct {
+	Body []byte
+	Key  string
+}
+
+// buildMessages encodes customers into messages. The key of a batched message is
+// the key of its first customer.
+func buildMessages(customers []CustomerSegmentMember, opts messageOptions) ([]queueMessage, error) {
+	batchSize := max(opts.BatchSize, 1)
+
+	var messages []queueMessage
+	for start := 0; start < len(customers); start += batchSize {
+		end := min(start+batchSize, len(customers))
+
+		var body []byte
+		var err error
+		if batchSize == 1 {
+			body, err = json.Marshal(customers[start].Node)
+		} else {
+			nodes := make([]Node, 0, end-start)
+			for _, c := range customers[start:end] {
+				nodes = append(nodes, c.Node)
+			}
+			body, err = json.Marshal(nodes)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode message: %w", err)
+		}
+
+		key, err := customerField(customers[start], opts.PartitionKey)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, queueMessage{Body: body, Key: key})
+	}
+	return messages, nil
+}
+
+// customerField returns the value of a customer field by its attribute name
+// ("id", "email", "display_name", "currency_code"), or "" for an empty name.
+func customerField(c CustomerSegmentMember, name string) (string, error) {
+	switch name {
+	case "":
+		return "", nil
+	case "id":
+		return c.Node.Id, nil
+	case "display_name":
+		return c.Node.DisplayName, nil
+	case "email":
+		if c.Node.DefaultEmailAddress == nil {
+			return "", nil
+		}
+		return c.Node.DefaultEmailAddress.EmailAddress, nil
+	case "currency_code":
+		return string(c.Node.AmountSpent.CurrencyCode), nil
+	default:
+		return "", fmt.Errorf("unknown customer field %q", name)
+	}
+}
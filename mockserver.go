@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/urfave/cli/v2"
+
+	"sultans/shopify"
+)
+
+func mockServerCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "mock-server",
+		Usage: "Serve a fake Admin GraphQL endpoint with synthetic customers",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "addr", Value: ":8081", Usage: "Address to listen on"},
+			&cli.IntFlag{Name: "customers", Value: 100, Usage: "Number of synthetic customers"},
+			&cli.StringFlag{Name: "currencies", Value: "USD", Usage: "Comma-separated currency codes assigned to customers"},
+			&cli.StringFlag{Name: "countries", Value: "US", Usage: "Comma-separated country codes of customers' default addresses"},
+			&cli.Float64Flag{Name: "missing-email-rate", Value: 0.1, Usage: "Fraction of customers without an email address (0-1)"},
+			&cli.StringFlag{Name: "shop-timezone", Value: "UTC", Usage: "IANA time zone returned as the shop's ianaTimezone"},
+			&cli.Int64Flag{Name: "seed", Value: 1, Usage: "Random seed, so the same flags always produce the same data"},
+		},
+		Action: func(c *cli.Context) error {
+			rate := c.Float64("missing-email-rate")
+			if rate < 0 || rate > 1 {
+				return fmt.Errorf("--missing-email-rate must be between 0 and 1")
+			}
+			currencies := mockCodes(c.String("currencies"))
+			if len(currencies) == 0 {
+				return fmt.Errorf("--currencies must list at least one currency code")
+			}
+			countries := mockCodes(c.String("countries"))
+			if len(countries) == 0 {
+				return fmt.Errorf("--countries must list at least one country code")
+			}
+
+			customers := mockCustomers(c.Int("customers"), currencies, countries, rate, c.Int64("seed"))
+
+			shop := mockShop{Currency: currencies[0], Timezone: c.String("shop-timezone")}
+			server := &http.Server{
+				Addr:              c.String("addr"),
+				Handler:           mockHandler(customers, shop),
+				ReadHeaderTimeout: 5 * time.Second,
+			}
+			log.Printf("Serving %d mock customers on %s; use SHOPIFY_DOMAIN=http://localhost%s", len(customers), c.String("addr"), c.String("addr"))
+			return server.ListenAndServe()
+		},
+	}
+}
+
+// mockHandler serves the Admin GraphQL and REST APIs of a mock shop with
+// customers, and its Storefront API.
+func mockHandler(customers []CustomerSegmentMember, shop mockShop) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/admin/api/", mockAdminToken(mockGraphQLHandler(customers, shop)))
+	mux.Handle("/admin/api/"+shopify.APIVersion+"/shop.json", mockAdminToken(mockRESTShopHandler(shop)))
+	mux.Handle("/admin/api/"+shopify.APIVersion+"/customers.json", mockAdminToken(mockRESTCustomersHandler(customers)))
+	mux.Handle("/api/", mockStorefrontHandler())
+	return mux
+}
+
+var (
+	mockFirstNames = []string{"Ada", "Bruno", "Chiara", "Dev", "Elif", "Farah", "Goran", "Hana", "Ivo", "Jun", "Kofi", "Lena"}
+	mockLastNames  = []string{"Alvarez", "Brandt", "Costa", "Dube", "Eriksen", "Fischer", "Garcia", "Hoang", "Ito", "Jensen", "Kowalski", "Larsen"}
+)
+
+// mockCodes splits a comma-separated list of upper-case codes.
+func mockCodes(s string) []string {
+	var codes []string
+	for _, code := range strings.Split(s, ",") {
+		if code = strings.ToUpper(strings.TrimSpace(code)); code != "" {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// mockCustomers generates n deterministic customers for the given seed.
+func mockCustomers(n int, currencies, countries []string, missingEmailRate float64, seed int64) []CustomerSegmentMember {
+	rng := rand.New(rand.NewSource(seed))
+	// Countries use their own source so the other fields generated for a seed
+	// do not depend on --countries.
+	countryRng := rand.New(rand.NewSource(seed + 1))
+	customers := make([]CustomerSegmentMember, n)
+	for i := range customers {
+		first := mockFirstNames[rng.Intn(len(mockFirstNames))]
+		last := mockLastNames[rng.Intn(len(mockLastNames))]
+
+		// Values are derived from the customer ID, which lookups use too.
+		id := mockCustomerID(i)
+		node := &customers[i].Node
+		node.Id = mockMemberID(i)
+		node.DisplayName = first + " " + last
+		if rng.Float64() >= missingEmailRate {
+			node.DefaultEmailAddress = &DefaultEmail{
+				EmailAddress:   fmt.Sprintf("%s.%s.%d@example.com", strings.ToLower(first), strings.ToLower(last), i),
+				MarketingState: mockEmailConsent(id),
+			}
+		}
+		if seededHash("phone:"+id) < 0.5 {
+			node.DefaultPhoneNumber = &DefaultPhone{
+				PhoneNumber:    fmt.Sprintf("+1555%07d", i),
+				MarketingState: mockSMSConsent(id),
+			}
+		}
+		node.AmountSpent = MonetaryAmount{
+			Amount:       decimal.New(rng.Int63n(500000), -2),
+			CurrencyCode: CurrencyCode(currencies[rng.Intn(len(currencies))]),
+		}
+		node.DefaultAddress = mockAddress(id, countries[countryRng.Intn(len(countries))])
+	}
+	return customers
+}
+
+// mockCustomerID and mockMemberID are the IDs of the i-th mock customer and of
+// its segment member. Like Shopify's, they share their number but not their
+// type, so only the customer ID resolves with nodes(ids:).
+func mockCustomerID(i int) string { return fmt.Sprintf("gid://shopify/Customer/%d", 1000+i) }
+
+func mockMemberID(i int) string { return fmt.Sprintf("gid://shopify/CustomerSegmentMember/%d", 1000+i) }
+
+// mockCustomerIndex returns i for the ID of the i-th of n mock customers.
+func mockCustomerIndex(id string, n int) (int, bool) {
+	number, ok := strings.CutPrefix(id, "gid://shopify/Customer/")
+	i, err := strconv.Atoi(number)
+	if !ok || err != nil || i < 1000 || i >= 1000+n {
+		return 0, false
+	}
+	return i - 1000, true
+}
+
+// mockAddress returns a default address in country, with a province derived from
+// the ID for the countries whose subdivisions are listed.
+func mockAddress(id, country string) *DefaultAddress {
+	a := &DefaultAddress{CountryCodeV2: CountryCode(country)}
+	if c, ok := countriesByCode[country]; ok {
+		a.Country = c.names[0]
+	}
+	var provinces []isoSubdivision
+	for _, s := range isoSubdivisions {
+		if strings.HasPrefix(s.code, country+"-") {
+			provinces = append(provinces, s)
+		}
+	}
+	if len(provinces) > 0 {
+		p := provinces[int(seededHash("province:"+id)*float64(len(provinces)))]
+		a.Province, a.ProvinceCode = p.name, strings.TrimPrefix(p.code, country+"-")
+	}
+	return a
+}
+
+// mockEmailConsent subscribes about four in five customers to email marketing.
+// Consent is derived from the ID so it does not consume the seeded source.
+func mockEmailConsent(id string) CustomerEmailAddressMarketingState {
+	switch h := seededHash("email-consent:" + id); {
+	case h < 0.8:
+		return CustomerEmailAddressMarketingStateSubscribed
+	case h < 0.9:
+		return CustomerEmailAddressMarketingStateUnsubscribed
+	}
+	return CustomerEmailAddressMarketingStateNotSubscribed
+}
+
+// mockSMSConsent subscribes about half of the customers with a phone number to
+// SMS marketing.
+func mockSMSConsent(id string) CustomerSmsMarketingState {
+	if seededHash("sms-consent:"+id) < 0.5 {
+		return CustomerSmsMarketingStateSubscribed
+	}
+	return CustomerSmsMarketingStateNotSubscribed
+}
+
+// mockShop is the shop returned by the mock server. Its primary currency is the
+// first of --currencies.
+type mockShop struct {
+	Currency string
+	Timezone string
+}
+
+// mockAdminToken gives Admin API responses a request ID and rejects requests
+// without an access token, as Shopify does.
+func mockAdminToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", fmt.Sprintf("mock-%d", time.Now().UnixNano()))
+		if r.Header.Get("X-Shopify-Access-Token") == "" {
+			http.Error(w, `{"errors":"[API] Invalid API key or access token (unrecognized login or wrong password)"}`, http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
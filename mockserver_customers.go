@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// mockTags are the tags mock customers can have.
+var mockTags = []string{"vip", "newsletter", "wholesale", "task1", "level:3"}
+
+// mockCustomerNode returns a customer node with a state, tags, tax exemptions,
+// statistics and dates derived from its ID, so every run serves the same
+// values. About one in five customers has no orders.
+func mockCustomerNode(id string) map[string]interface{} {
+	day := 24 * time.Hour
+	created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(seededHash("created:"+id) * 1500 * float64(day))).Truncate(time.Second)
+	node := map[string]interface{}{
+		"__typename": "Customer",
+		"id":         id,
+		"createdAt":  created,
+		"updatedAt":  created.Add(time.Duration(seededHash("updated:"+id) * 300 * float64(day))).Truncate(time.Second),
+		"lastOrder":  nil,
+		"orders":     map[string]interface{}{"edges": []interface{}{}},
+		"tags":       []string{},
+		"state":      customerStates[int(seededHash("state:"+id)*float64(len(customerStates)))],
+		// About one in ten customers is tax exempt, as a reseller.
+		"taxExempt":     seededHash("tax:"+id) < 0.1,
+		"taxExemptions": []TaxExemption{},
+	}
+	// About one in ten customers has not been scored yet.
+	if seededHash("statistics:"+id) >= 0.1 {
+		tiers := []CustomerPredictedSpendTier{CustomerPredictedSpendTierLow, CustomerPredictedSpendTierMedium, CustomerPredictedSpendTierHigh}
+		groups := []CustomerRfmGroup{CustomerRfmGroupChampions, CustomerRfmGroupLoyal, CustomerRfmGroupActive, CustomerRfmGroupAtRisk, CustomerRfmGroupDormant, CustomerRfmGroupNew}
+		node["statistics"] = map[string]interface{}{
+			"predictedSpendTier": tiers[int(seededHash("tier:"+id)*float64(len(tiers)))],
+			"rfmGroup":           groups[int(seededHash("rfm:"+id)*float64(len(groups)))],
+		}
+	} else {
+		node["statistics"] = map[string]interface{}{"predictedSpendTier": nil, "rfmGroup": nil}
+	}
+	if node["taxExempt"].(bool) {
+		node["taxExemptions"] = []TaxExemption{TaxExemptionUsCaResellerExemption, TaxExemptionUsNyResellerExemption}[:1+int(seededHash("exemptions:"+id)*2)]
+	}
+	for _, tag := range mockTags {
+		if seededHash("tag:"+tag+":"+id) < 0.3 {
+			node["tags"] = append(node["tags"].([]string), tag)
+		}
+	}
+	if first, last, ok := mockOrderDates(id, created); ok {
+		node["lastOrder"] = map[string]interface{}{"processedAt": last}
+		node["orders"] = map[string]interface{}{"edges": []interface{}{map[string]interface{}{"node": map[string]interface{}{"processedAt": first}}}}
+	}
+	return node
+}
+
+// mockMergeable returns the merge status of the member of customer id. About
+// one in twenty customers cannot be merged, for example because of an active
+// subscription.
+func mockMergeable(id string) *MergeableStatus {
+	if seededHash("mergeable:"+id) < 0.05 {
+		return &MergeableStatus{ErrorFields: []CustomerMergeErrorFieldType{CustomerMergeErrorFieldTypeSubscriptions}}
+	}
+	return &MergeableStatus{IsMergeable: true, ErrorFields: []CustomerMergeErrorFieldType{}}
+}
+
+// mockOrderDates returns the first and last order dates of a customer, or false
+// for the one in five customers without orders.
+func mockOrderDates(id string, created time.Time) (first, last time.Time, ok bool) {
+	if seededHash("orders:"+id) < 0.2 {
+		return time.Time{}, time.Time{}, false
+	}
+	day := 24 * time.Hour
+	first = created.Add(time.Duration(seededHash("first:"+id) * 30 * float64(day))).Truncate(time.Second)
+	last = first.Add(time.Duration(seededHash("last:"+id) * 600 * float64(day))).Truncate(time.Second)
+	return first, last, true
+}
+
+// mockOrders returns a page of a customer's orders, most recent first: 1 to 30
+// orders between its first and last order dates, in the shop currency. Cursors
+// are offsets into the orders.
+func mockOrders(id string, first int, after, currency string) map[string]interface{} {
+	var orders []interface{}
+	created := mockCustomerNode(id)["createdAt"].(time.Time)
+	if firstOrder, last, ok := mockOrderDates(id, created); ok {
+		n := 1 + int(seededHash("count:"+id)*30)
+		number, _ := strconv.Atoi(id[strings.LastIndex(id, "/")+1:])
+		for i := 0; i < n; i++ {
+			at := last
+			if n > 1 {
+				at = last.Add(-time.Duration(i) * last.Sub(firstOrder) / time.Duration(n-1)).Truncate(time.Second)
+			}
+			amount := decimal.NewFromFloat(5 + seededHash(fmt.Sprintf("order:%s:%d", id, i))*500).Round(2)
+			orders = append(orders, map[string]interface{}{
+				"id":            fmt.Sprintf("gid://shopify/Order/%d", number*100+n-i),
+				"name":          fmt.Sprintf("#%d", number*100+n-i),
+				"processedAt":   at,
+				"totalPriceSet": map[string]interface{}{"shopMoney": map[string]interface{}{"amount": amount, "currencyCode": currency}},
+			})
+		}
+	}
+	offset, _ := strconv.Atoi(after)
+	orders = orders[min(max(offset, 0), len(orders)):]
+	hasNext := first >= 0 && first < len(orders)
+	if hasNext {
+		orders = orders[:first]
+	}
+	if orders == nil {
+		orders = []interface{}{}
+	}
+	return map[string]interface{}{
+		"nodes":    orders,
+		"pageInfo": map[string]interface{}{"hasNextPage": hasNext, "endCursor": strconv.Itoa(offset + len(orders))},
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// mockRequest is a GraphQL request to the mock server with the variables of
+// the tool's queries.
+type mockRequest struct {
+	Query     string `json:"query"`
+	Variables struct {
+		First   int    `json:"first"`
+		SortKey string `json:"sortKey"`
+		Reverse bool   `json:"reverse"`
+		After   string `json:"after"`
+		// Mergeable includes the members' merge status.
+		Mergeable bool     `json:"mergeable"`
+		IDs       []string `json:"ids"`
+		ID        string   `json:"id"`
+	} `json:"variables"`
+}
+
+// mockGraphQLHandler answers the customerSegmentMembers, nodes, customer and shop
+// fields of Admin API queries. The segment query itself is not evaluated; every
+// customer is a member.
+func mockGraphQLHandler(customers []CustomerSegmentMember, shop mockShop) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/graphql.json") {
+			http.NotFound(w, r)
+			return
+		}
+		var req mockRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		doc, fields, err := mockRootFields(req.Query)
+		if err != nil {
+			mockGraphQLErrors(w, err.Error())
+			return
+		}
+
+		data := map[string]interface{}{}
+		var cost map[string]interface{}
+		for _, field := range fields {
+			switch field.Name {
+			case "shop":
+				data[field.Alias] = map[string]interface{}{"name": "Mock Shop", "currencyCode": shop.Currency, "ianaTimezone": shop.Timezone}
+				cost = mockCost(1, 1)
+			case "customerSegmentMembers":
+				data[field.Alias], cost = mockSegmentMembers(customers, req)
+			case "nodes":
+				data[field.Alias] = mockNodes(customers, req, mockOrdersSelected(doc, field), shop.Currency)
+			case "customer":
+				data[field.Alias] = nil
+				if _, ok := mockCustomerIndex(req.Variables.ID, len(customers)); ok {
+					data[field.Alias] = map[string]interface{}{"orders": mockOrders(req.Variables.ID, req.Variables.First, req.Variables.After, shop.Currency)}
+				}
+			default:
+				mockGraphQLErrors(w, "mock server only supports customerSegmentMembers, nodes, customer and shop queries")
+				return
+			}
+		}
+		resp := map[string]interface{}{"data": data}
+		if cost != nil {
+			resp["extensions"] = cost
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			log.Printf("mock-server: failed to write response: %v", err)
+		}
+	})
+}
+
+// mockRootFields parses a query and returns it with the root fields of its
+// first operation.
+func mockRootFields(query string) (*ast.QueryDocument, []*ast.Field, error) {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(doc.Operations) == 0 {
+		return nil, nil, fmt.Errorf("no operation in query")
+	}
+	return doc, mockFields(doc, doc.Operations[0].SelectionSet), nil
+}
+
+// mockFields returns the fields of set, including those of its fragments.
+func mockFields(doc *ast.QueryDocument, set ast.SelectionSet) []*ast.Field {
+	var fields []*ast.Field
+	for _, s := range set {
+		switch s := s.(type) {
+		case *ast.Field:
+			fields = append(fields, s)
+		case *ast.InlineFragment:
+			fields = append(fields, mockFields(doc, s.SelectionSet)...)
+		case *ast.FragmentSpread:
+			if f := doc.Fragments.ForName(s.Name); f != nil {
+				fields = append(fields, mockFields(doc, f.SelectionSet)...)
+			}
+		}
+	}
+	return fields
+}
+
+// mockField returns the field name of set, or nil.
+func mockField(doc *ast.QueryDocument, set ast.SelectionSet, name string) *ast.Field {
+	for _, f := range mockFields(doc, set) {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// mockOrdersSelected reports whether a nodes field pages through the orders of
+// each customer with their totals, as the order lookups do, rather than
+// selecting the date of the first one.
+func mockOrdersSelected(doc *ast.QueryDocument, nodes *ast.Field) bool {
+	orders := mockField(doc, nodes.SelectionSet, "orders")
+	if orders == nil {
+		return false
+	}
+	page := mockField(doc, orders.SelectionSet, "nodes")
+	return page != nil && mockField(doc, page.SelectionSet, "totalPriceSet") != nil
+}
+
+// mockGraphQLErrors answers with a GraphQL error, with status 200 like Shopify.
+func mockGraphQLErrors(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"errors": []GraphQLError{{Message: message}}})
+}
+
+// mockNodes returns the customer nodes of the IDs of req, with a page of their
+// orders in currency if orders is set, and null for IDs that are not mock
+// customers, such as segment member IDs.
+func mockNodes(customers []CustomerSegmentMember, req mockRequest, orders bool, currency string) []interface{} {
+	nodes := make([]interface{}, len(req.Variables.IDs))
+	for i, id := range req.Variables.IDs {
+		if _, ok := mockCustomerIndex(id, len(customers)); !ok {
+			continue
+		}
+		node := mockCustomerNode(id)
+		if orders {
+			node["orders"] = mockOrders(id, req.Variables.First, "", currency)
+		}
+		nodes[i] = node
+	}
+	return nodes
+}
+
+// mockSegmentMembers returns a page of the members of the segment and its cost.
+func mockSegmentMembers(customers []CustomerSegmentMember, req mockRequest) (map[string]interface{}, map[string]interface{}) {
+	members := append([]CustomerSegmentMember(nil), customers...)
+	if req.Variables.SortKey == "amount_spent" {
+		sort.SliceStable(members, func(i, j int) bool {
+			return members[i].Node.AmountSpent.Amount.LessThan(members[j].Node.AmountSpent.Amount)
+		})
+	}
+	if req.Variables.Reverse {
+		for i, j := 0, len(members)-1; i < j; i, j = i+1, j-1 {
+			members[i], members[j] = members[j], members[i]
+		}
+	}
+	// Cursors are offsets into the sorted members.
+	offset, _ := strconv.Atoi(req.Variables.After)
+	members = members[min(max(offset, 0), len(members)):]
+	if req.Variables.First >= 0 && req.Variables.First < len(members) {
+		members = members[:req.Variables.First]
+	}
+	end := offset + len(members)
+	if req.Variables.Mergeable {
+		for i := range members {
+			members[i].Node.Mergeable = mockMergeable(customerID(members[i].Node.Id))
+		}
+	}
+
+	// Costs follow Shopify's: a page requests its size plus its connection,
+	// and is charged for the members returned.
+	requested, actual := max(req.Variables.First, 1)+2, len(members)+2
+	return map[string]interface{}{
+		"edges": members,
+		"pageInfo": map[string]interface{}{
+			"hasNextPage": end < len(customers),
+			"endCursor":   strconv.Itoa(end),
+		},
+		"totalCount": len(customers),
+	}, mockCost(requested, actual)
+}
+
+// mockCost is the cost extension of a response, for a bucket that is full
+// before the query.
+func mockCost(requested, actual int) map[string]interface{} {
+	return map[string]interface{}{
+		"cost": map[string]interface{}{
+			"requestedQueryCost": requested,
+			"actualQueryCost":    actual,
+			"throttleStatus": map[string]interface{}{
+				"maximumAvailable":   2000,
+				"currentlyAvailable": 2000 - actual,
+				"restoreRate":        100,
+			},
+		},
+	}
+}
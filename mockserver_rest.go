@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// mockRESTShopHandler answers shop.json, for the REST call limit of limits.
+func mockRESTShopHandler(shop mockShop) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set(restCallLimitHeader, "1/40")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"shop": map[string]interface{}{"name": "Mock Shop", "currency": shop.Currency, "iana_timezone": shop.Timezone},
+		})
+	})
+}
+
+// mockRESTCustomersHandler answers the REST customers resource, paginated with
+// limit and page_info like Shopify, where page_info is the offset here.
+func mockRESTCustomersHandler(customers []CustomerSegmentMember) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.NotFound(w, r)
+			return
+		}
+		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		if err != nil || limit <= 0 || limit > 250 {
+			limit = 50
+		}
+		offset, _ := strconv.Atoi(r.URL.Query().Get("page_info"))
+		offset = min(max(offset, 0), len(customers))
+		end := min(offset+limit, len(customers))
+
+		page := []map[string]interface{}{}
+		for i, c := range customers[offset:end] {
+			first, last, _ := strings.Cut(c.Node.DisplayName, " ")
+			customer := map[string]interface{}{
+				"id":          strings.TrimPrefix(mockCustomerID(offset+i), "gid://shopify/Customer/"),
+				"first_name":  first,
+				"last_name":   last,
+				"email":       nil,
+				"total_spent": c.Node.AmountSpent.Amount.StringFixed(2),
+				"currency":    c.Node.AmountSpent.CurrencyCode,
+			}
+			if e := c.Node.DefaultEmailAddress; e != nil {
+				customer["email"] = e.EmailAddress
+			}
+			page = append(page, customer)
+		}
+		if end < len(customers) {
+			next := *r.URL
+			q := next.Query()
+			q.Set("limit", strconv.Itoa(limit))
+			q.Set("page_info", strconv.Itoa(end))
+			next.RawQuery = q.Encode()
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s%s>; rel="next"`, r.Host, next.RequestURI()))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"customers": page})
+	})
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// mockStorefrontHandler answers Storefront API shop queries, which need a
+// Storefront access token rather than an Admin API one.
+func mockStorefrontHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/graphql.json") {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("X-Shopify-Storefront-Access-Token") == "" {
+			http.Error(w, `{"errors":[{"message":"Unauthorized"}]}`, http.StatusUnauthorized)
+			return
+		}
+		var req mockRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		_, fields, err := mockRootFields(req.Query)
+		if err != nil {
+			mockGraphQLErrors(w, err.Error())
+			return
+		}
+		data := map[string]interface{}{}
+		for _, field := range fields {
+			if field.Name != "shop" {
+				mockGraphQLErrors(w, "mock Storefront API only supports shop queries")
+				return
+			}
+			data[field.Alias] = map[string]interface{}{"name": "Mock Shop", "description": "A mock shop for testing"}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	})
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"sultans/shopify"
+)
+
+func multipassCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "multipass",
+		Usage:     "Generate Multipass login tokens and URLs for the customers of an export CSV",
+		ArgsUsage: "<export.csv>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "output", Aliases: []string{"o"}, Usage: "Output CSV filename (default: stdout)"},
+			&cli.StringFlag{Name: "store-domain", Usage: "Storefront domain of the login URLs (default: SHOPIFY_DOMAIN)"},
+			&cli.StringFlag{Name: "return-to", Usage: "URL customers are sent to after logging in"},
+			&cli.StringFlag{Name: "identifier-column", Usage: "Column with each customer's unique identifier in your system, sent as the Multipass identifier"},
+			&cli.StringFlag{Name: "tag-separator", Value: ";", Usage: "Separator of the Tags column"},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 1 {
+				return fmt.Errorf("expected an export CSV file")
+			}
+			secret, err := secretEnv("MULTIPASS_SECRET")
+			if err != nil {
+				return err
+			}
+			if secret == "" {
+				return fmt.Errorf("MULTIPASS_SECRET (or MULTIPASS_SECRET_FILE) must be set")
+			}
+			domain := c.String("store-domain")
+			if domain == "" {
+				domain = os.Getenv("SHOPIFY_DOMAIN")
+			}
+			if domain == "" {
+				return fmt.Errorf("--store-domain or SHOPIFY_DOMAIN must be set")
+			}
+
+			in, err := os.Open(c.Args().First())
+			if err != nil {
+				return err
+			}
+			defer in.Close()
+			w := io.Writer(os.Stdout)
+			if output := c.String("output"); output != "" {
+				file, err := os.Create(output)
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				w = file
+			}
+			m := multipass{secret: secret, domain: domain, returnTo: c.String("return-to")}
+			return m.writeTokens(in, w, c.String("identifier-column"), c.String("tag-separator"))
+		},
+	}
+}
+
+// multipass generates Shopify Multipass tokens, which log customers in to the
+// storefront with the customer data they carry. Shopify only accepts tokens
+// created in the last few minutes, so they are generated right before use.
+type multipass struct {
+	secret   string
+	domain   string
+	returnTo string
+}
+
+// writeTokens reads an export CSV from r and writes its ID and Email Address
+// columns with a Multipass Token and Multipass URL to w. Customers without an
+// email address are skipped.
+func (m multipass) writeTokens(r io.Reader, w io.Writer, identifierColumn, tagSeparator string) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read export: %w", err)
+	}
+	index := map[string]int{}
+	for i, name := range header {
+		index[name] = i
+	}
+	for _, name := range []string{"ID", "Email Address", identifierColumn} {
+		if _, ok := index[name]; name != "" && !ok {
+			return fmt.Errorf("export has no %q column", name)
+		}
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"ID", "Email Address", "Multipass Token", "Multipass URL"}); err != nil {
+		return err
+	}
+	skipped := 0
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read export: %w", err)
+		}
+		field := func(name string) string {
+			if i, ok := index[name]; ok && i < len(record) && record[i] != nullValue {
+				return record[i]
+			}
+			return ""
+		}
+
+		customer := map[string]string{"email": field("Email Address")}
+		if customer["email"] == "" {
+			skipped++
+			continue
+		}
+		customer["first_name"], customer["last_name"], _ = strings.Cut(field("Display Name"), " ")
+		if identifierColumn != "" {
+			customer["identifier"] = field(identifierColumn)
+		}
+		if tags := field("Tags"); tags != "" {
+			customer["tag_string"] = strings.Join(strings.Split(tags, tagSeparator), ",")
+		}
+		if m.returnTo != "" {
+			customer["return_to"] = m.returnTo
+		}
+		token, err := m.token(customer, time.Now())
+		if err != nil {
+			return err
+		}
+		url := shopify.BaseURL(m.domain) + "/account/login/multipass/" + token
+		if err := writer.Write([]string{field("ID"), customer["email"], token, url}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	if skipped > 0 {
+		log.Printf("Skipped %d customers without an email address", skipped)
+	}
+	return writer.Error()
+}
+
+// token encrypts the customer data with AES-128-CBC and signs it with
+// HMAC-SHA256, using the two halves of the SHA-256 of the Multipass secret as
+// keys, and returns the URL-safe Base64 of ciphertext and signature.
+func (m multipass) token(customer map[string]string, now time.Time) (string, error) {
+	data := map[string]string{"created_at": now.UTC().Format(time.RFC3339)}
+	for k, v := range customer {
+		if v != "" {
+			data[k] = v
+		}
+	}
+	plaintext, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+
+	keys := sha256.Sum256([]byte(m.secret))
+	block, err := aes.NewCipher(keys[:16])
+	if err != nil {
+		return "", err
+	}
+	// PKCS#7 padding to the block size.
+	pad := aes.BlockSize - len(plaintext)%aes.BlockSize
+	plaintext = append(plaintext, bytes.Repeat([]byte{byte(pad)}, pad)...)
+
+	ciphertext := make([]byte, aes.BlockSize+len(plaintext))
+	iv := ciphertext[:aes.BlockSize]
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext[aes.BlockSize:], plaintext)
+
+	mac := hmac.New(sha256.New, keys[16:])
+	mac.Write(ciphertext)
+	return base64.URLEncoding.EncodeToString(mac.Sum(ciphertext)), nil
+}
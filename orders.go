@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"sultans/shopify"
+)
+
+// customerOrders looks up the recent orders of exported customers for --orders,
+// nil without it. They are looked up on the customers per page.
+var customerOrders *orderLookup
+
+// Orders requested per customer in the batched lookup, which keeps its query cost
+// within Shopify's limit, and per page for customers with more recent orders.
+const (
+	ordersBatchFirst = 10
+	ordersPageSize   = 50
+)
+
+type orderLookup struct {
+	// limit is the number of most recent orders kept per customer.
+	limit int
+	// query filters the orders, e.g. processed_at:>='2024-01-01'.
+	query string
+}
+
+// newOrderLookup keeps up to limit orders per customer, processed since since
+// (a date or RFC 3339 timestamp) if it is set.
+func newOrderLookup(limit int, since string) (*orderLookup, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("invalid --order-limit %d, expected a positive number", limit)
+	}
+	l := &orderLookup{limit: limit}
+	if since != "" {
+		if _, err := time.Parse(time.DateOnly, since); err != nil {
+			if _, err := time.Parse(time.RFC3339, since); err != nil {
+				return nil, fmt.Errorf("invalid --orders-since %q, expected a date such as 2024-01-31 or an RFC 3339 timestamp", since)
+			}
+		}
+		l.query = fmt.Sprintf("processed_at:>='%s'", since)
+	}
+	return l, nil
+}
+
+// ordersColumns returns the order columns for an --orders mode: rows for one
+// row per order, or aggregate for the count, total and date of the last order.
+func ordersColumns(mode string) ([]column, error) {
+	switch mode {
+	case "rows":
+		field := func(value func(o CustomerOrder) string) func(CustomerSegmentMember) []string {
+			return func(c CustomerSegmentMember) []string {
+				var values []string
+				for _, o := range c.lookedUp().orders {
+					values = append(values, value(o))
+				}
+				return values
+			}
+		}
+		return []column{
+			{Header: "Order ID", Group: "orders", Explode: field(func(o CustomerOrder) string { return o.Id })},
+			{Header: "Order Name", Group: "orders", Explode: field(func(o CustomerOrder) string { return o.Name })},
+			{Header: "Order Processed At", Group: "orders", Explode: field(func(o CustomerOrder) string { return formatTime(o.ProcessedAt) })},
+			{Header: "Order Total", Group: "orders", Explode: field(func(o CustomerOrder) string {
+				money := o.TotalPriceSet.ShopMoney
+				return formatAmount(money.Amount, string(money.CurrencyCode))
+			})},
+			{Header: "Order Currency", Group: "orders", Explode: field(func(o CustomerOrder) string {
+				return string(o.TotalPriceSet.ShopMoney.CurrencyCode)
+			})},
+		}, nil
+	case "aggregate":
+		return []column{
+			{Header: "Order Count", Value: func(c CustomerSegmentMember) string {
+				return strconv.Itoa(len(c.lookedUp().orders))
+			}},
+			// Totals are in the shop currency, so the orders of a customer add up.
+			{Header: "Order Total", Value: func(c CustomerSegmentMember) string {
+				orders := c.lookedUp().orders
+				if len(orders) == 0 {
+					return nullValue
+				}
+				total := decimal.Zero
+				for _, o := range orders {
+					total = total.Add(o.TotalPriceSet.ShopMoney.Amount)
+				}
+				return formatAmount(total, string(orders[0].TotalPriceSet.ShopMoney.CurrencyCode))
+			}},
+			{Header: "Order Currency", Value: func(c CustomerSegmentMember) string {
+				if orders := c.lookedUp().orders; len(orders) > 0 {
+					return string(orders[0].TotalPriceSet.ShopMoney.CurrencyCode)
+				}
+				return nullValue
+			}},
+			{Header: "Last Order At", Value: func(c CustomerSegmentMember) string {
+				if orders := c.lookedUp().orders; len(orders) > 0 {
+					return formatTime(orders[0].ProcessedAt)
+				}
+				return nullValue
+			}},
+		}, nil
+	}
+	return nil, fmt.Errorf("invalid --orders %q, expected rows or aggregate", mode)
+}
+
+// wrap looks up the orders of every page of in, most recent first, before
+// delivering it. The first
+// orders of each customer come with the batched lookup; customers with more
+// orders within the limit have the rest fetched page by page.
+func (l *orderLookup) wrap(ctx context.Context, client *shopify.Client, in *segmentStream) *segmentStream {
+	return lookupPages(ctx, in, "orders", func(ids []string, fields func(string) *memberFields) error {
+		resp, err := GetCustomerOrders(ctx, client, ids, min(l.limit, ordersBatchFirst), l.query)
+		if err != nil {
+			return err
+		}
+		for _, node := range resp.Nodes {
+			customer, ok := node.(*CustomerOrdersNodeCustomer)
+			if !ok {
+				continue
+			}
+			orders, err := l.fetchRemaining(ctx, client, customer.Id, customer.Orders)
+			if err != nil {
+				return err
+			}
+			fields(customer.Id).orders = orders
+		}
+		return nil
+	})
+}
+
+// fetchRemaining follows the pagination of a customer's orders from page until
+// the limit is reached or there are no more orders.
+func (l *orderLookup) fetchRemaining(ctx context.Context, client *shopify.Client, id string, page CustomerOrders) ([]CustomerOrder, error) {
+	orders := page.Nodes
+	for page.PageInfo.HasNextPage && page.PageInfo.EndCursor != "" && len(orders) < l.limit {
+		resp, err := GetCustomerOrdersPage(ctx, client, id, min(l.limit-len(orders), ordersPageSize), l.query, page.PageInfo.EndCursor)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", id, err)
+		}
+		if resp.Customer == nil {
+			break
+		}
+		page = resp.Customer.Orders
+		orders = append(orders, page.Nodes...)
+	}
+	return orders[:min(len(orders), l.limit)], nil
+}
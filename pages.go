@@ -0,0 +1,579 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/urfave/cli/v2"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"sultans/shopify"
+)
+
+// errEmptySegment is returned with --fail-if-empty when a query returns no customers.
+var errEmptySegment = errors.New("query returned no customers")
+
+// maxPageSize is the largest page Shopify returns for customerSegmentMembers.
+const maxPageSize = 250
+
+// segmentStream delivers segment members one at a time as they are decoded.
+type segmentStream struct {
+	Items   <-chan segmentItem
+	err     error
+	partial gqlerror.List
+}
+
+// segmentItem is either a customer or, with EndOfPage set, the marker that the
+// preceding customers completed a page ending at Cursor. Fetched is the number of
+// members Shopify returned on the page, including those filtered out since.
+type segmentItem struct {
+	Customer  CustomerSegmentMember
+	EndOfPage bool
+	Cursor    string
+	Fetched   int
+}
+
+// Err returns the error that ended the stream. It is only valid once Items is closed.
+func (s *segmentStream) Err() error { return s.err }
+
+// partialErr returns the GraphQL errors of pages that were delivered despite them
+// with SegmentQuery.AllowPartial, or nil. It is only valid once Items is closed.
+func (s *segmentStream) partialErr() error {
+	if len(s.partial) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %d GraphQL errors: %s", errPartialData, len(s.partial), formatGraphQLErrors(s.partial))
+}
+
+// errPartialData is returned with --allow-partial when customers were exported
+// but some fields of the response failed.
+var errPartialData = errors.New("partial data")
+
+// formatGraphQLErrors lists each error with the response path it applies to.
+func formatGraphQLErrors(errs gqlerror.List) string {
+	parts := make([]string, len(errs))
+	for i, e := range errs {
+		parts[i] = e.Message
+		if len(e.Path) > 0 {
+			parts[i] = e.Path.String() + ": " + e.Message
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// fetchSegmentStream fetches up to q.First members page by page in the background,
+// starting after q.After, keeping up to prefetch pages buffered ahead of the
+// consumer. Members arrive in order. The customers are added to the reports of
+// p as they pass through. Cancel ctx to stop fetching early.
+func fetchSegmentStream(ctx context.Context, client *shopify.Client, q SegmentQuery, prefetch int, p *pipeline) *segmentStream {
+	if p == nil {
+		p = &pipeline{}
+	}
+	pageSize := q.PageSize
+	if pageSize <= 0 || pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	items := make(chan segmentItem, max(prefetch, 0)*(pageSize+1))
+	stream := &segmentStream{Items: items}
+	go func() {
+		defer close(items)
+
+		send := func(item segmentItem) error {
+			select {
+			case items <- item:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		emit := func(c CustomerSegmentMember) error {
+			// Consent and suppression are checked before excluded fields are
+			// stripped, so they also work with --no-pii.
+			if consent != nil && !consent.keep(c) {
+				return nil
+			}
+			if suppression != nil && !suppression.keep(c) {
+				return nil
+			}
+			checkCurrency(string(c.Node.AmountSpent.CurrencyCode))
+			c.fields = &memberFields{}
+			if identifierHashes != nil {
+				identifierHashes.record(c)
+			}
+			stripExcluded(&c)
+			if texts != nil {
+				texts.apply(&c)
+			}
+			if emails != nil {
+				if err := emails.apply(&c); err != nil {
+					return err
+				}
+			}
+			if phones != nil {
+				if err := phones.apply(&c); err != nil {
+					return err
+				}
+			}
+			return send(segmentItem{Customer: c})
+		}
+
+		after := q.After
+		for remaining := q.First; remaining > 0; {
+			page := &segmentPageDecoder{emit: emit}
+			req := &graphql.Request{
+				OpName: "GetCustomerSegmentMembers",
+				Query:  GetCustomerSegmentMembers_Operation,
+				Variables: &__GetCustomerSegmentMembersInput{
+					First:   min(remaining, pageSize),
+					Query:   q.Query,
+					SortKey: q.SortKey,
+					Reverse: q.Reverse,
+					After:   after,
+					// The merge status is only selected for --duplicate-columns.
+					Mergeable: duplicates != nil,
+				},
+			}
+			if err := client.MakeRequest(ctx, req, &graphql.Response{Data: page}); err != nil {
+				var gqlErrs gqlerror.List
+				if !q.AllowPartial || !page.present || !errors.As(err, &gqlErrs) {
+					stream.err = segmentQueryError(err)
+					return
+				}
+				stream.partial = append(stream.partial, gqlErrs...)
+			}
+
+			remaining -= page.count
+			if send(segmentItem{EndOfPage: true, Cursor: page.pageInfo.EndCursor, Fetched: page.count}) != nil {
+				return
+			}
+			if !page.pageInfo.HasNextPage || page.pageInfo.EndCursor == "" || page.count == 0 {
+				return
+			}
+			after = page.pageInfo.EndCursor
+		}
+	}()
+	if accountStates != nil {
+		stream = accountStates.wrap(ctx, client, stream)
+	}
+	if sampler != nil {
+		stream = sampler.wrap(ctx, stream)
+	}
+	if lifecycle != nil {
+		stream = lifecycle.wrap(ctx, client, stream)
+	}
+	if customerTags != nil {
+		stream = customerTags.wrap(ctx, client, stream)
+	}
+	if taxExemptions != nil {
+		stream = taxExemptions.wrap(ctx, client, stream)
+	}
+	if customerStatistics != nil {
+		stream = customerStatistics.wrap(ctx, client, stream)
+	}
+	if duplicates != nil {
+		stream = duplicates.wrap(ctx, stream)
+	}
+	if customerOrders != nil {
+		stream = customerOrders.wrap(ctx, client, stream)
+	}
+	if transformPlugin != nil {
+		stream = transformPlugin.wrap(ctx, stream)
+	}
+	if validator != nil {
+		stream = validator.wrap(ctx, stream)
+	}
+	if sorter != nil {
+		stream = sorter.wrap(ctx, stream)
+	}
+	if p.report != nil {
+		stream = p.report.wrap(ctx, stream)
+	}
+	if p.quality != nil {
+		stream = p.quality.wrap(ctx, stream)
+	}
+	if p.domains != nil {
+		stream = p.domains.wrap(ctx, stream)
+	}
+	return stream
+}
+
+// filterStream delivers the members of in for which keep returns true, and
+// in's page boundaries.
+func filterStream(ctx context.Context, in *segmentStream, keep func(CustomerSegmentMember) bool) *segmentStream {
+	items := make(chan segmentItem)
+	out := &segmentStream{Items: items}
+	go func() {
+		defer close(items)
+		for item := range in.Items {
+			if !item.EndOfPage && !keep(item.Customer) {
+				continue
+			}
+			select {
+			case items <- item:
+			case <-ctx.Done():
+				out.err = ctx.Err()
+				return
+			}
+		}
+		out.err, out.partial = in.err, in.partial
+	}()
+	return out
+}
+
+// pageStream calls process with the members of each page of in before
+// delivering the page, for lookups that are batched per page.
+func pageStream(ctx context.Context, in *segmentStream, process func([]CustomerSegmentMember) error) *segmentStream {
+	items := make(chan segmentItem)
+	out := &segmentStream{Items: items}
+	go func() {
+		defer close(items)
+		var page []segmentItem
+		var customers []CustomerSegmentMember
+		for item := range in.Items {
+			page = append(page, item)
+			if !item.EndOfPage {
+				customers = append(customers, item.Customer)
+				continue
+			}
+			if err := process(customers); err != nil {
+				out.err = err
+				return
+			}
+			for _, item := range page {
+				select {
+				case items <- item:
+				case <-ctx.Done():
+					out.err = ctx.Err()
+					return
+				}
+			}
+			page, customers = page[:0], customers[:0]
+		}
+		out.err, out.partial = in.err, in.partial
+	}()
+	return out
+}
+
+// bufferStream reads all of in and delivers transform of its members as a single
+// page, for processing that needs the whole segment, such as sorting.
+func bufferStream(ctx context.Context, in *segmentStream, transform func([]CustomerSegmentMember) []CustomerSegmentMember) *segmentStream {
+	items := make(chan segmentItem)
+	out := &segmentStream{Items: items}
+	go func() {
+		defer close(items)
+		var customers []CustomerSegmentMember
+		var cursor string
+		fetched := 0
+		for item := range in.Items {
+			if item.EndOfPage {
+				cursor = item.Cursor
+				fetched += item.Fetched
+				continue
+			}
+			customers = append(customers, item.Customer)
+		}
+		out.err, out.partial = in.err, in.partial
+		if out.err != nil {
+			return
+		}
+		for _, c := range transform(customers) {
+			select {
+			case items <- segmentItem{Customer: c}:
+			case <-ctx.Done():
+				out.err = ctx.Err()
+				return
+			}
+		}
+		select {
+		case items <- segmentItem{EndOfPage: true, Cursor: cursor, Fetched: fetched}:
+		case <-ctx.Done():
+			out.err = ctx.Err()
+		}
+	}()
+	return out
+}
+
+func segmentQueryError(err error) error {
+	var gqlErrs gqlerror.List
+	if errors.As(err, &gqlErrs) {
+		return fmt.Errorf("GraphQL errors: %w", err)
+	}
+	return fmt.Errorf("GraphQL query failed: %w", err)
+}
+
+// segmentPageDecoder decodes the data of a GetCustomerSegmentMembers response
+// token by token, handing each edge to emit as soon as it is decoded instead of
+// materializing the page's edges slice.
+type segmentPageDecoder struct {
+	emit     func(CustomerSegmentMember) error
+	count    int
+	pageInfo PageInfo
+	// present is set when the response contained the connection, even if some
+	// of its fields failed.
+	present bool
+}
+
+func (d *segmentPageDecoder) DecodeStream(dec *json.Decoder) error {
+	return shopify.DecodeObject(dec, func(key string) error {
+		if key != "customerSegmentMembers" {
+			return skipValue(dec)
+		}
+		return shopify.DecodeObject(dec, func(key string) error {
+			d.present = true
+			switch key {
+			case "edges":
+				return shopify.DecodeArray(dec, func() error {
+					var c CustomerSegmentMember
+					if err := dec.Decode(&c); err != nil {
+						return err
+					}
+					d.count++
+					return d.emit(c)
+				})
+			case "pageInfo":
+				return dec.Decode(&d.pageInfo)
+			default:
+				return skipValue(dec)
+			}
+		})
+	})
+}
+
+func skipValue(dec *json.Decoder) error {
+	var v json.RawMessage
+	return dec.Decode(&v)
+}
+
+// streamSegment writes members to output as they are decoded, so the next page
+// is fetched while the current one is written. Destinations receive one batch per
+// page. With a journal, a checkpoint is recorded after every written page.
+func streamSegment(ctx context.Context, c *cli.Context, client *shopify.Client, q SegmentQuery, output string, j *journal, p *pipeline) (int, error) {
+	sink, err := newSink(c, output)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream := fetchSegmentStream(ctx, client, q, c.Int("prefetch"), p)
+
+	var exported int
+	if sink != nil {
+		exported, err = streamToSink(ctx, stream, sink, output, j)
+		if err == nil && exported == 0 && c.Bool("fail-if-empty") {
+			err = errEmptySegment
+		}
+	} else {
+		exported, err = streamToCSV(ctx, stream, output, j, c.Bool("fail-if-empty"))
+	}
+	if err != nil && !errors.Is(err, errEmptySegment) {
+		return exported, err
+	}
+	if finishErr := j.finish(exported); finishErr != nil {
+		return exported, finishErr
+	}
+	if err == nil {
+		err = stream.partialErr()
+	}
+	return exported, err
+}
+
+func streamToSink(ctx context.Context, stream *segmentStream, sink Sink, output string, j *journal) (int, error) {
+	exported, fetched := j.resumeRows(), j.resumeFetched()
+	var batch []CustomerSegmentMember
+	for item := range stream.Items {
+		if !item.EndOfPage {
+			batch = append(batch, item.Customer)
+			continue
+		}
+		fetched += item.Fetched
+		if len(batch) > 0 {
+			if err := sink.Write(ctx, batch); err != nil {
+				return exported, fmt.Errorf("failed to export to %s: %w", output, err)
+			}
+			exported += len(batch)
+			batch = batch[:0]
+		}
+		if err := j.checkpoint(item.Cursor, exported, fetched, 0); err != nil {
+			return exported, err
+		}
+	}
+	return exported, stream.Err()
+}
+
+// streamToCSV writes the stream as CSV. With failIfEmpty, an empty segment fails
+// before a file output is replaced.
+func streamToCSV(ctx context.Context, stream *segmentStream, output string, j *journal, failIfEmpty bool) (int, error) {
+	if partitionKey != nil {
+		return streamToPartitionedCSV(ctx, stream, output, failIfEmpty)
+	}
+	rows, header, err := newRowTransform(ctx, outputHeader())
+	if err != nil {
+		return 0, err
+	}
+	w := io.Writer(os.Stdout)
+	var file *os.File
+	var clipboard *bytes.Buffer
+	switch {
+	case output == clipboardOutput:
+		clipboard = &bytes.Buffer{}
+		w = clipboard
+	case j != nil:
+		// Journaled exports write in place so a resumed run can continue the file.
+		var err error
+		if file, err = j.openOutput(output); err != nil {
+			return 0, fmt.Errorf("failed to export CSV: %w", err)
+		}
+		defer file.Close()
+		w = file
+	case output != "":
+		// Files are written under a temporary name and renamed once complete, so a
+		// failure midway leaves any previous export in place.
+		var err error
+		if file, err = os.CreateTemp(filepath.Dir(output), filepath.Base(output)+".*.tmp"); err != nil {
+			return 0, fmt.Errorf("failed to export CSV: %w", err)
+		}
+		defer os.Remove(file.Name())
+		defer file.Close()
+		// Match the permissions os.Create would give the file.
+		if err := file.Chmod(0o644); err != nil {
+			return 0, fmt.Errorf("failed to export CSV: %w", err)
+		}
+		w = file
+	}
+
+	writer := csv.NewWriter(w)
+	if !j.resuming() {
+		if err := writer.Write(header); err != nil {
+			return 0, fmt.Errorf("failed to export CSV: %w", err)
+		}
+	}
+	// Rows are written per page, so a transform script gets them in batches.
+	var page [][]string
+	writePage := func() error {
+		records, err := rows.apply(ctx, page)
+		page = page[:0]
+		if err != nil {
+			return fmt.Errorf("failed to export CSV: %w", err)
+		}
+		for _, record := range records {
+			if err := writer.Write(record); err != nil {
+				return fmt.Errorf("failed to export CSV: %w", err)
+			}
+		}
+		return nil
+	}
+	exported, fetched := j.resumeRows(), j.resumeFetched()
+	for item := range stream.Items {
+		if !item.EndOfPage {
+			page = append(page, outputRecords(item.Customer)...)
+			exported++
+			continue
+		}
+		fetched += item.Fetched
+		if err := writePage(); err != nil {
+			return exported, err
+		}
+		if j == nil {
+			continue
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return exported, fmt.Errorf("failed to export CSV: %w", err)
+		}
+		if err := file.Sync(); err != nil {
+			return exported, fmt.Errorf("failed to export CSV: %w", err)
+		}
+		offset, err := file.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return exported, fmt.Errorf("failed to export CSV: %w", err)
+		}
+		if err := j.checkpoint(item.Cursor, exported, fetched, offset); err != nil {
+			return exported, err
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return exported, err
+	}
+	if exported == 0 && failIfEmpty {
+		return 0, errEmptySegment
+	}
+	if err := writePage(); err != nil {
+		return exported, err
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return exported, fmt.Errorf("failed to export CSV: %w", err)
+	}
+
+	if clipboard != nil {
+		return exported, copyToClipboard(clipboard.Bytes())
+	}
+	if file != nil && j == nil {
+		if err := file.Close(); err != nil {
+			return exported, fmt.Errorf("failed to export CSV: %w", err)
+		}
+		if err := os.Rename(file.Name(), output); err != nil {
+			return exported, fmt.Errorf("failed to export CSV: %w", err)
+		}
+	}
+	return exported, nil
+}
+
+// streamToPartitionedCSV writes the stream to one CSV file per partitionKey.
+func streamToPartitionedCSV(ctx context.Context, stream *segmentStream, output string, failIfEmpty bool) (int, error) {
+	rows, header, err := newRowTransform(ctx, outputHeader())
+	if err != nil {
+		return 0, err
+	}
+	files := newPartitionedCSV(output, header)
+	defer files.abort()
+
+	var keys []string
+	var page [][]string
+	writePage := func() error {
+		err := rows.applyByKey(ctx, keys, page, files.write)
+		keys, page = keys[:0], page[:0]
+		if err != nil {
+			return fmt.Errorf("failed to export CSV: %w", err)
+		}
+		return nil
+	}
+	exported := 0
+	for item := range stream.Items {
+		if item.EndOfPage {
+			if err := writePage(); err != nil {
+				return exported, err
+			}
+			continue
+		}
+		for _, record := range outputRecords(item.Customer) {
+			keys = append(keys, partitionKey(item.Customer))
+			page = append(page, record)
+		}
+		exported++
+	}
+	if err := stream.Err(); err != nil {
+		return exported, err
+	}
+	if exported == 0 && failIfEmpty {
+		return 0, errEmptySegment
+	}
+	if err := writePage(); err != nil {
+		return exported, err
+	}
+	if err := files.commit(); err != nil {
+		return exported, fmt.Errorf("failed to export CSV: %w", err)
+	}
+	return exported, nil
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sultans/shopify"
+)
+
+// newMockClient serves the mock shop of n customers for the duration of the
+// test and returns a client of it.
+func newMockClient(t *testing.T, n int) *shopify.Client {
+	t.Helper()
+	customers := mockCustomers(n, []string{"USD", "EUR"}, []string{"US", "DE"}, 0.1, 1)
+	server := httptest.NewServer(mockHandler(customers, mockShop{Currency: "USD", Timezone: "UTC"}))
+	t.Cleanup(server.Close)
+	return &shopify.Client{Domain: server.URL, AccessToken: "test"}
+}
+
+// collectStream reads s to the end, returning the member IDs and the Fetched
+// count of every page.
+func collectStream(t *testing.T, s *segmentStream) (ids []string, pages []int) {
+	t.Helper()
+	for item := range s.Items {
+		if item.EndOfPage {
+			pages = append(pages, item.Fetched)
+			continue
+		}
+		ids = append(ids, item.Customer.Node.Id)
+	}
+	return ids, pages
+}
+
+func TestFetchSegmentStream(t *testing.T) {
+	client := newMockClient(t, 20)
+	all, _ := collectStream(t, fetchSegmentStream(context.Background(), client, SegmentQuery{First: 20, SortKey: "amount_spent", Reverse: true}, 1, nil))
+	if len(all) != 20 {
+		t.Fatalf("fetched %d members of 20", len(all))
+	}
+
+	tests := []struct {
+		name     string
+		first    int
+		pageSize int
+		prefetch int
+		want     []string
+		pages    []int
+	}{
+		{name: "single page", first: 7, pageSize: 250, prefetch: 1, want: all[:7], pages: []int{7}},
+		{name: "several pages", first: 7, pageSize: 3, prefetch: 1, want: all[:7], pages: []int{3, 3, 1}},
+		{name: "no prefetch", first: 7, pageSize: 3, prefetch: 0, want: all[:7], pages: []int{3, 3, 1}},
+		{name: "more than the segment", first: 50, pageSize: 8, prefetch: 2, want: all, pages: []int{8, 8, 4}},
+		{name: "page size over the maximum", first: 20, pageSize: 1000, prefetch: 1, want: all, pages: []int{20}},
+		{name: "none", first: 0, pageSize: 3, prefetch: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := SegmentQuery{First: tt.first, PageSize: tt.pageSize, SortKey: "amount_spent", Reverse: true}
+			stream := fetchSegmentStream(context.Background(), client, q, tt.prefetch, nil)
+			ids, pages := collectStream(t, stream)
+			if err := stream.Err(); err != nil {
+				t.Fatalf("stream failed: %v", err)
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("members = %q, want %q", ids, tt.want)
+			}
+			if !reflect.DeepEqual(pages, tt.pages) {
+				t.Errorf("page sizes = %v, want %v", pages, tt.pages)
+			}
+		})
+	}
+}
+
+func TestFetchSegmentStreamAfter(t *testing.T) {
+	client := newMockClient(t, 10)
+	q := SegmentQuery{First: 10, PageSize: 4, SortKey: "amount_spent"}
+	var all []string
+	var cursor string
+	stream := fetchSegmentStream(context.Background(), client, q, 1, nil)
+	for item := range stream.Items {
+		if item.EndOfPage && cursor == "" {
+			cursor = item.Cursor
+		}
+		if !item.EndOfPage {
+			all = append(all, item.Customer.Node.Id)
+		}
+	}
+	if cursor == "" {
+		t.Fatal("first page has no cursor")
+	}
+
+	q.After, q.First = cursor, 6
+	rest, _ := collectStream(t, fetchSegmentStream(context.Background(), client, q, 1, nil))
+	if !reflect.DeepEqual(rest, all[4:]) {
+		t.Errorf("members after the first page = %q, want %q", rest, all[4:])
+	}
+}
+
+func TestFetchSegmentStreamCancel(t *testing.T) {
+	client := newMockClient(t, 20)
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := fetchSegmentStream(ctx, client, SegmentQuery{First: 20, PageSize: 2}, 0, nil)
+	<-stream.Items
+	cancel()
+	for range stream.Items {
+	}
+	if err := stream.Err(); err != nil && !errors.Is(err, context.Canceled) {
+		t.Errorf("stream failed with %v, want no error or context.Canceled", err)
+	}
+}
+
+func TestFetchSegmentStreamUnauthorized(t *testing.T) {
+	client := newMockClient(t, 5)
+	client.AccessToken = ""
+	stream := fetchSegmentStream(context.Background(), client, SegmentQuery{First: 5}, 1, nil)
+	ids, _ := collectStream(t, stream)
+	if len(ids) != 0 {
+		t.Errorf("fetched %d members without an access token", len(ids))
+	}
+	if err := stream.Err(); !errors.Is(err, shopify.ErrUnauthorized) {
+		t.Errorf("stream failed with %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestSegmentPageDecoder(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		ids     []string
+		present bool
+		cursor  string
+		wantErr bool
+	}{
+		{
+			name:    "page",
+			data:    `{"customerSegmentMembers": {"totalCount": 2, "edges": [{"node": {"id": "a"}}, {"node": {"id": "b"}}], "pageInfo": {"hasNextPage": true, "endCursor": "c"}}}`,
+			ids:     []string{"a", "b"},
+			present: true,
+			cursor:  "c",
+		},
+		{
+			name:    "pageInfo first",
+			data:    `{"customerSegmentMembers": {"pageInfo": {"hasNextPage": false, "endCursor": "c"}, "edges": [{"node": {"id": "a"}}]}}`,
+			ids:     []string{"a"},
+			present: true,
+			cursor:  "c",
+		},
+		{name: "null connection", data: `{"customerSegmentMembers": null}`},
+		{name: "other fields", data: `{"shop": {"name": "x"}}`},
+		{name: "invalid edge", data: `{"customerSegmentMembers": {"edges": [{"node": {"id": "a"}}, 1]}}`, ids: []string{"a"}, present: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ids []string
+			d := &segmentPageDecoder{emit: func(c CustomerSegmentMember) error {
+				ids = append(ids, c.Node.Id)
+				return nil
+			}}
+			err := d.DecodeStream(json.NewDecoder(strings.NewReader(tt.data)))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeStream() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(ids, tt.ids) || d.count != len(tt.ids) {
+				t.Errorf("DecodeStream() members = %q (count %d), want %q", ids, d.count, tt.ids)
+			}
+			if d.present != tt.present {
+				t.Errorf("DecodeStream() present = %v, want %v", d.present, tt.present)
+			}
+			if d.pageInfo.EndCursor != tt.cursor {
+				t.Errorf("DecodeStream() cursor = %q, want %q", d.pageInfo.EndCursor, tt.cursor)
+			}
+		})
+	}
+}
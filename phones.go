@@ -0,0 +1,391 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/urfave/cli/v2"
+)
+
+// phones normalizes the phone numbers of fetched customers when
+// --normalize-phones is set.
+var phones *phoneValidator
+
+// callingCodes maps ISO 3166-1 alpha-2 codes to their country calling code.
+var callingCodes = map[string]string{}
+
+// callingCodeSet lists every calling code, to find the one an international
+// number starts with.
+var callingCodeSet = map[string]bool{}
+
+func init() {
+	for _, line := range strings.Split(`
+		1 US CA AG AI AS BB BM BS DM DO GD GU JM KN KY LC MP MS PR SX TC TT UM VC VG VI
+		7 RU KZ
+		20 EG
+		27 ZA
+		30 GR
+		31 NL
+		32 BE
+		33 FR
+		34 ES
+		36 HU
+		39 IT VA
+		40 RO
+		41 CH
+		43 AT
+		44 GB GG IM JE
+		45 DK
+		46 SE
+		47 NO SJ
+		48 PL
+		49 DE
+		51 PE
+		52 MX
+		53 CU
+		54 AR
+		55 BR
+		56 CL
+		57 CO
+		58 VE
+		60 MY
+		61 AU CC CX
+		62 ID
+		63 PH
+		64 NZ PN
+		65 SG
+		66 TH
+		81 JP
+		82 KR
+		84 VN
+		86 CN
+		90 TR
+		91 IN
+		92 PK
+		93 AF
+		94 LK
+		95 MM
+		98 IR
+		211 SS
+		212 MA EH
+		213 DZ
+		216 TN
+		218 LY
+		220 GM
+		221 SN
+		222 MR
+		223 ML
+		224 GN
+		225 CI
+		226 BF
+		227 NE
+		228 TG
+		229 BJ
+		230 MU
+		231 LR
+		232 SL
+		233 GH
+		234 NG
+		235 TD
+		236 CF
+		237 CM
+		238 CV
+		239 ST
+		240 GQ
+		241 GA
+		242 CG
+		243 CD
+		244 AO
+		245 GW
+		246 IO
+		248 SC
+		249 SD
+		250 RW
+		251 ET
+		252 SO
+		253 DJ
+		254 KE
+		255 TZ
+		256 UG
+		257 BI
+		258 MZ
+		260 ZM
+		261 MG
+		262 RE YT
+		263 ZW
+		264 NA
+		265 MW
+		266 LS
+		267 BW
+		268 SZ
+		269 KM
+		290 SH
+		291 ER
+		297 AW
+		298 FO
+		299 GL
+		350 GI
+		351 PT
+		352 LU
+		353 IE
+		354 IS
+		355 AL
+		356 MT
+		357 CY
+		358 FI AX
+		359 BG
+		370 LT
+		371 LV
+		372 EE
+		373 MD
+		374 AM
+		375 BY
+		376 AD
+		377 MC
+		378 SM
+		380 UA
+		381 RS
+		382 ME
+		385 HR
+		386 SI
+		387 BA
+		389 MK
+		420 CZ
+		421 SK
+		423 LI
+		500 FK GS
+		501 BZ
+		502 GT
+		503 SV
+		504 HN
+		505 NI
+		506 CR
+		507 PA
+		508 PM
+		509 HT
+		590 GP BL MF
+		591 BO
+		592 GY
+		593 EC
+		594 GF
+		595 PY
+		596 MQ
+		597 SR
+		598 UY
+		599 CW BQ
+		670 TL
+		672 NF
+		673 BN
+		674 NR
+		675 PG
+		676 TO
+		677 SB
+		678 VU
+		679 FJ
+		680 PW
+		681 WF
+		682 CK
+		683 NU
+		685 WS
+		686 KI
+		687 NC
+		688 TV
+		689 PF
+		690 TK
+		691 FM
+		692 MH
+		850 KP
+		852 HK
+		853 MO
+		855 KH
+		856 LA
+		880 BD
+		886 TW
+		960 MV
+		961 LB
+		962 JO
+		963 SY
+		964 IQ
+		965 KW
+		966 SA
+		967 YE
+		968 OM
+		970 PS
+		971 AE
+		972 IL
+		973 BH
+		974 QA
+		975 BT
+		976 MN
+		977 NP
+		992 TJ
+		993 TM
+		994 AZ
+		995 GE
+		996 KG
+		998 UZ`, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		callingCodeSet[fields[0]] = true
+		for _, country := range fields[1:] {
+			callingCodes[country] = fields[0]
+		}
+	}
+}
+
+// keepsTrunkZero are the countries whose national numbers keep their leading 0
+// after the calling code.
+var keepsTrunkZero = map[string]bool{"IT": true, "VA": true, "SM": true}
+
+// phoneValidator rewrites phone numbers to E.164 and removes the ones that cannot
+// be parsed, which are exported as missing and optionally written to a rejects
+// file. It is shared by concurrent exports.
+type phoneValidator struct {
+	mu       sync.Mutex
+	file     *os.File
+	rejects  *csv.Writer
+	rejected int
+}
+
+func newPhoneValidator(c *cli.Context) (*phoneValidator, error) {
+	if !c.Bool("normalize-phones") {
+		return nil, nil
+	}
+	v := &phoneValidator{}
+	if path := c.String("phone-rejects"); path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create phone rejects file: %w", err)
+		}
+		v.file = f
+		v.rejects = csv.NewWriter(f)
+		if err := v.rejects.Write([]string{"ID", "Display Name", "Phone Number", "Country Code", "Reason"}); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to write phone rejects file: %w", err)
+		}
+	}
+	return v, nil
+}
+
+// apply normalizes the phone number of c, with the country of its default
+// address for numbers without a calling code, clearing it if it is invalid.
+func (v *phoneValidator) apply(c *CustomerSegmentMember) error {
+	p := c.Node.DefaultPhoneNumber
+	if p == nil {
+		return nil
+	}
+	var country string
+	if a := c.Node.DefaultAddress; a != nil {
+		if country = normalizeCountry(string(a.CountryCodeV2)); country == "" {
+			country = normalizeCountry(a.Country)
+		}
+	}
+	phone, reason := parseE164(p.PhoneNumber, country)
+	if reason == "" {
+		p.PhoneNumber = phone
+		return nil
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.rejected++
+	if v.rejects != nil {
+		if err := v.rejects.Write([]string{c.Node.Id, c.Node.DisplayName, p.PhoneNumber, country, reason}); err != nil {
+			return fmt.Errorf("failed to write phone rejects file: %w", err)
+		}
+	}
+	c.Node.DefaultPhoneNumber = nil
+	return nil
+}
+
+// parseE164 returns phone in E.164, such as +14155550123, or the reason it
+// cannot be parsed. Numbers starting with + or an international prefix (00 or
+// 011) carry their calling code; others are national numbers of country, whose
+// trunk prefix (0, or 1 in North America) is dropped. Extensions are removed.
+func parseE164(phone, country string) (string, string) {
+	phone = strings.ToLower(strings.TrimSpace(phone))
+	for _, sep := range []string{"ext", "x", "#"} {
+		if i := strings.Index(phone, sep); i > 0 {
+			phone = phone[:i]
+		}
+	}
+	international := strings.HasPrefix(phone, "+")
+	var digits strings.Builder
+	for _, r := range phone {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r != ' ' && r != '-' && r != '.' && r != '(' && r != ')' && r != '/' && !(r == '+' && digits.Len() == 0):
+			return "", "invalid characters"
+		}
+	}
+	number := digits.String()
+	if number == "" {
+		return "", "no digits"
+	}
+
+	code := callingCodes[country]
+	switch {
+	case international:
+	case strings.HasPrefix(number, "00"):
+		number = number[2:]
+	case code == "1" && strings.HasPrefix(number, "011"):
+		number = number[3:]
+	case country == "":
+		return "", "no calling code or address country"
+	case code == "":
+		return "", "unknown address country " + country
+	case code == "1":
+		if len(number) == 11 && number[0] == '1' {
+			number = number[1:]
+		}
+		number = code + number
+	default:
+		if number[0] == '0' && !keepsTrunkZero[country] {
+			number = number[1:]
+		}
+		number = code + number
+	}
+
+	code = ""
+	for n := 1; n <= 3 && n <= len(number); n++ {
+		if callingCodeSet[number[:n]] {
+			code = number[:n]
+			break
+		}
+	}
+	national := strings.TrimPrefix(number, code)
+	switch {
+	case code == "":
+		return "", "unknown calling code"
+	case len(number) > 15:
+		return "", "too long"
+	case code == "1" && (len(national) != 10 || national[0] < '2'):
+		return "", "not a 10-digit North American number"
+	case len(national) < 4 || len(number) < 8:
+		return "", "too short"
+	}
+	return "+" + number, ""
+}
+
+// Close flushes the rejects file and logs how many numbers were rejected.
+func (v *phoneValidator) Close() error {
+	if v.rejected > 0 {
+		log.Printf("%d invalid phone numbers were removed", v.rejected)
+	}
+	if v.file == nil {
+		return nil
+	}
+	v.rejects.Flush()
+	if err := v.rejects.Error(); err != nil {
+		v.file.Close()
+		return fmt.Errorf("failed to write phone rejects file: %w", err)
+	}
+	return v.file.Close()
+}
@@ -0,0 +1,16 @@
+package main
+
+// pipeline holds the reports of one export, accumulated over its customers on
+// the way to the output. Each export starts its own from the configured report,
+// quality and domains, so concurrent exports do not mix their customers; a nil
+// pipeline, as used by serve, collects none.
+type pipeline struct {
+	report  *exportReport
+	quality *qualityReport
+	domains *domainReport
+}
+
+// newPipeline returns an empty pipeline for an export.
+func newPipeline() *pipeline {
+	return &pipeline{report: report.start(), quality: quality.start(), domains: domains.start()}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// pluginPrefix marks an output such as "plugin:./my-sink" as an exec plugin.
+const pluginPrefix = "plugin:"
+
+// transformPlugin rewrites every page of customers, set by --transform-plugin.
+var transformPlugin *execPlugin
+
+// pluginBatch is what plugins read on stdin and transform plugins write back on
+// stdout: the same {"customers": [...]} body the webhook destination posts.
+type pluginBatch struct {
+	Customers []Node `json:"customers"`
+}
+
+// execPlugin is an external command that is run once per batch of customers.
+// Anything it writes to stderr is included in the error when it exits non-zero.
+type execPlugin struct {
+	name string
+	args []string
+}
+
+// newExecPlugin parses a plugin command line such as "./my-sink --verbose".
+// Arguments are split on whitespace, without shell quoting.
+func newExecPlugin(command string) (*execPlugin, error) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty plugin command")
+	}
+	if _, err := exec.LookPath(fields[0]); err != nil {
+		return nil, fmt.Errorf("plugin %q: %w", fields[0], err)
+	}
+	return &execPlugin{name: fields[0], args: fields[1:]}, nil
+}
+
+// run sends customers to the plugin and returns what it wrote to stdout.
+func (p *execPlugin) run(ctx context.Context, customers []CustomerSegmentMember) ([]byte, error) {
+	batch := pluginBatch{Customers: make([]Node, 0, len(customers))}
+	for _, c := range customers {
+		batch.Customers = append(batch.Customers, c.Node)
+	}
+	input, err := json.Marshal(batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal plugin input: %w", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.name, p.args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("plugin %s failed: %w: %s", p.name, err, msg)
+		}
+		return nil, fmt.Errorf("plugin %s failed: %w", p.name, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// pluginSink hands every batch of exported customers to a plugin. Its stdout is
+// ignored.
+type pluginSink struct {
+	plugin *execPlugin
+}
+
+func newPluginSink(command string) (*pluginSink, error) {
+	p, err := newExecPlugin(command)
+	if err != nil {
+		return nil, err
+	}
+	return &pluginSink{plugin: p}, nil
+}
+
+func (s *pluginSink) Write(ctx context.Context, customers []CustomerSegmentMember) error {
+	_, err := s.plugin.run(ctx, customers)
+	return err
+}
+
+// transform replaces the customers of a page with the ones the plugin returns,
+// so it can change, drop or add customers. Returned customers keep the fields
+// looked up on the customer with their ID.
+func (p *execPlugin) transform(ctx context.Context, customers []CustomerSegmentMember) ([]CustomerSegmentMember, error) {
+	out, err := p.run(ctx, customers)
+	if err != nil {
+		return nil, err
+	}
+	var batch pluginBatch
+	if err := json.Unmarshal(out, &batch); err != nil {
+		return nil, fmt.Errorf("plugin %s wrote invalid output: %w", p.name, err)
+	}
+	fields := make(map[string]*memberFields, len(customers))
+	for _, c := range customers {
+		fields[c.Node.Id] = c.fields
+	}
+	transformed := make([]CustomerSegmentMember, 0, len(batch.Customers))
+	for _, node := range batch.Customers {
+		f := fields[node.Id]
+		if f == nil {
+			f = &memberFields{}
+		}
+		transformed = append(transformed, CustomerSegmentMember{Node: node, fields: f})
+	}
+	return transformed, nil
+}
+
+// wrap runs the members of each page of in through the plugin before delivering
+// them and the page boundary.
+func (p *execPlugin) wrap(ctx context.Context, in *segmentStream) *segmentStream {
+	items := make(chan segmentItem)
+	out := &segmentStream{Items: items}
+	go func() {
+		defer close(items)
+		var customers []CustomerSegmentMember
+		for item := range in.Items {
+			if !item.EndOfPage {
+				customers = append(customers, item.Customer)
+				continue
+			}
+			transformed, err := p.transform(ctx, customers)
+			if err != nil {
+				out.err = err
+				return
+			}
+			page := make([]segmentItem, 0, len(transformed)+1)
+			for _, c := range transformed {
+				page = append(page, segmentItem{Customer: c})
+			}
+			for _, item := range append(page, item) {
+				select {
+				case items <- item:
+				case <-ctx.Done():
+					out.err = ctx.Err()
+					return
+				}
+			}
+			customers = customers[:0]
+		}
+		out.err, out.partial = in.err, in.partial
+	}()
+	return out
+}
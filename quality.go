@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/shopspring/decimal"
+	"github.com/urfave/cli/v2"
+)
+
+// quality collects the --quality-report statistics, nil without it.
+var quality *qualityReport
+
+// qualityReport counts missing and repeated values per CSV column of an export,
+// invalid email addresses, and the customers and spend range per currency, so
+// upstream data problems show up before the file is used.
+type qualityReport struct {
+	path   string
+	header []string
+
+	customers     int
+	rows          int
+	missing       []int
+	duplicates    []int
+	seen          []map[string]bool
+	invalidEmails int
+	currencies    map[string]*currencySpend
+}
+
+// currencySpend is the number of customers and their lowest and highest spend in
+// one currency.
+type currencySpend struct {
+	customers int
+	min, max  decimal.Decimal
+}
+
+func newQualityReport(c *cli.Context) (*qualityReport, error) {
+	path := c.String("quality-report")
+	if path == "" {
+		return nil, nil
+	}
+	if c.String("queries-file") != "" {
+		return nil, fmt.Errorf("--quality-report cannot be combined with --queries-file")
+	}
+	return &qualityReport{path: path, header: outputHeader()}, nil
+}
+
+// start returns an empty report of one export for the same file and columns as
+// q, or nil without --quality-report.
+func (q *qualityReport) start() *qualityReport {
+	if q == nil {
+		return nil
+	}
+	s := &qualityReport{
+		path:       q.path,
+		header:     q.header,
+		missing:    make([]int, len(q.header)),
+		duplicates: make([]int, len(q.header)),
+		seen:       make([]map[string]bool, len(q.header)),
+		currencies: map[string]*currencySpend{},
+	}
+	for i := range s.seen {
+		s.seen[i] = map[string]bool{}
+	}
+	return s
+}
+
+// wrap records the members of in as they pass through.
+func (q *qualityReport) wrap(ctx context.Context, in *segmentStream) *segmentStream {
+	return filterStream(ctx, in, func(c CustomerSegmentMember) bool {
+		q.add(c)
+		return true
+	})
+}
+
+func (q *qualityReport) add(c CustomerSegmentMember) {
+	q.customers++
+	records := outputRecords(c)
+	q.rows += len(records)
+	// Columns are counted per customer: missing if none of its rows has a value,
+	// a duplicate if a value already appeared for an earlier customer.
+	for i := range q.header {
+		missing, duplicate := true, false
+		for _, record := range records {
+			if v := record[i]; v != "" && v != nullValue {
+				missing = false
+				duplicate = duplicate || q.seen[i][v]
+			}
+		}
+		for _, record := range records {
+			if v := record[i]; v != "" && v != nullValue {
+				q.seen[i][v] = true
+			}
+		}
+		if missing {
+			q.missing[i]++
+		}
+		if duplicate {
+			q.duplicates[i]++
+		}
+	}
+
+	if e := c.Node.DefaultEmailAddress; e != nil {
+		if _, reason := (&emailValidator{}).normalize(e.EmailAddress); reason != "" {
+			q.invalidEmails++
+		}
+	}
+
+	amount := c.Node.AmountSpent.Amount
+	currency := string(c.Node.AmountSpent.CurrencyCode)
+	s := q.currencies[currency]
+	if s == nil {
+		s = &currencySpend{min: amount, max: amount}
+		q.currencies[currency] = s
+	}
+	s.customers++
+	if amount.LessThan(s.min) {
+		s.min = amount
+	}
+	if amount.GreaterThan(s.max) {
+		s.max = amount
+	}
+}
+
+type qualityColumn struct {
+	Name             string  `json:"name"`
+	Missing          int     `json:"missing"`
+	MissingPercent   float64 `json:"missingPercent"`
+	Duplicates       int     `json:"duplicates"`
+	DuplicatePercent float64 `json:"duplicatePercent"`
+	Distinct         int     `json:"distinct"`
+}
+
+type qualityCurrency struct {
+	Currency  string  `json:"currency"`
+	Customers int     `json:"customers"`
+	Percent   float64 `json:"percent"`
+	MinSpend  string  `json:"minSpend"`
+	MaxSpend  string  `json:"maxSpend"`
+}
+
+type qualitySummary struct {
+	Customers     int               `json:"customers"`
+	Rows          int               `json:"rows"`
+	Columns       []qualityColumn   `json:"columns"`
+	InvalidEmails int               `json:"invalidEmails"`
+	Currencies    []qualityCurrency `json:"currencies"`
+}
+
+func percentOf(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) * 100 / float64(total)
+}
+
+func (q *qualityReport) summarize() qualitySummary {
+	s := qualitySummary{Customers: q.customers, Rows: q.rows, InvalidEmails: q.invalidEmails}
+	for i, name := range q.header {
+		s.Columns = append(s.Columns, qualityColumn{
+			Name:             name,
+			Missing:          q.missing[i],
+			MissingPercent:   percentOf(q.missing[i], q.customers),
+			Duplicates:       q.duplicates[i],
+			DuplicatePercent: percentOf(q.duplicates[i], q.customers),
+			Distinct:         len(q.seen[i]),
+		})
+	}
+	for currency, spend := range q.currencies {
+		s.Currencies = append(s.Currencies, qualityCurrency{
+			Currency:  currency,
+			Customers: spend.customers,
+			Percent:   percentOf(spend.customers, q.customers),
+			MinSpend:  spend.min.StringFixed(2),
+			MaxSpend:  spend.max.StringFixed(2),
+		})
+	}
+	// Most common currency first, like the summary.
+	sort.Slice(s.Currencies, func(i, j int) bool {
+		a, b := s.Currencies[i], s.Currencies[j]
+		if a.Customers != b.Customers {
+			return a.Customers > b.Customers
+		}
+		return a.Currency < b.Currency
+	})
+	return s
+}
+
+// write saves the report as JSON if its file ends in .json, otherwise as text
+// tables.
+func (q *qualityReport) write() error {
+	file, err := os.Create(q.path)
+	if err != nil {
+		return fmt.Errorf("failed to write quality report: %w", err)
+	}
+	s := q.summarize()
+	if strings.EqualFold(filepath.Ext(q.path), ".json") {
+		enc := json.NewEncoder(file)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(s)
+	} else {
+		err = writeQualityText(file, s)
+	}
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write quality report: %w", err)
+	}
+	return file.Close()
+}
+
+func writeQualityText(w io.Writer, s qualitySummary) error {
+	fmt.Fprintf(w, "Customers: %d\nRows: %d\nInvalid email addresses: %d\n\n", s.Customers, s.Rows, s.InvalidEmails)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "COLUMN\tMISSING\tDUPLICATES\tDISTINCT")
+	for _, col := range s.Columns {
+		fmt.Fprintf(tw, "%s\t%d (%.1f%%)\t%d (%.1f%%)\t%d\n", col.Name, col.Missing, col.MissingPercent, col.Duplicates, col.DuplicatePercent, col.Distinct)
+	}
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "CURRENCY\tCUSTOMERS\tMIN SPEND\tMAX SPEND")
+	for _, cur := range s.Currencies {
+		fmt.Fprintf(tw, "%s\t%d (%.1f%%)\t%s\t%s\n", cur.Currency, cur.Customers, cur.Percent, cur.MinSpend, cur.MaxSpend)
+	}
+	return tw.Flush()
+}
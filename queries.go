@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// segmentJob is one row of a --queries-file.
+type segmentJob struct {
+	Output string
+	Query  string
+}
+
+// readQueriesFile reads "output,query" rows. Blank lines and lines starting with
+// "#" are skipped; queries containing commas must be quoted.
+func readQueriesFile(path string) ([]segmentJob, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comment = '#'
+	r.FieldsPerRecord = 2
+	r.TrimLeadingSpace = true
+
+	var jobs []segmentJob
+	seen := map[string]bool{}
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid queries file: %w", err)
+		}
+		job := segmentJob{Output: strings.TrimSpace(record[0]), Query: strings.TrimSpace(record[1])}
+		if job.Output == "" || job.Query == "" {
+			return nil, fmt.Errorf("invalid queries file: empty output or query in %q", strings.Join(record, ","))
+		}
+		if seen[job.Output] {
+			return nil, fmt.Errorf("invalid queries file: output %q is used more than once", job.Output)
+		}
+		seen[job.Output] = true
+		jobs = append(jobs, job)
+	}
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("queries file %s has no queries", path)
+	}
+	return jobs, nil
+}
+
+// exportQueriesFile exports every segment in the queries file.
+func exportQueriesFile(c *cli.Context, path string) error {
+	if c.String("journal") != "" {
+		return fmt.Errorf("--journal cannot be combined with --queries-file")
+	}
+	jobs, err := readQueriesFile(path)
+	if err != nil {
+		return err
+	}
+	return exportJobs(c, jobs, segmentQueryFromFlags(c), c.Duration("timeout"))
+}
+
+// exportJobs exports every segment through a pool of --concurrency workers
+// sharing one Shopify client, so retries, the circuit breaker and --max-rps
+// apply across all of them. The queries are those of the jobs with the other
+// settings of defaults. Each segment gets its own timeout, none when it is 0.
+// Failures are reported per segment and do not stop the others.
+func exportJobs(c *cli.Context, jobs []segmentJob, defaults SegmentQuery, timeout time.Duration) error {
+	outputs := make([]string, len(jobs))
+	for i, job := range jobs {
+		outputs[i] = job.Output
+	}
+	lock, err := acquireRunLock(c, outputs...)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	client, err := newShopifyClient(c)
+	if err != nil {
+		return err
+	}
+	state, err := openStateDBFromFlags(c)
+	if err != nil {
+		return err
+	}
+	if state != nil {
+		defer state.Close()
+	}
+	ctx, cancel := exportContext(timeout)
+	err = resolvePrimaryCurrency(ctx, c)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	work := make(chan segmentJob)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	failed, empty, partial := 0, 0, 0
+
+	for i := 0; i < max(c.Int("concurrency"), 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range work {
+				q := defaults
+				q.Query = job.Query
+
+				run := startRun("export", q, job.Output)
+				exported, err := 0, runHook(c, "pre-hook", run)
+				if err == nil {
+					ctx, cancel := exportContext(timeout)
+					exported, err = exportSegment(ctx, c, client, state, q, job.Output, newPipeline())
+					cancel()
+				}
+				err = runPostHook(c, recordRun(c, run, exported, err), err)
+
+				if errors.Is(err, errPartialData) {
+					log.Printf("exported partial data to %s: %v", job.Output, err)
+					mu.Lock()
+					partial++
+					mu.Unlock()
+					err = nil
+				}
+				if err != nil {
+					log.Printf("failed to export %s: %v", job.Output, err)
+					mu.Lock()
+					failed++
+					if errors.Is(err, errEmptySegment) {
+						empty++
+					}
+					mu.Unlock()
+					continue
+				}
+				fmt.Fprintf(statusOutput(job.Output), "Successfully exported %d customers to %s\n", exported, outputName(job.Output))
+			}
+		}()
+	}
+	for _, job := range jobs {
+		work <- job
+	}
+	close(work)
+	wg.Wait()
+
+	if failed > 0 && failed == empty {
+		return fmt.Errorf("%d of %d segments: %w", empty, len(jobs), errEmptySegment)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d segment exports failed", failed, len(jobs))
+	}
+	if partial > 0 {
+		return fmt.Errorf("%d of %d segments: %w", partial, len(jobs), errPartialData)
+	}
+	return nil
+}
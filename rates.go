@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/urfave/cli/v2"
+)
+
+// currencyRates converts amounts into primaryCurrency for the grand total of
+// --summary and --report and for --tiers, nil without --rates or without them.
+var currencyRates *exchangeRates
+
+// primaryCurrency is --primary-currency, or the shop's primary currency once
+// resolvePrimaryCurrency looked it up.
+var primaryCurrency string
+
+// configureRates loads --rates when an output converts amounts with them.
+func configureRates(c *cli.Context) error {
+	primaryCurrency = strings.ToUpper(c.String("primary-currency"))
+	if primaryCurrency != "" && !iso4217[primaryCurrency] {
+		return fmt.Errorf("invalid --primary-currency %q, expected an ISO 4217 currency code", primaryCurrency)
+	}
+	source := c.String("rates")
+	if source == "" || c.String("report") == "" && !c.Bool("summary") && c.String("tiers") == "" {
+		return nil
+	}
+	var err error
+	currencyRates, err = loadExchangeRates(context.Background(), source)
+	return err
+}
+
+// resolvePrimaryCurrency looks up the shop's primary currency, unless
+// --primary-currency set it or no rates are configured.
+func resolvePrimaryCurrency(ctx context.Context, c *cli.Context) error {
+	if currencyRates == nil || primaryCurrency != "" {
+		return nil
+	}
+	client, err := newShopifyClient(c)
+	if err != nil {
+		return err
+	}
+	resp, err := GetShop(ctx, client)
+	if err != nil {
+		return fmt.Errorf("failed to fetch the shop currency: %w", err)
+	}
+	primaryCurrency = string(resp.Shop.CurrencyCode)
+	return nil
+}
+
+// exchangeRates converts amounts between currencies, loaded by --rates from a
+// file or URL serving {"base": "USD", "rates": {"EUR": 0.92, ...}}: how many
+// units of each currency one unit of the base buys, the format most rate
+// providers use.
+type exchangeRates struct {
+	source string
+	base   string
+	rates  map[string]decimal.Decimal
+}
+
+func loadExchangeRates(ctx context.Context, source string) (*exchangeRates, error) {
+	var r io.Reader
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --rates URL: %w", err)
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch exchange rates: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to fetch exchange rates: %s", resp.Status)
+		}
+		r = resp.Body
+	} else {
+		f, err := os.Open(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read exchange rates: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var doc struct {
+		Base  string                     `json:"base"`
+		Rates map[string]decimal.Decimal `json:"rates"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid exchange rates: %w", err)
+	}
+	if doc.Base == "" {
+		return nil, fmt.Errorf("invalid exchange rates: missing base currency")
+	}
+	rates := &exchangeRates{source: source, base: strings.ToUpper(doc.Base), rates: map[string]decimal.Decimal{}}
+	for currency, rate := range doc.Rates {
+		currency = strings.ToUpper(currency)
+		if !rate.IsPositive() {
+			return nil, fmt.Errorf("invalid exchange rates: rate of %s must be positive", currency)
+		}
+		checkCurrency(currency)
+		rates.rates[currency] = rate
+	}
+	rates.rates[rates.base] = decimal.NewFromInt(1)
+	return rates, nil
+}
+
+// convert returns amount in currency from expressed in currency to, through the
+// base currency. It returns false if either currency has no rate.
+func (r *exchangeRates) convert(amount decimal.Decimal, from, to string) (decimal.Decimal, bool) {
+	fromRate, ok := r.rates[from]
+	if !ok {
+		return decimal.Decimal{}, false
+	}
+	toRate, ok := r.rates[to]
+	if !ok {
+		return decimal.Decimal{}, false
+	}
+	return amount.Div(fromRate).Mul(toRate), true
+}
+
+// grandTotal adds up per-currency totals in currency to. Currencies without a
+// rate are left out and returned, so callers can say the total is incomplete.
+func (r *exchangeRates) grandTotal(totals map[string]decimal.Decimal, to string) (decimal.Decimal, []string) {
+	var sum decimal.Decimal
+	var missing []string
+	for currency, total := range totals {
+		converted, ok := r.convert(total, currency, to)
+		if !ok {
+			missing = append(missing, currency)
+			continue
+		}
+		sum = sum.Add(converted)
+	}
+	sort.Strings(missing)
+	return sum, missing
+}
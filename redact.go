@@ -0,0 +1,130 @@
+package main
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// redaction scrubs credentials, and with --redact-emails email addresses, from
+// log output, errors and HAR captures, so they can be attached to tickets.
+var redaction = newRedactor()
+
+// minSecretLength keeps short values, such as "1" or "true" in a variable named
+// like a secret, from redacting every occurrence of them.
+const minSecretLength = 8
+
+// redactionPatterns match credentials by their shape, for those not known as
+// values: Shopify tokens, credential headers, Bearer and Basic credentials,
+// passwords in URLs and credential query parameters.
+var redactionPatterns = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`shp(?:at|ca|pa|ss|ua)_[0-9a-fA-F]{32}`), "REDACTED"},
+	{regexp.MustCompile(`(?i)\b((?:proxy-)?authorization|x-shopify-access-token|x-api-key|api-key|x-clickhouse-key|set-cookie|cookie)(["']?\s*[:=]\s*["']?)[^"'\r\n]+`), "${1}${2}REDACTED"},
+	{regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/=-]{8,}`), "${1} REDACTED"},
+	{regexp.MustCompile(`(://[^/\s:@]+:)[^/\s@]+@`), "${1}REDACTED@"},
+	{regexp.MustCompile(`(?i)([?&](?:access_token|token|api_key|apikey|key|secret|password|signature)=)[^&\s"']+`), "${1}REDACTED"},
+}
+
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@([A-Za-z0-9-]+\.)+[A-Za-z]{2,}`)
+
+// redactor replaces known secret values and credential patterns with REDACTED.
+type redactor struct {
+	mu      sync.RWMutex
+	secrets []string
+	// emails also replaces the local part of email addresses, keeping the domain.
+	emails bool
+}
+
+// newRedactor knows the values of environment variables named like credentials
+// from the start, so even errors in reading the configuration are redacted.
+func newRedactor() *redactor {
+	r := &redactor{}
+	for _, env := range os.Environ() {
+		name, value, _ := strings.Cut(env, "=")
+		if isSecretEnv(name) {
+			r.addSecret(value)
+		}
+	}
+	return r
+}
+
+// isSecretEnv reports whether an environment variable holds a credential by its
+// name, such as SHOPIFY_ACCESS_TOKEN, AWS_SECRET_ACCESS_KEY or BRAZE_API_KEY.
+// Keys that are field names, such as SHOPIFY_CUSTOMERS_SORT_KEY, are not.
+func isSecretEnv(name string) bool {
+	parts := strings.Split(strings.ToUpper(name), "_")
+	for i, part := range parts {
+		switch part {
+		case "TOKEN", "SECRET", "PASSWORD", "PASSPHRASE", "CREDENTIALS":
+			return parts[len(parts)-1] != "FILE"
+		case "KEY":
+			if i > 0 && i == len(parts)-1 {
+				switch parts[i-1] {
+				case "API", "ACCESS", "WRITE", "STORE", "PRIVATE", "SIGNING", "ENCRYPTION":
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// addSecret redacts value from now on. Secrets read from files and backends are
+// added as they are read.
+func (r *redactor) addSecret(value string) {
+	value = strings.TrimSpace(value)
+	if len(value) < minSecretLength {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.secrets {
+		if s == value {
+			return
+		}
+	}
+	r.secrets = append(r.secrets, value)
+	// Longest first, so a secret containing another is redacted whole.
+	sort.Slice(r.secrets, func(i, j int) bool { return len(r.secrets[i]) > len(r.secrets[j]) })
+}
+
+func (r *redactor) setEmails(emails bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.emails = emails
+}
+
+func (r *redactor) redact(s string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, "REDACTED")
+	}
+	for _, p := range redactionPatterns {
+		s = p.pattern.ReplaceAllString(s, p.replacement)
+	}
+	if r.emails {
+		s = emailPattern.ReplaceAllStringFunc(s, func(email string) string {
+			return "REDACTED@" + email[strings.LastIndex(email, "@")+1:]
+		})
+	}
+	return s
+}
+
+// redactingWriter redacts what is written to w, such as log output.
+type redactingWriter struct {
+	w io.Writer
+}
+
+func (w redactingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, redaction.redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
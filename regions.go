@@ -0,0 +1,149 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// isoCountry is an ISO 3166-1 country: its codes, names and sales region.
+type isoCountry struct {
+	alpha2, alpha3 string
+	region         string
+	names          []string
+}
+
+// isoSubdivision is an ISO 3166-2 subdivision, such as US-CA for California.
+type isoSubdivision struct {
+	code, name string
+}
+
+// countryAliases are names in common use, including Shopify's own, that are not
+// ISO 3166 names.
+var countryAliases = map[string]string{
+	"UK": "GB", "Great Britain": "GB", "England": "GB", "Scotland": "GB", "Wales": "GB", "Northern Ireland": "GB",
+	"Russia": "RU", "Turkey": "TR", "Ivory Coast": "CI", "Cape Verde": "CV", "Swaziland": "SZ",
+	"Macedonia": "MK", "Holland": "NL", "Vatican City": "VA", "Vatican": "VA",
+	"Palestine": "PS", "Palestinian Territories": "PS", "Democratic Republic of the Congo": "CD",
+	"Hong Kong SAR": "HK", "Macao SAR": "MO", "Macau": "MO", "Myanmar (Burma)": "MM", "Burma": "MM",
+}
+
+// Lookups of isoCountries and isoSubdivisions by code and by lookupKey of their
+// names.
+var (
+	countriesByCode    = map[string]*isoCountry{}
+	countriesByName    = map[string]*isoCountry{}
+	subdivisionCodes   = map[string]bool{}
+	subdivisionsByName = map[string]string{}
+	// subdivisionCountries are the countries whose subdivisions are listed, and
+	// so whose province codes can be checked.
+	subdivisionCountries = map[string]bool{}
+)
+
+func init() {
+	for i := range isoCountries {
+		c := &isoCountries[i]
+		countriesByCode[c.alpha2] = c
+		countriesByCode[c.alpha3] = c
+		for _, name := range c.names {
+			countriesByName[lookupKey(name)] = c
+		}
+	}
+	for name, code := range countryAliases {
+		countriesByName[lookupKey(name)] = countriesByCode[code]
+	}
+	for _, s := range isoSubdivisions {
+		country, _, _ := strings.Cut(s.code, "-")
+		subdivisionCodes[s.code] = true
+		subdivisionsByName[country+":"+lookupKey(s.name)] = s.code
+		subdivisionCountries[country] = true
+	}
+}
+
+// lookupKey compares names case-, space- and punctuation-insensitively, so
+// "Bosnia & Herzegovina" matches "Bosnia and Herzegovina".
+func lookupKey(name string) string {
+	name = strings.ReplaceAll(strings.ToLower(name), "&", "and")
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, name)
+}
+
+// normalizeCountry returns the ISO 3166-1 alpha-2 code of a country code or name,
+// such as "US" for "USA" or "United States", or "" if it is not known.
+func normalizeCountry(s string) string {
+	s = strings.TrimSpace(s)
+	if c, ok := countriesByCode[strings.ToUpper(s)]; ok {
+		return c.alpha2
+	}
+	if c, ok := countriesByName[lookupKey(s)]; ok {
+		return c.alpha2
+	}
+	return ""
+}
+
+// normalizeProvince returns the ISO 3166-2 code of a province of country, from
+// Shopify's province code or else its name: "US-CA" for "CA" or "California".
+// Codes of countries whose subdivisions are not listed are taken as they are.
+func normalizeProvince(country, code, name string) string {
+	if country == "" {
+		return ""
+	}
+	if code = strings.ToUpper(strings.TrimSpace(code)); code != "" {
+		code = strings.TrimPrefix(code, country+"-")
+		if !subdivisionCountries[country] || subdivisionCodes[country+"-"+code] {
+			return country + "-" + code
+		}
+	}
+	if name = strings.TrimSpace(name); name == "" {
+		return ""
+	}
+	if c, ok := subdivisionsByName[country+":"+lookupKey(name)]; ok {
+		return c
+	}
+	// Addresses imported without a code often have it in the name.
+	if subdivisionCodes[country+"-"+strings.ToUpper(name)] {
+		return country + "-" + strings.ToUpper(name)
+	}
+	return ""
+}
+
+// regionColumns returns the Country Code, Province Code and Region columns: the
+// customer's default address normalized to ISO 3166 codes, and its region (NA,
+// LATAM, EU, EMEA or APAC). Addresses that cannot be normalized get nullValue.
+func regionColumns() []column {
+	country := func(c CustomerSegmentMember) string {
+		a := c.Node.DefaultAddress
+		if a == nil {
+			return ""
+		}
+		if code := normalizeCountry(string(a.CountryCodeV2)); code != "" {
+			return code
+		}
+		return normalizeCountry(a.Country)
+	}
+	orNull := func(s string) string {
+		if s == "" {
+			return nullValue
+		}
+		return s
+	}
+	return []column{
+		{Header: "Country Code", Value: func(c CustomerSegmentMember) string { return orNull(country(c)) }},
+		{Header: "Province Code", Value: func(c CustomerSegmentMember) string {
+			a := c.Node.DefaultAddress
+			if a == nil {
+				return nullValue
+			}
+			return orNull(normalizeProvince(country(c), a.ProvinceCode, a.Province))
+		}},
+		{Header: "Region", Value: func(c CustomerSegmentMember) string {
+			if cc, ok := countriesByCode[country(c)]; ok {
+				return orNull(cc.region)
+			}
+			return nullValue
+		}},
+	}
+}
@@ -0,0 +1,551 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/term"
+
+	"sultans/shopify"
+)
+
+const (
+	replPrompt       = "graphql> "
+	replContinuation = "     ... "
+	// replHistorySize is the number of lines the history file keeps.
+	replHistorySize = 1000
+)
+
+// replCommands are the commands of the prompt, which start with a backslash.
+var replCommands = []string{`\clear`, `\help`, `\history`, `\pipe`, `\quit`, `\vars`}
+
+const replHelp = `Enter a GraphQL query over one or more lines; it is sent once its braces are
+balanced. Tab completes fields, arguments and types from the Admin API schema,
+and the up and down keys recall earlier lines.
+
+  \vars [json]           Show the variables of the queries, or set them, e.g.
+                         \vars {"first": 10}
+  \pipe <output> [rows]  Send the last result to an output: a CSV file, - for
+                         the terminal, clipboard, or a destination such as
+                         braze://rest.iad-01.braze.com. Destinations get the
+                         customers in the result; CSV rows are read from the
+                         dot-separated rows path, as with graphql --rows
+  \history [n]           List the last n lines of the query history (default 20)
+  \clear                 Discard the query being entered
+  \quit                  Exit, as do Ctrl-C and Ctrl-D
+`
+
+func replCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "repl",
+		Usage: "Run Admin API GraphQL queries at an interactive prompt with schema-aware completion",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "query-history", Usage: "File of the prompt's query history (default: repl_history.jsonl in the user cache directory)"},
+			&cli.BoolFlag{Name: "no-schema", Usage: "Do not fetch the schema at startup, which turns completion off"},
+		},
+		Action: func(c *cli.Context) error {
+			client, err := newShopifyClient(c)
+			if err != nil {
+				return err
+			}
+			history, err := openReplHistory(c.String("query-history"))
+			if err != nil {
+				return err
+			}
+			r := &repl{c: c, client: client, history: history, variables: map[string]interface{}{}, out: os.Stdout}
+			if !c.Bool("no-schema") {
+				ctx, cancel := exportContext(c.Duration("timeout"))
+				r.schema, err = loadReplSchema(ctx, client)
+				cancel()
+				if err != nil {
+					log.Printf("warning: failed to fetch the schema, completion is off: %v", err)
+				}
+			}
+			if !term.IsTerminal(int(os.Stdin.Fd())) {
+				return r.runScript(os.Stdin)
+			}
+			return r.runTerminal()
+		},
+	}
+}
+
+// repl is an interactive session. Queries share one client, so retries, the
+// circuit breaker and --max-rps apply across them.
+type repl struct {
+	c       *cli.Context
+	client  *shopify.Client
+	schema  *replSchema
+	history *replHistory
+	out     io.Writer
+
+	// buffer holds the lines of a query whose braces are not balanced yet.
+	buffer    []string
+	variables map[string]interface{}
+	// last is the data of the last successful query, for \pipe.
+	last json.RawMessage
+}
+
+// replIO lets the terminal first read the saved history, with its output
+// discarded, and then the user's input.
+type replIO struct {
+	r io.Reader
+	w io.Writer
+}
+
+func (rw *replIO) Read(p []byte) (int, error)  { return rw.r.Read(p) }
+func (rw *replIO) Write(p []byte) (int, error) { return rw.w.Write(p) }
+
+func (r *repl) runTerminal() error {
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("failed to set up the terminal: %w", err)
+	}
+	defer term.Restore(fd, state)
+
+	// The terminal has no way to add to its history but entering lines, so the
+	// saved lines are replayed into it.
+	rw := &replIO{r: strings.NewReader(r.history.replay()), w: io.Discard}
+	t := term.NewTerminal(rw, replPrompt)
+	for range r.history.lines {
+		if _, err := t.ReadLine(); err != nil {
+			break
+		}
+	}
+	rw.r, rw.w = os.Stdin, os.Stdout
+	if width, height, err := term.GetSize(fd); err == nil && width > 0 {
+		t.SetSize(width, height)
+	}
+	t.AutoCompleteCallback = r.autoComplete
+	r.out = t
+	// Log output needs the terminal's line endings in raw mode.
+	if logFile == nil {
+		log.SetOutput(redactingWriter{t})
+		defer log.SetOutput(redactingWriter{logOutput})
+	}
+
+	fmt.Fprintf(t, "Admin API %s at %s. Type \\help for help.\n", shopify.APIVersion, r.client.Domain)
+	for {
+		if len(r.buffer) == 0 {
+			t.SetPrompt(replPrompt)
+		} else {
+			t.SetPrompt(replContinuation)
+		}
+		line, err := t.ReadLine()
+		if errors.Is(err, io.EOF) {
+			fmt.Fprintln(t)
+			return nil
+		}
+		if err != nil && !errors.Is(err, term.ErrPasteIndicator) {
+			return err
+		}
+		if r.handle(line) {
+			return nil
+		}
+	}
+}
+
+// runScript reads queries and commands from a file or pipe, without prompts.
+func (r *repl) runScript(in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(nil, maxLineBytes)
+	for scanner.Scan() {
+		if r.handle(scanner.Text()) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// maxLineBytes bounds the length of a line read by runScript.
+const maxLineBytes = 1 << 20
+
+// handle processes an entered line and reports whether the session is over.
+func (r *repl) handle(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" && len(r.buffer) == 0 {
+		return false
+	}
+	r.history.add(line)
+	if strings.HasPrefix(trimmed, `\`) {
+		quit, err := r.command(trimmed)
+		if err != nil {
+			r.printError(err)
+		}
+		return quit
+	}
+	r.buffer = append(r.buffer, line)
+	query := strings.Join(r.buffer, "\n")
+	if queryComplete(query) {
+		r.buffer = nil
+		r.run(query)
+	}
+	return false
+}
+
+// run sends a query and prints the data of the response as indented JSON.
+func (r *repl) run(query string) {
+	ctx, cancel := exportContext(r.c.Duration("timeout"))
+	defer cancel()
+	data, err := r.client.Query(ctx, query, r.variables)
+	if err != nil {
+		r.printError(err)
+		return
+	}
+	r.last = data
+	var b bytes.Buffer
+	if err := json.Indent(&b, data, "", "  "); err != nil {
+		r.printError(err)
+		return
+	}
+	b.WriteByte('\n')
+	r.out.Write(b.Bytes())
+}
+
+func (r *repl) printError(err error) {
+	fmt.Fprintf(r.out, "error: %s\n", redaction.redact(err.Error()))
+	var reqErr *shopify.RequestError
+	if errors.As(err, &reqErr) {
+		fmt.Fprintf(r.out, "Shopify request ID: %s\n", reqErr.RequestID)
+	}
+}
+
+// command runs a backslash command and reports whether it ends the session.
+func (r *repl) command(line string) (bool, error) {
+	name, args, _ := strings.Cut(line, " ")
+	args = strings.TrimSpace(args)
+	switch name {
+	case `\quit`, `\q`:
+		return true, nil
+	case `\help`, `\?`:
+		io.WriteString(r.out, replHelp)
+	case `\clear`:
+		r.buffer = nil
+	case `\vars`:
+		if args == "" {
+			b, err := json.MarshalIndent(r.variables, "", "  ")
+			if err != nil {
+				return false, err
+			}
+			fmt.Fprintf(r.out, "%s\n", b)
+			return false, nil
+		}
+		variables := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(args), &variables); err != nil {
+			return false, fmt.Errorf("invalid variables, expected a JSON object: %w", err)
+		}
+		r.variables = variables
+	case `\history`:
+		n := 20
+		if args != "" {
+			var err error
+			if n, err = strconv.Atoi(args); err != nil || n < 1 {
+				return false, fmt.Errorf("invalid line count %q", args)
+			}
+		}
+		lines := r.history.lines
+		start := max(len(lines)-n, 0)
+		for i, l := range lines[start:] {
+			fmt.Fprintf(r.out, "%5d  %s\n", start+i+1, l)
+		}
+	case `\pipe`:
+		output, rows, _ := strings.Cut(args, " ")
+		if output == "" {
+			return false, fmt.Errorf(`expected an output, e.g. \pipe customers.csv`)
+		}
+		return false, r.pipe(output, strings.TrimSpace(rows))
+	default:
+		return false, fmt.Errorf(`unknown command %s, see \help`, name)
+	}
+	return false, nil
+}
+
+// pipe sends the last result to output. Destinations get the customers in the
+// result; CSV outputs get the rows at the rows path, flattened as by graphql.
+func (r *repl) pipe(output, rows string) error {
+	if r.last == nil {
+		return fmt.Errorf("no result to send yet, run a query first")
+	}
+	ctx, cancel := exportContext(r.c.Duration("timeout"))
+	defer cancel()
+
+	sink, err := newSink(r.c, output)
+	if err != nil {
+		return err
+	}
+	if sink != nil {
+		customers, err := resultCustomers(r.last)
+		if err != nil {
+			return err
+		}
+		if len(customers) == 0 {
+			return fmt.Errorf("the last result has no customers to send: select the id of Customer objects")
+		}
+		if err := sink.Write(ctx, customers); err != nil {
+			return fmt.Errorf("failed to export to %s: %w", output, err)
+		}
+		fmt.Fprintf(r.out, "Sent %d customers to %s\n", len(customers), output)
+		return nil
+	}
+
+	data, err := decodeData(r.last)
+	if err != nil {
+		return err
+	}
+	f := &flattener{separator: ";"}
+	var flat []flatRow
+	for _, v := range selectRows(data, rows) {
+		flat = append(flat, f.rows("", v)...)
+	}
+	types := inferColumnTypes(f.columns, flat)
+	for _, t := range types {
+		if t == kindDateTime {
+			if err := resolveDateLocation(ctx, r.c); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	var b bytes.Buffer
+	if err := writeFlatCSV(&b, f.columns, types, flat); err != nil {
+		return err
+	}
+	switch output {
+	case "-":
+		_, err = r.out.Write(b.Bytes())
+		return err
+	case clipboardOutput:
+		err = copyToClipboard(b.Bytes())
+	default:
+		err = os.WriteFile(output, b.Bytes(), 0o666)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(r.out, "Wrote %d rows to %s\n", len(flat), output)
+	return nil
+}
+
+// resultCustomers returns the objects of a result with the ID of a customer or
+// segment member, in the order they appear and once each, as the customers of
+// a segment.
+func resultCustomers(data json.RawMessage) ([]CustomerSegmentMember, error) {
+	v, err := decodeData(data)
+	if err != nil {
+		return nil, err
+	}
+	var customers []CustomerSegmentMember
+	seen := map[string]bool{}
+	var walk func(v interface{}) error
+	walk = func(v interface{}) error {
+		switch v := v.(type) {
+		case []interface{}:
+			for _, elem := range v {
+				if err := walk(elem); err != nil {
+					return err
+				}
+			}
+		case *jsonObject:
+			if id, ok := v.values["id"].(string); ok && (strings.HasPrefix(id, "gid://shopify/Customer/") || strings.HasPrefix(id, "gid://shopify/CustomerSegmentMember/")) && !seen[id] {
+				seen[id] = true
+				b, err := json.Marshal(plainJSON(v))
+				if err != nil {
+					return err
+				}
+				var c CustomerSegmentMember
+				if err := json.Unmarshal(b, &c.Node); err != nil {
+					return fmt.Errorf("customer %s: %w", id, err)
+				}
+				customers = append(customers, c)
+			}
+			for _, key := range v.keys {
+				if err := walk(v.values[key]); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return customers, walk(v)
+}
+
+// plainJSON converts the *jsonObject values of decodeOrdered into maps.
+func plainJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case *jsonObject:
+		m := make(map[string]interface{}, len(v.values))
+		for k, elem := range v.values {
+			m[k] = plainJSON(elem)
+		}
+		return m
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, elem := range v {
+			list[i] = plainJSON(elem)
+		}
+		return list
+	}
+	return v
+}
+
+// autoComplete completes the name before the cursor when Tab is pressed. When
+// there are several candidates it completes their common prefix, and lists
+// them if that adds nothing.
+func (r *repl) autoComplete(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+	before := line[:pos]
+	start := pos
+	for start > 0 && isGraphQLNameByte(before[start-1]) {
+		start--
+	}
+	word := before[start:]
+
+	var candidates []string
+	switch {
+	case strings.HasPrefix(before, `\`) && !strings.Contains(before, " "):
+		start, word = 0, before
+		candidates = withPrefix(replCommands, word)
+	case strings.HasPrefix(strings.TrimSpace(before), `\`):
+	case r.schema != nil:
+		text := strings.Join(append(append([]string(nil), r.buffer...), before[:start]), "\n")
+		candidates = r.schema.complete(text, word)
+	}
+	if len(candidates) == 0 {
+		return line, pos, true
+	}
+	completion := commonPrefix(candidates)
+	if len(candidates) > 1 && completion == word {
+		const maxListed = 100
+		listed := candidates
+		if len(listed) > maxListed {
+			listed = listed[:maxListed]
+		}
+		list := strings.Join(listed, "  ")
+		if len(candidates) > maxListed {
+			list += fmt.Sprintf("  (%d more)", len(candidates)-maxListed)
+		}
+		fmt.Fprintln(r.out, list)
+	}
+	return line[:start] + completion + line[pos:], start + len(completion), true
+}
+
+// replHistory is the saved history of entered lines. With --encrypt-stores its
+// lines are encrypted like journal lines.
+type replHistory struct {
+	path  string
+	lines []string
+}
+
+type replHistoryEntry struct {
+	Line string    `json:"line"`
+	Time time.Time `json:"time"`
+}
+
+// openReplHistory reads the history, keeping its last replHistorySize lines.
+func openReplHistory(path string) (*replHistory, error) {
+	if path == "" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate cache directory: %w", err)
+		}
+		path = filepath.Join(dir, "shopify-customers", "repl_history.jsonl")
+	}
+	h := &replHistory{path: path}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read query history: %w", err)
+	}
+	var raw [][]byte
+	for _, line := range bytes.Split(b, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		line, err := openJournalLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("query history %s is %w", path, err)
+		}
+		var e replHistoryEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("invalid query history %s: %w", path, err)
+		}
+		h.lines = append(h.lines, e.Line)
+		raw = append(raw, line)
+	}
+	if len(h.lines) > replHistorySize {
+		h.lines = h.lines[len(h.lines)-replHistorySize:]
+		if err := h.rewrite(raw[len(raw)-replHistorySize:]); err != nil {
+			log.Printf("warning: failed to trim query history: %v", err)
+		}
+	}
+	return h, nil
+}
+
+// replay returns the lines as they are entered into the terminal.
+func (h *replHistory) replay() string {
+	var b strings.Builder
+	for _, line := range h.lines {
+		b.WriteString(line)
+		b.WriteByte('\r')
+	}
+	return b.String()
+}
+
+// add appends line to the history. Failing to save it is not worth ending
+// the session over.
+func (h *replHistory) add(line string) {
+	h.lines = append(h.lines, line)
+	if err := h.append(line); err != nil {
+		log.Printf("warning: failed to save query history: %v", err)
+	}
+}
+
+func (h *replHistory) append(line string) error {
+	b, err := json.Marshal(replHistoryEntry{Line: line, Time: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+	if b, err = sealJournalLine(b); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(h.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (h *replHistory) rewrite(lines [][]byte) error {
+	var b bytes.Buffer
+	for _, line := range lines {
+		sealed, err := sealJournalLine(line)
+		if err != nil {
+			return err
+		}
+		b.Write(sealed)
+		b.WriteByte('\n')
+	}
+	return os.WriteFile(h.path, b.Bytes(), 0o600)
+}
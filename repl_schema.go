@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"sultans/shopify"
+)
+
+const replSchemaQuery = `query ReplSchema {
+	__schema {
+		queryType { name }
+		mutationType { name }
+		types {
+			kind
+			name
+			fields { name args { name } type { ...TypeRef } }
+		}
+	}
+}
+` + typeRefFragment
+
+// replSchema is the part of the schema the repl completes queries from: the
+// fields of every type and their arguments.
+type replSchema struct {
+	query, mutation string
+	types           map[string]introspectionType
+	// names are the types a fragment can be on, for completion after "on".
+	names []string
+}
+
+func loadReplSchema(ctx context.Context, client *shopify.Client) (*replSchema, error) {
+	data, err := client.Query(ctx, replSchemaQuery, nil)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Schema introspectionSchema `json:"__schema"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("invalid introspection result: %w", err)
+	}
+	s := &replSchema{types: map[string]introspectionType{}}
+	if resp.Schema.QueryType != nil {
+		s.query = resp.Schema.QueryType.Name
+	}
+	if resp.Schema.MutationType != nil {
+		s.mutation = resp.Schema.MutationType.Name
+	}
+	for _, t := range resp.Schema.Types {
+		s.types[t.Name] = t
+		if (t.Kind == "OBJECT" || t.Kind == "INTERFACE" || t.Kind == "UNION") && !strings.HasPrefix(t.Name, "__") {
+			s.names = append(s.names, t.Name)
+		}
+	}
+	sort.Strings(s.names)
+	return s, nil
+}
+
+func (s *replSchema) field(typeName, name string) *introspectionField {
+	t := s.types[typeName]
+	for i := range t.Fields {
+		if t.Fields[i].Name == name {
+			return &t.Fields[i]
+		}
+	}
+	return nil
+}
+
+// complete returns the candidates for word, the name being typed at the end of
+// text. Inside a selection set they are the fields of its type, inside the
+// arguments of a field its argument names, and after "on" the type names.
+func (s *replSchema) complete(text, word string) []string {
+	tokens := graphQLTokens(text)
+	var stack []string
+	var operation, pending, last string
+	var current, arguments *introspectionField
+	parens := 0
+	for i, tok := range tokens {
+		prev := ""
+		if i > 0 {
+			prev = tokens[i-1]
+		}
+		switch tok {
+		case "(":
+			if parens++; parens == 1 {
+				arguments = current
+			}
+		case ")":
+			if parens > 0 {
+				parens--
+			}
+		case "{":
+			// Braces inside arguments are input object values.
+			if parens > 0 {
+				break
+			}
+			if len(stack) == 0 && pending == "" {
+				pending = s.query
+				if operation == "mutation" {
+					pending = s.mutation
+				}
+			}
+			stack = append(stack, pending)
+			pending, current = "", nil
+		case "}":
+			if parens == 0 && len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		default:
+			if parens > 0 || !isGraphQLName(tok) {
+				break
+			}
+			switch {
+			case prev == "on":
+				pending = tok
+			case len(stack) == 0:
+				if operation == "" {
+					operation = tok
+				}
+			case prev == "..." || prev == "$" || prev == "@":
+			default:
+				current, pending = s.field(stack[len(stack)-1], tok), ""
+				if current != nil {
+					pending = current.Type.named()
+				}
+			}
+		}
+		last = tok
+	}
+
+	var candidates []string
+	switch {
+	case last == "on":
+		candidates = s.names
+	case parens > 0:
+		if arguments != nil && last != ":" && last != "$" {
+			for _, a := range arguments.Args {
+				candidates = append(candidates, a.Name)
+			}
+		}
+	case len(stack) == 0:
+		if len(tokens) == 0 {
+			candidates = []string{"query", "mutation", "fragment"}
+		}
+	case last != "...":
+		for _, f := range s.types[stack[len(stack)-1]].Fields {
+			candidates = append(candidates, f.Name)
+		}
+		candidates = append(candidates, "__typename")
+	}
+	return withPrefix(candidates, word)
+}
+
+// withPrefix returns the sorted candidates starting with prefix.
+func withPrefix(candidates []string, prefix string) []string {
+	var out []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			out = append(out, c)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// commonPrefix returns the longest prefix shared by all of candidates.
+func commonPrefix(candidates []string) string {
+	prefix := candidates[0]
+	for _, c := range candidates[1:] {
+		for !strings.HasPrefix(c, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// graphQLTokens splits a GraphQL document into names and punctuators, leaving
+// out comments. String values become a single `""` token.
+func graphQLTokens(text string) []string {
+	var tokens []string
+	for i := 0; i < len(text); {
+		switch ch := text[i]; {
+		case ch == '#':
+			for i < len(text) && text[i] != '\n' {
+				i++
+			}
+		case ch == '"':
+			for i++; i < len(text) && text[i] != '"'; i++ {
+				if text[i] == '\\' {
+					i++
+				}
+			}
+			i++
+			tokens = append(tokens, `""`)
+		case isGraphQLNameByte(ch):
+			j := i
+			for j < len(text) && isGraphQLNameByte(text[j]) {
+				j++
+			}
+			tokens = append(tokens, text[i:j])
+			i = j
+		case strings.HasPrefix(text[i:], "..."):
+			tokens = append(tokens, "...")
+			i += 3
+		case strings.IndexByte("{}():$@", ch) >= 0:
+			tokens = append(tokens, string(ch))
+			i++
+		default:
+			i++
+		}
+	}
+	return tokens
+}
+
+// queryComplete reports whether text is a document whose braces are balanced,
+// ready to be sent.
+func queryComplete(text string) bool {
+	depth, braces := 0, false
+	for _, tok := range graphQLTokens(text) {
+		switch tok {
+		case "{":
+			depth++
+			braces = true
+		case "}":
+			depth--
+		}
+	}
+	return braces && depth <= 0
+}
+
+func isGraphQLNameByte(ch byte) bool {
+	return ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9'
+}
+
+func isGraphQLName(tok string) bool {
+	return tok != "" && isGraphQLNameByte(tok[0]) && (tok[0] < '0' || tok[0] > '9')
+}
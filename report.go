@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/urfave/cli/v2"
+)
+
+// report summarizes the exported customers for --report and --summary, nil
+// without either.
+var report *exportReport
+
+// reportTopN is the number of highest-spending customers listed in reports.
+const reportTopN = 10
+
+// exportReport collects statistics of the customers of one export as they are
+// written, keeping only the top customers and amounts rather than whole customers.
+type exportReport struct {
+	// path is the PDF file, empty without --report.
+	path    string
+	summary bool
+	query   string
+
+	customers int
+	withEmail int
+	// totals and counts are per currency, since amounts in different currencies
+	// cannot be added up.
+	totals  map[string]decimal.Decimal
+	counts  map[string]int
+	top     []CustomerSegmentMember
+	amounts []float64
+}
+
+func newExportReport(c *cli.Context) (*exportReport, error) {
+	format := c.String("report")
+	if format == "" && !c.Bool("summary") {
+		return nil, nil
+	}
+	if format != "" && format != "pdf" {
+		return nil, fmt.Errorf("invalid --report %q, expected pdf", format)
+	}
+	if c.String("queries-file") != "" {
+		return nil, fmt.Errorf("--report and --summary cannot be combined with --queries-file")
+	}
+	r := &exportReport{
+		summary: c.Bool("summary"),
+		query:   c.String("query"),
+	}
+	if format != "" {
+		if r.path = c.String("report-file"); r.path == "" {
+			r.path = reportPath(c.String("output"), "."+format)
+		}
+	}
+	return r, nil
+}
+
+// grandTotal returns the total spend in the primary currency, with a note on
+// the currencies it leaves out for lack of a rate. ok is false without --rates.
+func (r *exportReport) grandTotal() (total, note string, ok bool) {
+	if currencyRates == nil {
+		return "", "", false
+	}
+	sum, missing := currencyRates.grandTotal(r.totals, primaryCurrency)
+	if len(missing) > 0 {
+		note = "excludes " + strings.Join(missing, ", ") + " without an exchange rate"
+	}
+	return humanAmounts.formatWithCode(sum, primaryCurrency), note, true
+}
+
+// writeSummary prints the customers and totals per currency, and the grand total.
+func (r *exportReport) writeSummary(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CURRENCY\tCUSTOMERS\tTOTAL\tAVERAGE")
+	for _, currency := range r.currencies() {
+		total := r.totals[currency]
+		average := total.Div(decimal.NewFromInt(int64(r.counts[currency])))
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", currency, r.counts[currency], humanAmounts.format(total, currency), humanAmounts.format(average, currency))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if total, note, ok := r.grandTotal(); ok {
+		if note != "" {
+			note = " (" + note + ")"
+		}
+		fmt.Fprintf(w, "Grand total: %s at rates from %s%s\n", total, currencyRates.source, note)
+	}
+	return nil
+}
+
+// reportPath returns the default report file next to a CSV output:
+// "customers.csv" becomes "customers.pdf". Other outputs get "report.pdf".
+func reportPath(output, ext string) string {
+	if !isFileOutput(output) {
+		return "report" + ext
+	}
+	return strings.TrimSuffix(output, filepath.Ext(output)) + ext
+}
+
+// wrap records the members of in as they pass through.
+// start returns an empty report of one export with the settings of r, or nil
+// without --report and --summary.
+func (r *exportReport) start() *exportReport {
+	if r == nil {
+		return nil
+	}
+	return &exportReport{
+		path:    r.path,
+		summary: r.summary,
+		query:   r.query,
+		totals:  map[string]decimal.Decimal{},
+		counts:  map[string]int{},
+	}
+}
+
+func (r *exportReport) wrap(ctx context.Context, in *segmentStream) *segmentStream {
+	return filterStream(ctx, in, func(c CustomerSegmentMember) bool {
+		r.add(c)
+		return true
+	})
+}
+
+func (r *exportReport) add(c CustomerSegmentMember) {
+	r.customers++
+	if c.Node.DefaultEmailAddress != nil {
+		r.withEmail++
+	}
+	currency := string(c.Node.AmountSpent.CurrencyCode)
+	r.totals[currency] = r.totals[currency].Add(c.Node.AmountSpent.Amount)
+	r.counts[currency]++
+	amount, _ := c.Node.AmountSpent.Amount.Float64()
+	r.amounts = append(r.amounts, amount)
+
+	r.top = append(r.top, c)
+	sort.SliceStable(r.top, func(i, j int) bool {
+		return r.top[i].Node.AmountSpent.Amount.GreaterThan(r.top[j].Node.AmountSpent.Amount)
+	})
+	r.top = r.top[:min(len(r.top), reportTopN)]
+}
+
+// currencies returns the currencies of the export, most common first.
+func (r *exportReport) currencies() []string {
+	var currencies []string
+	for currency := range r.counts {
+		currencies = append(currencies, currency)
+	}
+	sort.Slice(currencies, func(i, j int) bool {
+		if r.counts[currencies[i]] != r.counts[currencies[j]] {
+			return r.counts[currencies[i]] > r.counts[currencies[j]]
+		}
+		return currencies[i] < currencies[j]
+	})
+	return currencies
+}
+
+// write renders the report to its file.
+func (r *exportReport) write() error {
+	page := r.render(time.Now())
+	file, err := os.Create(r.path)
+	if err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	if _, err := page.WriteTo(file); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return file.Close()
+}
+
+// render lays out the one-page report: the query, counts, spend per currency,
+// the top customers and a histogram of amounts spent.
+func (r *exportReport) render(now time.Time) *pdfPage {
+	const left, right = 50.0, pdfPageWidth - 50.0
+	p := &pdfPage{}
+	y := float64(pdfPageHeight - 60)
+
+	p.text(left, y, 18, true, "Customer Segment Report")
+	y -= 22
+	p.text(left, y, 9, false, "Generated "+now.UTC().Format("2006-01-02 15:04 UTC"))
+	y -= 16
+	for i, line := range wrapText("Query: "+r.query, 95) {
+		if i == 3 {
+			break
+		}
+		p.text(left, y, 10, false, line)
+		y -= 13
+	}
+
+	y -= 14
+	p.text(left, y, 13, true, "Summary")
+	y -= 18
+	p.text(left, y, 10, false, fmt.Sprintf("Customers: %d", r.customers))
+	y -= 14
+	if r.customers > 0 {
+		p.text(left, y, 10, false, fmt.Sprintf("With email: %d (%.0f%%)", r.withEmail, 100*float64(r.withEmail)/float64(r.customers)))
+		y -= 14
+	}
+	currencies := r.currencies()
+	for i, currency := range currencies {
+		if i == 4 {
+			p.text(left, y, 10, false, fmt.Sprintf("... and %d more currencies", len(currencies)-i))
+			y -= 14
+			break
+		}
+		total := r.totals[currency]
+		average := total.Div(decimal.NewFromInt(int64(r.counts[currency])))
+		p.text(left, y, 10, false, fmt.Sprintf("%s: total spend %s, average %s over %d customers", currency, humanAmounts.format(total, currency), humanAmounts.format(average, currency), r.counts[currency]))
+		y -= 14
+	}
+	if total, note, ok := r.grandTotal(); ok {
+		line := "Grand total: " + total
+		if note != "" {
+			line += " (" + note + ")"
+		}
+		p.text(left, y, 10, true, line)
+		y -= 14
+	}
+	if r.customers == 0 {
+		return p
+	}
+
+	y -= 14
+	p.text(left, y, 13, true, fmt.Sprintf("Top %d Customers", len(r.top)))
+	y -= 18
+	columns := []float64{left, left + 25, left + 190, right - 90}
+	for i, header := range []string{"#", "Name", "Email", "Amount Spent"} {
+		p.text(columns[i], y, 9, true, header)
+	}
+	y -= 4
+	p.line(left, y, right, y)
+	y -= 12
+	for i, c := range r.top {
+		email := ""
+		if c.Node.DefaultEmailAddress != nil {
+			email = c.Node.DefaultEmailAddress.EmailAddress
+		}
+		p.text(columns[0], y, 9, false, fmt.Sprint(i+1))
+		p.text(columns[1], y, 9, false, truncateText(c.Node.DisplayName, 30))
+		p.text(columns[2], y, 9, false, truncateText(email, 40))
+		p.text(columns[3], y, 9, false, humanAmounts.formatWithCode(c.Node.AmountSpent.Amount, string(c.Node.AmountSpent.CurrencyCode)))
+		y -= 13
+	}
+
+	y -= 14
+	title := "Spend Distribution"
+	if len(currencies) == 1 {
+		title += " (" + currencies[0] + ")"
+	} else {
+		title += " (all currencies, unconverted)"
+	}
+	p.text(left, y, 13, true, title)
+	const bottom = 70.0
+	r.renderHistogram(p, left, bottom, right-left, y-30-bottom)
+	return p
+}
+
+// renderHistogram draws the amounts as ten equal-width bins between zero and
+// the highest amount, in a chart of the given width and height above bottom.
+func (r *exportReport) renderHistogram(p *pdfPage, left, bottom, width, height float64) {
+	const bins = 10
+	highest := 0.0
+	for _, amount := range r.amounts {
+		highest = max(highest, amount)
+	}
+	if highest <= 0 {
+		highest = 1
+	}
+	counts := make([]int, bins)
+	for _, amount := range r.amounts {
+		counts[min(max(int(amount/highest*bins), 0), bins-1)]++
+	}
+	tallest := 1
+	for _, n := range counts {
+		tallest = max(tallest, n)
+	}
+
+	slot := width / bins
+	for i, n := range counts {
+		x := left + float64(i)*slot
+		h := (height - 14) * float64(n) / float64(tallest)
+		p.rect(x+3, bottom, slot-6, h, 0.45)
+		p.text(x+slot/2-6, bottom+h+3, 8, false, fmt.Sprint(n))
+		p.text(x+3, bottom-11, 7, false, fmt.Sprintf("%.0f-%.0f", highest*float64(i)/bins, highest*float64(i+1)/bins))
+	}
+	p.line(left, bottom, left+width, bottom)
+}
+
+// wrapText splits s into lines of at most width characters at spaces.
+func wrapText(s string, width int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		if line != "" && len([]rune(line))+1+len([]rune(word)) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	return append(lines, line)
+}
+
+// truncateText shortens s to at most n characters, marking the cut with "...".
+func truncateText(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-3]) + "..."
+	}
+	return s
+}
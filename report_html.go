@@ -0,0 +1,444 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/urfave/cli/v2"
+)
+
+func reportCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "report",
+		Usage:     "Render an HTML report with charts from an export CSV, compared with a previous export if given",
+		ArgsUsage: "<export.csv> [previous.csv]",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "output", Aliases: []string{"o"}, Value: "report.html", Usage: "HTML report filename"},
+			&cli.IntFlag{Name: "bins", Value: 20, Usage: "Bars of the spend distribution chart"},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() < 1 || c.NArg() > 2 {
+				return fmt.Errorf("expected an export CSV and optionally a previous export CSV")
+			}
+			if c.Int("bins") < 1 {
+				return fmt.Errorf("--bins must be at least 1")
+			}
+			current, err := readExportFile(c.Args().Get(0))
+			if err != nil {
+				return err
+			}
+			var previous *exportFile
+			if c.NArg() == 2 {
+				if previous, err = readExportFile(c.Args().Get(1)); err != nil {
+					return err
+				}
+			}
+
+			file, err := os.Create(c.String("output"))
+			if err != nil {
+				return fmt.Errorf("failed to write report: %w", err)
+			}
+			if err := writeHTMLReport(file, current, previous, c.Int("bins"), time.Now()); err != nil {
+				file.Close()
+				return fmt.Errorf("failed to write report: %w", err)
+			}
+			if err := file.Close(); err != nil {
+				return fmt.Errorf("failed to write report: %w", err)
+			}
+			fmt.Printf("Report written to %s\n", c.String("output"))
+			return nil
+		},
+	}
+}
+
+// exportFile is an export CSV read back for reporting.
+type exportFile struct {
+	Name      string
+	Customers []exportCustomer
+}
+
+type exportCustomer struct {
+	ID       string
+	Currency string
+	// Amount is nil when the export has no amount for the customer.
+	Amount *decimal.Decimal
+}
+
+// readExportFile reads the ID, Amount Spent and Currency Code columns of an
+// export CSV. Other columns, such as those added by --tiers, are ignored.
+func readExportFile(path string) (*exportFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	index := map[string]int{}
+	for i, name := range header {
+		index[name] = i
+	}
+	for _, name := range []string{"ID", "Amount Spent", "Currency Code"} {
+		if _, ok := index[name]; !ok {
+			return nil, fmt.Errorf("%s has no %q column", path, name)
+		}
+	}
+
+	export := &exportFile{Name: filepath.Base(path)}
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		field := func(name string) string {
+			if i := index[name]; i < len(record) {
+				return record[i]
+			}
+			return ""
+		}
+		c := exportCustomer{ID: field("ID"), Currency: field("Currency Code")}
+		if amount, err := decimal.NewFromString(field("Amount Spent")); err == nil {
+			c.Amount = &amount
+		}
+		export.Customers = append(export.Customers, c)
+	}
+	return export, nil
+}
+
+// currencyStats are the totals of one currency in an export.
+type currencyStats struct {
+	Currency  string
+	Customers int
+	Total     decimal.Decimal
+}
+
+// byCurrency returns the totals per currency, most customers first.
+func (e *exportFile) byCurrency() []currencyStats {
+	stats := map[string]*currencyStats{}
+	for _, c := range e.Customers {
+		s, ok := stats[c.Currency]
+		if !ok {
+			s = &currencyStats{Currency: c.Currency}
+			stats[c.Currency] = s
+		}
+		s.Customers++
+		if c.Amount != nil {
+			s.Total = s.Total.Add(*c.Amount)
+		}
+	}
+	var out []currencyStats
+	for _, s := range stats {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Customers != out[j].Customers {
+			return out[i].Customers > out[j].Customers
+		}
+		return out[i].Currency < out[j].Currency
+	})
+	return out
+}
+
+// Chart geometry of the HTML report, in SVG user units.
+const (
+	chartWidth  = 720.0
+	chartHeight = 240.0
+	chartMargin = 40.0
+)
+
+var chartColors = []string{"#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f", "#edc948", "#b07aa1", "#ff9da7", "#9c755f", "#bab0ac"}
+
+type chartBar struct {
+	X, Y, W, H float64
+	Color      string
+	Tooltip    string
+}
+
+// histogram is the spend distribution of one currency, or of all of them.
+type histogram struct {
+	Currency string
+	Bars     []chartBar
+	Labels   []chartLabel
+}
+
+type chartLabel struct {
+	X, Y float64
+	Text string
+}
+
+// spendHistogram bins the amounts of customers in currency ("" for all) into
+// equal-width bars between zero and the highest amount.
+func spendHistogram(customers []exportCustomer, currency string, bins int) histogram {
+	var amounts []float64
+	highest := 0.0
+	for _, c := range customers {
+		if c.Amount == nil || (currency != "" && c.Currency != currency) {
+			continue
+		}
+		amount, _ := c.Amount.Float64()
+		amounts = append(amounts, amount)
+		highest = max(highest, amount)
+	}
+	if highest <= 0 {
+		highest = 1
+	}
+	counts := make([]int, bins)
+	for _, amount := range amounts {
+		counts[min(max(int(amount/highest*float64(bins)), 0), bins-1)]++
+	}
+	tallest := 1
+	for _, n := range counts {
+		tallest = max(tallest, n)
+	}
+
+	h := histogram{Currency: currency}
+	plotHeight := chartHeight - chartMargin
+	slot := (chartWidth - chartMargin) / float64(bins)
+	for i, n := range counts {
+		lo, hi := highest*float64(i)/float64(bins), highest*float64(i+1)/float64(bins)
+		height := plotHeight * float64(n) / float64(tallest)
+		x := chartMargin + float64(i)*slot
+		h.Bars = append(h.Bars, chartBar{
+			X: x + 1, Y: plotHeight - height, W: slot - 2, H: height,
+			Color:   chartColors[0],
+			Tooltip: fmt.Sprintf("%.2f to %.2f: %d customers", lo, hi, n),
+		})
+		if i%max(bins/5, 1) == 0 {
+			h.Labels = append(h.Labels, chartLabel{X: x, Y: chartHeight - 24, Text: fmt.Sprintf("%.0f", lo)})
+		}
+	}
+	h.Labels = append(h.Labels,
+		chartLabel{X: chartWidth - 30, Y: chartHeight - 24, Text: fmt.Sprintf("%.0f", highest)},
+		chartLabel{X: 0, Y: 12, Text: fmt.Sprint(tallest)},
+	)
+	return h
+}
+
+// currencyMix is one segment of the stacked currency bar.
+type currencyMix struct {
+	chartBar
+	Currency string
+	Share    float64
+}
+
+func currencyMixBars(stats []currencyStats, customers int) []currencyMix {
+	var mix []currencyMix
+	x := 0.0
+	for i, s := range stats {
+		share := float64(s.Customers) / float64(max(customers, 1))
+		mix = append(mix, currencyMix{
+			chartBar: chartBar{
+				X: x, Y: 0, W: share * chartWidth, H: 36,
+				Color:   chartColors[i%len(chartColors)],
+				Tooltip: fmt.Sprintf("%s: %d customers (%.1f%%)", s.Currency, s.Customers, 100*share),
+			},
+			Currency: s.Currency,
+			Share:    100 * share,
+		})
+		x += share * chartWidth
+	}
+	return mix
+}
+
+// growthRow compares one currency between the previous and current export.
+type growthRow struct {
+	Currency                    string
+	Previous, Current           int
+	PreviousTotal, CurrentTotal string
+	Change                      string
+}
+
+type growth struct {
+	Previous               string
+	Added, Removed, Stayed int
+	Rows                   []growthRow
+	Bars                   []chartBar
+	Labels                 []chartLabel
+}
+
+// compareExports counts the customers added to, removed from and kept in the
+// segment, and the customers and spend per currency of both exports.
+func compareExports(current, previous *exportFile) *growth {
+	g := &growth{Previous: previous.Name}
+	before := map[string]bool{}
+	for _, c := range previous.Customers {
+		before[c.ID] = true
+	}
+	now := map[string]bool{}
+	for _, c := range current.Customers {
+		now[c.ID] = true
+		if before[c.ID] {
+			g.Stayed++
+		} else {
+			g.Added++
+		}
+	}
+	for id := range before {
+		if !now[id] {
+			g.Removed++
+		}
+	}
+
+	prev := map[string]currencyStats{}
+	for _, s := range previous.byCurrency() {
+		prev[s.Currency] = s
+	}
+	seen := map[string]bool{}
+	add := func(cur currencyStats, p currencyStats) {
+		change := "n/a"
+		if p.Customers > 0 {
+			change = fmt.Sprintf("%+.1f%%", 100*float64(cur.Customers-p.Customers)/float64(p.Customers))
+		}
+		g.Rows = append(g.Rows, growthRow{
+			Currency: cur.Currency, Previous: p.Customers, Current: cur.Customers,
+			PreviousTotal: humanAmounts.format(p.Total, cur.Currency), CurrentTotal: humanAmounts.format(cur.Total, cur.Currency), Change: change,
+		})
+	}
+	for _, s := range current.byCurrency() {
+		seen[s.Currency] = true
+		add(s, prev[s.Currency])
+	}
+	for _, s := range previous.byCurrency() {
+		if !seen[s.Currency] {
+			add(currencyStats{Currency: s.Currency}, s)
+		}
+	}
+
+	tallest := 1
+	for _, row := range g.Rows {
+		tallest = max(tallest, row.Previous, row.Current)
+	}
+	plotHeight := chartHeight - chartMargin
+	slot := (chartWidth - chartMargin) / float64(max(len(g.Rows), 1))
+	barWidth := min(slot/2-4, 60)
+	for i, row := range g.Rows {
+		x := chartMargin + float64(i)*slot + slot/2 - barWidth
+		for j, n := range []int{row.Previous, row.Current} {
+			height := plotHeight * float64(n) / float64(tallest)
+			label := "previous"
+			if j == 1 {
+				label = "current"
+			}
+			g.Bars = append(g.Bars, chartBar{
+				X: x + float64(j)*barWidth, Y: plotHeight - height, W: barWidth - 2, H: height,
+				Color:   chartColors[j],
+				Tooltip: fmt.Sprintf("%s %s: %d customers", row.Currency, label, n),
+			})
+		}
+		g.Labels = append(g.Labels, chartLabel{X: x + barWidth - 12, Y: chartHeight - 24, Text: row.Currency})
+	}
+	g.Labels = append(g.Labels, chartLabel{X: 0, Y: 12, Text: fmt.Sprint(tallest)})
+	return g
+}
+
+// writeHTMLReport renders a self-contained HTML page: charts are inline SVG with
+// tooltips and a currency selector, so the report works offline and can be mailed.
+func writeHTMLReport(w io.Writer, current, previous *exportFile, bins int, now time.Time) error {
+	stats := current.byCurrency()
+	histograms := []histogram{spendHistogram(current.Customers, "", bins)}
+	for _, s := range stats {
+		histograms = append(histograms, spendHistogram(current.Customers, s.Currency, bins))
+	}
+	data := map[string]interface{}{
+		"Name":        current.Name,
+		"Generated":   now.UTC().Format("2006-01-02 15:04 UTC"),
+		"Customers":   len(current.Customers),
+		"Currencies":  stats,
+		"Histograms":  histograms,
+		"Mix":         currencyMixBars(stats, len(current.Customers)),
+		"ChartWidth":  chartWidth,
+		"ChartHeight": chartHeight,
+	}
+	if previous != nil {
+		data["Growth"] = compareExports(current, previous)
+	}
+	return htmlReportTemplate.Execute(w, data)
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	// Looked up on every call, since --number-locale is only known once flags are parsed.
+	"amount": func(amount decimal.Decimal, code string) string { return humanAmounts.format(amount, code) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Customer report: {{.Name}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em auto; max-width: 800px; color: #222; }
+h1 { margin-bottom: 0; }
+.meta { color: #666; margin-top: 0.3em; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { padding: 4px 12px; text-align: right; border-bottom: 1px solid #ddd; }
+th:first-child, td:first-child { text-align: left; }
+svg rect:hover { opacity: 0.7; }
+svg text { font-size: 11px; fill: #555; }
+.legend span { display: inline-block; margin-right: 1em; }
+.swatch { display: inline-block; width: 10px; height: 10px; margin-right: 4px; }
+</style>
+</head>
+<body>
+<h1>Customer report</h1>
+<p class="meta">{{.Name}}: {{.Customers}} customers. Generated {{.Generated}}.</p>
+
+<h2>Spend by currency</h2>
+<table>
+<tr><th>Currency</th><th>Customers</th><th>Total spend</th></tr>
+{{range .Currencies}}<tr><td>{{.Currency}}</td><td>{{.Customers}}</td><td>{{amount .Total .Currency}}</td></tr>
+{{end}}</table>
+
+<h2>Spend distribution</h2>
+<label>Currency <select id="currency" onchange="showCurrency(this.value)">
+<option value="">All currencies (unconverted)</option>
+{{range .Currencies}}<option value="{{.Currency}}">{{.Currency}}</option>
+{{end}}</select></label>
+<svg width="{{.ChartWidth}}" height="{{.ChartHeight}}" viewBox="0 0 {{.ChartWidth}} {{.ChartHeight}}">
+{{range $i, $h := .Histograms}}<g class="histogram" data-currency="{{$h.Currency}}"{{if $i}} style="display: none"{{end}}>
+{{range $h.Bars}}<rect x="{{.X}}" y="{{.Y}}" width="{{.W}}" height="{{.H}}" fill="{{.Color}}"><title>{{.Tooltip}}</title></rect>
+{{end}}{{range $h.Labels}}<text x="{{.X}}" y="{{.Y}}">{{.Text}}</text>
+{{end}}</g>
+{{end}}</svg>
+
+<h2>Currency mix</h2>
+<svg width="{{.ChartWidth}}" height="36" viewBox="0 0 {{.ChartWidth}} 36">
+{{range .Mix}}<rect x="{{.X}}" y="{{.Y}}" width="{{.W}}" height="{{.H}}" fill="{{.Color}}"><title>{{.Tooltip}}</title></rect>
+{{end}}</svg>
+<p class="legend">{{range .Mix}}<span><i class="swatch" style="background: {{.Color}}"></i>{{.Currency}} {{printf "%.1f" .Share}}%</span>{{end}}</p>
+
+{{with .Growth}}<h2>Growth since {{.Previous}}</h2>
+<p>{{.Added}} customers added, {{.Removed}} removed, {{.Stayed}} in both exports.</p>
+<table>
+<tr><th>Currency</th><th>Previous customers</th><th>Current customers</th><th>Change</th><th>Previous spend</th><th>Current spend</th></tr>
+{{range .Rows}}<tr><td>{{.Currency}}</td><td>{{.Previous}}</td><td>{{.Current}}</td><td>{{.Change}}</td><td>{{.PreviousTotal}}</td><td>{{.CurrentTotal}}</td></tr>
+{{end}}</table>
+<svg width="{{$.ChartWidth}}" height="{{$.ChartHeight}}" viewBox="0 0 {{$.ChartWidth}} {{$.ChartHeight}}">
+{{range .Bars}}<rect x="{{.X}}" y="{{.Y}}" width="{{.W}}" height="{{.H}}" fill="{{.Color}}"><title>{{.Tooltip}}</title></rect>
+{{end}}{{range .Labels}}<text x="{{.X}}" y="{{.Y}}">{{.Text}}</text>
+{{end}}</svg>
+<p class="legend"><span><i class="swatch" style="background: #4e79a7"></i>previous</span><span><i class="swatch" style="background: #f28e2b"></i>current</span></p>
+{{end}}
+<script>
+function showCurrency(currency) {
+  document.querySelectorAll(".histogram").forEach(function (g) {
+    g.style.display = g.dataset.currency === currency ? "" : "none";
+  });
+}
+</script>
+</body>
+</html>
+`))
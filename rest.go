@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"sultans/shopify"
+)
+
+// restCommand exports REST Admin API resources, for data that GraphQL does not
+// offer (yet), or no longer offers, such as deprecated fields kept by older
+// integrations. Every other command uses GraphQL.
+func restCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "rest",
+		Usage:     "Export a REST Admin API resource as CSV, following pagination",
+		ArgsUsage: "<resource path, e.g. customers or customers/123/addresses>",
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{Name: "param", Usage: "Query parameter as key=value, e.g. fields=id,email (repeatable)"},
+			&cli.IntFlag{Name: "max-pages", Usage: "Stop after this many pages (default: all)"},
+			&cli.StringFlag{Name: "rows", Usage: "Dot-separated path of the rows in each response (default: the response's only field, e.g. customers)"},
+			&cli.StringFlag{Name: "lists", Value: "join", Usage: "How lists inside a row are written: join (one cell) or explode (one row per element)"},
+			&cli.StringFlag{Name: "list-separator", Value: ";", Usage: "Separator of joined list values"},
+			&cli.StringFlag{Name: "output", Aliases: []string{"o"}, Usage: "Output CSV filename, or clipboard to copy it (default: stdout)"},
+			&cli.StringFlag{Name: "schema-file", Usage: "Also write the inferred column types as JSON to this file"},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 1 {
+				return fmt.Errorf("expected a resource path, e.g. `rest customers`")
+			}
+			mode := c.String("lists")
+			if mode != "join" && mode != "explode" {
+				return fmt.Errorf("invalid --lists %q, expected join or explode", mode)
+			}
+			params := url.Values{}
+			for _, p := range c.StringSlice("param") {
+				k, v, ok := strings.Cut(p, "=")
+				if !ok {
+					return fmt.Errorf("invalid --param %q, expected key=value", p)
+				}
+				params.Add(k, v)
+			}
+
+			client, err := newShopifyClient(c)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := exportContext(c.Duration("timeout"))
+			defer cancel()
+
+			f := &flattener{explode: mode == "explode", separator: c.String("list-separator")}
+			var rows []flatRow
+			next := client.RESTURL(c.Args().First(), params)
+			for page := 1; next != ""; page++ {
+				var data interface{}
+				if data, next, err = restGet(ctx, client, next); err != nil {
+					return err
+				}
+				for _, v := range selectRows(data, restRowsPath(data, c.String("rows"))) {
+					rows = append(rows, f.rows("", v)...)
+				}
+				if limit := c.Int("max-pages"); limit > 0 && page >= limit {
+					break
+				}
+			}
+			return exportFlatRows(ctx, c, f.columns, rows)
+		},
+	}
+}
+
+// linkNext matches the next page URL of a REST Link header.
+var linkNext = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// restGet fetches a REST Admin API URL with the retries and rate limit of s,
+// and returns the response decoded with decodeOrdered and the URL of the
+// next page, empty on the last page.
+func restGet(ctx context.Context, s *shopify.Client, url string) (interface{}, string, error) {
+	var next string
+	var data interface{}
+	_, requestID, err := s.WithRetries(ctx, func() ([]byte, string, error) {
+		header, requestID, err := s.Do(ctx, "GET", url, nil, func(r io.Reader) error {
+			dec := json.NewDecoder(r)
+			dec.UseNumber()
+			var err error
+			if data, err = decodeOrdered(dec); err != nil {
+				return fmt.Errorf("failed to decode response: %w", err)
+			}
+			return nil
+		})
+		next = ""
+		if m := linkNext.FindStringSubmatch(header.Get("Link")); m != nil {
+			next = m[1]
+		}
+		return nil, requestID, err
+	})
+	if err != nil {
+		return nil, "", shopify.WithRequestID(fmt.Errorf("REST request failed: %w", err), requestID)
+	}
+	return data, next, nil
+}
+
+// restRowsPath returns path, or the only field of a REST response such as
+// {"customers": [...]}.
+func restRowsPath(data interface{}, path string) string {
+	if obj, ok := data.(*jsonObject); ok && path == "" && len(obj.keys) == 1 {
+		return obj.keys[0]
+	}
+	return path
+}
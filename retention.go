@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// outputPlaceholders are replaced in --output with the time of the run, so
+// scheduled runs write a new file each time. They sort chronologically.
+var outputPlaceholders = map[string]string{
+	"{date}": "2006-01-02",
+	"{time}": "20060102T150405Z",
+}
+
+// outputTemplate is --output before its placeholders were replaced, whose
+// earlier files --keep-last and --keep-for prune.
+var outputTemplate string
+
+// expandOutput replaces the placeholders of --output with the current UTC time
+// and checks the retention flags.
+func expandOutput(c *cli.Context) error {
+	output := c.String("output")
+	now := time.Now().UTC()
+	expanded := output
+	for placeholder, layout := range outputPlaceholders {
+		expanded = strings.ReplaceAll(expanded, placeholder, now.Format(layout))
+	}
+	retention := c.IsSet("keep-last") || c.IsSet("keep-for")
+	if retention && (expanded == output || !isFileOutput(output)) {
+		return fmt.Errorf("--keep-last and --keep-for need a CSV file --output with a {date} or {time} placeholder, such as exports/customers-{date}.csv")
+	}
+	if c.Int("keep-last") < 0 {
+		return fmt.Errorf("--keep-last must be at least 1")
+	}
+	// Partitioned exports write several files per run, which --keep-last
+	// would count separately.
+	if c.IsSet("keep-last") && (c.String("partition-by") != "" || c.Bool("split-files")) {
+		return fmt.Errorf("--keep-last cannot be combined with --partition-by or --split-files, use --keep-for")
+	}
+	if _, err := parseAge("keep-for", c.String("keep-for")); err != nil {
+		return err
+	}
+	if expanded == output {
+		return nil
+	}
+	outputTemplate = output
+	return c.Set("output", expanded)
+}
+
+// parseAge parses the age of flag: days such as 30d, or a Go duration such as
+// 12h. Empty means no age limit.
+func parseAge(flag, s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	} else if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return d, nil
+	}
+	return 0, fmt.Errorf("invalid --%s %q, expected days such as 30d or a duration such as 12h", flag, s)
+}
+
+// pruneExports removes the earlier files of the --output template beyond the
+// newest --keep-last, and those older than --keep-for. current, the file just
+// written, is always kept.
+func pruneExports(c *cli.Context, current string) {
+	keep := c.Int("keep-last")
+	age, _ := parseAge("keep-for", c.String("keep-for"))
+	if outputTemplate == "" || keep == 0 && age == 0 {
+		return
+	}
+	pattern := outputTemplate
+	for placeholder := range outputPlaceholders {
+		pattern = strings.ReplaceAll(pattern, placeholder, "*")
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		log.Printf("warning: failed to list earlier exports: %v", err)
+		return
+	}
+
+	type export struct {
+		path    string
+		modTime time.Time
+	}
+	var exports []export
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() || path == current {
+			continue
+		}
+		exports = append(exports, export{path, info.ModTime()})
+	}
+	sort.Slice(exports, func(i, j int) bool { return exports[i].modTime.After(exports[j].modTime) })
+
+	removed := 0
+	for i, e := range exports {
+		// The current file is the first of the --keep-last files.
+		expired := keep > 0 && i+1 >= keep || age > 0 && time.Since(e.modTime) > age
+		if !expired {
+			continue
+		}
+		if err := os.Remove(e.path); err != nil {
+			log.Printf("warning: failed to remove %s: %v", e.path, err)
+			continue
+		}
+		removed++
+	}
+	if removed > 0 {
+		fmt.Fprintf(statusOutput(current), "Removed %d earlier exports of %s\n", removed, pattern)
+	}
+}
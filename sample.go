@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// sampler selects a random subset of fetched customers, set by --sample or --sample-n.
+var sampler *customerSampler
+
+// customerSampler picks customers by a hash of the seed and their ID rather than
+// a random number generator, so the same seed selects the same customers in
+// every run and a customer's selection does not depend on the rest of the segment.
+type customerSampler struct {
+	seed int64
+	// fraction keeps customers whose hash falls below it, with --sample.
+	fraction float64
+	// n keeps the n customers with the lowest hashes, with --sample-n.
+	n int
+}
+
+func newCustomerSampler(c *cli.Context) (*customerSampler, error) {
+	sample, n := c.String("sample"), c.Int("sample-n")
+	switch {
+	case sample == "" && n <= 0:
+		return nil, nil
+	case sample != "" && n > 0:
+		return nil, fmt.Errorf("--sample and --sample-n cannot be combined")
+	case n > 0:
+		if c.String("journal") != "" {
+			return nil, fmt.Errorf("--sample-n cannot be combined with --journal")
+		}
+		return &customerSampler{seed: c.Int64("seed"), n: n}, nil
+	}
+
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(sample), "%"), 64)
+	if err != nil || percent <= 0 || percent > 100 {
+		return nil, fmt.Errorf("invalid --sample %q, expected a percentage such as 10%%", sample)
+	}
+	return &customerSampler{seed: c.Int64("seed"), fraction: percent / 100}, nil
+}
+
+func (s *customerSampler) hash(id string) float64 {
+	return seededHash(strconv.FormatInt(s.seed, 10) + ":" + id)
+}
+
+// seededHash maps key to a uniformly distributed value in [0, 1).
+func seededHash(key string) float64 {
+	sum := sha256.Sum256([]byte(key))
+	return float64(binary.BigEndian.Uint64(sum[:8])>>11) / (1 << 53)
+}
+
+// wrap filters the members of in. With a fraction, members are filtered as they
+// arrive. With n, the whole segment is read first.
+func (s *customerSampler) wrap(ctx context.Context, in *segmentStream) *segmentStream {
+	if s.n > 0 {
+		return bufferStream(ctx, in, s.pick)
+	}
+	return filterStream(ctx, in, func(c CustomerSegmentMember) bool { return s.hash(c.Node.Id) < s.fraction })
+}
+
+// pick returns the n customers with the lowest hashes, in segment order.
+func (s *customerSampler) pick(customers []CustomerSegmentMember) []CustomerSegmentMember {
+	hashes := make(map[string]float64, len(customers))
+	order := make(map[string]int, len(customers))
+	for i, c := range customers {
+		hashes[c.Node.Id] = s.hash(c.Node.Id)
+		order[c.Node.Id] = i
+	}
+	picked := append([]CustomerSegmentMember(nil), customers...)
+	sort.SliceStable(picked, func(i, j int) bool { return hashes[picked[i].Node.Id] < hashes[picked[j].Node.Id] })
+	picked = picked[:min(s.n, len(picked))]
+	sort.Slice(picked, func(i, j int) bool { return order[picked[i].Node.Id] < order[picked[j].Node.Id] })
+	return picked
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v2"
+
+	"sultans/shopify"
+)
+
+const introspectionFragments = `
+fragment FullType on __Type {
+	kind
+	name
+	description
+	fields(includeDeprecated: true) {
+		name
+		description
+		args { ...InputValue }
+		type { ...TypeRef }
+		isDeprecated
+		deprecationReason
+	}
+	inputFields { ...InputValue }
+	interfaces { ...TypeRef }
+	enumValues(includeDeprecated: true) {
+		name
+		description
+		isDeprecated
+		deprecationReason
+	}
+	possibleTypes { ...TypeRef }
+}
+
+fragment InputValue on __InputValue {
+	name
+	description
+	type { ...TypeRef }
+	defaultValue
+}
+` + typeRefFragment
+
+// typeRefFragment selects a type reference with up to seven levels of wrapping
+// in lists and non-null.
+const typeRefFragment = `
+fragment TypeRef on __Type {
+	kind
+	name
+	ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } } } }
+}`
+
+type introspectionSchema struct {
+	QueryType    *typeRef            `json:"queryType"`
+	MutationType *typeRef            `json:"mutationType"`
+	Types        []introspectionType `json:"types"`
+}
+
+type introspectionType struct {
+	Kind          string               `json:"kind"`
+	Name          string               `json:"name"`
+	Description   string               `json:"description"`
+	Fields        []introspectionField `json:"fields"`
+	InputFields   []inputValue         `json:"inputFields"`
+	Interfaces    []typeRef            `json:"interfaces"`
+	EnumValues    []enumValue          `json:"enumValues"`
+	PossibleTypes []typeRef            `json:"possibleTypes"`
+}
+
+type introspectionField struct {
+	Name              string       `json:"name"`
+	Description       string       `json:"description"`
+	Args              []inputValue `json:"args"`
+	Type              typeRef      `json:"type"`
+	IsDeprecated      bool         `json:"isDeprecated"`
+	DeprecationReason string       `json:"deprecationReason"`
+}
+
+type inputValue struct {
+	Name         string  `json:"name"`
+	Description  string  `json:"description"`
+	Type         typeRef `json:"type"`
+	DefaultValue *string `json:"defaultValue"`
+}
+
+type enumValue struct {
+	Name              string `json:"name"`
+	Description       string `json:"description"`
+	IsDeprecated      bool   `json:"isDeprecated"`
+	DeprecationReason string `json:"deprecationReason"`
+}
+
+type typeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	OfType *typeRef `json:"ofType"`
+}
+
+// named returns the name of the type without its list and non-null wrapping.
+func (t typeRef) named() string {
+	for t.OfType != nil {
+		t = *t.OfType
+	}
+	return t.Name
+}
+
+// String renders the reference in SDL notation, e.g. "[String!]!".
+func (t typeRef) String() string {
+	switch t.Kind {
+	case "NON_NULL":
+		return t.OfType.String() + "!"
+	case "LIST":
+		return "[" + t.OfType.String() + "]"
+	default:
+		return t.Name
+	}
+}
+
+func schemaCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "schema",
+		Usage: "Inspect the Admin API schema of the configured API version",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "dump",
+				Usage: "Print the full schema",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "format", Value: "sdl", Usage: "Output format: sdl or json (raw introspection result)"},
+				},
+				Action: func(c *cli.Context) error {
+					ctx, cancel := exportContext(c.Duration("timeout"))
+					defer cancel()
+					return dumpSchema(ctx, c, os.Stdout)
+				},
+			},
+			{
+				Name:      "fields",
+				Usage:     "List the fields, input fields or values of a type",
+				ArgsUsage: "<type>",
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 1 {
+						return fmt.Errorf("expected exactly one type name, e.g. schema fields Customer")
+					}
+					ctx, cancel := exportContext(c.Duration("timeout"))
+					defer cancel()
+					return printTypeFields(ctx, c, os.Stdout, c.Args().First())
+				},
+			},
+		},
+	}
+}
+
+func introspect(ctx context.Context, c *cli.Context, query string, variables map[string]interface{}, data interface{}) error {
+	client, err := newShopifyClient(c)
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []GraphQLError  `json:"errors,omitempty"`
+	}
+	requestID, err := client.Execute(ctx, shopify.Request{Query: query, Variables: variables}, &resp)
+	if err != nil {
+		return fmt.Errorf("GraphQL query failed: %w", err)
+	}
+	if len(resp.Errors) > 0 {
+		return shopify.WithRequestID(fmt.Errorf("GraphQL errors: %v", resp.Errors), requestID)
+	}
+	return json.Unmarshal(resp.Data, data)
+}
+
+func dumpSchema(ctx context.Context, c *cli.Context, w io.Writer) error {
+	format := c.String("format")
+	if format != "sdl" && format != "json" {
+		return fmt.Errorf("unsupported format %q, expected sdl or json", format)
+	}
+
+	var data struct {
+		Schema introspectionSchema `json:"__schema"`
+	}
+	query := `query IntrospectSchema {
+	__schema {
+		queryType { name }
+		mutationType { name }
+		types { ...FullType }
+	}
+}` + introspectionFragments
+	if err := introspect(ctx, c, query, nil, &data); err != nil {
+		return err
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(data)
+	}
+	return writeSDL(w, data.Schema)
+}
+
+// builtinScalars are defined by the GraphQL spec and omitted from SDL output.
+var builtinScalars = map[string]bool{"String": true, "Int": true, "Float": true, "Boolean": true, "ID": true}
+
+// writeSDL renders an introspection result as GraphQL SDL, in type name order,
+// headed by the API version it was introspected from.
+func writeSDL(w io.Writer, schema introspectionSchema) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Shopify Admin API %s schema, generated by introspection with:\n#\n#   go run . schema dump > graphql/schema.graphql\n\n", shopify.APIVersion)
+	b.WriteString("schema {\n")
+	if schema.QueryType != nil {
+		fmt.Fprintf(&b, "  query: %s\n", schema.QueryType.Name)
+	}
+	if schema.MutationType != nil {
+		fmt.Fprintf(&b, "  mutation: %s\n", schema.MutationType.Name)
+	}
+	b.WriteString("}\n")
+
+	types := schema.Types
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+
+	for _, t := range types {
+		if strings.HasPrefix(t.Name, "__") || builtinScalars[t.Name] {
+			continue
+		}
+		b.WriteString("\n")
+		writeDescription(&b, "", t.Description)
+
+		switch t.Kind {
+		case "SCALAR":
+			fmt.Fprintf(&b, "scalar %s\n", t.Name)
+		case "ENUM":
+			fmt.Fprintf(&b, "enum %s {\n", t.Name)
+			for _, v := range t.EnumValues {
+				writeDescription(&b, "  ", v.Description)
+				fmt.Fprintf(&b, "  %s%s\n", v.Name, deprecation(v.IsDeprecated, v.DeprecationReason))
+			}
+			b.WriteString("}\n")
+		case "UNION":
+			names := make([]string, 0, len(t.PossibleTypes))
+			for _, p := range t.PossibleTypes {
+				names = append(names, p.Name)
+			}
+			fmt.Fprintf(&b, "union %s = %s\n", t.Name, strings.Join(names, " | "))
+		case "INPUT_OBJECT":
+			fmt.Fprintf(&b, "input %s {\n", t.Name)
+			for _, f := range t.InputFields {
+				writeDescription(&b, "  ", f.Description)
+				fmt.Fprintf(&b, "  %s\n", formatInputValue(f))
+			}
+			b.WriteString("}\n")
+		case "OBJECT", "INTERFACE":
+			keyword := "type"
+			if t.Kind == "INTERFACE" {
+				keyword = "interface"
+			}
+			fmt.Fprintf(&b, "%s %s", keyword, t.Name)
+			if len(t.Interfaces) > 0 {
+				names := make([]string, 0, len(t.Interfaces))
+				for _, i := range t.Interfaces {
+					names = append(names, i.Name)
+				}
+				fmt.Fprintf(&b, " implements %s", strings.Join(names, " & "))
+			}
+			b.WriteString(" {\n")
+			for _, f := range t.Fields {
+				writeDescription(&b, "  ", f.Description)
+				fmt.Fprintf(&b, "  %s%s: %s%s\n", f.Name, formatArgs(f.Args), f.Type, deprecation(f.IsDeprecated, f.DeprecationReason))
+			}
+			b.WriteString("}\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeDescription(b *strings.Builder, indent, description string) {
+	if description == "" {
+		return
+	}
+	description = strings.ReplaceAll(description, `"""`, `\"""`)
+	fmt.Fprintf(b, "%s\"\"\"\n", indent)
+	for _, line := range strings.Split(description, "\n") {
+		fmt.Fprintf(b, "%s%s\n", indent, line)
+	}
+	fmt.Fprintf(b, "%s\"\"\"\n", indent)
+}
+
+func formatArgs(args []inputValue) string {
+	if len(args) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(args))
+	for _, a := range args {
+		parts = append(parts, formatInputValue(a))
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+func formatInputValue(v inputValue) string {
+	s := v.Name + ": " + v.Type.String()
+	if v.DefaultValue != nil {
+		s += " = " + *v.DefaultValue
+	}
+	return s
+}
+
+func deprecation(deprecated bool, reason string) string {
+	if !deprecated {
+		return ""
+	}
+	if reason == "" {
+		return " @deprecated"
+	}
+	b, _ := json.Marshal(reason)
+	return fmt.Sprintf(" @deprecated(reason: %s)", b)
+}
+
+// printTypeFields lists the members of a single type with the first line of their descriptions.
+func printTypeFields(ctx context.Context, c *cli.Context, w io.Writer, name string) error {
+	var data struct {
+		Type *introspectionType `json:"__type"`
+	}
+	query := `query IntrospectType($name: String!) {
+	__type(name: $name) { ...FullType }
+}` + introspectionFragments
+	if err := introspect(ctx, c, query, map[string]interface{}{"name": name}, &data); err != nil {
+		return err
+	}
+	if data.Type == nil {
+		return fmt.Errorf("type %q not found in Admin API %s", name, shopify.APIVersion)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	t := data.Type
+	switch t.Kind {
+	case "ENUM":
+		for _, v := range t.EnumValues {
+			fmt.Fprintf(tw, "%s\t%s\n", v.Name+deprecation(v.IsDeprecated, ""), firstLine(v.Description))
+		}
+	case "INPUT_OBJECT":
+		for _, f := range t.InputFields {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", f.Name, f.Type, firstLine(f.Description))
+		}
+	case "UNION":
+		for _, p := range t.PossibleTypes {
+			fmt.Fprintf(tw, "%s\n", p.Name)
+		}
+	default:
+		for _, f := range t.Fields {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", f.Name+formatArgs(f.Args)+deprecation(f.IsDeprecated, ""), f.Type, firstLine(f.Description))
+		}
+	}
+	return tw.Flush()
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
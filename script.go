@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// rowScript transforms the rows of CSV exports, set by --transform-script.
+var rowScript *wasmScript
+
+// scriptBatch is what a transform script reads on stdin and writes back on
+// stdout: the CSV columns and rows keyed by column.
+type scriptBatch struct {
+	Columns []string            `json:"columns"`
+	Rows    []map[string]string `json:"rows"`
+}
+
+// wasmScript is a WebAssembly module built for WASI, instantiated for every
+// batch of rows. It runs sandboxed: it only sees its stdin, and has no access to
+// files, the network or environment variables.
+type wasmScript struct {
+	path    string
+	runtime wazero.Runtime
+	module  wazero.CompiledModule
+}
+
+func newWASMScript(ctx context.Context, path string) (*wasmScript, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transform script: %w", err)
+	}
+	// Closing on context cancellation stops scripts that loop forever once the
+	// export times out.
+	r := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().WithCloseOnContextDone(true))
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, r); err != nil {
+		r.Close(ctx)
+		return nil, err
+	}
+	module, err := r.CompileModule(ctx, b)
+	if err != nil {
+		r.Close(ctx)
+		return nil, fmt.Errorf("invalid transform script %s: %w", path, err)
+	}
+	return &wasmScript{path: path, runtime: r, module: module}, nil
+}
+
+func (s *wasmScript) run(ctx context.Context, in scriptBatch) (scriptBatch, error) {
+	input, err := json.Marshal(in)
+	if err != nil {
+		return scriptBatch{}, err
+	}
+	var stdout, stderr bytes.Buffer
+	// Unnamed instances can run concurrently, for --queries-file.
+	config := wazero.NewModuleConfig().WithName("").WithArgs(s.path).
+		WithStdin(bytes.NewReader(input)).WithStdout(&stdout).WithStderr(&stderr)
+	mod, err := s.runtime.InstantiateModule(ctx, s.module, config)
+	var exit *sys.ExitError
+	if err != nil && !(errors.As(err, &exit) && exit.ExitCode() == 0) {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return scriptBatch{}, fmt.Errorf("transform script %s failed: %w: %s", s.path, err, msg)
+		}
+		return scriptBatch{}, fmt.Errorf("transform script %s failed: %w", s.path, err)
+	}
+	if mod != nil {
+		mod.Close(ctx)
+	}
+
+	var out scriptBatch
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return scriptBatch{}, fmt.Errorf("transform script %s wrote invalid output: %w", s.path, err)
+	}
+	return out, nil
+}
+
+// rowTransform applies the transform script to the rows of one CSV export. A nil
+// rowTransform leaves rows unchanged.
+type rowTransform struct {
+	script  *wasmScript
+	in, out []string
+}
+
+// newRowTransform runs the script once without rows to learn the columns it
+// writes, and returns them as the header to write in place of header.
+func newRowTransform(ctx context.Context, header []string) (*rowTransform, []string, error) {
+	if rowScript == nil {
+		return nil, header, nil
+	}
+	out, err := rowScript.run(ctx, scriptBatch{Columns: header, Rows: []map[string]string{}})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(out.Columns) == 0 {
+		return nil, nil, fmt.Errorf("transform script %s returned no columns", rowScript.path)
+	}
+	return &rowTransform{script: rowScript, in: header, out: out.Columns}, out.Columns, nil
+}
+
+// apply returns the rows the script makes of records. It may rename, add or drop
+// columns, and change, drop or add rows.
+func (t *rowTransform) apply(ctx context.Context, records [][]string) ([][]string, error) {
+	if t == nil || len(records) == 0 {
+		return records, nil
+	}
+	in := scriptBatch{Columns: t.in, Rows: make([]map[string]string, len(records))}
+	for i, record := range records {
+		row := make(map[string]string, len(t.in))
+		for j, col := range t.in {
+			row[col] = record[j]
+		}
+		in.Rows[i] = row
+	}
+	out, err := t.script.run(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Equal(out.Columns, t.out) {
+		return nil, fmt.Errorf("transform script %s changed its columns from %v to %v", t.script.path, t.out, out.Columns)
+	}
+
+	transformed := make([][]string, len(out.Rows))
+	for i, row := range out.Rows {
+		record := make([]string, len(t.out))
+		for j, col := range t.out {
+			record[j] = row[col]
+		}
+		transformed[i] = record
+	}
+	return transformed, nil
+}
+
+// applyByKey transforms the records of a partitioned export separately for
+// every partition key, so the rows the script returns can be written to the
+// partition they came from.
+func (t *rowTransform) applyByKey(ctx context.Context, keys []string, records [][]string, write func(key string, record []string) error) error {
+	var order []string
+	groups := map[string][][]string{}
+	for i, key := range keys {
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], records[i])
+	}
+	for _, key := range order {
+		transformed, err := t.apply(ctx, groups[key])
+		if err != nil {
+			return err
+		}
+		for _, record := range transformed {
+			if err := write(key, record); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
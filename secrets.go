@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// readSecretFile reads a secret from a file such as a Docker or Kubernetes
+// secret mount, without the trailing newline most editors and `echo` add.
+func readSecretFile(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	secret := strings.TrimRight(string(b), "\r\n")
+	if secret == "" {
+		return "", fmt.Errorf("secret file %s is empty", path)
+	}
+	redaction.addSecret(secret)
+	return secret, nil
+}
+
+// secretEnv returns the secret named by the environment variable name, or read
+// from the file named by name_FILE, following the Docker secrets convention.
+// Setting both is an error rather than a silent choice.
+func secretEnv(name string) (string, error) {
+	value, file := os.Getenv(name), os.Getenv(name+"_FILE")
+	switch {
+	case file == "":
+		return value, nil
+	case value != "":
+		return "", fmt.Errorf("%s and %s_FILE cannot both be set", name, name)
+	}
+	return readSecretFile(file)
+}
+
+// secretBackendTimeout bounds each fetch from a --secret-backend.
+const secretBackendTimeout = 10 * time.Second
+
+// secretBackends caches the secrets fetched from each --secret-backend URL, so
+// the clients created during a run share one fetch.
+var secretBackends = struct {
+	sync.Mutex
+	secrets map[string]*cachedSecret
+}{secrets: map[string]*cachedSecret{}}
+
+// cachedSecret is a secret from a backend, fetched again once it is older than
+// ttl. A failed renewal keeps the previous value until it is twice as old, so a
+// brief backend outage does not fail a long export.
+type cachedSecret struct {
+	ttl   time.Duration
+	fetch func(ctx context.Context) (string, error)
+
+	mu        sync.Mutex
+	value     string
+	fetchedAt time.Time
+}
+
+// secretBackend returns the cached secret for a URL such as
+// vault://secret/data/shopify#token, awssm://shopify/token or ssm:///shopify/token,
+// fetching it if this is the first use.
+func secretBackend(ctx context.Context, rawURL string, ttl time.Duration) (*cachedSecret, error) {
+	secretBackends.Lock()
+	defer secretBackends.Unlock()
+	if s, ok := secretBackends.secrets[rawURL]; ok {
+		return s, nil
+	}
+
+	scheme, rest, ok := strings.Cut(rawURL, "://")
+	path, key, _ := strings.Cut(rest, "#")
+	if !ok || path == "" {
+		return nil, fmt.Errorf("invalid --secret-backend %q, expected vault://<path>, awssm://<name> or ssm://<name>", rawURL)
+	}
+	s := &cachedSecret{ttl: ttl}
+	switch scheme {
+	case "vault":
+		s.fetch = func(ctx context.Context) (string, error) { return fetchVaultSecret(ctx, path, key) }
+	case "awssm":
+		s.fetch = func(ctx context.Context) (string, error) { return fetchAWSSecret(ctx, path, key) }
+	case "ssm":
+		s.fetch = func(ctx context.Context) (string, error) { return fetchSSMParameter(ctx, path) }
+	default:
+		return nil, fmt.Errorf("unsupported --secret-backend scheme %q, expected vault, awssm or ssm", scheme)
+	}
+	if _, err := s.Token(ctx); err != nil {
+		return nil, err
+	}
+	secretBackends.secrets[rawURL] = s
+	return s, nil
+}
+
+func (s *cachedSecret) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	age := time.Since(s.fetchedAt)
+	if s.value != "" && (s.ttl <= 0 || age < s.ttl) {
+		return s.value, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, secretBackendTimeout)
+	defer cancel()
+	value, err := s.fetch(ctx)
+	if err == nil && value == "" {
+		err = fmt.Errorf("secret is empty")
+	}
+	if err != nil {
+		if s.value != "" && age < 2*s.ttl {
+			log.Printf("warning: failed to renew secret, using the cached value: %v", err)
+			return s.value, nil
+		}
+		return "", fmt.Errorf("failed to fetch secret: %w", err)
+	}
+	redaction.addSecret(value)
+	s.value, s.fetchedAt = value, time.Now()
+	return value, nil
+}
+
+// secretField returns key of a secret holding a JSON object, or the secret
+// itself without a key.
+func secretField(secret, key string) (string, error) {
+	if key == "" {
+		return secret, nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object with key %q", key)
+	}
+	value, ok := fields[key].(string)
+	if !ok {
+		return "", fmt.Errorf("secret has no string key %q", key)
+	}
+	return value, nil
+}
+
+// fetchVaultSecret reads a KV secret from VAULT_ADDR with VAULT_TOKEN (or
+// VAULT_TOKEN_FILE). path is the API path, such as secret/data/shopify for KV
+// version 2. Without key, the secret must have a single field.
+func fetchVaultSecret(ctx context.Context, path, key string) (string, error) {
+	addr := os.Getenv("VAULT_ADDR")
+	token, err := secretEnv("VAULT_TOKEN")
+	if err != nil {
+		return "", err
+	}
+	if addr == "" || token == "" {
+		return "", fmt.Errorf("VAULT_ADDR and VAULT_TOKEN must be set")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(addr, "/")+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if ns := os.Getenv("VAULT_NAMESPACE"); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Vault returned HTTP %d: %s", resp.StatusCode, string(b))
+	}
+
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(b, &secret); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	// KV version 2 nests the fields under data.data.
+	fields := secret.Data
+	if nested, ok := fields["data"].(map[string]interface{}); ok {
+		fields = nested
+	}
+	if key == "" {
+		if len(fields) != 1 {
+			return "", fmt.Errorf("Vault secret %s has %d fields, select one with #<key>", path, len(fields))
+		}
+		for k := range fields {
+			key = k
+		}
+	}
+	value, ok := fields[key].(string)
+	if !ok {
+		return "", fmt.Errorf("Vault secret %s has no string field %q", path, key)
+	}
+	return value, nil
+}
+
+// fetchAWSSecret reads a secret string from AWS Secrets Manager.
+func fetchAWSSecret(ctx context.Context, name, key string) (string, error) {
+	creds, err := awsCredentialsFromEnv("")
+	if err != nil {
+		return "", err
+	}
+	var out struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := awsJSONRequest(ctx, creds, "secretsmanager", "application/x-amz-json-1.1", "secretsmanager.GetSecretValue", map[string]string{"SecretId": name}, &out); err != nil {
+		return "", err
+	}
+	return secretField(out.SecretString, key)
+}
+
+// fetchSSMParameter reads a parameter, decrypting SecureString values, from AWS
+// Systems Manager Parameter Store.
+func fetchSSMParameter(ctx context.Context, name string) (string, error) {
+	creds, err := awsCredentialsFromEnv("")
+	if err != nil {
+		return "", err
+	}
+	var out struct {
+		Parameter struct {
+			Value string `json:"Value"`
+		} `json:"Parameter"`
+	}
+	req := map[string]interface{}{"Name": name, "WithDecryption": true}
+	if err := awsJSONRequest(ctx, creds, "ssm", "application/x-amz-json-1.1", "AmazonSSM.GetParameter", req, &out); err != nil {
+		return "", err
+	}
+	return out.Parameter.Value, nil
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"sultans/shopify"
+)
+
+func serveCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "serve",
+		Usage: "Serve on-demand exports over HTTP",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "http", Value: ":8080", Usage: "Address to serve HTTP exports on (empty to disable)"},
+			&cli.StringFlag{Name: "grpc", Usage: "Address to serve the gRPC CustomerExportService on, e.g. :9090"},
+			&cli.StringFlag{Name: "shops", Usage: "YAML file of several shops to serve, each with its own credentials, auth token and rate budget"},
+			&cli.BoolFlag{Name: "dashboard", Usage: "Serve a web dashboard of the jobs in the run history on /dashboard of --http, with the SERVE_DASHBOARD_TOKEN password"},
+			&cli.StringFlag{Name: "health", Usage: "Address to serve only /healthz and /readyz on, e.g. :8081, for probes when --http is disabled"},
+		},
+		Action: func(c *cli.Context) error {
+			tenants, err := serveTenants(c)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := exportContext(c.Duration("timeout"))
+			err = resolvePrimaryCurrency(ctx, c)
+			cancel()
+			if err != nil {
+				return err
+			}
+			if c.String("http") == "" && c.String("grpc") == "" {
+				return fmt.Errorf("at least one of --http and --grpc must be set")
+			}
+			h := newServeHealth(tenants)
+			var d *dashboard
+			if c.Bool("dashboard") {
+				token := os.Getenv("SERVE_DASHBOARD_TOKEN")
+				if token == "" {
+					return fmt.Errorf("SERVE_DASHBOARD_TOKEN must be set for --dashboard")
+				}
+				if c.String("http") == "" {
+					return fmt.Errorf("--dashboard needs --http")
+				}
+				d = newDashboard(c, token, tenants, h)
+			}
+			if tenants[0].name != "" {
+				log.Printf("Serving %d shops: %s", len(tenants), tenantNames(tenants))
+			}
+
+			// Listen on every address before notifying systemd, so being
+			// ready means accepting connections.
+			listen := func(flag string) (net.Listener, error) {
+				if c.String(flag) == "" {
+					return nil, nil
+				}
+				lis, err := net.Listen("tcp", c.String(flag))
+				if err != nil {
+					return nil, fmt.Errorf("failed to listen on --%s: %w", flag, err)
+				}
+				return lis, nil
+			}
+			listeners := map[string]net.Listener{}
+			for _, flag := range []string{"http", "grpc", "health"} {
+				if listeners[flag], err = listen(flag); err != nil {
+					return err
+				}
+			}
+
+			errs := make(chan error, 3)
+			if lis := listeners["http"]; lis != nil {
+				go func() { errs <- serveHTTP(lis, tenants, h, d) }()
+			}
+			if lis := listeners["grpc"]; lis != nil {
+				go func() { errs <- serveGRPC(lis, tenants, h) }()
+			}
+			if lis := listeners["health"]; lis != nil {
+				go func() { errs <- serveHealthHTTP(lis, h) }()
+			}
+			go h.supervise()
+			return <-errs
+		},
+	}
+}
+
+// serveHTTP serves the exports of a single shop on /export, and those of the
+// shops of --shops on /shops/<name>/export.
+func serveHTTP(lis net.Listener, tenants []*tenant, h *serveHealth, d *dashboard) error {
+	mux := http.NewServeMux()
+	for _, t := range tenants {
+		path := "/export"
+		if t.name != "" {
+			path = "/shops/" + t.name + "/export"
+		}
+		mux.Handle(path, requireToken(t.token, exportHandler(t)))
+	}
+	h.register(mux)
+	if d != nil {
+		d.register(mux)
+	}
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	log.Printf("Serving exports on %s", lis.Addr())
+	return server.Serve(lis)
+}
+
+// requireToken rejects requests without "Authorization: Bearer <token>".
+func requireToken(token string, next http.Handler) http.Handler {
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// exportHandler serves GET /export. The query, first, sortKey and reverse parameters
+// override the tenant's defaults, and format selects ndjson (default), json or csv.
+func exportHandler(t *tenant) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		q, err := segmentQueryFromParams(r, t.defaults)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = "ndjson"
+		}
+		if format != "ndjson" && format != "json" && format != "csv" {
+			http.Error(w, fmt.Sprintf("unsupported format %q", format), http.StatusBadRequest)
+			return
+		}
+
+		if !t.acquire() {
+			http.Error(w, "too many concurrent exports", http.StatusTooManyRequests)
+			return
+		}
+		defer t.release()
+
+		ctx, cancel := timeoutContext(r.Context(), t.timeout)
+		defer cancel()
+
+		// Members are written as they are fetched. The first is awaited before
+		// the response starts, so a failing query still gets its status code;
+		// later failures and partial data are reported in trailers.
+		stream := fetchSegmentStream(ctx, t.client, q, 1, nil)
+		first, more := <-stream.Items
+		if !more {
+			if err := stream.Err(); err != nil {
+				exportFailed(t, w, err)
+				return
+			}
+			if err := stream.partialErr(); err != nil {
+				t.logf("export returned partial data: %v", err)
+				w.Header().Set("X-Partial-Data", "true")
+			}
+		} else {
+			w.Header().Set("Trailer", "X-Partial-Data, X-Export-Error")
+		}
+
+		var out exportWriter
+		switch format {
+		case "ndjson":
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			out = &ndjsonWriter{enc: json.NewEncoder(w)}
+		case "json":
+			w.Header().Set("Content-Type", "application/json")
+			out = &jsonWriter{w: w}
+		case "csv":
+			w.Header().Set("Content-Type", "text/csv")
+			out = &csvExportWriter{w: csv.NewWriter(w)}
+		}
+		flusher, _ := w.(http.Flusher)
+		write := func(item segmentItem) error {
+			if item.EndOfPage {
+				return nil
+			}
+			if err := out.write(item.Customer); err != nil {
+				return err
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+			return nil
+		}
+
+		err = out.begin()
+		if err == nil && more {
+			err = write(first)
+		}
+		for item := range stream.Items {
+			if err == nil {
+				err = write(item)
+			}
+			if err != nil {
+				// The client is gone; stop fetching.
+				cancel()
+			}
+		}
+		if err != nil {
+			t.logf("failed to write export: %v", err)
+			return
+		}
+		if err := stream.Err(); err != nil {
+			// The response is left unterminated, so clients that ignore the
+			// trailer still see a truncated JSON document or CSV row.
+			t.logf("export failed after the response started: %v", err)
+			recordExportError(t.name, err)
+			w.Header().Set("X-Export-Error", redaction.redact(err.Error()))
+			return
+		}
+		if err := out.end(); err != nil {
+			t.logf("failed to write export: %v", err)
+			return
+		}
+		if err := stream.partialErr(); err != nil && more {
+			t.logf("export returned partial data: %v", err)
+			w.Header().Set("X-Partial-Data", "true")
+		}
+	})
+}
+
+// exportFailed answers an export that failed before its response started with
+// the status of err.
+func exportFailed(t *tenant, w http.ResponseWriter, err error) {
+	t.logf("export failed: %v", err)
+	recordExportError(t.name, err)
+	var reqErr *shopify.RequestError
+	if errors.As(err, &reqErr) {
+		w.Header().Set("X-Shopify-Request-Id", reqErr.RequestID)
+	}
+	status := http.StatusBadGateway
+	switch {
+	case errors.Is(err, shopify.ErrCircuitOpen), errors.Is(err, shopify.ErrThrottled):
+		status = http.StatusServiceUnavailable
+	case errors.Is(err, shopify.ErrInvalidQuery):
+		status = http.StatusBadRequest
+	case errors.Is(err, shopify.ErrTimeout):
+		status = http.StatusGatewayTimeout
+	}
+	http.Error(w, redaction.redact(err.Error()), status)
+}
+
+func segmentQueryFromParams(r *http.Request, q SegmentQuery) (SegmentQuery, error) {
+	params := r.URL.Query()
+	if v := params.Get("query"); v != "" {
+		q.Query = v
+	}
+	if v := params.Get("sortKey"); v != "" {
+		q.SortKey = v
+	}
+	if v := params.Get("first"); v != "" {
+		first, err := strconv.Atoi(v)
+		if err != nil || first < 1 {
+			return q, fmt.Errorf("invalid first %q", v)
+		}
+		q.First = first
+	}
+	if v := params.Get("reverse"); v != "" {
+		reverse, err := strconv.ParseBool(v)
+		if err != nil {
+			return q, fmt.Errorf("invalid reverse %q", v)
+		}
+		q.Reverse = reverse
+	}
+	return q, nil
+}
+
+// exportWriter encodes the members of an export in a response format, one at a
+// time. end completes the document once every member was written.
+type exportWriter interface {
+	begin() error
+	write(c CustomerSegmentMember) error
+	end() error
+}
+
+// ndjsonWriter writes one customer node per line.
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func (n *ndjsonWriter) begin() error { return nil }
+
+func (n *ndjsonWriter) write(c CustomerSegmentMember) error { return n.enc.Encode(c.Node) }
+
+func (n *ndjsonWriter) end() error { return nil }
+
+// jsonWriter writes an array of customer nodes.
+type jsonWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (j *jsonWriter) begin() error {
+	_, err := io.WriteString(j.w, "[")
+	return err
+}
+
+func (j *jsonWriter) write(c CustomerSegmentMember) error {
+	b, err := json.Marshal(c.Node)
+	if err != nil {
+		return err
+	}
+	if j.count > 0 {
+		b = append([]byte(","), b...)
+	}
+	j.count++
+	_, err = j.w.Write(b)
+	return err
+}
+
+func (j *jsonWriter) end() error {
+	_, err := io.WriteString(j.w, "]\n")
+	return err
+}
+
+// csvExportWriter writes the CSV columns of csvHeader.
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvExportWriter) begin() error { return c.flush(csvHeader) }
+
+func (c *csvExportWriter) write(m CustomerSegmentMember) error { return c.flush(csvRecord(m)) }
+
+func (c *csvExportWriter) end() error { return nil }
+
+func (c *csvExportWriter) flush(record []string) error {
+	if err := c.w.Write(record); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
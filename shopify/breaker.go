@@ -0,0 +1,125 @@
+package shopify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CircuitBreaker stops sending requests to Shopify after threshold consecutive
+// failures. Once cooldown has passed a single probe request is let through; its
+// success closes the circuit and its failure opens it for another cooldown.
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// ErrCircuitOpen is returned without contacting Shopify while the circuit is open.
+var ErrCircuitOpen = errors.New("circuit open")
+
+// NewCircuitBreaker returns a breaker, or nil, which never opens, when threshold
+// is not positive.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow reports whether a request may be sent. A nil breaker allows everything.
+func (b *CircuitBreaker) allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return nil
+	}
+	if wait := b.cooldown - time.Since(b.openedAt); wait > 0 || b.probing {
+		if wait < 0 {
+			wait = 0
+		}
+		return fmt.Errorf("%w: Shopify API failed %d consecutive times, retrying in %s", ErrCircuitOpen, b.failures, wait.Round(time.Second))
+	}
+	b.probing = true
+	return nil
+}
+
+// IsOpen reports whether requests currently fail fast, before the cooldown
+// lets a probe through.
+func (b *CircuitBreaker) IsOpen() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures >= b.threshold && time.Since(b.openedAt) < b.cooldown
+}
+
+// record updates the breaker with the outcome of a request sent after allow.
+func (b *CircuitBreaker) record(err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	// A cancelled request says nothing about Shopify; only a cancelled probe
+	// needs to be let through again.
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	if isServiceFailure(err) {
+		b.failures++
+		if b.failures >= b.threshold {
+			b.openedAt = time.Now()
+		}
+		return
+	}
+	var httpErr *HTTPStatusError
+	if err == nil || errors.As(err, &httpErr) && httpErr.StatusCode >= 400 && httpErr.StatusCode < 500 {
+		b.failures = 0
+	}
+}
+
+// isServiceFailure reports whether err indicates that Shopify is unavailable or
+// overloaded: HTTP 429 and 5xx responses, and network errors and timeouts. Problems
+// with the request itself, or with handling a response that arrived, such as its
+// decoding or writing its customers, are not.
+func isServiceFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var httpErr *HTTPStatusError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode >= 500
+	}
+	var transportErr *transportError
+	return errors.As(err, &transportErr)
+}
+
+// transportReader reads a response body, returning its read errors as a
+// *transportError so they can be told apart from failures to decode it.
+type transportReader struct {
+	r io.Reader
+}
+
+func (t transportReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	if err != nil && err != io.EOF {
+		err = &transportError{err}
+	}
+	return n, err
+}
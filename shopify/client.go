@@ -0,0 +1,340 @@
+// Package shopify is a client of the Shopify Admin and Storefront GraphQL APIs,
+// with retries, rate limiting, a circuit breaker and typed errors.
+package shopify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// APIVersion is the API version all requests are made against.
+const APIVersion = "2025-01"
+
+// Client sends requests to the Admin GraphQL API of one shop. It implements
+// graphql.Client for genqlient's generated query functions.
+type Client struct {
+	Domain      string
+	AccessToken string
+	// Storefront sends requests to the Storefront API with a Storefront access
+	// token instead, for queries that need no Admin API scopes.
+	Storefront bool
+	// Tokens, if non-nil, supplies the access token instead.
+	Tokens  TokenSource
+	Cache   Cache
+	Breaker *CircuitBreaker
+	Retry   RetryPolicy
+	Limiter *RateLimiter
+	// HTTP sends the requests, http.DefaultClient if nil.
+	HTTP *http.Client
+	// UserAgent and Headers are sent with every request, to identify the
+	// traffic in Shopify's request logs.
+	UserAgent string
+	Headers   map[string]string
+}
+
+// TokenSource supplies the access token of every request, for tokens that are
+// rotated while a client is in use.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// Cache stores successful GraphQL responses by request.
+type Cache interface {
+	// Key identifies a request by endpoint (shop, API and version), query and
+	// variables.
+	Key(endpoint string, request []byte) string
+	// Get returns the response cached under key, or nil, and whether it is
+	// still fresh. Expired responses are revalidated if they have validators.
+	Get(key string) (*CachedResponse, bool)
+	// Put stores a response, or renews one that was revalidated.
+	Put(key string, r *CachedResponse) error
+}
+
+// CachedResponse is a response body with the ETag and Last-Modified headers it
+// was served with, which a conditional request revalidates it with.
+type CachedResponse struct {
+	Body         json.RawMessage `json:"body"`
+	ETag         string          `json:"etag,omitempty"`
+	LastModified string          `json:"lastModified,omitempty"`
+}
+
+// Request is a GraphQL request body.
+type Request struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// Endpoint returns the GraphQL URL of the client's API.
+func (s *Client) Endpoint() string {
+	if s.Storefront {
+		return fmt.Sprintf("%s/api/%s/graphql.json", BaseURL(s.Domain), APIVersion)
+	}
+	return fmt.Sprintf("%s/admin/api/%s/graphql.json", BaseURL(s.Domain), APIVersion)
+}
+
+// RESTURL returns the REST Admin API URL of a resource path such as
+// "customers" or "customers/123/addresses.json".
+func (s *Client) RESTURL(path string, params url.Values) string {
+	path = strings.Trim(path, "/")
+	if !strings.HasSuffix(path, ".json") {
+		path += ".json"
+	}
+	u := fmt.Sprintf("%s/admin/api/%s/%s", BaseURL(s.Domain), APIVersion, path)
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	return u
+}
+
+func (s *Client) MakeRequest(ctx context.Context, req *graphql.Request, resp *graphql.Response) error {
+	requestID, err := s.Execute(ctx, req, resp)
+	if err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		return WithRequestID(GraphQLErrors{resp.Errors}, requestID)
+	}
+	return nil
+}
+
+// Query sends an arbitrary query and returns the data of the response.
+func (s *Client) Query(ctx context.Context, query string, variables map[string]interface{}) (json.RawMessage, error) {
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors gqlerror.List   `json:"errors,omitempty"`
+	}
+	requestID, err := s.Execute(ctx, Request{Query: query, Variables: variables}, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("GraphQL query failed: %w", err)
+	}
+	if len(resp.Errors) > 0 {
+		return nil, WithRequestID(fmt.Errorf("GraphQL errors: %w", GraphQLErrors{resp.Errors}), requestID)
+	}
+	return resp.Data, nil
+}
+
+// Execute sends a GraphQL request and decodes the response into out, returning
+// Shopify's X-Request-Id for the response (empty when served from the cache).
+// With a Cache, successful responses are served from and stored in it, and
+// expired ones with an ETag or Last-Modified header are revalidated with a
+// conditional request.
+func (s *Client) Execute(ctx context.Context, request, out interface{}) (string, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var key string
+	var full *bytes.Buffer
+	var stale *CachedResponse
+	if s.Cache != nil {
+		key = s.Cache.Key(s.Endpoint(), body)
+		cached, fresh := s.Cache.Get(key)
+		if fresh {
+			_, err := decodeGraphQLResponse(bytes.NewReader(cached.Body), out)
+			return "", err
+		}
+		if cached != nil && (cached.ETag != "" || cached.LastModified != "") {
+			stale = cached
+		}
+		full = &bytes.Buffer{}
+	}
+
+	envelope, validators, requestID, err := s.send(ctx, body, out, full, stale)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded && !errors.Is(err, ErrTimeout) {
+			err = fmt.Errorf("%w: %w", ErrTimeout, err)
+		}
+		return requestID, WithRequestID(err, requestID)
+	}
+
+	if s.Cache != nil && !hasGraphQLErrors(envelope) {
+		validators.Body = full.Bytes()
+		if err := s.Cache.Put(key, validators); err != nil {
+			log.Printf("warning: failed to cache response: %v", err)
+		}
+	}
+	return requestID, nil
+}
+
+// send posts body to the GraphQL endpoint, retrying failures allowed by the
+// retry policy, and decodes the response into out. It returns the response
+// without streamed data, as decodeGraphQLResponse does, and its validators.
+// full, if non-nil, receives the whole response. With stale, the request is
+// conditional, and stale is decoded instead if Shopify answers 304 Not Modified.
+func (s *Client) send(ctx context.Context, body []byte, out interface{}, full *bytes.Buffer, stale *CachedResponse) ([]byte, *CachedResponse, string, error) {
+	conditional := http.Header{}
+	if stale != nil {
+		if stale.ETag != "" {
+			conditional.Set("If-None-Match", stale.ETag)
+		}
+		if stale.LastModified != "" {
+			conditional.Set("If-Modified-Since", stale.LastModified)
+		}
+	}
+	validators := &CachedResponse{}
+	envelope, requestID, err := s.WithRetries(ctx, func() ([]byte, string, error) {
+		var envelope []byte
+		decode := func(r io.Reader) error {
+			if full != nil {
+				full.Reset()
+				r = io.TeeReader(r, full)
+			}
+			var err error
+			envelope, err = decodeGraphQLResponse(r, out)
+			return err
+		}
+		header, requestID, err := s.request(ctx, "POST", s.Endpoint(), body, conditional, decode)
+		if errors.Is(err, errNotModified) {
+			err = decode(bytes.NewReader(stale.Body))
+		}
+		if err == nil {
+			validators.ETag = header.Get("ETag")
+			validators.LastModified = header.Get("Last-Modified")
+			if validators.ETag == "" && validators.LastModified == "" && stale != nil {
+				validators.ETag, validators.LastModified = stale.ETag, stale.LastModified
+			}
+		}
+		return envelope, requestID, err
+	})
+	return envelope, validators, requestID, err
+}
+
+// errNotModified is a 304 Not Modified response to a conditional request.
+var errNotModified = errors.New("not modified")
+
+// WithRetries calls request, which returns a response body and request ID,
+// until it succeeds or fails in a way the retry policy does not retry. Every
+// attempt is rate limited and counted by the circuit breaker.
+func (s *Client) WithRetries(ctx context.Context, request func() ([]byte, string, error)) ([]byte, string, error) {
+	for attempt := 0; ; attempt++ {
+		if err := s.Limiter.wait(ctx); err != nil {
+			return nil, "", err
+		}
+		if err := s.Breaker.allow(); err != nil {
+			return nil, "", err
+		}
+		respBody, requestID, err := request()
+		s.Breaker.record(err)
+
+		retryErr := err
+		if err == nil {
+			if !s.Retry.on["THROTTLED"] || !isThrottled(respBody) {
+				return respBody, requestID, nil
+			}
+			retryErr = ErrThrottled
+		}
+		if attempt >= s.Retry.maxRetries || !s.Retry.on[retryCode(retryErr)] || ctx.Err() != nil {
+			// A throttled response is returned as-is so its GraphQL errors are reported.
+			return respBody, requestID, err
+		}
+
+		wait := s.Retry.wait(attempt, retryErr)
+		log.Printf("Shopify request failed (%v), retrying in %s", WithRequestID(retryErr, requestID), wait)
+		if sleep(ctx, wait) != nil {
+			return respBody, requestID, err
+		}
+	}
+}
+
+// MaxErrorBody bounds the body of a failed response read for its error message.
+const MaxErrorBody = 64 << 10
+
+// Do sends an authenticated request to the shop, calls decode, if non-nil, with
+// the body of the response as it arrives, and returns the headers and request
+// ID. Responses other than 200 OK are an *HTTPStatusError.
+func (s *Client) Do(ctx context.Context, method, url string, body []byte, decode func(io.Reader) error) (http.Header, string, error) {
+	return s.request(ctx, method, url, body, nil, decode)
+}
+
+// request is Do with the additional headers of a conditional request, which
+// fails with errNotModified if the response is 304 Not Modified.
+func (s *Client) request(ctx context.Context, method, url string, body []byte, conditional http.Header, decode func(io.Reader) error) (http.Header, string, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range conditional {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range s.Headers {
+		req.Header.Set(k, v)
+	}
+	if s.UserAgent != "" {
+		req.Header.Set("User-Agent", s.UserAgent)
+	}
+	token := s.AccessToken
+	if s.Tokens != nil {
+		if token, err = s.Tokens.Token(ctx); err != nil {
+			return nil, "", err
+		}
+	}
+	if s.Storefront {
+		req.Header.Set("X-Shopify-Storefront-Access-Token", token)
+	} else {
+		req.Header.Set("X-Shopify-Access-Token", token)
+	}
+
+	httpClient := s.HTTP
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			if cause := context.Cause(ctx); errors.Is(cause, ErrTimeout) {
+				return nil, "", &transportError{cause}
+			}
+			return nil, "", &transportError{fmt.Errorf("%w: %w", ErrTimeout, ctx.Err())}
+		}
+		if os.IsTimeout(err) {
+			return nil, "", &transportError{fmt.Errorf("%w: HTTP request failed: %w", ErrTimeout, err)}
+		}
+		return nil, "", &transportError{fmt.Errorf("HTTP request failed: %w", err)}
+	}
+	defer resp.Body.Close()
+	requestID := resp.Header.Get("X-Request-Id")
+
+	if resp.StatusCode == http.StatusNotModified && len(conditional) > 0 {
+		return resp.Header, requestID, errNotModified
+	}
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, MaxErrorBody))
+		return resp.Header, requestID, &HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			Body:       string(b),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+
+	if decode != nil {
+		if err := decode(transportReader{resp.Body}); err != nil {
+			return resp.Header, requestID, err
+		}
+	}
+	return resp.Header, requestID, nil
+}
+
+// BaseURL returns the base URL for a shop domain. Domains may carry an explicit
+// scheme, e.g. "http://localhost:8081" for a mock server.
+func BaseURL(domain string) string {
+	if strings.Contains(domain, "://") {
+		return strings.TrimSuffix(domain, "/")
+	}
+	return "https://" + domain
+}
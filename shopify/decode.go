@@ -0,0 +1,99 @@
+package shopify
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+// StreamingData is response data decoded token by token as the response is
+// read, so large responses are not held in memory. Set it as the Data of a
+// *graphql.Response to have Execute stream into it.
+type StreamingData interface {
+	DecodeStream(dec *json.Decoder) error
+}
+
+// decodeGraphQLResponse decodes the GraphQL response read from r into out. When
+// out is a *graphql.Response whose Data is StreamingData, the data is streamed
+// into it. It returns the rest of the response, such as its errors, as JSON.
+func decodeGraphQLResponse(r io.Reader, out interface{}) ([]byte, error) {
+	var data StreamingData
+	if resp, ok := out.(*graphql.Response); ok {
+		data, _ = resp.Data.(StreamingData)
+	}
+	dec := json.NewDecoder(r)
+	rest := map[string]json.RawMessage{}
+	err := DecodeObject(dec, func(key string) error {
+		if key == "data" && data != nil {
+			return data.DecodeStream(dec)
+		}
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			return err
+		}
+		rest[key] = v
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	envelope, err := json.Marshal(rest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if err := json.Unmarshal(envelope, out); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return envelope, nil
+}
+
+func hasGraphQLErrors(body []byte) bool {
+	var probe struct {
+		Errors []json.RawMessage `json:"errors"`
+	}
+	return json.Unmarshal(body, &probe) != nil || len(probe.Errors) > 0
+}
+
+// DecodeObject calls field for every key of the next JSON object, which must
+// consume the key's value. A null value is treated as an empty object.
+func DecodeObject(dec *json.Decoder, field func(key string) error) error {
+	tok, err := dec.Token()
+	if err != nil || tok == nil {
+		return err
+	}
+	if tok != json.Delim('{') {
+		return fmt.Errorf("expected object, got %v", tok)
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if err := field(tok.(string)); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token()
+	return err
+}
+
+// DecodeArray calls elem for every element of the next JSON array, which must
+// consume the element. A null value is treated as an empty array.
+func DecodeArray(dec *json.Decoder, elem func() error) error {
+	tok, err := dec.Token()
+	if err != nil || tok == nil {
+		return err
+	}
+	if tok != json.Delim('[') {
+		return fmt.Errorf("expected array, got %v", tok)
+	}
+	for dec.More() {
+		if err := elem(); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token()
+	return err
+}
@@ -0,0 +1,140 @@
+package shopify
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+func TestDecodeObject(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		keys    []string
+		wantErr bool
+	}{
+		{name: "fields", input: `{"a": 1, "b": {"c": [2]}, "d": "e"}`, keys: []string{"a", "b", "d"}},
+		{name: "empty", input: `{}`},
+		{name: "null", input: `null`},
+		{name: "array", input: `[1]`, wantErr: true},
+		{name: "string", input: `"a"`, wantErr: true},
+		{name: "truncated", input: `{"a": 1,`, keys: []string{"a"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := json.NewDecoder(strings.NewReader(tt.input))
+			var keys []string
+			err := DecodeObject(dec, func(key string) error {
+				keys = append(keys, key)
+				var v json.RawMessage
+				return dec.Decode(&v)
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeObject() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(keys, tt.keys) {
+				t.Errorf("DecodeObject() keys = %q, want %q", keys, tt.keys)
+			}
+		})
+	}
+}
+
+func TestDecodeArray(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []int
+		wantErr bool
+	}{
+		{name: "elements", input: `[1, 2, 3]`, want: []int{1, 2, 3}},
+		{name: "empty", input: `[]`},
+		{name: "null", input: `null`},
+		{name: "object", input: `{"a": 1}`, wantErr: true},
+		{name: "wrong element", input: `[1, "b"]`, want: []int{1}, wantErr: true},
+		{name: "truncated", input: `[1, 2`, want: []int{1, 2}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := json.NewDecoder(strings.NewReader(tt.input))
+			var got []int
+			err := DecodeArray(dec, func() error {
+				var v int
+				if err := dec.Decode(&v); err != nil {
+					return err
+				}
+				got = append(got, v)
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeArray() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeArray() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// itemsData streams the elements of data.items.
+type itemsData struct {
+	items []string
+}
+
+func (d *itemsData) DecodeStream(dec *json.Decoder) error {
+	return DecodeObject(dec, func(key string) error {
+		if key != "items" {
+			var v json.RawMessage
+			return dec.Decode(&v)
+		}
+		return DecodeArray(dec, func() error {
+			var item string
+			if err := dec.Decode(&item); err != nil {
+				return err
+			}
+			d.items = append(d.items, item)
+			return nil
+		})
+	})
+}
+
+func TestDecodeGraphQLResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantItems  []string
+		wantErrors int
+		wantErr    bool
+	}{
+		{name: "data", body: `{"data": {"items": ["a", "b"], "other": 1}}`, wantItems: []string{"a", "b"}},
+		{name: "data with errors", body: `{"data": {"items": ["a"]}, "errors": [{"message": "failed", "path": ["items", 1]}]}`, wantItems: []string{"a"}, wantErrors: 1},
+		{name: "errors before data", body: `{"errors": [{"message": "failed"}], "data": {"items": ["a"]}}`, wantItems: []string{"a"}, wantErrors: 1},
+		{name: "null data", body: `{"data": null, "errors": [{"message": "failed"}]}`, wantErrors: 1},
+		{name: "not JSON", body: `<html>`, wantErr: true},
+		{name: "truncated", body: `{"data": {"items": ["a"`, wantItems: []string{"a"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := &itemsData{}
+			resp := &graphql.Response{Data: data}
+			envelope, err := decodeGraphQLResponse(strings.NewReader(tt.body), resp)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeGraphQLResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(data.items, tt.wantItems) {
+				t.Errorf("decodeGraphQLResponse() items = %q, want %q", data.items, tt.wantItems)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(resp.Errors) != tt.wantErrors {
+				t.Errorf("decodeGraphQLResponse() errors = %v, want %d", resp.Errors, tt.wantErrors)
+			}
+			if got := hasGraphQLErrors(envelope); got != (tt.wantErrors > 0) {
+				t.Errorf("hasGraphQLErrors(%s) = %v, want %v", envelope, got, tt.wantErrors > 0)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package shopify
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Kinds of Shopify request failures. Errors returned by Client match them with
+// errors.Is; the underlying *HTTPStatusError or gqlerror.List is still available
+// with errors.As.
+var (
+	ErrThrottled    = errors.New("throttled by Shopify")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrInvalidQuery = errors.New("invalid query")
+	ErrTimeout      = errors.New("operation timed out")
+)
+
+// HTTPStatusError is a non-success HTTP response.
+type HTTPStatusError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Body)
+}
+
+// Is classifies the HTTP status.
+func (e *HTTPStatusError) Is(target error) bool {
+	switch target {
+	case ErrThrottled:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrInvalidQuery:
+		return e.StatusCode == http.StatusBadRequest
+	}
+	return false
+}
+
+// GraphQLErrors are the errors of a GraphQL response, classified by their codes.
+type GraphQLErrors struct {
+	gqlerror.List
+}
+
+func (e GraphQLErrors) Unwrap() error { return e.List }
+
+// Is reports whether any of the errors is of the target kind. Errors without a
+// path concern the query itself, such as syntax errors or unknown fields.
+func (e GraphQLErrors) Is(target error) bool {
+	for _, err := range e.List {
+		code, _ := err.Extensions["code"].(string)
+		switch {
+		case code == "THROTTLED":
+			if target == ErrThrottled {
+				return true
+			}
+		case code == "ACCESS_DENIED":
+			if target == ErrUnauthorized {
+				return true
+			}
+		case len(err.Path) == 0:
+			if target == ErrInvalidQuery {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RequestError annotates an error with the X-Request-Id of the Shopify response
+// it came from, which Shopify support needs to trace a request.
+type RequestError struct {
+	RequestID string
+	Err       error
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("%v (Shopify request ID %s)", e.Err, e.RequestID)
+}
+
+func (e *RequestError) Unwrap() error { return e.Err }
+
+// WithRequestID wraps err in a *RequestError, unless it is nil or requestID is
+// empty.
+func WithRequestID(err error, requestID string) error {
+	if err == nil || requestID == "" {
+		return err
+	}
+	return &RequestError{RequestID: requestID, Err: err}
+}
+
+// transportError is a failure to reach Shopify or to read its response.
+type transportError struct {
+	err error
+}
+
+func (e *transportError) Error() string { return e.err.Error() }
+
+func (e *transportError) Unwrap() error { return e.err }
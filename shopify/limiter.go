@@ -0,0 +1,41 @@
+package shopify
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter spaces requests at least interval apart across goroutines.
+type RateLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// NewRateLimiter returns a limiter of perSecond requests, or nil, which never
+// blocks, when perSecond is not positive.
+func NewRateLimiter(perSecond float64) *RateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &RateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// wait blocks until the caller may send a request. A nil limiter never blocks.
+func (l *RateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	return sleep(ctx, time.Until(slot))
+}
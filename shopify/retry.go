@@ -0,0 +1,123 @@
+package shopify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy decides which failed Shopify requests are retried and how long to
+// wait between attempts. The zero value retries nothing.
+type RetryPolicy struct {
+	maxRetries int
+	backoff    time.Duration
+	maxWait    time.Duration
+	// on holds retryable HTTP status codes ("429", "503"), "network" for
+	// connection errors, "timeout" for HTTP client timeouts and "THROTTLED" for
+	// responses with a THROTTLED GraphQL error.
+	on map[string]bool
+}
+
+// DefaultRetryOn are the failures retried by default.
+const DefaultRetryOn = "429,500,502,503,504,network,timeout,THROTTLED"
+
+// NewRetryPolicy retries the comma-separated failures of on up to maxRetries
+// times, waiting backoff, doubled on every attempt, and at most maxWait.
+func NewRetryPolicy(maxRetries int, backoff, maxWait time.Duration, on string) (RetryPolicy, error) {
+	p := RetryPolicy{maxRetries: max(maxRetries, 0), backoff: backoff, maxWait: maxWait, on: map[string]bool{}}
+	for _, code := range strings.Split(on, ",") {
+		code = strings.TrimSpace(code)
+		switch {
+		case code == "":
+		case code == "network", code == "timeout", code == "THROTTLED":
+			p.on[code] = true
+		default:
+			status, err := strconv.Atoi(code)
+			if err != nil || status < 100 || status > 599 {
+				return RetryPolicy{}, fmt.Errorf("invalid --retry-on code %q, expected an HTTP status, network, timeout or THROTTLED", code)
+			}
+			p.on[code] = true
+		}
+	}
+	return p, nil
+}
+
+// retryCode classifies err for matching against the policy.
+func retryCode(err error) string {
+	var httpErr *HTTPStatusError
+	if errors.As(err, &httpErr) {
+		return strconv.Itoa(httpErr.StatusCode)
+	}
+	if errors.Is(err, ErrThrottled) {
+		return "THROTTLED"
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		if urlErr.Timeout() {
+			return "timeout"
+		}
+		return "network"
+	}
+	return ""
+}
+
+// wait returns the delay before retry number attempt (starting at 0): exponential
+// backoff, or the server's Retry-After, capped at maxWait.
+func (p RetryPolicy) wait(attempt int, err error) time.Duration {
+	d := p.backoff << attempt
+	var httpErr *HTTPStatusError
+	if errors.As(err, &httpErr) && httpErr.RetryAfter > 0 {
+		d = httpErr.RetryAfter
+	}
+	if p.maxWait > 0 && (d > p.maxWait || d <= 0) {
+		d = p.maxWait
+	}
+	return d
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// isThrottled reports whether a GraphQL response failed with a THROTTLED error,
+// which Shopify returns with status 200 when the query cost budget is exhausted.
+func isThrottled(body []byte) bool {
+	var probe struct {
+		Errors []struct {
+			Extensions struct {
+				Code string `json:"code"`
+			} `json:"extensions"`
+		} `json:"errors"`
+	}
+	if json.Unmarshal(body, &probe) != nil {
+		return false
+	}
+	for _, e := range probe.Errors {
+		if e.Extensions.Code == "THROTTLED" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds.
+func parseRetryAfter(v string) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
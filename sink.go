@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"sultans/shopify"
+)
+
+// Sink is a destination other than a CSV file that exported customers are sent to.
+type Sink interface {
+	Write(ctx context.Context, customers []CustomerSegmentMember) error
+}
+
+// stdoutOutput is the --output that, like an empty one, writes to stdout.
+const stdoutOutput = "-"
+
+// isFileOutput reports whether output is a CSV filename rather than stdout, the
+// clipboard, a destination URL or a plugin.
+func isFileOutput(output string) bool {
+	return output != "" && output != clipboardOutput && !strings.Contains(output, "://") && !strings.HasPrefix(output, pluginPrefix)
+}
+
+// newSink returns the sink for an output URL such as "braze://rest.iad-01.braze.com".
+// "plugin:./my-sink" runs an exec plugin. Outputs without a scheme are CSV
+// filenames and return a nil sink.
+func newSink(c *cli.Context, output string) (Sink, error) {
+	if command, ok := strings.CutPrefix(output, pluginPrefix); ok {
+		return newPluginSink(command)
+	}
+	scheme, rest, ok := strings.Cut(output, "://")
+	if !ok {
+		return nil, nil
+	}
+
+	attributeMap, err := parseKeyValues(c.String("attribute-map"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid --attribute-map: %w", err)
+	}
+	messageAttributes, err := parseKeyValues(c.String("message-attributes"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid --message-attributes: %w", err)
+	}
+	messageOpts := messageOptions{
+		BatchSize:    c.Int("batch-size"),
+		PartitionKey: c.String("partition-key"),
+		Attributes:   messageAttributes,
+	}
+
+	switch scheme {
+	case "customerio":
+		return newCustomerIOSink(rest, attributeMap)
+	case "braze":
+		return newBrazeSink(rest, attributeMap)
+	case "segment":
+		return newSegmentSink(rest, attributeMap)
+	case "sqs":
+		return newSQSSink(rest, messageOpts)
+	case "kinesis":
+		return newKinesisSink(rest, messageOpts)
+	case "pubsub":
+		return newPubSubSink(rest, messageOpts)
+	case "nats":
+		return newNATSSink(rest, c.Bool("jetstream"), messageOpts)
+	case "mongodb", "mongodb+srv":
+		return newMongoSink(scheme, rest, c.Bool("mongo-raw"), attributeMap)
+	case "clickhouse":
+		return newClickHouseSink("https", rest, attributeMap)
+	case "clickhouse+http":
+		return newClickHouseSink("http", rest, attributeMap)
+	case "snowflake":
+		return newSnowflakeSink(rest, c.Bool("merge"))
+	case "redshift":
+		return newRedshiftSink(rest, false, attributeMap)
+	case "redshift-serverless":
+		return newRedshiftSink(rest, true, attributeMap)
+	case "duckdb":
+		return newDuckDBSink(rest)
+	case "http", "https":
+		return newWebhookSink(output, c.Int("batch-size"), c.Int("webhook-retries"), c.StringSlice("header"))
+	case "elasticsearch", "opensearch":
+		return newElasticsearchSink("https", rest, c.String("index-mapping"), attributeMap)
+	case "elasticsearch+http", "opensearch+http":
+		return newElasticsearchSink("http", rest, c.String("index-mapping"), attributeMap)
+	default:
+		return nil, fmt.Errorf("unsupported output destination %q", scheme)
+	}
+}
+
+// customerAttributes returns the destination-neutral attributes for a customer,
+// renamed according to attributeMap. Missing values are left out rather than
+// sent empty, so destinations that update profiles keep what they have.
+func customerAttributes(c CustomerSegmentMember, attributeMap map[string]string) map[string]interface{} {
+	attributes := map[string]interface{}{
+		"amount_spent":  json.Number(formatAmount(c.Node.AmountSpent.Amount, string(c.Node.AmountSpent.CurrencyCode))),
+		"currency_code": c.Node.AmountSpent.CurrencyCode,
+	}
+	if c.Node.DisplayName != "" {
+		attributes["display_name"] = c.Node.DisplayName
+	}
+	if e := c.Node.DefaultEmailAddress; e != nil && e.EmailAddress != "" {
+		attributes["email"] = e.EmailAddress
+	}
+
+	if minorUnits {
+		currency := string(c.Node.AmountSpent.CurrencyCode)
+		attributes["amount_spent"] = json.Number(minorUnitAmount(c.Node.AmountSpent.Amount, currency))
+		if !excludedFields["amountSpent"] {
+			attributes["currency_exponent"] = currencyExponent(currency)
+		}
+	}
+
+	for name, field := range attributeFields {
+		if excludedFields[field] {
+			delete(attributes, name)
+		}
+	}
+
+	for from, to := range attributeMap {
+		if v, ok := attributes[from]; ok {
+			delete(attributes, from)
+			attributes[to] = v
+		}
+	}
+	return attributes
+}
+
+// parseKeyValues parses "key=value" pairs separated by commas.
+func parseKeyValues(s string) (map[string]string, error) {
+	m := map[string]string{}
+	if s == "" {
+		return m, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if !ok || k == "" || v == "" {
+			return nil, fmt.Errorf("invalid pair %q, expected key=value", pair)
+		}
+		m[k] = v
+	}
+	return m, nil
+}
+
+// sendJSON sends payload as a JSON request body and fails on non-2xx responses.
+// If out is non-nil the response body is decoded into it.
+func sendJSON(ctx context.Context, method, url string, headers map[string]string, payload interface{}, out interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	return sendRequest(ctx, method, url, "application/json", headers, body, out)
+}
+
+// sendRequest sends body with the given content type and fails on non-2xx responses.
+// If out is non-nil the JSON response body is decoded into it.
+func sendRequest(ctx context.Context, method, url, contentType string, headers map[string]string, body []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, shopify.MaxErrorBody))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(b))
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+// sqlString quotes s as a single-quoted SQL string literal.
+func sqlString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
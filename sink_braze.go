@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// brazeBatchSize is the maximum number of attribute objects per /users/track request.
+const brazeBatchSize = 75
+
+// brazeSink updates user attributes through the Braze /users/track endpoint.
+type brazeSink struct {
+	endpoint     string
+	apiKey       string
+	attributeMap map[string]string
+}
+
+func newBrazeSink(endpoint string, attributeMap map[string]string) (*brazeSink, error) {
+	apiKey := os.Getenv("BRAZE_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("BRAZE_API_KEY must be set")
+	}
+	if endpoint == "" {
+		return nil, fmt.Errorf("braze output requires a REST endpoint, e.g. braze://rest.iad-01.braze.com")
+	}
+	return &brazeSink{endpoint: endpoint, apiKey: apiKey, attributeMap: attributeMap}, nil
+}
+
+func (s *brazeSink) Write(ctx context.Context, customers []CustomerSegmentMember) error {
+	for start := 0; start < len(customers); start += brazeBatchSize {
+		end := min(start+brazeBatchSize, len(customers))
+
+		attributes := make([]map[string]interface{}, 0, end-start)
+		for _, c := range customers[start:end] {
+			a := customerAttributes(c, s.attributeMap)
+			a["external_id"] = c.Node.Id
+			attributes = append(attributes, a)
+		}
+
+		url := fmt.Sprintf("https://%s/users/track", s.endpoint)
+		headers := map[string]string{"Authorization": "Bearer " + s.apiKey}
+		if err := sendJSON(ctx, "POST", url, headers, map[string]interface{}{"attributes": attributes}, nil); err != nil {
+			return fmt.Errorf("braze users/track failed: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// clickhouseBatchSize is the number of rows per INSERT request.
+const clickhouseBatchSize = 10000
+
+// clickhouseSink inserts customers into a ClickHouse table through the HTTP interface.
+type clickhouseSink struct {
+	baseURL      string
+	table        string
+	headers      map[string]string
+	attributeMap map[string]string
+}
+
+// newClickHouseSink takes "<host:port>/<database>.<table>".
+func newClickHouseSink(scheme, path string, attributeMap map[string]string) (*clickhouseSink, error) {
+	host, table, ok := strings.Cut(path, "/")
+	if !ok || host == "" || !strings.Contains(table, ".") {
+		return nil, fmt.Errorf("clickhouse output must be clickhouse://<host:port>/<database>.<table>")
+	}
+
+	headers := map[string]string{}
+	if user := os.Getenv("CLICKHOUSE_USER"); user != "" {
+		headers["X-ClickHouse-User"] = user
+		headers["X-ClickHouse-Key"] = os.Getenv("CLICKHOUSE_PASSWORD")
+	}
+	return &clickhouseSink{
+		baseURL:      scheme + "://" + host,
+		table:        table,
+		headers:      headers,
+		attributeMap: attributeMap,
+	}, nil
+}
+
+func (s *clickhouseSink) Write(ctx context.Context, customers []CustomerSegmentMember) error {
+	insert := url.QueryEscape(fmt.Sprintf("INSERT INTO %s FORMAT JSONEachRow", s.table))
+
+	for start := 0; start < len(customers); start += clickhouseBatchSize {
+		end := min(start+clickhouseBatchSize, len(customers))
+
+		var body bytes.Buffer
+		enc := json.NewEncoder(&body)
+		for _, c := range customers[start:end] {
+			row := customerAttributes(c, s.attributeMap)
+			row["id"] = c.Node.Id
+			if err := enc.Encode(row); err != nil {
+				return err
+			}
+		}
+
+		if err := sendRequest(ctx, "POST", s.baseURL+"/?query="+insert, "application/x-ndjson", s.headers, body.Bytes(), nil); err != nil {
+			return fmt.Errorf("clickhouse insert failed: %w", err)
+		}
+	}
+	return nil
+}
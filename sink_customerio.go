@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+)
+
+// customerIOBatchSize keeps batch requests well under Customer.io's 500KB limit.
+const customerIOBatchSize = 100
+
+// customerIOSink sends identify calls through the Customer.io Track API v2 batch endpoint.
+type customerIOSink struct {
+	host         string
+	auth         string
+	attributeMap map[string]string
+}
+
+func newCustomerIOSink(host string, attributeMap map[string]string) (*customerIOSink, error) {
+	siteID := os.Getenv("CUSTOMERIO_SITE_ID")
+	apiKey := os.Getenv("CUSTOMERIO_API_KEY")
+	if siteID == "" || apiKey == "" {
+		return nil, fmt.Errorf("CUSTOMERIO_SITE_ID and CUSTOMERIO_API_KEY must be set")
+	}
+	if host == "" {
+		host = "track.customer.io"
+	}
+	return &customerIOSink{
+		host:         host,
+		auth:         base64.StdEncoding.EncodeToString([]byte(siteID + ":" + apiKey)),
+		attributeMap: attributeMap,
+	}, nil
+}
+
+func (s *customerIOSink) Write(ctx context.Context, customers []CustomerSegmentMember) error {
+	for start := 0; start < len(customers); start += customerIOBatchSize {
+		end := min(start+customerIOBatchSize, len(customers))
+
+		batch := make([]map[string]interface{}, 0, end-start)
+		for _, c := range customers[start:end] {
+			batch = append(batch, map[string]interface{}{
+				"type":        "person",
+				"action":      "identify",
+				"identifiers": map[string]string{"id": c.Node.Id},
+				"attributes":  customerAttributes(c, s.attributeMap),
+			})
+		}
+
+		url := fmt.Sprintf("https://%s/api/v2/batch", s.host)
+		headers := map[string]string{"Authorization": "Basic " + s.auth}
+		if err := sendJSON(ctx, "POST", url, headers, map[string]interface{}{"batch": batch}, nil); err != nil {
+			return fmt.Errorf("customer.io batch failed: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// duckdbSink upserts customers into a table of a DuckDB database file. The driver
+// needs cgo, so Write is implemented in sink_duckdb_cgo.go and stubbed for !cgo builds.
+type duckdbSink struct {
+	path  string
+	table string
+}
+
+// newDuckDBSink takes the database file path with an optional "?table=" parameter
+// (default "customers").
+func newDuckDBSink(rest string) (*duckdbSink, error) {
+	path, query, _ := strings.Cut(rest, "?")
+	if path == "" {
+		return nil, fmt.Errorf("duckdb output must be duckdb://<file>[?table=<name>]")
+	}
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid duckdb output parameters: %w", err)
+	}
+	table := params.Get("table")
+	if table == "" {
+		table = "customers"
+	}
+	return &duckdbSink{path: path, table: table}, nil
+}
@@ -0,0 +1,61 @@
+//go:build cgo
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	_ "github.com/marcboeker/go-duckdb"
+)
+
+// Write upserts customers into the table, creating it on first use.
+func (s *duckdbSink) Write(ctx context.Context, customers []CustomerSegmentMember) error {
+	db, err := sql.Open("duckdb", s.path)
+	if err != nil {
+		return fmt.Errorf("failed to open duckdb: %w", err)
+	}
+	defer db.Close()
+
+	// Without --precision, amounts have their currency's decimals, at most four.
+	scale := amountPrecision
+	if scale < 0 {
+		scale = 4
+	}
+	create := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		id VARCHAR PRIMARY KEY,
+		display_name VARCHAR,
+		email VARCHAR,
+		amount_spent DECIMAL(18, %d),
+		currency_code VARCHAR
+	)`, s.table, scale)
+	if _, err := db.ExecContext(ctx, create); err != nil {
+		return fmt.Errorf("failed to create table %s: %w", s.table, err)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf("INSERT OR REPLACE INTO %s VALUES (?, ?, ?, ?, ?)", s.table))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, c := range customers {
+		var email interface{}
+		if c.Node.DefaultEmailAddress != nil {
+			email = c.Node.DefaultEmailAddress.EmailAddress
+		}
+		_, err := stmt.ExecContext(ctx, c.Node.Id, c.Node.DisplayName, email,
+			formatAmount(c.Node.AmountSpent.Amount, string(c.Node.AmountSpent.CurrencyCode)), c.Node.AmountSpent.CurrencyCode)
+		if err != nil {
+			return fmt.Errorf("failed to upsert %s: %w", c.Node.Id, err)
+		}
+	}
+	return tx.Commit()
+}
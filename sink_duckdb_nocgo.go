@@ -0,0 +1,13 @@
+//go:build !cgo
+
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// Write fails because the DuckDB driver needs cgo.
+func (s *duckdbSink) Write(ctx context.Context, customers []CustomerSegmentMember) error {
+	return fmt.Errorf("duckdb output requires a build with CGO_ENABLED=1")
+}
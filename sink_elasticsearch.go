@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// elasticsearchBatchSize is the number of documents per _bulk request.
+const elasticsearchBatchSize = 500
+
+// elasticsearchSink upserts customers into an Elasticsearch or OpenSearch index,
+// using the Shopify ID as document ID so repeated exports update documents in place.
+type elasticsearchSink struct {
+	baseURL      string
+	index        string
+	headers      map[string]string
+	mapping      json.RawMessage
+	attributeMap map[string]string
+}
+
+// newElasticsearchSink takes "<host:port>/<index>". mappingFile optionally names a JSON
+// file with the index mappings, applied when the index is created.
+func newElasticsearchSink(scheme, path, mappingFile string, attributeMap map[string]string) (*elasticsearchSink, error) {
+	host, index, ok := strings.Cut(path, "/")
+	if !ok || host == "" || index == "" {
+		return nil, fmt.Errorf("elasticsearch output must be elasticsearch://<host:port>/<index>")
+	}
+
+	headers := map[string]string{}
+	if apiKey := os.Getenv("ELASTICSEARCH_API_KEY"); apiKey != "" {
+		headers["Authorization"] = "ApiKey " + apiKey
+	} else if user := os.Getenv("ELASTICSEARCH_USERNAME"); user != "" {
+		auth := base64.StdEncoding.EncodeToString([]byte(user + ":" + os.Getenv("ELASTICSEARCH_PASSWORD")))
+		headers["Authorization"] = "Basic " + auth
+	}
+
+	var mapping json.RawMessage
+	if mappingFile != "" {
+		b, err := os.ReadFile(mappingFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read index mapping: %w", err)
+		}
+		if !json.Valid(b) {
+			return nil, fmt.Errorf("index mapping %s is not valid JSON", mappingFile)
+		}
+		mapping = b
+	}
+
+	return &elasticsearchSink{
+		baseURL:      scheme + "://" + host,
+		index:        index,
+		headers:      headers,
+		mapping:      mapping,
+		attributeMap: attributeMap,
+	}, nil
+}
+
+func (s *elasticsearchSink) Write(ctx context.Context, customers []CustomerSegmentMember) error {
+	if s.mapping != nil {
+		if err := s.createIndex(ctx); err != nil {
+			return err
+		}
+	}
+
+	for start := 0; start < len(customers); start += elasticsearchBatchSize {
+		end := min(start+elasticsearchBatchSize, len(customers))
+
+		var body bytes.Buffer
+		enc := json.NewEncoder(&body)
+		for _, c := range customers[start:end] {
+			action := map[string]interface{}{"update": map[string]string{"_index": s.index, "_id": c.Node.Id}}
+			doc := map[string]interface{}{"doc": customerAttributes(c, s.attributeMap), "doc_as_upsert": true}
+			if err := enc.Encode(action); err != nil {
+				return err
+			}
+			if err := enc.Encode(doc); err != nil {
+				return err
+			}
+		}
+
+		var resp struct {
+			Errors bool                        `json:"errors"`
+			Items  []map[string]bulkItemResult `json:"items"`
+		}
+		if err := sendRequest(ctx, "POST", s.baseURL+"/_bulk", "application/x-ndjson", s.headers, body.Bytes(), &resp); err != nil {
+			return fmt.Errorf("bulk index failed: %w", err)
+		}
+		if resp.Errors {
+			return fmt.Errorf("bulk index failed: %s", firstBulkError(resp.Items))
+		}
+	}
+	return nil
+}
+
+// createIndex creates the index with the configured mapping unless it already exists.
+func (s *elasticsearchSink) createIndex(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "HEAD", s.baseURL+"/"+s.index, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("HTTP request failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	body, err := json.Marshal(map[string]json.RawMessage{"mappings": s.mapping})
+	if err != nil {
+		return err
+	}
+	if err := sendRequest(ctx, "PUT", s.baseURL+"/"+s.index, "application/json", s.headers, body, nil); err != nil {
+		return fmt.Errorf("failed to create index %s: %w", s.index, err)
+	}
+	return nil
+}
+
+// bulkItemResult is the per-document result of a _bulk action.
+type bulkItemResult struct {
+	Error *struct {
+		Reason string `json:"reason"`
+	} `json:"error"`
+}
+
+func firstBulkError(items []map[string]bulkItemResult) error {
+	for _, item := range items {
+		for _, result := range item {
+			if result.Error != nil {
+				return errors.New(result.Error.Reason)
+			}
+		}
+	}
+	return errors.New("unknown bulk error")
+}
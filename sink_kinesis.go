@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// kinesisBatchSize is the maximum number of records per PutRecords request.
+const kinesisBatchSize = 500
+
+// kinesisSink publishes customers to an Amazon Kinesis data stream.
+type kinesisSink struct {
+	stream string
+	creds  awsCredentials
+	opts   messageOptions
+}
+
+func newKinesisSink(stream string, opts messageOptions) (*kinesisSink, error) {
+	if stream == "" {
+		return nil, fmt.Errorf("kinesis output requires a stream name, e.g. kinesis://customers")
+	}
+	if len(opts.Attributes) > 0 {
+		return nil, fmt.Errorf("kinesis records do not support message attributes")
+	}
+	if opts.PartitionKey == "" {
+		opts.PartitionKey = "id"
+	}
+	creds, err := awsCredentialsFromEnv("")
+	if err != nil {
+		return nil, err
+	}
+	return &kinesisSink{stream: stream, creds: creds, opts: opts}, nil
+}
+
+func (s *kinesisSink) Write(ctx context.Context, customers []CustomerSegmentMember) error {
+	messages, err := buildMessages(customers, s.opts)
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(messages); start += kinesisBatchSize {
+		end := min(start+kinesisBatchSize, len(messages))
+
+		records := make([]map[string]interface{}, 0, end-start)
+		for _, m := range messages[start:end] {
+			key := m.Key
+			if key == "" {
+				// Kinesis requires a non-empty partition key, e.g. for customers without an email.
+				key = "none"
+			}
+			// []byte fields are base64 encoded by encoding/json, as Kinesis expects.
+			records = append(records, map[string]interface{}{"Data": m.Body, "PartitionKey": key})
+		}
+
+		var resp struct {
+			FailedRecordCount int `json:"FailedRecordCount"`
+		}
+		err := awsJSONRequest(ctx, s.creds, "kinesis", "application/x-amz-json-1.1", "Kinesis_20131202.PutRecords", map[string]interface{}{
+			"StreamName": s.stream,
+			"Records":    records,
+		}, &resp)
+		if err != nil {
+			return fmt.Errorf("kinesis put failed: %w", err)
+		}
+		if resp.FailedRecordCount > 0 {
+			return fmt.Errorf("kinesis rejected %d records", resp.FailedRecordCount)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+)
+
+// natsSink publishes customers to a NATS subject, optionally through JetStream so
+// that publishes are acknowledged by a stream.
+type natsSink struct {
+	url       string
+	subject   string
+	jetStream bool
+	opts      messageOptions
+}
+
+// newNATSSink takes "<host:port>/<subject>", with optional user:password@ before the host.
+func newNATSSink(path string, jetStream bool, opts messageOptions) (*natsSink, error) {
+	server, subject, ok := strings.Cut(path, "/")
+	if !ok || subject == "" {
+		return nil, fmt.Errorf("nats output must be nats://<server>/<subject>")
+	}
+	if opts.PartitionKey != "" {
+		return nil, fmt.Errorf("nats does not support --partition-key")
+	}
+	return &natsSink{url: "nats://" + server, subject: subject, jetStream: jetStream, opts: opts}, nil
+}
+
+func (s *natsSink) Write(ctx context.Context, customers []CustomerSegmentMember) error {
+	messages, err := buildMessages(customers, s.opts)
+	if err != nil {
+		return err
+	}
+
+	var options []nats.Option
+	if creds := os.Getenv("NATS_CREDS"); creds != "" {
+		options = append(options, nats.UserCredentials(creds))
+	}
+	nc, err := nats.Connect(s.url, options...)
+	if err != nil {
+		return fmt.Errorf("failed to connect to nats: %w", err)
+	}
+	defer nc.Close()
+
+	var js nats.JetStreamContext
+	if s.jetStream {
+		if js, err = nc.JetStream(); err != nil {
+			return fmt.Errorf("failed to open jetstream: %w", err)
+		}
+	}
+
+	for _, m := range messages {
+		msg := nats.NewMsg(s.subject)
+		msg.Data = m.Body
+		for k, v := range s.opts.Attributes {
+			msg.Header.Set(k, v)
+		}
+
+		if js != nil {
+			// Deduplicate retried publishes within the stream's duplicate window.
+			msg.Header.Set(nats.MsgIdHdr, sha256Hex(m.Body))
+			if _, err := js.PublishMsg(msg, nats.Context(ctx)); err != nil {
+				return fmt.Errorf("jetstream publish failed: %w", err)
+			}
+		} else if err := nc.PublishMsg(msg); err != nil {
+			return fmt.Errorf("nats publish failed: %w", err)
+		}
+	}
+
+	if js == nil {
+		if err := nc.FlushWithContext(ctx); err != nil {
+			return fmt.Errorf("nats flush failed: %w", err)
+		}
+	}
+	return nil
+}
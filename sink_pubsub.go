@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// pubsubBatchSize is the maximum number of messages per publish request.
+const pubsubBatchSize = 1000
+
+// pubsubSink publishes customers to a Google Cloud Pub/Sub topic.
+type pubsubSink struct {
+	topic       string
+	accessToken string
+	opts        messageOptions
+}
+
+// newPubSubSink takes "<project>/<topic>".
+func newPubSubSink(path string, opts messageOptions) (*pubsubSink, error) {
+	project, topic, ok := strings.Cut(path, "/")
+	if !ok || project == "" || topic == "" {
+		return nil, fmt.Errorf("pubsub output must be pubsub://<project>/<topic>")
+	}
+	accessToken := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+	if accessToken == "" {
+		return nil, fmt.Errorf("GOOGLE_OAUTH_ACCESS_TOKEN must be set")
+	}
+	return &pubsubSink{
+		topic:       fmt.Sprintf("projects/%s/topics/%s", project, topic),
+		accessToken: accessToken,
+		opts:        opts,
+	}, nil
+}
+
+func (s *pubsubSink) Write(ctx context.Context, customers []CustomerSegmentMember) error {
+	messages, err := buildMessages(customers, s.opts)
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(messages); start += pubsubBatchSize {
+		end := min(start+pubsubBatchSize, len(messages))
+
+		batch := make([]map[string]interface{}, 0, end-start)
+		for _, m := range messages[start:end] {
+			msg := map[string]interface{}{"data": m.Body}
+			if len(s.opts.Attributes) > 0 {
+				msg["attributes"] = s.opts.Attributes
+			}
+			if m.Key != "" {
+				msg["orderingKey"] = m.Key
+			}
+			batch = append(batch, msg)
+		}
+
+		url := fmt.Sprintf("https://pubsub.googleapis.com/v1/%s:publish", s.topic)
+		headers := map[string]string{"Authorization": "Bearer " + s.accessToken}
+		if err := sendJSON(ctx, "POST", url, headers, map[string]interface{}{"messages": batch}, nil); err != nil {
+			return fmt.Errorf("pubsub publish failed: %w", err)
+		}
+	}
+	return nil
+}
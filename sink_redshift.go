@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// redshiftColumns are the target table columns of the csvHeader columns. They can
+// be renamed with --attribute-map.
+var redshiftColumns = []string{"id", "display_name", "email", "amount_spent", "currency_code"}
+
+// redshiftSink uploads the CSV export to S3 and loads it into a table with COPY,
+// issued through the Redshift Data API.
+type redshiftSink struct {
+	creds      awsCredentials
+	serverless bool
+	cluster    string
+	database   string
+	table      string
+	bucket     string
+	prefix     string
+	iamRole    string
+	columns    []string
+}
+
+// newRedshiftSink takes "<cluster or workgroup>/<database>/<schema>.<table>".
+func newRedshiftSink(path string, serverless bool, attributeMap map[string]string) (*redshiftSink, error) {
+	parts := strings.Split(path, "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || !strings.Contains(parts[2], ".") {
+		return nil, fmt.Errorf("redshift output must be redshift://<cluster>/<database>/<schema>.<table>")
+	}
+
+	staging := os.Getenv("REDSHIFT_S3_STAGING")
+	iamRole := os.Getenv("REDSHIFT_IAM_ROLE")
+	if staging == "" || iamRole == "" {
+		return nil, fmt.Errorf("REDSHIFT_S3_STAGING and REDSHIFT_IAM_ROLE must be set")
+	}
+	bucketPath, ok := strings.CutPrefix(staging, "s3://")
+	if !ok {
+		return nil, fmt.Errorf("REDSHIFT_S3_STAGING must be an s3:// URL")
+	}
+	bucket, prefix, _ := strings.Cut(bucketPath, "/")
+
+	creds, err := awsCredentialsFromEnv("")
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]string, len(redshiftColumns))
+	for i, col := range redshiftColumns {
+		columns[i] = col
+		if to, ok := attributeMap[col]; ok {
+			columns[i] = to
+		}
+	}
+
+	return &redshiftSink{
+		creds:      creds,
+		serverless: serverless,
+		cluster:    parts[0],
+		database:   parts[1],
+		table:      parts[2],
+		bucket:     bucket,
+		prefix:     strings.Trim(prefix, "/"),
+		iamRole:    iamRole,
+		columns:    columns,
+	}, nil
+}
+
+func (s *redshiftSink) Write(ctx context.Context, customers []CustomerSegmentMember) error {
+	var buf bytes.Buffer
+	if err := writeCSV(ctx, customers, &buf); err != nil {
+		return fmt.Errorf("failed to encode CSV: %w", err)
+	}
+
+	key := fmt.Sprintf("customers-%d.csv", time.Now().UnixNano())
+	if s.prefix != "" {
+		key = s.prefix + "/" + key
+	}
+	objectURL := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.bucket, s.creds.Region, key)
+
+	if _, err := awsRequest(ctx, s.creds, "s3", "PUT", objectURL, map[string]string{"Content-Type": "text/csv"}, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to upload to s3: %w", err)
+	}
+	defer awsRequest(context.Background(), s.creds, "s3", "DELETE", objectURL, nil, nil)
+
+	copySQL := fmt.Sprintf("COPY %s (%s) FROM 's3://%s/%s' IAM_ROLE '%s' CSV IGNOREHEADER 1",
+		s.table, strings.Join(outputColumns(s.columns), ", "), s.bucket, key, s.iamRole)
+	if nullValue != "" {
+		copySQL += " NULL AS " + sqlString(nullValue)
+	}
+	if err := s.execute(ctx, copySQL); err != nil {
+		return fmt.Errorf("redshift copy failed: %w", err)
+	}
+	return nil
+}
+
+// execute runs sql through the Data API and waits for it to finish.
+func (s *redshiftSink) execute(ctx context.Context, sql string) error {
+	req := map[string]interface{}{"Database": s.database, "Sql": sql}
+	if s.serverless {
+		req["WorkgroupName"] = s.cluster
+	} else {
+		req["ClusterIdentifier"] = s.cluster
+	}
+	if secretArn := os.Getenv("REDSHIFT_SECRET_ARN"); secretArn != "" {
+		req["SecretArn"] = secretArn
+	} else if dbUser := os.Getenv("REDSHIFT_DB_USER"); dbUser != "" {
+		req["DbUser"] = dbUser
+	}
+
+	var stmt struct {
+		ID string `json:"Id"`
+	}
+	if err := awsJSONRequest(ctx, s.creds, "redshift-data", "application/x-amz-json-1.1", "RedshiftData.ExecuteStatement", req, &stmt); err != nil {
+		return err
+	}
+
+	for {
+		var desc struct {
+			Status string `json:"Status"`
+			Error  string `json:"Error"`
+		}
+		if err := awsJSONRequest(ctx, s.creds, "redshift-data", "application/x-amz-json-1.1", "RedshiftData.DescribeStatement",
+			map[string]string{"Id": stmt.ID}, &desc); err != nil {
+			return err
+		}
+
+		switch desc.Status {
+		case "FINISHED":
+			return nil
+		case "FAILED", "ABORTED":
+			return fmt.Errorf("statement %s: %s", strings.ToLower(desc.Status), desc.Error)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
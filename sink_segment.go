@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+)
+
+// segmentBatchSize keeps batch requests well under Segment's 500KB limit.
+const segmentBatchSize = 100
+
+// segmentSink sends identify calls through the Segment HTTP Tracking API batch endpoint.
+type segmentSink struct {
+	host         string
+	auth         string
+	attributeMap map[string]string
+}
+
+func newSegmentSink(host string, attributeMap map[string]string) (*segmentSink, error) {
+	writeKey := os.Getenv("SEGMENT_WRITE_KEY")
+	if writeKey == "" {
+		return nil, fmt.Errorf("SEGMENT_WRITE_KEY must be set")
+	}
+	if host == "" {
+		host = "api.segment.io"
+	}
+	return &segmentSink{
+		host:         host,
+		auth:         base64.StdEncoding.EncodeToString([]byte(writeKey + ":")),
+		attributeMap: attributeMap,
+	}, nil
+}
+
+func (s *segmentSink) Write(ctx context.Context, customers []CustomerSegmentMember) error {
+	for start := 0; start < len(customers); start += segmentBatchSize {
+		end := min(start+segmentBatchSize, len(customers))
+
+		batch := make([]map[string]interface{}, 0, end-start)
+		for _, c := range customers[start:end] {
+			batch = append(batch, map[string]interface{}{
+				"type":   "identify",
+				"userId": c.Node.Id,
+				"traits": customerAttributes(c, s.attributeMap),
+			})
+		}
+
+		url := fmt.Sprintf("https://%s/v1/batch", s.host)
+		headers := map[string]string{"Authorization": "Basic " + s.auth}
+		if err := sendJSON(ctx, "POST", url, headers, map[string]interface{}{"batch": batch}, nil); err != nil {
+			return fmt.Errorf("segment batch failed: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "github.com/snowflakedb/gosnowflake"
+)
+
+// snowflakeColumns are the target table columns of the csvHeader columns.
+var snowflakeColumns = []string{"ID", "DISPLAY_NAME", "EMAIL_ADDRESS", "AMOUNT_SPENT", "CURRENCY_CODE"}
+
+// snowflakeSink uploads the CSV export to the table's internal stage and loads it
+// with COPY INTO. In merge mode rows are loaded into a temporary table first and
+// merged into the target on ID.
+type snowflakeSink struct {
+	dsn   string
+	table string
+	merge bool
+}
+
+// newSnowflakeSink takes the fully qualified "<database>.<schema>.<table>".
+func newSnowflakeSink(table string, merge bool) (*snowflakeSink, error) {
+	dsn := os.Getenv("SNOWFLAKE_DSN")
+	if dsn == "" {
+		return nil, fmt.Errorf("SNOWFLAKE_DSN must be set")
+	}
+	if strings.Count(table, ".") != 2 {
+		return nil, fmt.Errorf("snowflake output must be snowflake://<database>.<schema>.<table>")
+	}
+	if merge && excludedFields["id"] {
+		return nil, fmt.Errorf("--merge cannot be combined with excluding the id field, which rows are merged on")
+	}
+	return &snowflakeSink{dsn: dsn, table: table, merge: merge}, nil
+}
+
+func (s *snowflakeSink) Write(ctx context.Context, customers []CustomerSegmentMember) error {
+	dir, err := os.MkdirTemp("", "shopify-customers")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "customers.csv")
+	if err := exportToCSV(ctx, customers, file); err != nil {
+		return fmt.Errorf("failed to write staging file: %w", err)
+	}
+	columns := outputColumns(snowflakeColumns)
+	for i := range columns {
+		columns[i] = strings.ToUpper(columns[i])
+	}
+
+	db, err := sql.Open("snowflake", s.dsn)
+	if err != nil {
+		return fmt.Errorf("failed to open snowflake: %w", err)
+	}
+	defer db.Close()
+
+	// Temporary tables only live for the session, so keep every statement on one connection.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to snowflake: %w", err)
+	}
+	defer conn.Close()
+
+	schema, name := s.table[:strings.LastIndex(s.table, ".")], s.table[strings.LastIndex(s.table, ".")+1:]
+	stage := fmt.Sprintf("@%s.%%%s", schema, name)
+
+	target := s.table
+	if s.merge {
+		target = s.table + "_STAGING"
+		if _, err := conn.ExecContext(ctx, fmt.Sprintf("CREATE OR REPLACE TEMPORARY TABLE %s LIKE %s", target, s.table)); err != nil {
+			return fmt.Errorf("failed to create staging table: %w", err)
+		}
+	}
+
+	nullIf := ""
+	if nullValue != "" {
+		nullIf = fmt.Sprintf(" NULL_IF = (%s)", sqlString(nullValue))
+	}
+	statements := []string{
+		fmt.Sprintf("PUT 'file://%s' %s AUTO_COMPRESS=TRUE OVERWRITE=TRUE", filepath.ToSlash(file), stage),
+		fmt.Sprintf("COPY INTO %s (%s) FROM %s FILES = ('customers.csv.gz') "+
+			"FILE_FORMAT = (TYPE = CSV SKIP_HEADER = 1 FIELD_OPTIONALLY_ENCLOSED_BY = '\"'%s) PURGE = TRUE",
+			target, strings.Join(columns, ", "), stage, nullIf),
+	}
+	if s.merge {
+		statements = append(statements, s.mergeStatement(target, columns))
+	}
+
+	for _, stmt := range statements {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("snowflake statement failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// mergeStatement upserts rows from staging into the target table, keeping one row per ID.
+func (s *snowflakeSink) mergeStatement(staging string, columns []string) string {
+	var set, values []string
+	for _, col := range columns {
+		if col != "ID" {
+			set = append(set, fmt.Sprintf("t.%s = s.%s", col, col))
+		}
+		values = append(values, "s."+col)
+	}
+	return fmt.Sprintf("MERGE INTO %s t USING (SELECT * FROM %s QUALIFY ROW_NUMBER() OVER (PARTITION BY ID ORDER BY ID) = 1) s "+
+		"ON t.ID = s.ID WHEN MATCHED THEN UPDATE SET %s WHEN NOT MATCHED THEN INSERT (%s) VALUES (%s)",
+		s.table, staging, strings.Join(set, ", "), strings.Join(columns, ", "), strings.Join(values, ", "))
+}
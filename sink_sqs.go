@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sqsBatchSize is the maximum number of entries per SendMessageBatch request.
+const sqsBatchSize = 10
+
+// sqsSink publishes customers to an Amazon SQS queue.
+type sqsSink struct {
+	queueURL string
+	creds    awsCredentials
+	opts     messageOptions
+}
+
+// newSQSSink takes the queue URL without its scheme, e.g.
+// "sqs.us-east-1.amazonaws.com/123456789012/customers". The region is taken from the host.
+func newSQSSink(queue string, opts messageOptions) (*sqsSink, error) {
+	host, _, _ := strings.Cut(queue, "/")
+	region := ""
+	if parts := strings.Split(host, "."); len(parts) == 4 && parts[0] == "sqs" {
+		region = parts[1]
+	}
+	creds, err := awsCredentialsFromEnv(region)
+	if err != nil {
+		return nil, err
+	}
+	return &sqsSink{queueURL: "https://" + queue, creds: creds, opts: opts}, nil
+}
+
+func (s *sqsSink) Write(ctx context.Context, customers []CustomerSegmentMember) error {
+	messages, err := buildMessages(customers, s.opts)
+	if err != nil {
+		return err
+	}
+
+	attributes := map[string]interface{}{}
+	for k, v := range s.opts.Attributes {
+		attributes[k] = map[string]string{"DataType": "String", "StringValue": v}
+	}
+	fifo := strings.HasSuffix(s.queueURL, ".fifo")
+
+	for start := 0; start < len(messages); start += sqsBatchSize {
+		end := min(start+sqsBatchSize, len(messages))
+
+		entries := make([]map[string]interface{}, 0, end-start)
+		for i, m := range messages[start:end] {
+			entry := map[string]interface{}{
+				"Id":          strconv.Itoa(i),
+				"MessageBody": string(m.Body),
+			}
+			if len(attributes) > 0 {
+				entry["MessageAttributes"] = attributes
+			}
+			if fifo {
+				group := m.Key
+				if group == "" {
+					group = "shopify-customers"
+				}
+				entry["MessageGroupId"] = group
+				entry["MessageDeduplicationId"] = sha256Hex(m.Body)
+			}
+			entries = append(entries, entry)
+		}
+
+		var resp struct {
+			Failed []struct {
+				ID      string `json:"Id"`
+				Message string `json:"Message"`
+			} `json:"Failed"`
+		}
+		err := awsJSONRequest(ctx, s.creds, "sqs", "application/x-amz-json-1.0", "AmazonSQS.SendMessageBatch", map[string]interface{}{
+			"QueueUrl": s.queueURL,
+			"Entries":  entries,
+		}, &resp)
+		if err != nil {
+			return fmt.Errorf("sqs send failed: %w", err)
+		}
+		if len(resp.Failed) > 0 {
+			return fmt.Errorf("sqs rejected %d messages: %s", len(resp.Failed), resp.Failed[0].Message)
+		}
+	}
+	return nil
+}
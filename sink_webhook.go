@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"sultans/shopify"
+)
+
+// webhookSink POSTs batches of customers as {"customers": [...]} to an HTTP endpoint.
+type webhookSink struct {
+	url        string
+	batchSize  int
+	retries    int
+	headers    map[string]string
+	hmacSecret []byte
+}
+
+// newWebhookSink builds a sink for url. Extra headers are given as "Name: value";
+// WEBHOOK_AUTHORIZATION sets the Authorization header and WEBHOOK_HMAC_SECRET
+// enables request signing, so secrets don't have to be passed as flags.
+func newWebhookSink(url string, batchSize, retries int, headerFlags []string) (*webhookSink, error) {
+	headers, err := parseHeaderFlags(headerFlags)
+	if err != nil {
+		return nil, err
+	}
+	if auth := os.Getenv("WEBHOOK_AUTHORIZATION"); auth != "" {
+		headers["Authorization"] = auth
+	}
+
+	var secret []byte
+	if s := os.Getenv("WEBHOOK_HMAC_SECRET"); s != "" {
+		secret = []byte(s)
+	}
+
+	return &webhookSink{
+		url:        url,
+		batchSize:  max(batchSize, 1),
+		retries:    max(retries, 0),
+		headers:    headers,
+		hmacSecret: secret,
+	}, nil
+}
+
+func (s *webhookSink) Write(ctx context.Context, customers []CustomerSegmentMember) error {
+	for start := 0; start < len(customers); start += s.batchSize {
+		end := min(start+s.batchSize, len(customers))
+
+		nodes := make([]Node, 0, end-start)
+		for _, c := range customers[start:end] {
+			nodes = append(nodes, c.Node)
+		}
+		body, err := json.Marshal(map[string]interface{}{"customers": nodes})
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+
+		if err := s.post(ctx, body); err != nil {
+			return fmt.Errorf("webhook batch %d-%d failed: %w", start+1, end, err)
+		}
+	}
+	return nil
+}
+
+// post sends body, retrying network errors, 429 and 5xx responses with exponential backoff.
+func (s *webhookSink) post(ctx context.Context, body []byte) error {
+	backoff := 500 * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		retryable, err := s.send(ctx, body)
+		if err == nil || !retryable || attempt >= s.retries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (s *webhookSink) send(ctx context.Context, body []byte) (retryable bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", s.url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+	if s.hmacSecret != nil {
+		mac := hmac.New(sha256.New, s.hmacSecret)
+		mac.Write(body)
+		req.Header.Set("X-Signature-SHA256", hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, shopify.MaxErrorBody))
+		retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retryable, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(b))
+	}
+	return false, nil
+}
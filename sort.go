@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// sorter orders fetched customers by --sort-by. It is nil without it.
+var sorter *customerSorter
+
+// customerSorter sorts the whole segment client-side, for fields Shopify's sortKey
+// does not cover. Ties are broken by customer ID so the order is deterministic.
+type customerSorter struct {
+	field string
+	desc  bool
+}
+
+func newCustomerSorter(field string, desc bool) (*customerSorter, error) {
+	field = strings.ReplaceAll(field, "-", "_")
+	if field != "amount_spent" {
+		if _, err := customerField(CustomerSegmentMember{}, field); err != nil || field == "" {
+			return nil, fmt.Errorf("invalid --sort-by %q, expected id, email, display_name, amount_spent or currency_code", field)
+		}
+	}
+	return &customerSorter{field: field, desc: desc}, nil
+}
+
+func (s *customerSorter) wrap(ctx context.Context, in *segmentStream) *segmentStream {
+	return bufferStream(ctx, in, s.sort)
+}
+
+func (s *customerSorter) sort(customers []CustomerSegmentMember) []CustomerSegmentMember {
+	sort.SliceStable(customers, func(i, j int) bool {
+		cmp := s.compare(customers[i], customers[j])
+		if cmp == 0 {
+			cmp = strings.Compare(customers[i].Node.Id, customers[j].Node.Id)
+		}
+		if s.desc {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+	return customers
+}
+
+func (s *customerSorter) compare(a, b CustomerSegmentMember) int {
+	if s.field == "amount_spent" {
+		return a.Node.AmountSpent.Amount.Cmp(b.Node.AmountSpent.Amount)
+	}
+	av, _ := customerField(a, s.field)
+	bv, _ := customerField(b, s.field)
+	return strings.Compare(av, bv)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"sultans/shopify"
+)
+
+// accountStates looks up the account states of exported customers for --state
+// and --state-column, nil without them. They are looked up on the customers per
+// page.
+var accountStates *stateLookup
+
+var customerStates = []CustomerState{CustomerStateEnabled, CustomerStateDisabled, CustomerStateInvited, CustomerStateDeclined}
+
+type stateLookup struct {
+	// keep are the states kept by --state, or nil to keep every customer.
+	keep map[CustomerState]bool
+}
+
+// newStateLookup parses a --state list such as "invited,declined".
+func newStateLookup(spec string) (*stateLookup, error) {
+	l := &stateLookup{}
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		known := false
+		for _, s := range customerStates {
+			known = known || string(s) == name
+		}
+		if !known {
+			return nil, fmt.Errorf("invalid --state %q, expected ENABLED, DISABLED, INVITED or DECLINED", name)
+		}
+		if l.keep == nil {
+			l.keep = map[CustomerState]bool{}
+		}
+		l.keep[CustomerState(name)] = true
+	}
+	return l, nil
+}
+
+func (l *stateLookup) column() column {
+	return column{Header: "State", Value: func(c CustomerSegmentMember) string {
+		if state := c.lookedUp().state; state != "" {
+			return string(state)
+		}
+		return nullValue
+	}}
+}
+
+// wrap looks up the states of every page of in and, with --state, drops the
+// customers in other states.
+func (l *stateLookup) wrap(ctx context.Context, client *shopify.Client, in *segmentStream) *segmentStream {
+	stream := lookupPages(ctx, in, "states", func(ids []string, fields func(string) *memberFields) error {
+		resp, err := GetCustomerStates(ctx, client, ids)
+		if err != nil {
+			return err
+		}
+		for _, node := range resp.Nodes {
+			if customer, ok := node.(*CustomerStateNodeCustomer); ok {
+				fields(customer.Id).state = customer.State
+			}
+		}
+		return nil
+	})
+	if l.keep == nil {
+		return stream
+	}
+	return filterStream(ctx, stream, func(c CustomerSegmentMember) bool { return l.keep[c.lookedUp().state] })
+}
@@ -0,0 +1,397 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Change states of a customer compared to the previous run.
+const (
+	changeAdded     = "added"
+	changeUpdated   = "updated"
+	changeUnchanged = "unchanged"
+	changeRemoved   = "removed"
+)
+
+// customerChange is a customer together with how it changed since the previous run.
+type customerChange struct {
+	Customer CustomerSegmentMember
+	Change   string
+}
+
+// deltaMode reports whether only changed customers are exported, with --mode
+// delta or --delta.
+func deltaMode(c *cli.Context) bool {
+	return c.Bool("delta") || c.String("mode") == "delta"
+}
+
+// defaultStateDBPath returns the state database used in delta mode without
+// --state-db: state.db in the user cache directory.
+func defaultStateDBPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate cache directory: %w", err)
+	}
+	dir = filepath.Join(dir, "shopify-customers")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create state database directory: %w", err)
+	}
+	return filepath.Join(dir, "state.db"), nil
+}
+
+// stateDB is a local bbolt database of the customers seen by previous runs, with
+// one bucket per shop and segment query so different shops and queries do not
+// mark each other's customers as removed.
+type stateDB struct {
+	db *bolt.DB
+}
+
+func openStateDB(path string) (*stateDB, error) {
+	// bbolt locks the file itself; fail fast rather than wait for another run.
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("state database %s is in use by another export", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state database: %w", err)
+	}
+	return &stateDB{db: db}, nil
+}
+
+func (s *stateDB) Close() error {
+	return s.db.Close()
+}
+
+// stateBucket returns the bucket name of the customers of query in the shop at
+// domain.
+func stateBucket(domain, query string) []byte {
+	return []byte(strings.ToLower(domain) + "\n" + query)
+}
+
+// legacyBucket returns the bucket of query written before buckets were named
+// by shop, or nil. It is read in place of a missing shop bucket, so the first
+// run after an upgrade keeps its delta, and removed by save.
+func legacyBucket(tx *bolt.Tx, query string) *bolt.Bucket {
+	return tx.Bucket(stores.name([]byte(query)))
+}
+
+// diff compares customers with those stored for query in the shop at domain.
+// Customers that were stored but are no longer present are returned last, with
+// their last known data.
+func (s *stateDB) diff(domain, query string, customers []CustomerSegmentMember) ([]customerChange, error) {
+	changes := make([]customerChange, 0, len(customers))
+	err := s.db.View(func(tx *bolt.Tx) error {
+		name := stateBucket(domain, query)
+		if err := checkStoreKey(tx, name, []byte(query)); err != nil {
+			return err
+		}
+		bucket := tx.Bucket(stores.name(name))
+		if bucket == nil {
+			bucket = legacyBucket(tx, query)
+		}
+		seen := map[string]bool{}
+
+		for _, c := range customers {
+			key := stores.name([]byte(c.Node.Id))
+			seen[string(key)] = true
+			change := changeAdded
+			if bucket != nil {
+				if prev := bucket.Get(key); prev != nil {
+					current, err := json.Marshal(c)
+					if err != nil {
+						return err
+					}
+					if prev, err = stores.open(prev); err != nil {
+						return err
+					}
+					change = changeUnchanged
+					if !bytes.Equal(prev, current) {
+						change = changeUpdated
+					}
+				}
+			}
+			changes = append(changes, customerChange{Customer: c, Change: change})
+		}
+
+		if bucket == nil {
+			return nil
+		}
+		var removed []customerChange
+		err := bucket.ForEach(func(k, v []byte) error {
+			if seen[string(k)] {
+				return nil
+			}
+			v, err := stores.open(v)
+			if err != nil {
+				return err
+			}
+			var c CustomerSegmentMember
+			if err := json.Unmarshal(v, &c); err != nil {
+				return fmt.Errorf("corrupt record: %w", err)
+			}
+			removed = append(removed, customerChange{Customer: c, Change: changeRemoved})
+			return nil
+		})
+		sort.Slice(removed, func(i, j int) bool { return removed[i].Customer.Node.Id < removed[j].Customer.Node.Id })
+		changes = append(changes, removed...)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state database: %w", err)
+	}
+	return changes, nil
+}
+
+// storeKeyBucket holds a value sealed with the --encrypt-stores key, to tell an
+// encrypted database, or one encrypted with another key, from an empty one: their
+// buckets are not found under the names of this run.
+const (
+	storeKeyBucket = "encryption"
+	storeKeyCheck  = "check"
+)
+
+func checkStoreKey(tx *bolt.Tx, names ...[]byte) error {
+	meta := tx.Bucket([]byte(storeKeyBucket))
+	switch {
+	case stores == nil && meta != nil:
+		return fmt.Errorf("database is %w", errEncrypted)
+	case stores == nil:
+		return nil
+	}
+	for _, name := range names {
+		if tx.Bucket(name) != nil {
+			return fmt.Errorf("database is %w", errNotEncrypted)
+		}
+	}
+	if meta == nil {
+		return nil
+	}
+	_, err := stores.open(meta.Get([]byte(storeKeyCheck)))
+	return err
+}
+
+// save replaces the customers stored for query in the shop at domain.
+func (s *stateDB) save(domain, query string, customers []CustomerSegmentMember) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		if stores != nil {
+			check, err := stores.seal([]byte(storeKeyCheck))
+			if err != nil {
+				return err
+			}
+			meta, err := tx.CreateBucketIfNotExists([]byte(storeKeyBucket))
+			if err != nil {
+				return err
+			}
+			if err := meta.Put([]byte(storeKeyCheck), check); err != nil {
+				return err
+			}
+		}
+		if legacyBucket(tx, query) != nil {
+			if err := tx.DeleteBucket(stores.name([]byte(query))); err != nil {
+				return err
+			}
+		}
+		name := stores.name(stateBucket(domain, query))
+		if err := tx.DeleteBucket(name); err != nil && err != bolt.ErrBucketNotFound {
+			return err
+		}
+		bucket, err := tx.CreateBucket(name)
+		if err != nil {
+			return err
+		}
+		for _, c := range customers {
+			b, err := json.Marshal(c)
+			if err != nil {
+				return err
+			}
+			if b, err = stores.seal(b); err != nil {
+				return err
+			}
+			if err := bucket.Put(stores.name([]byte(c.Node.Id)), b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update state database: %w", err)
+	}
+	return nil
+}
+
+// changedCustomers returns the added and updated customers.
+func changedCustomers(changes []customerChange) []CustomerSegmentMember {
+	var customers []CustomerSegmentMember
+	for _, ch := range changes {
+		if ch.Change == changeAdded || ch.Change == changeUpdated {
+			customers = append(customers, ch.Customer)
+		}
+	}
+	return customers
+}
+
+// deltaChanges drops unchanged customers.
+func deltaChanges(changes []customerChange) []customerChange {
+	var delta []customerChange
+	for _, ch := range changes {
+		if ch.Change != changeUnchanged {
+			delta = append(delta, ch)
+		}
+	}
+	return delta
+}
+
+// currentCustomers returns the customers present in this run.
+func currentCustomers(changes []customerChange) []CustomerSegmentMember {
+	var customers []CustomerSegmentMember
+	for _, ch := range changes {
+		if ch.Change != changeRemoved {
+			customers = append(customers, ch.Customer)
+		}
+	}
+	return customers
+}
+
+// writeRemovedCSV writes the removed customers of changes, with their last known
+// data, to a CSV file. It is written on every run, so a file without rows means
+// nobody left the segment.
+func writeRemovedCSV(changes []customerChange, path string) error {
+	file, err := createTempOutput(path)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write(outputHeader()); err != nil {
+		return err
+	}
+	for _, ch := range changes {
+		if ch.Change != changeRemoved {
+			continue
+		}
+		for _, record := range outputRecords(ch.Customer) {
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// createTempOutput creates the file a CSV file output is written to under a
+// temporary name and renamed to once complete, so a failure midway leaves the
+// previous file in place.
+func createTempOutput(path string) (*os.File, error) {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	// Match the permissions os.Create would give the file.
+	if err := file.Chmod(0o644); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+	return file, nil
+}
+
+// exportChangesToCSV writes the CSV export with an additional Change column.
+func exportChangesToCSV(ctx context.Context, changes []customerChange, filename string) error {
+	rows, header, err := newRowTransform(ctx, append(outputHeader(), "Change"))
+	if err != nil {
+		return err
+	}
+	var keys []string
+	var records [][]string
+	for _, ch := range changes {
+		for _, record := range outputRecords(ch.Customer) {
+			records = append(records, append(record, ch.Change))
+			if partitionKey != nil {
+				keys = append(keys, partitionKey(ch.Customer))
+			}
+		}
+	}
+
+	// Rows go through a transform script in batches of a page.
+	if partitionKey != nil {
+		files := newPartitionedCSV(filename, header)
+		defer files.abort()
+		for start := 0; start < len(records); start += maxPageSize {
+			end := min(start+maxPageSize, len(records))
+			if err := rows.applyByKey(ctx, keys[start:end], records[start:end], files.write); err != nil {
+				return err
+			}
+		}
+		return files.commit()
+	}
+
+	w := io.Writer(os.Stdout)
+	var file *os.File
+	var clipboard *bytes.Buffer
+	if filename == clipboardOutput {
+		clipboard = &bytes.Buffer{}
+		w = clipboard
+	} else if filename != "" {
+		if file, err = createTempOutput(filename); err != nil {
+			return err
+		}
+		defer os.Remove(file.Name())
+		defer file.Close()
+		w = file
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for start := 0; start < len(records); start += maxPageSize {
+		if ctx.Err() != nil {
+			return fmt.Errorf("operation timed out during CSV export")
+		}
+		batch, err := rows.apply(ctx, records[start:min(start+maxPageSize, len(records))])
+		if err != nil {
+			return err
+		}
+		for _, record := range batch {
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	if clipboard != nil {
+		return copyToClipboard(clipboard.Bytes())
+	}
+	if file != nil {
+		if err := file.Close(); err != nil {
+			return err
+		}
+		return os.Rename(file.Name(), filename)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// stateMember returns a member with the amount spent amount.
+func stateMember(id, amount string) CustomerSegmentMember {
+	var c CustomerSegmentMember
+	c.Node.Id = id
+	c.Node.AmountSpent.Amount = decimal.RequireFromString(amount)
+	c.Node.AmountSpent.CurrencyCode = "USD"
+	return c
+}
+
+// changeList returns the IDs and changes of changes as "id change".
+func changeList(changes []customerChange) []string {
+	var list []string
+	for _, ch := range changes {
+		list = append(list, ch.Customer.Node.Id+" "+ch.Change)
+	}
+	return list
+}
+
+func TestStateDBDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous []CustomerSegmentMember
+		current  []CustomerSegmentMember
+		want     []string
+	}{
+		{
+			name:    "first run",
+			current: []CustomerSegmentMember{stateMember("1", "10.0"), stateMember("2", "20.0")},
+			want:    []string{"1 added", "2 added"},
+		},
+		{
+			name:     "changes",
+			previous: []CustomerSegmentMember{stateMember("1", "10.0"), stateMember("2", "20.0"), stateMember("4", "40.0"), stateMember("3", "30.0")},
+			current:  []CustomerSegmentMember{stateMember("2", "25.0"), stateMember("1", "10.0"), stateMember("5", "50.0")},
+			want:     []string{"2 updated", "1 unchanged", "5 added", "3 removed", "4 removed"},
+		},
+		{
+			name:     "empty segment",
+			previous: []CustomerSegmentMember{stateMember("1", "10.0")},
+			want:     []string{"1 removed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := openStateDB(filepath.Join(t.TempDir(), "state.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			if tt.previous != nil {
+				if err := db.save("shop.myshopify.com", "q", tt.previous); err != nil {
+					t.Fatal(err)
+				}
+			}
+			changes, err := db.diff("shop.myshopify.com", "q", tt.current)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := changeList(changes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diff() = %q, want %q", got, tt.want)
+			}
+			// Removed customers keep their last known data.
+			for _, ch := range changes {
+				if ch.Change == changeRemoved && ch.Customer.Node.AmountSpent.Amount.IsZero() {
+					t.Errorf("removed customer %s lost its data", ch.Customer.Node.Id)
+				}
+			}
+		})
+	}
+}
+
+func TestStateDBBuckets(t *testing.T) {
+	db, err := openStateDB(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.save("a.myshopify.com", "q", []CustomerSegmentMember{stateMember("1", "10.0")}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		domain, query string
+		want          []string
+	}{
+		{domain: "a.myshopify.com", query: "q", want: []string{"1 unchanged"}},
+		{domain: "A.myshopify.com", query: "q", want: []string{"1 unchanged"}},
+		{domain: "b.myshopify.com", query: "q", want: []string{"1 added"}},
+		{domain: "a.myshopify.com", query: "other", want: []string{"1 added"}},
+	}
+	for _, tt := range tests {
+		changes, err := db.diff(tt.domain, tt.query, []CustomerSegmentMember{stateMember("1", "10.0")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := changeList(changes); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("diff(%q, %q) = %q, want %q", tt.domain, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestStateDBEncrypted(t *testing.T) {
+	key, other := testStoreCipher(t, 1), testStoreCipher(t, 2)
+	defer func(s *storeCipher) { stores = s }(stores)
+
+	tests := []struct {
+		name    string
+		save    *storeCipher
+		read    *storeCipher
+		want    []string
+		wantErr string
+	}{
+		{name: "same key", save: key, read: key, want: []string{"1 unchanged", "2 removed"}},
+		{name: "plaintext", want: []string{"1 unchanged", "2 removed"}},
+		{name: "read without key", save: key, wantErr: errEncrypted.Error()},
+		{name: "read plaintext with key", read: key, wantErr: errNotEncrypted.Error()},
+		{name: "other key", save: key, read: other, wantErr: "another store key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := openStateDB(filepath.Join(t.TempDir(), "state.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			stores = tt.save
+			if err := db.save("shop.myshopify.com", "q", []CustomerSegmentMember{stateMember("1", "10.0"), stateMember("2", "20.0")}); err != nil {
+				t.Fatal(err)
+			}
+			stores = tt.read
+			changes, err := db.diff("shop.myshopify.com", "q", []CustomerSegmentMember{stateMember("1", "10.0")})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("diff() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := changeList(changes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+
+	"sultans/shopify"
+)
+
+// customerStatistics looks up Shopify's statistics of exported customers for
+// --statistics-columns, nil without it. They are looked up on the customers per page.
+var customerStatistics *statisticsLookup
+
+type statisticsLookup struct{}
+
+// columns returns the Predicted Spend Tier and RFM Group columns. Shopify leaves
+// them null for customers it has not scored yet.
+func (l *statisticsLookup) columns() []column {
+	return []column{
+		{Header: "Predicted Spend Tier", Value: func(c CustomerSegmentMember) string {
+			if tier := c.lookedUp().statistics.PredictedSpendTier; tier != nil {
+				return string(*tier)
+			}
+			return nullValue
+		}},
+		{Header: "RFM Group", Value: func(c CustomerSegmentMember) string {
+			if group := c.lookedUp().statistics.RfmGroup; group != nil {
+				return string(*group)
+			}
+			return nullValue
+		}},
+	}
+}
+
+// wrap looks up the statistics of every page of in before delivering it.
+func (l *statisticsLookup) wrap(ctx context.Context, client *shopify.Client, in *segmentStream) *segmentStream {
+	return lookupPages(ctx, in, "statistics", func(ids []string, fields func(string) *memberFields) error {
+		resp, err := GetCustomerStatistics(ctx, client, ids)
+		if err != nil {
+			return err
+		}
+		for _, node := range resp.Nodes {
+			if customer, ok := node.(*CustomerStatisticsNodeCustomer); ok {
+				fields(customer.Id).statistics = customer.Statistics
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// suppression drops the customers on a do-not-contact list, set by --suppress,
+// nil without it.
+var suppression *suppressionList
+
+// sha256Pattern matches a hex SHA-256 hash.
+var sha256Pattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// suppressionList holds the SHA-256 hashes of suppressed email addresses, as
+// hashIdentifier computes them, so plain and hashed lists are compared alike.
+type suppressionList struct {
+	hashes map[string]bool
+
+	mu         sync.Mutex
+	suppressed int
+}
+
+// loadSuppressionList reads a CSV file with an email address or the SHA-256 hash
+// of a normalized (trimmed, lowercased) address in its first column. A header
+// row, or any other cell that is neither, is skipped.
+func loadSuppressionList(path string) (*suppressionList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open --suppress file: %w", err)
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	l := &suppressionList{hashes: map[string]bool{}}
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read --suppress file: %w", err)
+		}
+		switch v := strings.TrimSpace(record[0]); {
+		case sha256Pattern.MatchString(v):
+			l.hashes[strings.ToLower(v)] = true
+		case strings.Contains(v, "@"):
+			l.hashes[hashIdentifier(normalizeEmail(v))] = true
+		}
+	}
+	if len(l.hashes) == 0 {
+		return nil, fmt.Errorf("--suppress file %s lists no email addresses or hashes", path)
+	}
+	return l, nil
+}
+
+// keep reports whether c's email address is not suppressed. Customers without
+// one are kept.
+func (l *suppressionList) keep(c CustomerSegmentMember) bool {
+	e := c.Node.DefaultEmailAddress
+	if e == nil || !l.hashes[hashIdentifier(normalizeEmail(e.EmailAddress))] {
+		return true
+	}
+	l.mu.Lock()
+	l.suppressed++
+	l.mu.Unlock()
+	return false
+}
+
+// logSuppressed logs how many customers were left out.
+func (l *suppressionList) logSuppressed() {
+	if l.suppressed > 0 {
+		log.Printf("%d customers on the suppression list were not exported", l.suppressed)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"sultans/shopify"
+)
+
+// customerTags looks up the tags of exported customers for --tags, nil without
+// it. Like the --date-columns dates, they are looked up on the customers per page.
+var customerTags *tagLookup
+
+type tagLookup struct{}
+
+// tagsColumn returns the Tags column for a --tags mode: join with separator,
+// json for a JSON array, or explode for one row per tag.
+func tagsColumn(mode, separator string) (column, error) {
+	switch mode {
+	case "join":
+		return column{Header: "Tags", Value: func(c CustomerSegmentMember) string {
+			return strings.Join(c.lookedUp().tags, separator)
+		}}, nil
+	case "json":
+		return column{Header: "Tags", Value: func(c CustomerSegmentMember) string {
+			tags := c.lookedUp().tags
+			if tags == nil {
+				tags = []string{}
+			}
+			b, _ := json.Marshal(tags)
+			return string(b)
+		}}, nil
+	case "explode":
+		return column{Header: "Tags", Explode: func(c CustomerSegmentMember) []string {
+			return c.lookedUp().tags
+		}}, nil
+	}
+	return column{}, fmt.Errorf("invalid --tags %q, expected join, json or explode", mode)
+}
+
+// wrap looks up the tags of every page of in before delivering it.
+func (l *tagLookup) wrap(ctx context.Context, client *shopify.Client, in *segmentStream) *segmentStream {
+	return lookupPages(ctx, in, "tags", func(ids []string, fields func(string) *memberFields) error {
+		resp, err := GetCustomerTags(ctx, client, ids)
+		if err != nil {
+			return err
+		}
+		for _, node := range resp.Nodes {
+			if customer, ok := node.(*CustomerTagsNodeCustomer); ok {
+				fields(customer.Id).tags = customer.Tags
+			}
+		}
+		return nil
+	})
+}
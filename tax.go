@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"sultans/shopify"
+)
+
+// taxExemptions looks up the tax exemption fields of exported customers for
+// --tax-columns, nil without it. They are looked up on the customers per page.
+var taxExemptions *taxLookup
+
+type customerTax struct {
+	Exempt     bool
+	Exemptions []TaxExemption
+}
+
+type taxLookup struct{}
+
+// columns returns the Tax Exempt column and the Tax Exemptions column, which
+// joins the exemption reasons with separator.
+func (l *taxLookup) columns(separator string) []column {
+	return []column{
+		{Header: "Tax Exempt", Value: func(c CustomerSegmentMember) string {
+			if tax := c.lookedUp().tax; tax != nil {
+				return strconv.FormatBool(tax.Exempt)
+			}
+			return nullValue
+		}},
+		{Header: "Tax Exemptions", Value: func(c CustomerSegmentMember) string {
+			tax := c.lookedUp().tax
+			if tax == nil {
+				return nullValue
+			}
+			reasons := make([]string, len(tax.Exemptions))
+			for i, e := range tax.Exemptions {
+				reasons[i] = string(e)
+			}
+			return strings.Join(reasons, separator)
+		}},
+	}
+}
+
+// wrap looks up the tax exemptions of every page of in before delivering it.
+func (l *taxLookup) wrap(ctx context.Context, client *shopify.Client, in *segmentStream) *segmentStream {
+	return lookupPages(ctx, in, "tax exemptions", func(ids []string, fields func(string) *memberFields) error {
+		resp, err := GetCustomerTaxExemptions(ctx, client, ids)
+		if err != nil {
+			return err
+		}
+		for _, node := range resp.Nodes {
+			if customer, ok := node.(*CustomerTaxNodeCustomer); ok {
+				fields(customer.Id).tax = &customerTax{Exempt: customer.TaxExempt, Exemptions: customer.TaxExemptions}
+			}
+		}
+		return nil
+	})
+}
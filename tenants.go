@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+
+	"sultans/shopify"
+)
+
+// shopConfig is a shop of the --shops file of serve.
+type shopConfig struct {
+	Name   string `yaml:"name"`
+	Domain string `yaml:"domain"`
+	// The Admin API access token is read from AccessTokenFile or the
+	// AccessTokenEnv variable.
+	AccessTokenFile string `yaml:"access_token_file"`
+	AccessTokenEnv  string `yaml:"access_token_env"`
+	// AuthTokenEnv names the variable of the bearer token clients of the shop
+	// authenticate with, SERVE_AUTH_TOKEN by default.
+	AuthTokenEnv string `yaml:"auth_token_env"`
+	// MaxRPS and Concurrency bound the shop's requests per second and
+	// simultaneous exports, independently of the other shops.
+	MaxRPS      float64 `yaml:"max_rps"`
+	Concurrency int     `yaml:"concurrency"`
+	// Query is the shop's default segment query instead of --query.
+	Query string `yaml:"query"`
+}
+
+// tenant is a shop served by serve, with its own client, so retries, the
+// circuit breaker and rate limits of one shop don't affect the others.
+type tenant struct {
+	name     string
+	token    string
+	client   *shopify.Client
+	defaults SegmentQuery
+	// timeout bounds each export, none when it is 0.
+	timeout time.Duration
+	// slots bounds the simultaneous exports; nil means unlimited.
+	slots chan struct{}
+}
+
+var shopNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// serveTenants returns the shops of --shops, or the single shop of the
+// environment credentials, named "".
+func serveTenants(c *cli.Context) ([]*tenant, error) {
+	path := c.String("shops")
+	if path == "" {
+		token := os.Getenv("SERVE_AUTH_TOKEN")
+		if token == "" {
+			return nil, fmt.Errorf("SERVE_AUTH_TOKEN must be set")
+		}
+		client, err := newShopifyClient(c)
+		if err != nil {
+			return nil, err
+		}
+		return []*tenant{{token: token, client: client, defaults: segmentQueryFromFlags(c), timeout: c.Duration("timeout")}}, nil
+	}
+	if c.String("secret-backend") != "" {
+		return nil, fmt.Errorf("--secret-backend cannot be combined with --shops, which sets the token of every shop")
+	}
+	if currencyRates != nil && primaryCurrency == "" {
+		return nil, fmt.Errorf("--rates with --shops needs --primary-currency, as the shops may have different primary currencies")
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read --shops: %w", err)
+	}
+	var file struct {
+		Shops []shopConfig `yaml:"shops"`
+	}
+	if err := yaml.Unmarshal(b, &file); err != nil {
+		return nil, fmt.Errorf("invalid --shops file %s: %w", path, err)
+	}
+	if len(file.Shops) == 0 {
+		return nil, fmt.Errorf("--shops file %s lists no shops", path)
+	}
+	var tenants []*tenant
+	names := map[string]bool{}
+	for i, shop := range file.Shops {
+		t, err := newTenant(c, shop)
+		if err != nil {
+			return nil, fmt.Errorf("shop %d of %s: %w", i+1, path, err)
+		}
+		if names[t.name] {
+			return nil, fmt.Errorf("shop %d of %s: duplicate name %q", i+1, path, t.name)
+		}
+		names[t.name] = true
+		tenants = append(tenants, t)
+	}
+	return tenants, nil
+}
+
+func newTenant(c *cli.Context, shop shopConfig) (*tenant, error) {
+	if !shopNamePattern.MatchString(shop.Name) {
+		return nil, fmt.Errorf("name %q must be lowercase letters, digits, - and _", shop.Name)
+	}
+	if shop.Domain == "" {
+		return nil, fmt.Errorf("%s has no domain", shop.Name)
+	}
+	var accessToken string
+	var err error
+	switch {
+	case shop.AccessTokenFile != "" && shop.AccessTokenEnv != "":
+		return nil, fmt.Errorf("%s sets both access_token_file and access_token_env", shop.Name)
+	case shop.AccessTokenFile != "":
+		accessToken, err = readSecretFile(shop.AccessTokenFile)
+	case shop.AccessTokenEnv != "":
+		accessToken, err = secretEnv(shop.AccessTokenEnv)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if accessToken == "" {
+		return nil, fmt.Errorf("%s has no access token, set access_token_file or access_token_env", shop.Name)
+	}
+	authEnv := shop.AuthTokenEnv
+	if authEnv == "" {
+		authEnv = "SERVE_AUTH_TOKEN"
+	}
+	token := os.Getenv(authEnv)
+	if token == "" {
+		return nil, fmt.Errorf("%s must be set for %s", authEnv, shop.Name)
+	}
+	if shop.MaxRPS < 0 || shop.Concurrency < 0 {
+		return nil, fmt.Errorf("max_rps and concurrency of %s cannot be negative", shop.Name)
+	}
+
+	client, err := newShopClient(c, shop.Domain, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	if shop.MaxRPS > 0 {
+		client.Limiter = shopify.NewRateLimiter(shop.MaxRPS)
+	}
+	t := &tenant{name: shop.Name, token: token, client: client, defaults: segmentQueryFromFlags(c), timeout: c.Duration("timeout")}
+	if shop.Query != "" {
+		t.defaults.Query = shop.Query
+	}
+	if shop.Concurrency > 0 {
+		t.slots = make(chan struct{}, shop.Concurrency)
+	}
+	return t, nil
+}
+
+// acquire takes one of the tenant's export slots, reporting false when all are
+// in use. Every successful acquire must be followed by a release.
+func (t *tenant) acquire() bool {
+	if t.slots == nil {
+		return true
+	}
+	select {
+	case t.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (t *tenant) release() {
+	if t.slots != nil {
+		<-t.slots
+	}
+}
+
+// logf logs a message of the tenant, prefixed with its name with several shops.
+func (t *tenant) logf(format string, args ...interface{}) {
+	if t.name != "" {
+		format = "shop " + t.name + ": " + format
+	}
+	log.Printf(format, args...)
+}
+
+// tenantNames lists the names of tenants for messages.
+func tenantNames(tenants []*tenant) string {
+	names := make([]string, len(tenants))
+	for i, t := range tenants {
+		names[i] = t.name
+	}
+	return strings.Join(names, ", ")
+}
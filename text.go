@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// texts normalizes the text fields of fetched customers, set by --unicode-normalization.
+// It is nil with "none".
+var texts *textNormalizer
+
+// textNormalizer brings display names and emails into one Unicode normalization
+// form and strips control characters, so equal values compare equal downstream.
+type textNormalizer struct {
+	form norm.Form
+}
+
+func newTextNormalizer(form string) (*textNormalizer, error) {
+	switch strings.ToLower(form) {
+	case "nfc":
+		return &textNormalizer{form: norm.NFC}, nil
+	case "nfkc":
+		return &textNormalizer{form: norm.NFKC}, nil
+	case "none":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("invalid --unicode-normalization %q, expected nfc, nfkc or none", form)
+	}
+}
+
+func (t *textNormalizer) apply(c *CustomerSegmentMember) {
+	c.Node.DisplayName = t.normalize(c.Node.DisplayName)
+	if e := c.Node.DefaultEmailAddress; e != nil {
+		e.EmailAddress = t.normalize(e.EmailAddress)
+	}
+}
+
+func (t *textNormalizer) normalize(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+	return t.form.String(s)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// spendTier is an amount spent range [min, max) and its name. A tier without a
+// max has no upper bound.
+type spendTier struct {
+	min, max decimal.Decimal
+	open     bool
+	name     string
+}
+
+// parseTiers parses --tiers ranges such as "0-100:bronze,100-1000:silver,1000+:gold".
+func parseTiers(s string) ([]spendTier, error) {
+	var tiers []spendTier
+	for _, part := range strings.Split(s, ",") {
+		bounds, name, ok := strings.Cut(strings.TrimSpace(part), ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid --tiers entry %q, expected min-max:name or min+:name", part)
+		}
+
+		var t spendTier
+		var err error
+		if lo, isOpen := strings.CutSuffix(strings.TrimSpace(bounds), "+"); isOpen {
+			t.open = true
+			t.min, err = decimal.NewFromString(lo)
+		} else {
+			lo, hi, ok := strings.Cut(bounds, "-")
+			if !ok {
+				return nil, fmt.Errorf("invalid --tiers entry %q, expected min-max:name or min+:name", part)
+			}
+			if t.min, err = decimal.NewFromString(strings.TrimSpace(lo)); err == nil {
+				t.max, err = decimal.NewFromString(strings.TrimSpace(hi))
+			}
+			if err == nil && !t.max.GreaterThan(t.min) {
+				err = fmt.Errorf("max must be greater than min")
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid --tiers entry %q: %w", part, err)
+		}
+		t.name = name
+		tiers = append(tiers, t)
+	}
+	return tiers, nil
+}
+
+// tierOf returns the name of the first tier containing the customer's amount
+// spent, or "" if none does. With --rates the amount is converted into the
+// primary currency first, and customers in a currency without a rate have no
+// tier.
+func tierOf(tiers []spendTier, c CustomerSegmentMember) string {
+	amount := c.Node.AmountSpent.Amount
+	if currencyRates != nil {
+		var ok bool
+		if amount, ok = currencyRates.convert(amount, string(c.Node.AmountSpent.CurrencyCode), primaryCurrency); !ok {
+			return ""
+		}
+	}
+	for _, t := range tiers {
+		if amount.LessThan(t.min) || (!t.open && !amount.LessThan(t.max)) {
+			continue
+		}
+		return t.name
+	}
+	return ""
+}
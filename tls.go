@@ -0,0 +1,50 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+
+	"github.com/urfave/cli/v2"
+)
+
+// fipsCipherSuites are the TLS 1.2 cipher suites approved by FIPS 140: ECDHE key
+// exchange with AES-GCM.
+var fipsCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+}
+
+// configureTLS applies --tls-min-version and --fips to the HTTPS connections of
+// httpClient. It must run before the transport is wrapped, as by --har.
+func configureTLS(c *cli.Context) error {
+	config := &tls.Config{}
+	switch v := c.String("tls-min-version"); v {
+	case "1.2":
+		config.MinVersion = tls.VersionTLS12
+	case "1.3":
+		config.MinVersion = tls.VersionTLS13
+	default:
+		return fmt.Errorf("invalid --tls-min-version %q, expected 1.2 or 1.3", v)
+	}
+	if c.Bool("fips") || fipsBuild {
+		if config.MinVersion == tls.VersionTLS13 && !fipsBuild {
+			// The TLS 1.3 cipher suites cannot be restricted, and include
+			// ChaCha20-Poly1305, so only a FIPS build may use TLS 1.3.
+			return fmt.Errorf("--fips cannot be combined with --tls-min-version 1.3 unless built with GOEXPERIMENT=boringcrypto")
+		}
+		if !fipsBuild {
+			config.MaxVersion = tls.VersionTLS12
+		}
+		config.CipherSuites = fipsCipherSuites
+		config.CurvePreferences = []tls.CurveID{tls.CurveP256, tls.CurveP384}
+	}
+	transport, ok := httpClient.Transport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("TLS options must be applied before the HTTP transport is wrapped")
+	}
+	transport.TLSClientConfig = config
+	return nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// validator drops customers that break the --validation-rules rules.
+var validator *rowValidator
+
+// validationRule checks one column of the CSV rows of a customer.
+type validationRule struct {
+	Column   string   `yaml:"column"`
+	Required bool     `yaml:"required"`
+	Pattern  string   `yaml:"pattern"`
+	Min      *float64 `yaml:"min"`
+	Max      *float64 `yaml:"max"`
+
+	index   int
+	pattern *regexp.Regexp
+}
+
+// rowValidator writes the customers whose rows break a rule to a rejects file,
+// with the reasons, instead of exporting them. It is shared by concurrent exports.
+type rowValidator struct {
+	rules []validationRule
+
+	mu       sync.Mutex
+	path     string
+	file     *os.File
+	rejects  *csv.Writer
+	rejected int
+}
+
+// newRowValidator reads a YAML or JSON list of rules and checks their columns
+// against the export header.
+func newRowValidator(c *cli.Context) (*rowValidator, error) {
+	path := c.String("validation-rules")
+	if path == "" {
+		return nil, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read validation rules: %w", err)
+	}
+	var rules []validationRule
+	if err := yaml.Unmarshal(b, &rules); err != nil {
+		return nil, fmt.Errorf("invalid validation rules: %w", err)
+	}
+
+	header := outputHeader()
+	for i := range rules {
+		r := &rules[i]
+		if r.index = slices.Index(header, r.Column); r.index < 0 {
+			return nil, fmt.Errorf("invalid validation rule: unknown column %q, expected one of %s", r.Column, strings.Join(header, ", "))
+		}
+		if r.Pattern != "" {
+			if r.pattern, err = regexp.Compile(r.Pattern); err != nil {
+				return nil, fmt.Errorf("invalid validation rule pattern for %s: %w", r.Column, err)
+			}
+		}
+	}
+
+	v := &rowValidator{rules: rules, path: c.String("rejects-file")}
+	if v.file, err = os.Create(v.path); err != nil {
+		return nil, fmt.Errorf("failed to create rejects file: %w", err)
+	}
+	v.rejects = csv.NewWriter(v.file)
+	if err := v.rejects.Write(append(header, "Reasons")); err != nil {
+		v.file.Close()
+		return nil, fmt.Errorf("failed to write rejects file: %w", err)
+	}
+	return v, nil
+}
+
+// check returns why value breaks the rule, or "" if it does not. Empty values
+// and nullValue are missing; only required checks them.
+func (r *validationRule) check(value string) string {
+	if value == "" || value == nullValue {
+		if r.Required {
+			return "missing"
+		}
+		return ""
+	}
+	if r.pattern != nil && !r.pattern.MatchString(value) {
+		return fmt.Sprintf("does not match %s", r.Pattern)
+	}
+	if r.Min == nil && r.Max == nil {
+		return ""
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	switch {
+	case err != nil:
+		return "not a number"
+	case r.Min != nil && n < *r.Min:
+		return fmt.Sprintf("below %v", *r.Min)
+	case r.Max != nil && n > *r.Max:
+		return fmt.Sprintf("above %v", *r.Max)
+	}
+	return ""
+}
+
+// reasons returns the broken rules of every row of a customer, e.g.
+// "Email Address: missing".
+func (v *rowValidator) reasons(records [][]string) []string {
+	var reasons []string
+	for _, record := range records {
+		for i := range v.rules {
+			r := &v.rules[i]
+			if reason := r.check(record[r.index]); reason != "" {
+				reason = r.Column + ": " + reason
+				if !slices.Contains(reasons, reason) {
+					reasons = append(reasons, reason)
+				}
+			}
+		}
+	}
+	return reasons
+}
+
+// wrap drops the members of in that break a rule, writing their rows to the
+// rejects file.
+func (v *rowValidator) wrap(ctx context.Context, in *segmentStream) *segmentStream {
+	return filterStream(ctx, in, func(c CustomerSegmentMember) bool {
+		records := outputRecords(c)
+		reasons := v.reasons(records)
+		if len(reasons) == 0 {
+			return true
+		}
+
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		v.rejected++
+		for _, record := range records {
+			if err := v.rejects.Write(append(record, strings.Join(reasons, "; "))); err != nil {
+				log.Printf("warning: failed to write rejects file: %v", err)
+			}
+		}
+		return false
+	})
+}
+
+// Close flushes the rejects file and logs how many customers were rejected.
+func (v *rowValidator) Close() error {
+	if v.rejected > 0 {
+		log.Printf("%d customers failed validation and were written to %s", v.rejected, v.path)
+	}
+	v.rejects.Flush()
+	if err := v.rejects.Error(); err != nil {
+		v.file.Close()
+		return fmt.Errorf("failed to write rejects file: %w", err)
+	}
+	return v.file.Close()
+}